@@ -1,5 +1,4 @@
-// Package docs GENERATED BY SWAG; DO NOT EDIT
-// This file was generated by swaggo/swag
+// Code generated by swaggo/swag. DO NOT EDIT
 package docs
 
 import "github.com/swaggo/swag"
@@ -132,6 +131,42 @@ const docTemplateIUF = `{
                     }
                 }
             },
+            "delete": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Activities"
+                ],
+                "summary": "Delete an IUF activity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "activity name",
+                        "name": "activity_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            },
             "patch": {
                 "consumes": [
                     "application/json"
@@ -180,6 +215,41 @@ const docTemplateIUF = `{
                 }
             }
         },
+        "/iuf/v1/activities/{activity_name}/events": {
+            "get": {
+                "description": "Streams history entries, session state changes, stage starts and finishes, operation results and\noperation outputs of the activity as Server-Sent Events, as they happen. The event name is the type of\nthe event, and its data is the event as JSON. Events are shared between the instances of the service\nthrough Kubernetes events in the argo namespace, so the stream has the events of the activity whichever\ninstance caused them. Only events that happen while the stream is open are sent, and the stream is best\neffort: events are dropped for a client that does not keep up, and when they cannot be published.\nUse the history and sessions of the activity for a complete record. The stream stays open for as long\nas the client keeps it open, and ends when the instance shuts down, in which case the client reconnects.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Activities"
+                ],
+                "summary": "Stream the events of an IUF activity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "activity name",
+                        "name": "activity_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/iuf.Event"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            }
+        },
         "/iuf/v1/activities/{activity_name}/history": {
             "get": {
                 "consumes": [
@@ -368,6 +438,12 @@ const docTemplateIUF = `{
                             "$ref": "#/definitions/iuf.Session"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -451,6 +527,12 @@ const docTemplateIUF = `{
                             "$ref": "#/definitions/iuf.Session"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -656,6 +738,166 @@ const docTemplateIUF = `{
                 }
             }
         },
+        "/iuf/v1/activities/{activity_name}/sessions/{session_name}/stages/{stage}/workflow": {
+            "get": {
+                "description": "The response is an Argo Workflow (argoproj.io/v1alpha1), the same as what would be submitted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "Sessions"
+                ],
+                "summary": "Get the Argo workflow that would be generated for a stage of a session, without submitting it",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "activity name",
+                        "name": "activity_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "session name",
+                        "name": "session_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "stage name",
+                        "name": "stage",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "yaml"
+                        ],
+                        "type": "string",
+                        "description": "output format",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            }
+        },
+        "/iuf/v1/audit": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "List audit records of calls to the API that changed something, newest first",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "only records of this principal",
+                        "name": "principal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only records of this HTTP method",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only records whose path starts with this",
+                        "name": "path",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "success",
+                            "failure"
+                        ],
+                        "type": "string",
+                        "description": "only records with this outcome",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only records at or after this time, in RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only records before this time, in RFC 3339",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximum number of records to return, defaults to 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/iuf.AuditRecord"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            }
+        },
         "/iuf/v1/stages": {
             "get": {
                 "produces": [
@@ -767,6 +1009,115 @@ const docTemplateIUF = `{
                 "ActivityStateWaitForAdmin"
             ]
         },
+        "iuf.AuditRecord": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "description": "Body of the call, with secrets redacted"
+                },
+                "client_ip": {
+                    "description": "Address the call came from",
+                    "type": "string"
+                },
+                "duration_ms": {
+                    "description": "How long the call took",
+                    "type": "integer"
+                },
+                "error": {
+                    "description": "Error message of the response, for failures",
+                    "type": "string"
+                },
+                "method": {
+                    "description": "HTTP method",
+                    "type": "string"
+                },
+                "outcome": {
+                    "description": "success for 2xx and 3xx responses, failure otherwise",
+                    "type": "string",
+                    "enum": [
+                        "success",
+                        "failure"
+                    ]
+                },
+                "path": {
+                    "description": "Path that was called, e.g. /apis/iuf/v1/activities/admin-230127/history/abort",
+                    "type": "string"
+                },
+                "principal": {
+                    "description": "Who made the call, from the preferred_username or subject of their token",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Query string of the call",
+                    "type": "string"
+                },
+                "route": {
+                    "description": "Route that handled the call, e.g. /apis/iuf/v1/activities/:activity_name/history/abort",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "HTTP status of the response",
+                    "type": "integer"
+                },
+                "time": {
+                    "description": "When the call was received",
+                    "type": "string"
+                }
+            }
+        },
+        "iuf.ChecksumMismatch": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "The checksum of the file, empty when the file cannot be read",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why the file cannot be read",
+                    "type": "string"
+                },
+                "expected": {
+                    "description": "The checksum in the checksums file",
+                    "type": "string"
+                },
+                "file": {
+                    "description": "The path of the file, relative to the distribution",
+                    "type": "string"
+                }
+            }
+        },
+        "iuf.ChecksumResult": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "description": "sha256 or md5",
+                    "type": "string"
+                },
+                "file": {
+                    "description": "The name of the checksums file",
+                    "type": "string"
+                },
+                "files": {
+                    "description": "The number of files in the checksums file",
+                    "type": "integer"
+                },
+                "mismatches": {
+                    "description": "The files that do not match their checksum",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/iuf.ChecksumMismatch"
+                    }
+                },
+                "pending": {
+                    "description": "The files are being verified in the background, and the other fields are not known yet",
+                    "type": "boolean"
+                },
+                "verified": {
+                    "description": "Whether every file matches its checksum",
+                    "type": "boolean"
+                }
+            }
+        },
         "iuf.CreateActivityRequest": {
             "type": "object",
             "required": [
@@ -779,15 +1130,31 @@ const docTemplateIUF = `{
                 }
             }
         },
-        "iuf.ManagementRolloutStrategy": {
+        "iuf.EEventType": {
             "type": "string",
             "enum": [
-                "reboot",
-                "rebuild"
+                "history",
+                "session",
+                "stage_started",
+                "stage_finished",
+                "operation",
+                "output"
             ],
+            "x-enum-comments": {
+                "EEventTypeHistory": "a history entry was added to the activity",
+                "EEventTypeOperation": "an operation of a finished workflow succeeded or failed",
+                "EEventTypeOutput": "the output of an operation was saved in the activity",
+                "EEventTypeSession": "the state of a session was saved",
+                "EEventTypeStageFinished": "a stage completed, or its workflow failed",
+                "EEventTypeStageStarted": "a workflow was created for a stage"
+            },
             "x-enum-varnames": [
-                "EManagementRolloutStrategyReboot",
-                "EManagementRolloutStrategyRebuild"
+                "EEventTypeHistory",
+                "EEventTypeSession",
+                "EEventTypeStageStarted",
+                "EEventTypeStageFinished",
+                "EEventTypeOperation",
+                "EEventTypeOutput"
             ]
         },
         "iuf.EManagedRolloutStrategy": {
@@ -801,6 +1168,97 @@ const docTemplateIUF = `{
                 "EManagedRolloutStrategyStaged"
             ]
         },
+        "iuf.EManagementRolloutStrategy": {
+            "type": "string",
+            "enum": [
+                "reboot",
+                "rebuild"
+            ],
+            "x-enum-varnames": [
+                "EManagementRolloutStrategyReboot",
+                "EManagementRolloutStrategyRebuild"
+            ]
+        },
+        "iuf.Event": {
+            "type": "object",
+            "properties": {
+                "activity_name": {
+                    "description": "Name of the activity",
+                    "type": "string"
+                },
+                "activity_state": {
+                    "description": "State of the activity, for history events",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/iuf.ActivityState"
+                        }
+                    ]
+                },
+                "comment": {
+                    "description": "Comment of the history entry or session update",
+                    "type": "string"
+                },
+                "operation": {
+                    "description": "Name of the operation, for operation and output events",
+                    "type": "string"
+                },
+                "output": {
+                    "description": "Output parameters of the operation, for output events"
+                },
+                "phase": {
+                    "description": "Argo phase of the stage or operation, e.g. Succeeded or Failed",
+                    "type": "string"
+                },
+                "product": {
+                    "description": "Product key of the operation, for product stages",
+                    "type": "string"
+                },
+                "session_name": {
+                    "description": "Name of the session, if any",
+                    "type": "string"
+                },
+                "session_state": {
+                    "description": "State of the session, for session events",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/iuf.SessionState"
+                        }
+                    ]
+                },
+                "stage": {
+                    "description": "Name of the stage, if any",
+                    "type": "string"
+                },
+                "step": {
+                    "description": "Name of the step of the operation, for output events",
+                    "type": "string"
+                },
+                "time": {
+                    "description": "When the event happened",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Type of event",
+                    "enum": [
+                        "history",
+                        "session",
+                        "stage_started",
+                        "stage_finished",
+                        "operation",
+                        "output"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/iuf.EEventType"
+                        }
+                    ]
+                },
+                "workflow": {
+                    "description": "Name of the Argo workflow, if any",
+                    "type": "string"
+                }
+            }
+        },
         "iuf.History": {
             "type": "object",
             "required": [
@@ -896,6 +1354,14 @@ const docTemplateIUF = `{
         "iuf.InputParameters": {
             "type": "object",
             "properties": {
+                "block_on_invalid_products": {
+                    "description": "Do not run any stage after process-media while a product to execute the stages for is not validated",
+                    "type": "boolean"
+                },
+                "boot_image_management": {
+                    "description": "The name of the boot image to be used for management nodes",
+                    "type": "string"
+                },
                 "bootprep_config_managed": {
                     "description": "The path to the bootprep config file for managed nodes, relative to the media_dir",
                     "type": "string"
@@ -904,6 +1370,10 @@ const docTemplateIUF = `{
                     "description": "The path to the bootprep config file for management nodes, relative to the media_dir",
                     "type": "string"
                 },
+                "cfs_configuration_management": {
+                    "description": "The name of the cfs configuration for management nodes",
+                    "type": "string"
+                },
                 "concurrency": {
                     "description": "An integer defining how many products / operations can we concurrently execute.",
                     "type": "integer"
@@ -930,27 +1400,27 @@ const docTemplateIUF = `{
                         "type": "string"
                     }
                 },
-		        "management_rollout_strategy": {
-                    "description": "Whether to use a reboot or rebuild rollout strategy for management nodes.",
+                "managed_rollout_strategy": {
+                    "description": "Whether to use a reboot or staged rollout strategy for managed nodes. Refer to BOS v2 for more details.",
                     "enum": [
                         "reboot",
-                        "rebuild"
+                        "stage"
                     ],
                     "allOf": [
                         {
-                            "$ref": "#/definitions/iuf.EManagementRolloutStrategy"
+                            "$ref": "#/definitions/iuf.EManagedRolloutStrategy"
                         }
                     ]
                 },
-                "managed_rollout_strategy": {
-                    "description": "Whether to use a reboot or staged rollout strategy for managed nodes. Refer to BOS v2 for more details.",
+                "management_rollout_strategy": {
+                    "description": "Whether to use a reboot or rebuild strategy for management nodes.",
                     "enum": [
                         "reboot",
-                        "stage"
+                        "rebuild"
                     ],
                     "allOf": [
                         {
-                            "$ref": "#/definitions/iuf.EManagedRolloutStrategy"
+                            "$ref": "#/definitions/iuf.EManagementRolloutStrategy"
                         }
                     ]
                 },
@@ -962,6 +1432,27 @@ const docTemplateIUF = `{
                     "description": "A string containing the hostname of where the media is located",
                     "type": "string"
                 },
+                "operation_policies": {
+                    "description": "Timeout and retry policies by operation name, overriding the ones in stages.yaml",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/iuf.OperationPolicy"
+                    }
+                },
+                "operations": {
+                    "description": "Operations to execute within the stages. When set, only these operations are executed and product hooks are not run. Empty means all operations.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "products": {
+                    "description": "Products to execute the stages for, either by name or by \u003cname\u003e-\u003cversion\u003e. Empty means all products.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "site_parameters": {
                     "description": "DEPRECATED: use site_parameters at the top level of the activity or session resource. The inline contents of the site_parameters.yaml file.",
                     "type": "string"
@@ -975,6 +1466,31 @@ const docTemplateIUF = `{
                 }
             }
         },
+        "iuf.OperationPolicy": {
+            "type": "object",
+            "properties": {
+                "retry-backoff": {
+                    "description": "how long to wait before the first retry, as a duration such as 1m. Doubled for each retry after that",
+                    "type": "string"
+                },
+                "retry-limit": {
+                    "description": "how many times to retry a failed operation",
+                    "type": "integer"
+                },
+                "retry-policy": {
+                    "description": "when to retry: Always, OnFailure, OnError or OnTransientError. Defaults to OnFailure",
+                    "type": "string"
+                },
+                "retry-window": {
+                    "description": "how long after its first attempt started an operation may still be retried, as a duration such as 6h",
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "how long each attempt of an operation may run, as a duration such as 1h",
+                    "type": "string"
+                }
+            }
+        },
         "iuf.Operations": {
             "type": "object",
             "required": [
@@ -989,15 +1505,39 @@ const docTemplateIUF = `{
                     "description": "Name of the operation",
                     "type": "string"
                 },
+                "no-retry": {
+                    "description": "never retry the operation automatically, whatever its retry-limit, because running it again could do harm",
+                    "type": "boolean"
+                },
                 "required-manifest-attributes": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "retry-backoff": {
+                    "description": "how long to wait before the first retry, as a duration such as 1m. Doubled for each retry after that",
+                    "type": "string"
+                },
+                "retry-limit": {
+                    "description": "how many times to retry a failed operation",
+                    "type": "integer"
+                },
+                "retry-policy": {
+                    "description": "when to retry: Always, OnFailure, OnError or OnTransientError. Defaults to OnFailure",
+                    "type": "string"
+                },
+                "retry-window": {
+                    "description": "how long after its first attempt started an operation may still be retried, as a duration such as 6h",
+                    "type": "string"
+                },
                 "static-parameters": {
                     "type": "object",
                     "additionalProperties": true
+                },
+                "timeout": {
+                    "description": "how long each attempt of an operation may run, as a duration such as 1h",
+                    "type": "string"
                 }
             }
         },
@@ -1010,6 +1550,14 @@ const docTemplateIUF = `{
                 "version"
             ],
             "properties": {
+                "checksums": {
+                    "description": "The result of verifying the checksums file of the product, if there is one. Verified once process-media succeeded",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/iuf.ChecksumResult"
+                        }
+                    ]
+                },
                 "manifest": {
                     "description": "the content of manifest",
                     "type": "string"
@@ -1023,9 +1571,23 @@ const docTemplateIUF = `{
                     "type": "string"
                 },
                 "validated": {
-                    "description": "The flag indicates md5 of a product tarball file has been validated",
+                    "description": "The flag indicates the manifest is valid and the files of the product match its checksums file, if there is one",
                     "type": "boolean"
                 },
+                "validation_errors": {
+                    "description": "Why the product is not validated, e.g. its manifest does not follow the schema or is not compatible with the IUF version",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "validation_warnings": {
+                    "description": "What should be looked at although the product is validated, e.g. it has no checksums file",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "version": {
                     "description": "The version of the product.",
                     "type": "string"
@@ -1047,6 +1609,19 @@ const docTemplateIUF = `{
                 "products"
             ],
             "properties": {
+                "completed_stages": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "current_stages": {
+                    "description": "The stages that are currently running. Stages that do not depend on each other run in parallel",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "current_state": {
                     "enum": [
                         "paused",
@@ -1087,6 +1662,7 @@ const docTemplateIUF = `{
                     "$ref": "#/definitions/iuf.SiteParameters"
                 },
                 "stage": {
+                    "description": "The stage that was last started or synced",
                     "type": "string"
                 },
                 "workflows": {
@@ -1153,6 +1729,13 @@ const docTemplateIUF = `{
                 "type"
             ],
             "properties": {
+                "depends-on": {
+                    "description": "stages that must complete before this stage can run. When not set, the stage depends on the stage before it",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "name": {
                     "description": "Name of the stage",
                     "type": "string"
@@ -1172,6 +1755,10 @@ const docTemplateIUF = `{
                     "description": "this stage wants to make sure all products with the same name (but different versions) are processed sequentially, not in parallel, to avoid operational race conditions",
                     "type": "boolean"
                 },
+                "timeout": {
+                    "description": "how long each workflow of this stage may run, as a duration such as 2h",
+                    "type": "string"
+                },
                 "type": {
                     "description": "Type of the stage",
                     "type": "string"
@@ -1,5 +1,4 @@
-// Package docs GENERATED BY SWAG; DO NOT EDIT
-// This file was generated by swaggo/swag
+// Code generated by swaggo/swag. DO NOT EDIT
 package docs
 
 import "github.com/swaggo/swag"
@@ -16,6 +15,54 @@ const docTemplateNLS = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/nls/v1/health/detail": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "Whether NLS and every dependency it needs are healthy, with the error of each check that failed",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthDetailResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthDetailResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nls/v1/liveness": {
+            "get": {
+                "description": "Does not check any dependency: restarting NLS does not bring Argo or Kubernetes back",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "K8s Liveness endpoint",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
         "/nls/v1/ncns/reboot": {
             "post": {
                 "consumes": [
@@ -118,6 +165,63 @@ const docTemplateNLS = `{
                 }
             }
         },
+        "/nls/v1/readiness": {
+            "get": {
+                "description": "The results are cached for 10 seconds. Use /nls/v1/health/detail to see why a check failed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "K8s Readiness endpoint, checks Kubernetes, Argo, Keycloak in production, the IUF stages and the rebuild templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/models.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/nls/v1/version": {
+            "get": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Misc"
+                ],
+                "summary": "Get version of cray-nls service, and of the IUF stages, the IUF manifest schema, Argo and the workflow templates",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.VersionResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            }
+        },
         "/nls/v1/workflows": {
             "get": {
                 "consumes": [
@@ -170,6 +274,54 @@ const docTemplateNLS = `{
             }
         },
         "/nls/v1/workflows/{name}": {
+            "get": {
+                "description": "The response is an Argo Workflow (argoproj.io/v1alpha1)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workflow Management"
+                ],
+                "summary": "Get a workflow by name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name of workflow",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/ResponseError"
+                        }
+                    }
+                }
+            },
             "delete": {
                 "consumes": [
                     "application/json"
@@ -439,6 +591,59 @@ const docTemplateNLS = `{
                 }
             }
         },
+        "models.HealthCheck": {
+            "type": "object",
+            "properties": {
+                "duration": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "target": {
+                    "description": "what was checked, such as a URL or a directory",
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthDetailResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.HealthCheck"
+                    }
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "description": "ok or failed, by the name of the dependency",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "healthy": {
+                    "type": "boolean"
+                }
+            }
+        },
         "models.RetryWorkflowRequestBody": {
             "type": "object",
             "properties": {
@@ -449,6 +654,52 @@ const docTemplateNLS = `{
                     "type": "string"
                 }
             }
+        },
+        "models.VersionResponse": {
+            "type": "object",
+            "properties": {
+                "argoServerVersion": {
+                    "type": "string"
+                },
+                "buildDate": {
+                    "type": "string"
+                },
+                "gitCommit": {
+                    "type": "string"
+                },
+                "goVersion": {
+                    "type": "string"
+                },
+                "manifestSchemaVersion": {
+                    "description": "of the newest IUF Product Manifest schema",
+                    "type": "string"
+                },
+                "manifestSchemaVersions": {
+                    "description": "of every IUF Product Manifest schema that manifests can follow",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "description": "the version of NLS, where it has always been",
+                    "type": "string"
+                },
+                "stagesVersion": {
+                    "description": "of the IUF stages.yaml",
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "workflowTemplates": {
+                    "description": "version by name, of the workflow templates that have a version label",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
         }
     }
 }`
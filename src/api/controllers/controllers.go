@@ -37,4 +37,5 @@ var Module = fx.Options(
 	fx.Provide(misc_controllers.NewMiscController),
 	fx.Provide(controllers_v1.NewHookController),
 	fx.Provide(iuf_controllers.NewIufController),
+	fx.Provide(iuf_controllers.NewAuditController),
 )
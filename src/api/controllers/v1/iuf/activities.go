@@ -55,7 +55,7 @@ func (u IufController) CreateActivity(c *gin.Context) {
 
 	u.logger.Infof("CreateActivity: received request with params %#v and parsed params %#v", c.Request.Form, requestBody)
 
-	res, err := u.iufService.CreateActivity(requestBody)
+	res, err := u.iufService.CreateActivity(c.Request.Context(), requestBody)
 	if err != nil {
 		u.logger.Errorf("CreateActivity: An error occurred while creating an activity named %s: %v", requestBody.Name, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -75,7 +75,7 @@ func (u IufController) CreateActivity(c *gin.Context) {
 //	@Router		/iuf/v1/activities [get]
 func (u IufController) ListActivities(c *gin.Context) {
 	u.logger.Infof("ListActivities: received request with params %#v", c.Request.Form)
-	res, err := u.iufService.ListActivities()
+	res, err := u.iufService.ListActivities(c.Request.Context(), )
 	if err != nil {
 		u.logger.Errorf("ListActivities: An error occurred while listing activities: %v", err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -98,7 +98,7 @@ func (u IufController) ListActivities(c *gin.Context) {
 func (u IufController) GetActivity(c *gin.Context) {
 	activityName := c.Param("activity_name")
 	u.logger.Infof("GetActivity: received request for activity %s with params %#v", activityName, c.Request.Form)
-	res, err := u.iufService.GetActivity(activityName)
+	res, err := u.iufService.GetActivity(c.Request.Context(), activityName)
 	if err != nil {
 		u.logger.Errorf("GetActivity: An error occurred while fetching activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -121,7 +121,7 @@ func (u IufController) DeleteActivity(c *gin.Context) {
     activityName := c.Param("activity_name")
     u.logger.Infof("DeleteActivity: received request to delete activity %s", activityName)
    
-    _, err := u.iufService.DeleteActivity(activityName)
+    _, err := u.iufService.DeleteActivity(c.Request.Context(), activityName)
     if err != nil {
         u.logger.Errorf("DeleteActivity: An error occurred while deleting activity %s: %v", activityName, err)
         errResponse := utils.ResponseError{Message: err.Error()}
@@ -148,7 +148,7 @@ func (u IufController) DeleteActivity(c *gin.Context) {
 func (u IufController) PatchActivity(c *gin.Context) {
 	var requestBody iuf.PatchActivityRequest
 	name := c.Param("activity_name")
-	activity, err := u.iufService.GetActivity(name)
+	activity, err := u.iufService.GetActivity(c.Request.Context(), name)
 	if err != nil {
 		u.logger.Errorf("PatchActivity: An error occurred while fetching activity %s: %v", name, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -165,7 +165,7 @@ func (u IufController) PatchActivity(c *gin.Context) {
 
 	u.logger.Infof("PatchActivity: received request for activity %s with params %#v and parsed params %#v", name, c.Request.Form, requestBody)
 
-	res, err := u.iufService.PatchActivity(activity, requestBody)
+	res, err := u.iufService.PatchActivity(c.Request.Context(), activity, requestBody)
 	if err != nil {
 		u.logger.Errorf("PatchActivity: An error occurred patching activity %s: %v", name, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
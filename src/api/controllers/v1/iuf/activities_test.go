@@ -65,7 +65,7 @@ func TestCreateActivity(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
 		iufServiceMock := mocks.NewMockIufService(ctrl)
-		iufServiceMock.EXPECT().CreateActivity(gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
+		iufServiceMock.EXPECT().CreateActivity(gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
 		var tests = []struct {
 			name        string
 			requestBody string
@@ -116,8 +116,8 @@ func TestPatchActivity(t *testing.T) {
 		t.Run("should be allowed to change the input_parameters", func(t *testing.T) {
 			workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
 			iufServiceMock := mocks.NewMockIufService(ctrl)
-			iufServiceMock.EXPECT().GetActivity(gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
-			iufServiceMock.EXPECT().PatchActivity(gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
+			iufServiceMock.EXPECT().GetActivity(gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
+			iufServiceMock.EXPECT().PatchActivity(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
 			res := executeWithContext(workflowServiceMock, iufServiceMock, "asd", `{"input_parameters":{"site_parameters": "test"}}`)
 			assert.Equal(t, http.StatusOK, res.Code)
 		})
@@ -125,8 +125,8 @@ func TestPatchActivity(t *testing.T) {
 		t.Run("should return 404 on activity not found", func(t *testing.T) {
 			workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
 			iufServiceMock := mocks.NewMockIufService(ctrl)
-			iufServiceMock.EXPECT().GetActivity(gomock.Any()).Return(iuf.Activity{}, fmt.Errorf("not found")).AnyTimes()
-			iufServiceMock.EXPECT().PatchActivity(gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
+			iufServiceMock.EXPECT().GetActivity(gomock.Any(), gomock.Any()).Return(iuf.Activity{}, fmt.Errorf("not found")).AnyTimes()
+			iufServiceMock.EXPECT().PatchActivity(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Activity{}, nil).AnyTimes()
 			res := executeWithContext(workflowServiceMock, iufServiceMock, "asd", `{}`)
 			assert.Equal(t, http.StatusNotFound, res.Code)
 		})
@@ -0,0 +1,89 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"net/http"
+
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditController data type
+type AuditController struct {
+	auditService services_shared.AuditService
+	logger       utils.Logger
+}
+
+// NewAuditController creates new Audit controller
+func NewAuditController(auditService services_shared.AuditService, logger utils.Logger) AuditController {
+	return AuditController{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ListAudit
+//	@Summary	List audit records of calls to the API that changed something, newest first
+//	@Param		principal	query	string	false	"only records of this principal"
+//	@Param		method		query	string	false	"only records of this HTTP method"
+//	@Param		path		query	string	false	"only records whose path starts with this"
+//	@Param		outcome		query	string	false	"only records with this outcome"	Enums(success, failure)
+//	@Param		since		query	string	false	"only records at or after this time, in RFC 3339"
+//	@Param		until		query	string	false	"only records before this time, in RFC 3339"
+//	@Param		limit		query	int		false	"maximum number of records to return, defaults to 100"
+//	@Tags		Audit
+//	@Accept		json
+//	@Produce	json
+//	@Success	200	{object}	[]iuf.AuditRecord
+//	@Failure	400	{object}	utils.ResponseError
+//	@Failure	500	{object}	utils.ResponseError
+//	@Router		/iuf/v1/audit [get]
+func (u AuditController) ListAudit(c *gin.Context) {
+	var filter iuf.AuditFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		u.logger.Errorf("ListAudit.1: invalid query %s: %v", c.Request.URL.RawQuery, err)
+		errResponse := utils.ResponseError{Message: err.Error()}
+		c.JSON(http.StatusBadRequest, errResponse)
+		return
+	}
+	if filter.Outcome != "" && filter.Outcome != iuf.AuditOutcomeSuccess && filter.Outcome != iuf.AuditOutcomeFailure {
+		errResponse := utils.ResponseError{Message: "outcome must be one of success, failure"}
+		c.JSON(http.StatusBadRequest, errResponse)
+		return
+	}
+
+	res, err := u.auditService.ListRecords(filter)
+	if err != nil {
+		u.logger.Errorf("ListAudit.2: An error occurred listing audit records: %v", err)
+		errResponse := utils.ResponseError{Message: err.Error()}
+		c.JSON(http.StatusInternalServerError, errResponse)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+func TestListAudit(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	executeWithContext := func(auditService *mocks.MockAuditService, requestUrl string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		context, ginEngine := gin.CreateTestContext(response)
+
+		context.Request, _ = http.NewRequest("GET", requestUrl, nil)
+
+		ginEngine.GET("/iuf/v1/audit", NewAuditController(auditService, *utils.GetLogger().GetGinLogger().Logger).ListAudit)
+		ginEngine.ServeHTTP(response, context.Request)
+		return response
+	}
+	t.Run("200: passes the filter to the audit service", func(t *testing.T) {
+		auditServiceMock := mocks.NewMockAuditService(ctrl)
+		expectedFilter := iuf.AuditFilter{
+			Principal: "admin",
+			Outcome:   iuf.AuditOutcomeFailure,
+			Since:     time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+			Limit:     10,
+		}
+		auditServiceMock.EXPECT().ListRecords(gomock.Any()).DoAndReturn(func(filter iuf.AuditFilter) ([]iuf.AuditRecord, error) {
+			assert.Equal(t, expectedFilter.Principal, filter.Principal)
+			assert.Equal(t, expectedFilter.Outcome, filter.Outcome)
+			assert.True(t, expectedFilter.Since.Equal(filter.Since))
+			assert.Equal(t, expectedFilter.Limit, filter.Limit)
+			return []iuf.AuditRecord{{Principal: "admin"}}, nil
+		})
+		res := executeWithContext(auditServiceMock, "/iuf/v1/audit?principal=admin&outcome=failure&since=2025-01-02T03:04:05Z&limit=10")
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+	t.Run("400: invalid time", func(t *testing.T) {
+		auditServiceMock := mocks.NewMockAuditService(ctrl)
+		res := executeWithContext(auditServiceMock, "/iuf/v1/audit?since=yesterday")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+	t.Run("400: invalid outcome", func(t *testing.T) {
+		auditServiceMock := mocks.NewMockAuditService(ctrl)
+		res := executeWithContext(auditServiceMock, "/iuf/v1/audit?outcome=maybe")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+	t.Run("500: audit log cannot be read", func(t *testing.T) {
+		auditServiceMock := mocks.NewMockAuditService(ctrl)
+		auditServiceMock.EXPECT().ListRecords(gomock.Any()).Return(nil, errors.New("permission denied"))
+		res := executeWithContext(auditServiceMock, "/iuf/v1/audit")
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
@@ -0,0 +1,94 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// how often to send a comment to idle event streams, so that proxies do not close them
+const EVENTS_KEEPALIVE_INTERVAL = 30 * time.Second
+
+// ActivityEvents
+//	@Summary		Stream the events of an IUF activity
+//	@Description	Streams history entries, session state changes, stage starts and finishes, operation results and
+//	@Description	operation outputs of the activity as Server-Sent Events, as they happen. The event name is the type of
+//	@Description	the event, and its data is the event as JSON. Events are shared between the instances of the service
+//	@Description	through Kubernetes events in the argo namespace, so the stream has the events of the activity whichever
+//	@Description	instance caused them. Only events that happen while the stream is open are sent, and the stream is best
+//	@Description	effort: events are dropped for a client that does not keep up, and when they cannot be published.
+//	@Description	Use the history and sessions of the activity for a complete record. The stream stays open for as long
+//	@Description	as the client keeps it open, and ends when the instance shuts down, in which case the client reconnects.
+//	@Param			activity_name	path	string	true	"activity name"
+//	@Tags			Activities
+//	@Produce		text/event-stream
+//	@Success		200	{object}	iuf.Event
+//	@Failure		404	{object}	utils.ResponseError
+//	@Router			/iuf/v1/activities/{activity_name}/events [get]
+func (u IufController) ActivityEvents(c *gin.Context) {
+	activityName := c.Param("activity_name")
+	u.logger.Infof("ActivityEvents: received request for activity %s with params %#v", activityName, c.Request.Form)
+	_, err := u.iufService.GetActivity(c.Request.Context(), activityName)
+	if err != nil {
+		u.logger.Errorf("ActivityEvents: An error occurred while fetching activity %s: %v", activityName, err)
+		errResponse := utils.ResponseError{Message: err.Error()}
+		c.JSON(http.StatusNotFound, errResponse)
+		return
+	}
+
+	events, unsubscribe := u.iufService.SubscribeToActivityEvents(activityName)
+	defer unsubscribe()
+	ctx, cancel := utils.StreamContext(c.Request)
+	defer cancel()
+
+	keepalive := time.NewTicker(EVENTS_KEEPALIVE_INTERVAL)
+	defer keepalive.Stop()
+
+	// stop nginx and istio from buffering the stream
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.SSEvent(string(event.Type), event)
+			return true
+		case <-keepalive.C:
+			_, err := io.WriteString(w, ": keepalive\n\n")
+			return err == nil
+		case <-ctx.Done():
+			return false
+		}
+	})
+	u.logger.Infof("ActivityEvents: stopped streaming events of activity %s", activityName)
+}
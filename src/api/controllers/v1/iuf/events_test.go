@@ -0,0 +1,97 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+// closeNotifyingRecorder is a ResponseRecorder that gin can stream to
+type closeNotifyingRecorder struct {
+	*httptest.ResponseRecorder
+}
+
+func (r closeNotifyingRecorder) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func TestActivityEvents(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	executeWithContext := func(
+		workflowService *mocks.MockWorkflowService,
+		iufServices *mocks.MockIufService,
+		requestUrl string,
+	) closeNotifyingRecorder {
+		response := closeNotifyingRecorder{httptest.NewRecorder()}
+		context, ginEngine := gin.CreateTestContext(response)
+
+		context.Request, _ = http.NewRequest("GET", requestUrl, nil)
+
+		ginEngine.GET("/iuf/v1/activities/:activity_name/events", NewIufController(workflowService, iufServices, *utils.GetLogger().GetGinLogger().Logger).ActivityEvents)
+		ginEngine.ServeHTTP(response, context.Request)
+		return response
+	}
+
+	t.Run("200: streams events until the subscription ends", func(t *testing.T) {
+		events := make(chan iuf.Event, 2)
+		events <- iuf.Event{Type: iuf.EEventTypeStageStarted, ActivityName: "activity-a", Stage: "deliver-product"}
+		events <- iuf.Event{Type: iuf.EEventTypeOperation, ActivityName: "activity-a", Operation: "s3-upload", Phase: "Succeeded"}
+		close(events)
+
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetActivity(gomock.Any(), "activity-a").Return(iuf.Activity{Name: "activity-a"}, nil)
+		iufServiceMock.EXPECT().SubscribeToActivityEvents("activity-a").Return(events, func() {})
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/events")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "text/event-stream", res.Header().Get("Content-Type"))
+		assert.True(t, strings.Contains(res.Body.String(), "event:stage_started\n"))
+		assert.True(t, strings.Contains(res.Body.String(), `"stage":"deliver-product"`))
+		assert.True(t, strings.Contains(res.Body.String(), "event:operation\n"))
+	})
+
+	t.Run("404: activity does not exist", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetActivity(gomock.Any(), "activity-a").Return(iuf.Activity{}, utils.GenericError{Message: "not found"})
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/events")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
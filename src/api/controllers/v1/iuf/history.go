@@ -26,11 +26,13 @@
 package iuf
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_iuf "github.com/Cray-HPE/cray-nls/src/api/services/iuf"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/gin-gonic/gin"
 )
@@ -47,7 +49,7 @@ import (
 func (u IufController) ListHistory(c *gin.Context) {
 	activityName := c.Param("activity_name")
 	u.logger.Infof("ListHistory: received request for activity %s with params %#v", activityName, c.Request.Form)
-	res, err := u.iufService.ListActivityHistory(activityName)
+	res, err := u.iufService.ListActivityHistory(c.Request.Context(), activityName)
 	if err != nil {
 		u.logger.Errorf("ListHistory: An error occurred listing history for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -80,7 +82,7 @@ func (u IufController) GetHistory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, errResponse)
 		return
 	}
-	res, err := u.iufService.GetActivityHistory(activityName, int32(startTime))
+	res, err := u.iufService.GetActivityHistory(c.Request.Context(), activityName, int32(startTime))
 	if err != nil {
 		u.logger.Errorf("GetHistory: An error occurred getting history for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -131,7 +133,7 @@ func (u IufController) ReplaceHistoryComment(c *gin.Context) {
 
 	u.logger.Infof("ReplaceHistoryComment: received request for activity %s and start time %v with params %#v and parsed params %#v", activityName, startTimeParam, c.Request.Form, requestBody)
 
-	res, err := u.iufService.ReplaceHistoryComment(activityName, int32(startTime), requestBody)
+	res, err := u.iufService.ReplaceHistoryComment(c.Request.Context(), activityName, int32(startTime), requestBody)
 	if err != nil {
 		u.logger.Errorf("ReplaceHistoryComment: An error occurred replacing history comment for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -149,6 +151,7 @@ func (u IufController) ReplaceHistoryComment(c *gin.Context) {
 //	@Accept		json
 //	@Produce	json
 //	@Success	201	{object}	iuf.Session
+//	@Failure	400	{object}	utils.ResponseError
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/iuf/v1/activities/{activity_name}/history/run [post]
 func (u IufController) HistoryRunAction(c *gin.Context) {
@@ -164,10 +167,15 @@ func (u IufController) HistoryRunAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryRunAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryRunAction(activityName, requestBody)
+	res, err := u.iufService.HistoryRunAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryRunAction: An error occurred during run for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
+		var stageValidationErr services_iuf.StageValidationError
+		if errors.As(err, &stageValidationErr) {
+			c.JSON(http.StatusBadRequest, errResponse)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errResponse)
 		return
 	}
@@ -182,6 +190,7 @@ func (u IufController) HistoryRunAction(c *gin.Context) {
 //	@Accept		json
 //	@Produce	json
 //	@Success	201	{object}	iuf.Session
+//	@Failure	400	{object}	utils.ResponseError
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/iuf/v1/activities/{activity_name}/history/restart [post]
 func (u IufController) HistoryRestartAction(c *gin.Context) {
@@ -197,10 +206,15 @@ func (u IufController) HistoryRestartAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryRestartAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryRestartAction(activityName, requestBody)
+	res, err := u.iufService.HistoryRestartAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryRestartAction: An error occurred during restart for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
+		var stageValidationErr services_iuf.StageValidationError
+		if errors.As(err, &stageValidationErr) {
+			c.JSON(http.StatusBadRequest, errResponse)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errResponse)
 		return
 	}
@@ -228,7 +242,7 @@ func (u IufController) HistoryBlockedAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryBlockedAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryBlockedAction(activityName, requestBody)
+	res, err := u.iufService.HistoryBlockedAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryBlockedAction: An error occurred calling blocked action for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -260,10 +274,15 @@ func (u IufController) HistoryResumeAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryResumeAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryResumeAction(activityName, requestBody)
+	res, err := u.iufService.HistoryResumeAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryResumeAction: An error occurred calling resume action for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
+		var stageValidationErr services_iuf.StageValidationError
+		if errors.As(err, &stageValidationErr) {
+			c.JSON(http.StatusBadRequest, errResponse)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, errResponse)
 		return
 	}
@@ -292,7 +311,7 @@ func (u IufController) HistoryPausedAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryPausedAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryPausedAction(activityName, requestBody)
+	res, err := u.iufService.HistoryPausedAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryPausedAction: An error occurred calling resume action for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -324,7 +343,7 @@ func (u IufController) HistoryAbortAction(c *gin.Context) {
 
 	u.logger.Infof("HistoryAbortAction: received request for activity %s with params %#v and parsed params %#v", activityName, c.Request.Form, requestBody)
 
-	res, err := u.iufService.HistoryAbortAction(activityName, requestBody)
+	res, err := u.iufService.HistoryAbortAction(c.Request.Context(), activityName, requestBody)
 	if err != nil {
 		u.logger.Errorf("HistoryAbortAction: An error occurred calling resume action for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -24,12 +24,15 @@
 package iuf
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_iuf "github.com/Cray-HPE/cray-nls/src/api/services/iuf"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/alecthomas/assert"
 	"github.com/gin-gonic/gin"
@@ -60,7 +63,7 @@ func TestGetHistory(t *testing.T) {
 	t.Run("404: not found", func(t *testing.T) {
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
 		iufServiceMock := mocks.NewMockIufService(ctrl)
-		iufServiceMock.EXPECT().GetActivityHistory(gomock.Any(), gomock.Any()).Return(iuf.History{}, nil).AnyTimes()
+		iufServiceMock.EXPECT().GetActivityHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.History{}, nil).AnyTimes()
 		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/:activity_name/history/:start_time", "/iuf/v1/activities/test/history/123")
 		assert.Equal(t, http.StatusNotFound, res.Code)
 	})
@@ -68,9 +71,103 @@ func TestGetHistory(t *testing.T) {
 	t.Run("400: wrong start time", func(t *testing.T) {
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
 		iufServiceMock := mocks.NewMockIufService(ctrl)
-		iufServiceMock.EXPECT().GetActivityHistory(gomock.Any(), gomock.Any()).Return(iuf.History{}, nil).AnyTimes()
+		iufServiceMock.EXPECT().GetActivityHistory(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.History{}, nil).AnyTimes()
 		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/:activity_name/history/:start_time", "/iuf/v1/activities/test/history/asdf")
 		assert.Equal(t, http.StatusBadRequest, res.Code)
 	})
 
 }
+
+func TestHistoryRunAction(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	executeWithContext := func(
+		workflowService *mocks.MockWorkflowService,
+		iufServices *mocks.MockIufService,
+		requestBody string,
+	) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		context, ginEngine := gin.CreateTestContext(response)
+
+		context.Request, _ = http.NewRequest("POST", "/iuf/v1/activities/test/history/run", strings.NewReader(requestBody))
+
+		ginEngine.POST("/iuf/v1/activities/:activity_name/history/run", NewIufController(workflowService, iufServices, *utils.GetLogger().GetGinLogger().Logger).HistoryRunAction)
+		ginEngine.ServeHTTP(response, context.Request)
+		return response
+	}
+	t.Run("201: session created", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryRunAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{Name: "session"}, nil)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, `{"input_parameters":{"stages":["process-media"]}}`)
+		assert.Equal(t, http.StatusCreated, res.Code)
+	})
+
+	t.Run("400: invalid stages", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryRunAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{}, services_iuf.StageValidationError{Message: "Unknown stages: foo."})
+		res := executeWithContext(workflowServiceMock, iufServiceMock, `{"input_parameters":{"stages":["foo"]}}`)
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+		assert.Contains(t, res.Body.String(), "Unknown stages: foo.")
+	})
+
+	t.Run("500: other errors", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryRunAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{}, fmt.Errorf("boom"))
+		res := executeWithContext(workflowServiceMock, iufServiceMock, `{"input_parameters":{"stages":["process-media"]}}`)
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
+
+func TestHistoryRestartAndResumeAction(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	executeWithContext := func(
+		workflowService *mocks.MockWorkflowService,
+		iufServices *mocks.MockIufService,
+		action string,
+	) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		context, ginEngine := gin.CreateTestContext(response)
+
+		context.Request, _ = http.NewRequest("POST", "/iuf/v1/activities/test/history/"+action, strings.NewReader(`{}`))
+
+		controller := NewIufController(workflowService, iufServices, *utils.GetLogger().GetGinLogger().Logger)
+		ginEngine.POST("/iuf/v1/activities/:activity_name/history/restart", controller.HistoryRestartAction)
+		ginEngine.POST("/iuf/v1/activities/:activity_name/history/resume", controller.HistoryResumeAction)
+		ginEngine.ServeHTTP(response, context.Request)
+		return response
+	}
+
+	t.Run("400: restart with stages that are no longer valid", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryRestartAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{}, services_iuf.StageValidationError{Message: "Unknown stages: foo."})
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "restart")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("400: resume with stages that are no longer valid", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryResumeAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{}, services_iuf.StageValidationError{Message: "Unknown stages: foo."})
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "resume")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("500: other errors", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().HistoryRestartAction(gomock.Any(), gomock.Any(), gomock.Any()).Return(iuf.Session{}, fmt.Errorf("boom"))
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "restart")
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+	})
+}
@@ -26,6 +26,7 @@
 package iuf
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
@@ -34,6 +35,7 @@ import (
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"sigs.k8s.io/yaml"
 )
 
 const RESYNC_TIME_IN_SECONDS = 5
@@ -52,7 +54,7 @@ func (u IufController) ListSessions(c *gin.Context) {
 	activityName := c.Param("activity_name")
 	u.logger.Infof("ListSessions: received request for activity %s with params %#v", activityName, c.Request.Form)
 
-	res, err := u.iufService.ListSessions(activityName)
+	res, err := u.iufService.ListSessions(c.Request.Context(), activityName)
 	if err != nil {
 		u.logger.Errorf("ListSessions: An error occurred listing sessions for activity %s: %v", activityName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -77,7 +79,7 @@ func (u IufController) GetSession(c *gin.Context) {
 	sessionName := c.Param("session_name")
 	u.logger.Infof("GetSession: received request for session %s with params %#v", sessionName, c.Request.Form)
 
-	res, err := u.iufService.GetSession(sessionName)
+	res, err := u.iufService.GetSession(c.Request.Context(), sessionName)
 	if err != nil {
 		u.logger.Errorf("GetSession: An error occurred getting session %s: %v", sessionName, err)
 		errResponse := utils.ResponseError{Message: err.Error()}
@@ -87,6 +89,71 @@ func (u IufController) GetSession(c *gin.Context) {
 	c.JSON(http.StatusOK, res)
 }
 
+// GetSessionStageWorkflow
+//
+//	@Summary		Get the Argo workflow that would be generated for a stage of a session, without submitting it
+//	@Param			activity_name	path	string	true	"activity name"
+//	@Param			session_name	path	string	true	"session name"
+//	@Param			stage			path	string	true	"stage name"
+//	@Param			format			query	string	false	"output format"	Enums(json, yaml)
+//	@Tags			Sessions
+//	@Accept			json
+//	@Description	The response is an Argo Workflow (argoproj.io/v1alpha1), the same as what would be submitted
+//	@Produce		json,application/yaml
+//	@Success		200	{object}	object
+//	@Failure		400	{object}	utils.ResponseError
+//	@Failure		404	{object}	utils.ResponseError
+//	@Failure		500	{object}	utils.ResponseError
+//	@Router			/iuf/v1/activities/{activity_name}/sessions/{session_name}/stages/{stage}/workflow [get]
+func (u IufController) GetSessionStageWorkflow(c *gin.Context) {
+	activityName := c.Param("activity_name")
+	sessionName := c.Param("session_name")
+	stageName := c.Param("stage")
+	format := c.DefaultQuery("format", "json")
+	u.logger.Infof("GetSessionStageWorkflow: received request for stage %s of session %s in activity %s with params %#v", stageName, sessionName, activityName, c.Request.Form)
+
+	if format != "json" && format != "yaml" {
+		err := fmt.Errorf("GetSessionStageWorkflow: unsupported format %s, must be one of json or yaml", format)
+		u.logger.Error(err)
+		c.JSON(http.StatusBadRequest, utils.ResponseError{Message: err.Error()})
+		return
+	}
+
+	session, err := u.iufService.GetSession(c.Request.Context(), sessionName)
+	if err != nil || session.ActivityRef != activityName {
+		if err == nil {
+			err = fmt.Errorf("GetSessionStageWorkflow: session %s does not belong to activity %s", sessionName, activityName)
+		}
+		u.logger.Errorf("GetSessionStageWorkflow: An error occurred getting session %s: %v", sessionName, err)
+		c.JSON(http.StatusNotFound, utils.ResponseError{Message: err.Error()})
+		return
+	}
+
+	res, err, skipStage := u.iufService.GetStageWorkflow(c.Request.Context(), session, stageName)
+	if err != nil {
+		u.logger.Errorf("GetSessionStageWorkflow: An error occurred generating workflow for stage %s of session %s: %v", stageName, sessionName, err)
+		c.JSON(http.StatusInternalServerError, utils.ResponseError{Message: err.Error()})
+		return
+	} else if skipStage {
+		err := fmt.Errorf("GetSessionStageWorkflow: stage %s of session %s has no tasks to run and would be skipped", stageName, sessionName)
+		u.logger.Info(err)
+		c.JSON(http.StatusNotFound, utils.ResponseError{Message: err.Error()})
+		return
+	}
+
+	if format == "yaml" {
+		bytes, err := yaml.Marshal(res)
+		if err != nil {
+			u.logger.Errorf("GetSessionStageWorkflow: An error occurred converting workflow for stage %s of session %s to YAML: %v", stageName, sessionName, err)
+			c.JSON(http.StatusInternalServerError, utils.ResponseError{Message: err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "application/yaml", bytes)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
 func (u IufController) Sync(context *gin.Context) {
 	var requestBody iuf.SyncRequest
 	if err := context.BindJSON(&requestBody); err != nil {
@@ -94,8 +161,10 @@ func (u IufController) Sync(context *gin.Context) {
 		context.JSON(500, utils.ResponseError{Message: err.Error()})
 		return
 	}
+	// the session stays locked until the sync is done, so it runs to the end even if the caller goes away
+	ctx := utils.DetachedContext(context.Request.Context())
 	sessionName := requestBody.Object.Name
-	session, err := u.iufService.GetSession(sessionName)
+	session, err := u.iufService.GetSession(ctx, sessionName)
 	if err != nil {
 		u.logger.Errorf("Sync.2: An error occurred getting session %s: %v", sessionName, err)
 		context.JSON(500, utils.ResponseError{Message: err.Error()})
@@ -103,7 +172,7 @@ func (u IufController) Sync(context *gin.Context) {
 	}
 
 	u.logger.Infof("Sync.lock: Locking session %s of activity %s to prevent reentrants...", sessionName, session.ActivityRef)
-	sessionLocked := u.iufService.LockSession(session)
+	sessionLocked := u.iufService.LockSession(ctx, session)
 
 	if !sessionLocked {
 		// Another worker is working on it. Let's wait and try again in case the other pod/thread has died.
@@ -119,11 +188,11 @@ func (u IufController) Sync(context *gin.Context) {
 		// reset to anything but transitioning at the end.
 		defer func() {
 			u.logger.Debugf("Sync.lock.3: Trying to unlock session %s of activity %s.", sessionName, session.ActivityRef)
-			u.iufService.UnlockSession(session)
+			u.iufService.UnlockSession(ctx, session)
 		}()
 	}
 
-	err = u.iufService.SyncWorkflowsToSession(&session)
+	err = u.iufService.SyncWorkflowsToSession(ctx, &session)
 	if err != nil {
 		u.logger.Warnf("Sync.3: State is empty, creating workflow: %s, resource version: %s, session: %s, activity: %s", session.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
 	}
@@ -135,7 +204,7 @@ func (u IufController) Sync(context *gin.Context) {
 	switch session.CurrentState {
 	case "":
 		u.logger.Infof("Sync: State is empty, creating workflow: %s, resource version: %s, session: %s, activity: %s", session.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
-		response, err, _ := u.iufService.RunNextStage(&session)
+		response, err, _ := u.iufService.RunNextStage(ctx, &session)
 		if err != nil {
 			context.JSON(500, utils.ResponseError{Message: err.Error()})
 			return
@@ -143,93 +212,39 @@ func (u IufController) Sync(context *gin.Context) {
 		context.JSON(200, response)
 		return
 	case iuf.SessionStateInProgress:
-		activeWorkflow := u.iufService.FindLastWorkflowForCurrentStage(&session)
-		if activeWorkflow == nil {
-			u.restartCurrentStageFromSyncCall(context, session, requestBody, response)
-			return
+		// stages that do not depend on each other run in parallel, so sync every stage that is running
+		currentStages := append([]string{}, session.CurrentStages...)
+		if len(currentStages) == 0 {
+			currentStages = []string{session.CurrentStage}
 		}
 
-		u.logger.Debugf("Sync: Going to sync with the workflow %s for session %s in activity %s. Also, .ObjectMeta.Labels: %#v, .Labels: %#v", activeWorkflow.Name, sessionName, session.ActivityRef, activeWorkflow.ObjectMeta.Labels, activeWorkflow.Labels)
-
-		if activeWorkflow.Status.Phase == v1alpha1.WorkflowRunning || activeWorkflow.Status.Phase == v1alpha1.WorkflowPending {
-			u.logger.Debugf("Sync: Workflow %s is still running for session %s in activity %s", activeWorkflow.Name, sessionName, session.ActivityRef)
-			context.JSON(200, response)
-			return
-		} else if activeWorkflow.Status.Phase == v1alpha1.WorkflowError || activeWorkflow.Status.Phase == v1alpha1.WorkflowFailed {
-			u.logger.Infof("Sync: Workflow is in failed/error state. Workflow: %s, resource version: %s, session: %s, activity: %s", activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
-
-			// still extract the outputs from the successful steps so that if we restart we can skip over those steps.
-			u.doProcessOutputs(activeWorkflow, &session, requestBody, sessionName)
-
-			// don't do anything if session has already been aborted.
-			if session.CurrentState == iuf.SessionStateAborted {
-				context.JSON(200, response)
+		for _, stage := range currentStages {
+			session.CurrentStage = stage
+			stageResponse, err := u.syncCurrentStage(ctx, &session, requestBody, sessionName)
+			if err != nil {
+				context.JSON(500, utils.ResponseError{Message: err.Error()})
 				return
 			}
 
-			// if this was a partial workflow, let the processing for partial workflow do the work
-			if activeWorkflow.ObjectMeta.Labels[services_iuf.LABEL_PARTIAL_WORKFLOW] == "true" {
-				u.logger.Infof("Sync: Stage: %s has a partial workflow that failed, moving on to the remaining products in the next workflow. Workflow failed: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
-				response, err, _ = u.iufService.RunNextPartialWorkflow(&session)
-				if err != nil {
-					u.logger.Errorf("Sync: Unable to run the next set of products for the current stage or go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
-					// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
-					//  This is the downside of using a non-transactional storage such as CRDs.
-					context.JSON(500, utils.ResponseError{Message: err.Error()})
-					return
-				}
-			} else {
-				u.logger.Infof("Sync: Stage: %s's workflow failed, and since it was not a partial workflow, setting the session state to DEBUG. Workflow failed: %s, resource version: %s, session: %s, activity: %s, .ObjectMeta.Labels: %#v, .Labels: %#v", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, activeWorkflow.ObjectMeta.Labels, activeWorkflow.Labels)
-				session.CurrentState = iuf.SessionStateDebug
-				err = u.iufService.UpdateSessionAndActivity(session, fmt.Sprintf("Failed workflow %s", activeWorkflow.Name))
-				if err == nil {
-					// since the workflow failed, and there was no error in updating the session and activity, we don't want to resync
-					response = iuf.SyncResponse{}
-				}
+			if session.CurrentState != iuf.SessionStateInProgress {
+				// the session is not running anymore, so the remaining stages will be synced later if needed
+				response = stageResponse
+				break
 			}
-
-			context.JSON(200, response)
-			return
-		} else if activeWorkflow.Status.Phase == v1alpha1.WorkflowSucceeded {
-			u.doProcessOutputs(activeWorkflow, &session, requestBody, sessionName)
-
-			u.logger.Infof("Sync: Stage: %s succeeded, move to the next stage. Workflow: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
-			currentStage := session.CurrentStage
-
-			if activeWorkflow.ObjectMeta.Labels[services_iuf.LABEL_PARTIAL_WORKFLOW] == "true" {
-				u.logger.Infof("Sync: Stage: %s has a partial workflow that succeeded, moving on to the remaining products in the next workflow. Workflow completed: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
-				response, err, _ = u.iufService.RunNextPartialWorkflow(&session)
-				if err != nil {
-					u.logger.Errorf("Sync: Unable to run the next set of products for the current stage or go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
-					// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
-					//  This is the downside of using a non-transactional storage such as CRDs.
-					context.JSON(500, utils.ResponseError{Message: err.Error()})
-					return
-				}
-			} else {
-				response, err, _ = u.iufService.RunNextStage(&session)
-				if err != nil {
-					u.logger.Errorf("Sync: Unable to go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
-					// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
-					//  This is the downside of using a non-transactional storage such as CRDs.
-					context.JSON(500, utils.ResponseError{Message: err.Error()})
-					return
-				}
+			if stageResponse.ResyncAfterSeconds > 0 && stageResponse.ResyncAfterSeconds < response.ResyncAfterSeconds {
+				response = stageResponse
 			}
-
-			context.JSON(200, response)
-			return
-		} else {
-			context.JSON(200, response)
-			return
 		}
+
+		context.JSON(200, response)
+		return
 	case iuf.SessionStateAborted, iuf.SessionStatePaused, iuf.SessionStateDebug, iuf.SessionStateCompleted:
 		u.logger.Infof("Sync: The session %s in activity %s is in state %s and there is nothing to do", session.Name, session.ActivityRef, session.CurrentState)
 		context.JSON(200, iuf.SyncResponse{})
 		return
 	default:
 		session.CurrentState = iuf.SessionStateDebug
-		err = u.iufService.UpdateSessionAndActivity(session, fmt.Sprintf("Unknown state %s", session.CurrentState))
+		err = u.iufService.UpdateSessionAndActivity(ctx, session, fmt.Sprintf("Unknown state %s", session.CurrentState))
 		if err != nil {
 			context.JSON(500, utils.ResponseError{Message: err.Error()})
 			return
@@ -241,43 +256,123 @@ func (u IufController) Sync(context *gin.Context) {
 		context.JSON(500, utils.ResponseError{Message: err.Error()})
 		return
 	}
+}
+
+// syncCurrentStage syncs the session with the last workflow of its current stage, and moves on to the next workflow of
+//  that stage or to the next stages when that workflow is done.
+func (u IufController) syncCurrentStage(ctx context.Context, session *iuf.Session, requestBody iuf.SyncRequest, sessionName string) (iuf.SyncResponse, error) {
+	response := iuf.SyncResponse{
+		ResyncAfterSeconds: RESYNC_TIME_IN_SECONDS,
+	}
 
-	// why did we end up here? Golang really needs better static analysis.
-	context.JSON(500, utils.ResponseError{Message: "Sync: Unknown code path. Shouldn't have landed here."})
-	return
+	activeWorkflow := u.iufService.FindLastWorkflowForCurrentStage(ctx, session)
+	if activeWorkflow == nil {
+		return u.restartCurrentStageFromSyncCall(ctx, session, requestBody)
+	}
+
+	u.logger.Debugf("Sync: Going to sync with the workflow %s for stage %s in session %s in activity %s. Also, .ObjectMeta.Labels: %#v, .Labels: %#v", activeWorkflow.Name, session.CurrentStage, sessionName, session.ActivityRef, activeWorkflow.ObjectMeta.Labels, activeWorkflow.Labels)
+
+	var err error
+	if activeWorkflow.Status.Phase == v1alpha1.WorkflowRunning || activeWorkflow.Status.Phase == v1alpha1.WorkflowPending {
+		u.logger.Debugf("Sync: Workflow %s is still running for session %s in activity %s", activeWorkflow.Name, sessionName, session.ActivityRef)
+		return response, nil
+	} else if activeWorkflow.Status.Phase == v1alpha1.WorkflowError || activeWorkflow.Status.Phase == v1alpha1.WorkflowFailed {
+		u.logger.Infof("Sync: Workflow is in failed/error state. Workflow: %s, resource version: %s, session: %s, activity: %s", activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
+
+		// still extract the outputs from the successful steps so that if we restart we can skip over those steps.
+		u.doProcessOutputs(ctx, activeWorkflow, session, requestBody, sessionName)
+
+		// don't do anything if session has already been aborted.
+		if session.CurrentState == iuf.SessionStateAborted {
+			return response, nil
+		}
+
+		// if this was a partial workflow, let the processing for partial workflow do the work
+		if activeWorkflow.ObjectMeta.Labels[services_iuf.LABEL_PARTIAL_WORKFLOW] == "true" {
+			u.logger.Infof("Sync: Stage: %s has a partial workflow that failed, moving on to the remaining products in the next workflow. Workflow failed: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
+			currentStage := session.CurrentStage
+			response, err, _ = u.iufService.RunNextPartialWorkflow(ctx, session)
+			if err != nil {
+				u.logger.Errorf("Sync: Unable to run the next set of products for the current stage or go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
+				// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
+				//  This is the downside of using a non-transactional storage such as CRDs.
+				return response, err
+			}
+		} else {
+			u.logger.Infof("Sync: Stage: %s's workflow failed, and since it was not a partial workflow, setting the session state to DEBUG. Workflow failed: %s, resource version: %s, session: %s, activity: %s, .ObjectMeta.Labels: %#v, .Labels: %#v", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, activeWorkflow.ObjectMeta.Labels, activeWorkflow.Labels)
+			session.CurrentState = iuf.SessionStateDebug
+			err = u.iufService.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Failed workflow %s", activeWorkflow.Name))
+			if err == nil {
+				// since the workflow failed, and there was no error in updating the session and activity, we don't want to resync
+				response = iuf.SyncResponse{}
+			}
+		}
+
+		return response, nil
+	} else if activeWorkflow.Status.Phase == v1alpha1.WorkflowSucceeded {
+		u.doProcessOutputs(ctx, activeWorkflow, session, requestBody, sessionName)
+
+		if services_iuf.HasPendingChecksums(session.Products) {
+			u.logger.Infof("Sync: Stage: %s succeeded, waiting for the checksums of its products to be verified before moving to the next stage. Workflow: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
+			return response, nil
+		}
+
+		u.logger.Infof("Sync: Stage: %s succeeded, move to the next stage. Workflow: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
+		currentStage := session.CurrentStage
+
+		if activeWorkflow.ObjectMeta.Labels[services_iuf.LABEL_PARTIAL_WORKFLOW] == "true" {
+			u.logger.Infof("Sync: Stage: %s has a partial workflow that succeeded, moving on to the remaining products in the next workflow. Workflow completed: %s, resource version: %s, session: %s, activity: %s", session.CurrentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
+			response, err, _ = u.iufService.RunNextPartialWorkflow(ctx, session)
+			if err != nil {
+				u.logger.Errorf("Sync: Unable to run the next set of products for the current stage or go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
+				// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
+				//  This is the downside of using a non-transactional storage such as CRDs.
+				return response, err
+			}
+		} else {
+			response, err, _ = u.iufService.RunNextStage(ctx, session)
+			if err != nil {
+				u.logger.Errorf("Sync: Unable to go to next stage. Current stage: %s, workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, activeWorkflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
+				// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
+				//  This is the downside of using a non-transactional storage such as CRDs.
+				return response, err
+			}
+		}
+
+		return response, nil
+	}
+
+	return response, nil
 }
 
 // Processes the outputs of the given workflow.
-func (u IufController) doProcessOutputs(workflow *v1alpha1.Workflow, session *iuf.Session, requestBody iuf.SyncRequest, sessionName string) {
+func (u IufController) doProcessOutputs(ctx context.Context, workflow *v1alpha1.Workflow, session *iuf.Session, requestBody iuf.SyncRequest, sessionName string) {
 	u.logger.Infof("doProcessOutputs: About to process outputs for workflow: %s, resource version: %s, session: %s, activity: %s", workflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef)
 
-	err := u.iufService.ProcessOutput(session, workflow)
+	err := u.iufService.ProcessOutput(ctx, session, workflow)
 	if err != nil {
 		u.logger.Errorf("Sync: An error occurred processing the output for the workflow: %s, resource version: %s, session: %s, activity: %s, error: %v", workflow.Name, requestBody.Object.ObjectMeta.ResourceVersion, sessionName, session.ActivityRef, err)
 		// do not return error, just continue because process output should not re-attempt stage.
 	}
 }
 
-func (u IufController) restartCurrentStageFromSyncCall(context *gin.Context, session iuf.Session, requestBody iuf.SyncRequest, response iuf.SyncResponse) {
+func (u IufController) restartCurrentStageFromSyncCall(ctx context.Context, session *iuf.Session, requestBody iuf.SyncRequest) (iuf.SyncResponse, error) {
 	u.logger.Infof("Sync: Restarting stage %s in session %s in activity %s", session.CurrentStage, session.Name, session.ActivityRef)
 
-	err := u.iufService.RestartCurrentStage(&session, session.CurrentStage)
+	currentStage := session.CurrentStage
+	err := u.iufService.RestartCurrentStage(ctx, session, currentStage)
 	if err != nil {
-		u.logger.Errorf("Sync: Unable to restart current stage. Current stage: %s, resource version: %s, session: %s, activity: %s, error: %v", session.CurrentStage, requestBody.Object.ObjectMeta.ResourceVersion, session.Name, session.ActivityRef, err)
+		u.logger.Errorf("Sync: Unable to restart current stage. Current stage: %s, resource version: %s, session: %s, activity: %s, error: %v", currentStage, requestBody.Object.ObjectMeta.ResourceVersion, session.Name, session.ActivityRef, err)
 		// note: do NOT automatically retry -- we don't know whether CurrentStage has already been updated
 		//  This is the downside of using a non-transactional storage such as CRDs.
-		context.JSON(500, utils.ResponseError{Message: err.Error()})
-
 		session.CurrentState = iuf.SessionStateDebug
-		u.iufService.UpdateSessionAndActivity(session, "Unable to restart current stage")
-		return
+		u.iufService.UpdateSessionAndActivity(ctx, *session, "Unable to restart current stage")
+		return iuf.SyncResponse{}, err
 	}
 
-	response = iuf.SyncResponse{
+	return iuf.SyncResponse{
 		ResyncAfterSeconds: RESYNC_TIME_IN_SECONDS,
-	}
-
-	context.JSON(200, response)
+	}, nil
 }
 
 // WorkflowSync **experimental** Instead of a webhook on Session, we should have defined a webhook on Argo workflows instead
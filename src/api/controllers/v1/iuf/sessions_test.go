@@ -0,0 +1,211 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+//
+package iuf
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetSessionStageWorkflow(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	executeWithContext := func(
+		workflowService *mocks.MockWorkflowService,
+		iufServices *mocks.MockIufService,
+		requestUrl string,
+	) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		context, ginEngine := gin.CreateTestContext(response)
+
+		context.Request, _ = http.NewRequest("GET", requestUrl, nil)
+
+		ginEngine.GET("/iuf/v1/activities/:activity_name/sessions/:session_name/stages/:stage/workflow", NewIufController(workflowService, iufServices, *utils.GetLogger().GetGinLogger().Logger).GetSessionStageWorkflow)
+		ginEngine.ServeHTTP(response, context.Request)
+		return response
+	}
+
+	workflow := v1alpha1.Workflow{}
+	workflow.GenerateName = "session-a-deliver-product-"
+
+	t.Run("200: json by default", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(iuf.Session{Name: "session-a", ActivityRef: "activity-a"}, nil)
+		iufServiceMock.EXPECT().GetStageWorkflow(gomock.Any(), gomock.Any(), "deliver-product").Return(workflow, nil, false)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/sessions/session-a/stages/deliver-product/workflow")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.True(t, strings.Contains(res.Body.String(), `"generateName":"session-a-deliver-product-"`))
+	})
+
+	t.Run("200: yaml", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(iuf.Session{Name: "session-a", ActivityRef: "activity-a"}, nil)
+		iufServiceMock.EXPECT().GetStageWorkflow(gomock.Any(), gomock.Any(), "deliver-product").Return(workflow, nil, false)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/sessions/session-a/stages/deliver-product/workflow?format=yaml")
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "application/yaml", res.Header().Get("Content-Type"))
+		assert.True(t, strings.Contains(res.Body.String(), "generateName: session-a-deliver-product-"))
+	})
+
+	t.Run("400: unsupported format", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/sessions/session-a/stages/deliver-product/workflow?format=xml")
+		assert.Equal(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("404: session belongs to another activity", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(iuf.Session{Name: "session-a", ActivityRef: "activity-b"}, nil)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/sessions/session-a/stages/deliver-product/workflow")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("404: stage would be skipped", func(t *testing.T) {
+		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+		iufServiceMock := mocks.NewMockIufService(ctrl)
+		iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(iuf.Session{Name: "session-a", ActivityRef: "activity-a"}, nil)
+		iufServiceMock.EXPECT().GetStageWorkflow(gomock.Any(), gomock.Any(), "deliver-product").Return(v1alpha1.Workflow{}, nil, true)
+		res := executeWithContext(workflowServiceMock, iufServiceMock, "/iuf/v1/activities/activity-a/sessions/session-a/stages/deliver-product/workflow")
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestSyncParallelStages(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newWorkflow := func(name string, stage string, phase v1alpha1.WorkflowPhase) *v1alpha1.Workflow {
+		wf := v1alpha1.Workflow{}
+		wf.Name = name
+		wf.Labels = map[string]string{"stage": stage}
+		wf.Status.Phase = phase
+		return &wf
+	}
+	workflowsByStage := map[string]*v1alpha1.Workflow{
+		"update-vcs-config": newWorkflow("vcs", "update-vcs-config", v1alpha1.WorkflowRunning),
+		"prepare-images":    newWorkflow("images", "prepare-images", v1alpha1.WorkflowSucceeded),
+	}
+
+	session := iuf.Session{
+		Name:            "session-a",
+		ActivityRef:     "activity-a",
+		CurrentStage:    "update-vcs-config",
+		CurrentStages:   []string{"update-vcs-config", "prepare-images"},
+		CompletedStages: []string{"process-media", "deliver-product"},
+		CurrentState:    iuf.SessionStateInProgress,
+	}
+
+	workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+	iufServiceMock := mocks.NewMockIufService(ctrl)
+	iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(session, nil)
+	iufServiceMock.EXPECT().LockSession(gomock.Any(), gomock.Any()).Return(true)
+	iufServiceMock.EXPECT().UnlockSession(gomock.Any(), gomock.Any())
+	iufServiceMock.EXPECT().SyncWorkflowsToSession(gomock.Any(), gomock.Any()).Return(nil)
+	iufServiceMock.EXPECT().FindLastWorkflowForCurrentStage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx interface{}, session *iuf.Session) *v1alpha1.Workflow {
+			return workflowsByStage[session.CurrentStage]
+		}).Times(2)
+	iufServiceMock.EXPECT().ProcessOutput(gomock.Any(), gomock.Any(), workflowsByStage["prepare-images"]).Return(nil)
+	// only the stage whose workflow succeeded moves on, the running stage is left alone
+	iufServiceMock.EXPECT().RunNextStage(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx interface{}, session *iuf.Session) (iuf.SyncResponse, error, bool) {
+			assert.Equal(t, "prepare-images", session.CurrentStage)
+			return iuf.SyncResponse{ResyncAfterSeconds: 1}, nil, false
+		})
+
+	response := httptest.NewRecorder()
+	context, ginEngine := gin.CreateTestContext(response)
+	body, _ := json.Marshal(iuf.SyncRequest{Object: v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "session-a"}}})
+	context.Request, _ = http.NewRequest("POST", "/apis/iuf/v1/session/sync", bytes.NewReader(body))
+	ginEngine.POST("/apis/iuf/v1/session/sync", NewIufController(workflowServiceMock, iufServiceMock, *utils.GetLogger().GetGinLogger().Logger).Sync)
+	ginEngine.ServeHTTP(response, context.Request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"resyncAfterSeconds":1`), response.Body.String())
+}
+
+func TestSyncWaitsForChecksums(t *testing.T) {
+
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	workflow := v1alpha1.Workflow{}
+	workflow.Name = "process-media"
+	workflow.Labels = map[string]string{"stage": "process-media"}
+	workflow.Status.Phase = v1alpha1.WorkflowSucceeded
+	session := iuf.Session{
+		Name:         "session-a",
+		ActivityRef:  "activity-a",
+		CurrentStage: "process-media",
+		CurrentState: iuf.SessionStateInProgress,
+	}
+
+	workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
+	iufServiceMock := mocks.NewMockIufService(ctrl)
+	iufServiceMock.EXPECT().GetSession(gomock.Any(), "session-a").Return(session, nil)
+	iufServiceMock.EXPECT().LockSession(gomock.Any(), gomock.Any()).Return(true)
+	iufServiceMock.EXPECT().UnlockSession(gomock.Any(), gomock.Any())
+	iufServiceMock.EXPECT().SyncWorkflowsToSession(gomock.Any(), gomock.Any()).Return(nil)
+	iufServiceMock.EXPECT().FindLastWorkflowForCurrentStage(gomock.Any(), gomock.Any()).Return(&workflow)
+	iufServiceMock.EXPECT().ProcessOutput(gomock.Any(), gomock.Any(), &workflow).DoAndReturn(
+		func(ctx interface{}, session *iuf.Session, workflow *v1alpha1.Workflow) error {
+			session.Products = []iuf.Product{{Name: "product", Version: "1.0.0", Checksums: &iuf.ChecksumResult{Pending: true}}}
+			return nil
+		})
+	// RunNextStage is not expected: the session stays at process-media until the checksums are verified
+
+	response := httptest.NewRecorder()
+	context, ginEngine := gin.CreateTestContext(response)
+	body, _ := json.Marshal(iuf.SyncRequest{Object: v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "session-a"}}})
+	context.Request, _ = http.NewRequest("POST", "/apis/iuf/v1/session/sync", bytes.NewReader(body))
+	ginEngine.POST("/apis/iuf/v1/session/sync", NewIufController(workflowServiceMock, iufServiceMock, *utils.GetLogger().GetGinLogger().Logger).Sync)
+	ginEngine.ServeHTTP(response, context.Request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	assert.True(t, strings.Contains(response.Body.String(), `"resyncAfterSeconds":5`), response.Body.String())
+}
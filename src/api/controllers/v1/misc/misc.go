@@ -26,60 +26,102 @@ package controllers_v1
 import (
 	"net/http"
 
+	models_nls "github.com/Cray-HPE/cray-nls/src/api/models/nls"
 	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/gin-gonic/gin"
 )
 
-// use ldflags to replace this value during build:
-// 		https://www.digitalocean.com/community/tutorials/using-ldflags-to-set-version-information-for-go-applications
-const VERSION string = "development"
-
 // MiscController data type
 type MiscController struct {
 	workflowService services_shared.WorkflowService
+	healthService   services_shared.HealthService
+	versionService  services_shared.VersionService
 	logger          utils.Logger
 }
 
 // NewMiscController creates new Misc controller
-func NewMiscController(workflowService services_shared.WorkflowService, logger utils.Logger) MiscController {
+func NewMiscController(workflowService services_shared.WorkflowService, healthService services_shared.HealthService, versionService services_shared.VersionService, logger utils.Logger) MiscController {
 	return MiscController{
 		workflowService: workflowService,
+		healthService:   healthService,
+		versionService:  versionService,
 		logger:          logger,
 	}
 }
 
 // GetVersion
-//	@Summary	Get version of cray-nls service
+//	@Summary	Get version of cray-nls service, and of the IUF stages, the IUF manifest schema, Argo and the workflow templates
 //	@Tags		Misc
 //	@Accept		json
 //	@Produce	json
-//	@Success	200	{object}	utils.ResponseOk
+//	@Success	200	{object}	models.VersionResponse
 //	@Failure	500	{object}	utils.ResponseError
-//	@Router		/v1/version [get]
+//	@Router		/nls/v1/version [get]
 func (u MiscController) GetVersion(c *gin.Context) {
-	c.JSON(200, utils.ResponseOk{Message: VERSION})
+	c.JSON(200, u.versionService.GetVersion(c.Request.Context()))
 }
 
 // GetReadiness
-//	@Summary	K8s Readiness endpoint
-//	@Tags		Misc
-//	@Accept		json
-//	@Produce	json
-//	@Success	204
-//	@Failure	500	{object}	utils.ResponseError
-//	@Router		/v1/readiness [get]
+//	@Summary		K8s Readiness endpoint, checks Kubernetes, Argo, Keycloak in production, the IUF stages and the rebuild templates
+//	@Description	The results are cached for 10 seconds. Use /nls/v1/health/detail to see why a check failed
+//	@Tags			Misc
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	models.HealthResponse
+//	@Failure		503	{object}	models.HealthResponse
+//	@Router			/nls/v1/readiness [get]
 func (u MiscController) GetReadiness(c *gin.Context) {
-	c.Status(http.StatusNoContent)
+	checks := u.healthService.Ready(c.Request.Context())
+	response := models_nls.HealthResponse{Healthy: isHealthy(checks), Checks: map[string]string{}}
+	for _, check := range checks {
+		if check.Healthy {
+			response.Checks[check.Name] = models_nls.HEALTH_CHECK_OK
+		} else {
+			response.Checks[check.Name] = models_nls.HEALTH_CHECK_FAILED
+		}
+	}
+	c.JSON(healthStatus(response.Healthy), response)
 }
 
-// GetLiveness
-//	@Summary	K8s Liveness endpoint
+// GetHealthDetail
+//	@Summary	Whether NLS and every dependency it needs are healthy, with the error of each check that failed
 //	@Tags		Misc
 //	@Accept		json
 //	@Produce	json
-//	@Success	204
-//	@Router		/v1/liveness [get]
+//	@Success	200	{object}	models.HealthDetailResponse
+//	@Failure	503	{object}	models.HealthDetailResponse
+//	@Router		/nls/v1/health/detail [get]
+func (u MiscController) GetHealthDetail(c *gin.Context) {
+	checks := u.healthService.Check(c.Request.Context())
+	response := models_nls.HealthDetailResponse{Healthy: isHealthy(checks), Version: utils.Version, Checks: checks}
+	c.JSON(healthStatus(response.Healthy), response)
+}
+
+// GetLiveness
+//	@Summary		K8s Liveness endpoint
+//	@Description	Does not check any dependency: restarting NLS does not bring Argo or Kubernetes back
+//	@Tags			Misc
+//	@Accept			json
+//	@Produce		json
+//	@Success		204
+//	@Router			/nls/v1/liveness [get]
 func (u MiscController) GetLiveness(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
+
+func isHealthy(checks []models_nls.HealthCheck) bool {
+	for _, check := range checks {
+		if !check.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+func healthStatus(healthy bool) int {
+	if healthy {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,139 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package controllers_v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
+	models_nls "github.com/Cray-HPE/cray-nls/src/api/models/nls"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	execute := func(healthService *mocks.MockHealthService, path string) *httptest.ResponseRecorder {
+		response := httptest.NewRecorder()
+		_, ginEngine := gin.CreateTestContext(response)
+		controller := NewMiscController(nil, healthService, nil, utils.GetLogger())
+		ginEngine.GET("/v1/readiness", controller.GetReadiness)
+		ginEngine.GET("/v1/health/detail", controller.GetHealthDetail)
+		request, _ := http.NewRequest("GET", path, nil)
+		ginEngine.ServeHTTP(response, request)
+		return response
+	}
+	allHealthy := []models_nls.HealthCheck{
+		{Name: "kubernetes", Healthy: true},
+		{Name: "iuf_stages", Healthy: true},
+		{Name: "worker_rebuild_templates", Healthy: true},
+	}
+	stagesMissing := []models_nls.HealthCheck{
+		{Name: "iuf_stages", Healthy: false, Error: "open stages.yaml: no such file or directory"},
+		{Name: "worker_rebuild_templates", Healthy: true},
+	}
+	argoDown := []models_nls.HealthCheck{
+		{Name: "kubernetes", Healthy: true},
+		{Name: "argo", Healthy: false, Error: "connection refused"},
+	}
+
+	t.Run("It is ready when every check is healthy", func(t *testing.T) {
+		healthServiceMock := mocks.NewMockHealthService(ctrl)
+		healthServiceMock.EXPECT().Ready(gomock.Any()).Return(allHealthy)
+
+		res := execute(healthServiceMock, "/v1/readiness")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		var body models_nls.HealthResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.Equal(t, models_nls.HealthResponse{Healthy: true, Checks: map[string]string{"kubernetes": "ok", "iuf_stages": "ok", "worker_rebuild_templates": "ok"}}, body)
+	})
+	t.Run("It is not ready when a check fails, and does not tell why", func(t *testing.T) {
+		healthServiceMock := mocks.NewMockHealthService(ctrl)
+		healthServiceMock.EXPECT().Ready(gomock.Any()).Return(stagesMissing)
+
+		res := execute(healthServiceMock, "/v1/readiness")
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.NotContains(t, res.Body.String(), "no such file or directory")
+		var body models_nls.HealthResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.Equal(t, models_nls.HealthResponse{Healthy: false, Checks: map[string]string{"iuf_stages": "failed", "worker_rebuild_templates": "ok"}}, body)
+	})
+	t.Run("It is not ready when a dependency is down", func(t *testing.T) {
+		healthServiceMock := mocks.NewMockHealthService(ctrl)
+		healthServiceMock.EXPECT().Ready(gomock.Any()).Return(argoDown)
+
+		res := execute(healthServiceMock, "/v1/readiness")
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		var body models_nls.HealthResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.Equal(t, models_nls.HealthResponse{Healthy: false, Checks: map[string]string{"kubernetes": "ok", "argo": "failed"}}, body)
+	})
+	t.Run("The detail has the error of each dependency", func(t *testing.T) {
+		healthServiceMock := mocks.NewMockHealthService(ctrl)
+		healthServiceMock.EXPECT().Check(gomock.Any()).Return(argoDown)
+
+		res := execute(healthServiceMock, "/v1/health/detail")
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		var body models_nls.HealthDetailResponse
+		assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+		assert.Equal(t, utils.Version, body.Version)
+		assert.Equal(t, argoDown, body.Checks)
+	})
+}
+
+func TestGetVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	versionServiceMock := mocks.NewMockVersionService(ctrl)
+	versionServiceMock.EXPECT().GetVersion(gomock.Any()).Return(models_nls.VersionResponse{Message: "1.2.3", Version: "1.2.3", ArgoServerVersion: "v3.3.8"})
+	response := httptest.NewRecorder()
+	_, ginEngine := gin.CreateTestContext(response)
+	ginEngine.GET("/v1/version", NewMiscController(nil, nil, versionServiceMock, utils.GetLogger()).GetVersion)
+	request, _ := http.NewRequest("GET", "/v1/version", nil)
+
+	ginEngine.ServeHTTP(response, request)
+
+	assert.Equal(t, http.StatusOK, response.Code)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(response.Body.Bytes(), &body))
+	// clients that only know the message still get the version
+	assert.Equal(t, "1.2.3", body["message"])
+	assert.Equal(t, "v3.3.8", body["argoServerVersion"])
+}
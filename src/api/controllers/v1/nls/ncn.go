@@ -104,7 +104,7 @@ func (u NcnController) createRebuildWorkflow(req models_nls.CreateRebuildWorkflo
 	}
 	u.logger.Infof("Hostnames: %v, dryRun: %v", req.Hosts, req.DryRun)
 
-	workflow, err := u.workflowService.CreateRebuildWorkflow(req)
+	workflow, err := u.workflowService.CreateRebuildWorkflow(c.Request.Context(), req)
 
 	if err != nil {
 		u.logger.Error(err)
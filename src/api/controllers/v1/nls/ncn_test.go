@@ -66,7 +66,7 @@ func TestNcnsCreateRebuildWorkflow(t *testing.T) {
 	t.Run("Happy", func(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
-		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any()).Return(
+		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any(), gomock.Any()).Return(
 			&v1alpha1.Workflow{
 				ObjectMeta: v1.ObjectMeta{Name: "mocked", Labels: map[string]string{"targetNcn": "mocked-target-ncn"}},
 			}, nil)
@@ -87,7 +87,7 @@ func TestNcnsCreateRebuildWorkflow(t *testing.T) {
 	t.Run("Happy", func(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
-		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any()).Return(
+		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any(), gomock.Any()).Return(
 			&v1alpha1.Workflow{
 				ObjectMeta: v1.ObjectMeta{Name: "mocked", Labels: map[string]string{"targetNcn": "mocked-target-ncn"}},
 			}, nil)
@@ -108,7 +108,7 @@ func TestNcnsCreateRebuildWorkflow(t *testing.T) {
 	t.Run("Error", func(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
-		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any()).Return(nil, fmt.Errorf("mocked error"))
+		workflowServiceMock.EXPECT().CreateRebuildWorkflow(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("mocked error"))
 		res := executeWithContext(
 			workflowServiceMock,
 			`{
@@ -57,7 +57,7 @@ func NewWorkflowController(Service services_shared.WorkflowService, logger utils
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/nls/v1/workflows [get]
 func (u WorkflowController) GetWorkflows(c *gin.Context) {
-	workflowList, err := u.service.GetWorkflows(c)
+	workflowList, err := u.service.GetWorkflows(c.Request.Context(), c.Query("labelSelector"))
 	if err != nil {
 		errResponse := utils.ResponseError{Message: err.Error()}
 		c.JSON(500, errResponse)
@@ -89,7 +89,7 @@ func (u WorkflowController) GetWorkflows(c *gin.Context) {
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/nls/v1/workflows/{name} [delete]
 func (u WorkflowController) DeleteWorkflow(c *gin.Context) {
-	err := u.service.DeleteWorkflow(c)
+	err := u.service.DeleteWorkflow(c.Request.Context(), c.Param("name"))
 	if err != nil {
 		errResponse := utils.ResponseError{Message: err.Error()}
 		c.JSON(500, errResponse)
@@ -111,7 +111,15 @@ func (u WorkflowController) DeleteWorkflow(c *gin.Context) {
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/nls/v1/workflows/{name}/retry [put]
 func (u WorkflowController) RetryWorkflow(c *gin.Context) {
-	err := u.service.RetryWorkflow(c)
+	var requestBody models_nls.RetryWorkflowRequestBody
+	if err := c.BindJSON(&requestBody); err != nil {
+		u.logger.Error(err)
+		errResponse := utils.ResponseError{Message: err.Error()}
+		c.JSON(400, errResponse)
+		return
+	}
+
+	err := u.service.RetryWorkflow(c.Request.Context(), c.Param("name"), requestBody)
 	if err != nil {
 		errResponse := utils.ResponseError{Message: err.Error()}
 		c.JSON(500, errResponse)
@@ -132,7 +140,7 @@ func (u WorkflowController) RetryWorkflow(c *gin.Context) {
 //	@Failure	500	{object}	utils.ResponseError
 //	@Router		/nls/v1/workflows/{name}/rerun [put]
 func (u WorkflowController) RerunWorkflow(c *gin.Context) {
-	err := u.service.RerunWorkflow(c)
+	err := u.service.RerunWorkflow(c.Request.Context(), c.Param("name"))
 	if err != nil {
 		errResponse := utils.ResponseError{Message: err.Error()}
 		c.JSON(500, errResponse)
@@ -142,16 +150,17 @@ func (u WorkflowController) RerunWorkflow(c *gin.Context) {
 }
 
 // GetWorkflowByName
-//	@Summary	Get a workflow by name
-//	@Param		name	path	string	true	"name of workflow"
-//	@Tags		Workflow Management
-//	@Accept		json
-//	@Produce	json
-//	@Success	200	{object}	v1alpha1.Workflow
-//	@Failure	400	{object}	utils.ResponseError
-//	@Failure	404	{object}	utils.ResponseError
-//	@Failure	500	{object}	utils.ResponseError
-//	@Router		/nls/v1/workflows/{name} [get]
+//	@Summary		Get a workflow by name
+//	@Description	The response is an Argo Workflow (argoproj.io/v1alpha1)
+//	@Param			name	path	string	true	"name of workflow"
+//	@Tags			Workflow Management
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	object
+//	@Failure		400	{object}	utils.ResponseError
+//	@Failure		404	{object}	utils.ResponseError
+//	@Failure		500	{object}	utils.ResponseError
+//	@Router			/nls/v1/workflows/{name} [get]
 func (u WorkflowController) GetWorkflowByName(c *gin.Context) {
 	wfName := c.Param("name")
 	
@@ -162,14 +171,20 @@ func (u WorkflowController) GetWorkflowByName(c *gin.Context) {
 
 	// Retry loop
 	for i := 0; i < retries; i++ {
-		workflow, err = u.service.GetWorkflowByName(wfName, c)
+		workflow, err = u.service.GetWorkflowByName(c.Request.Context(), wfName)
 		if err == nil {
 			c.JSON(200, workflow)
 			return
 		}
 		// If it's not the last attempt, wait before retrying
 		if i < retries-1 {
-			time.Sleep(delay)
+			select {
+			case <-c.Request.Context().Done():
+				// the client is gone, there is nobody to answer
+				u.logger.Warnf("GetWorkflowByName: stopped retrying to get workflow %s: %v", wfName, c.Request.Context().Err())
+				return
+			case <-time.After(delay):
+			}
 		}
 	}
 
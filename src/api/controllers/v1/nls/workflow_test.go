@@ -67,7 +67,7 @@ func TestGetWorkflows(t *testing.T) {
 	t.Run("Happy", func(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
-		workflowServiceMock.EXPECT().GetWorkflows(gomock.Any()).Return(
+		workflowServiceMock.EXPECT().GetWorkflows(gomock.Any(), gomock.Any()).Return(
 			&v1alpha1.WorkflowList{
 				Items: v1alpha1.Workflows{
 					v1alpha1.Workflow{
@@ -82,7 +82,7 @@ func TestGetWorkflows(t *testing.T) {
 	t.Run("Error", func(t *testing.T) {
 
 		workflowServiceMock := mocks.NewMockWorkflowService(ctrl)
-		workflowServiceMock.EXPECT().GetWorkflows(gomock.Any()).Return(nil, fmt.Errorf("mocked error"))
+		workflowServiceMock.EXPECT().GetWorkflows(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("mocked error"))
 		res := executeWithContext(workflowServiceMock)
 		assert.Equal(t, http.StatusInternalServerError, res.Code)
 	})
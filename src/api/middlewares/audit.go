@@ -0,0 +1,231 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// gin context key holding the caller of the request, once it has been authenticated
+	PRINCIPAL_CONTEXT_KEY = "principal"
+	// bodies larger than this are not kept in the audit record
+	AUDIT_MAX_BODY_SIZE = 64 * 1024
+	// calls that change something cannot send a body larger than this
+	MAX_REQUEST_BODY_SIZE = 1024 * 1024
+	// how much of the response is kept to find the error message of a failure
+	AUDIT_MAX_RESPONSE_SIZE         = 4 * 1024
+	AUDIT_REDACTED                  = "<redacted>"
+	AUDIT_UNAUTHENTICATED_PRINCIPAL = "unauthenticated"
+)
+
+// values of JSON keys that match this are never written to the audit log
+var auditRedactedKeys = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private[-_]?key|api[-_]?key|authorization)`)
+
+// calls made by metacontroller to keep sessions up to date. They are not made by a user, and there are a lot of them.
+var auditSkippedPaths = map[string]bool{
+	"/apis/iuf/v1/session/sync":         true,
+	"/apis/iuf/v1/session/workflowsync": true,
+}
+
+// AuditMiddleware records every call to the API that changes something. Calls rejected by the auth middleware before
+//  it knew who made them, and calls to paths that are not routes of the API, are not recorded, so that anyone who can
+//  reach the API cannot fill the audit log. They are still counted in cray_nls_http_requests_total.
+type AuditMiddleware struct {
+	handler      utils.RequestHandler
+	logger       utils.Logger
+	auditService services_shared.AuditService
+}
+
+// NewAuditMiddleware creates a new AuditMiddleware
+func NewAuditMiddleware(handler utils.RequestHandler, logger utils.Logger, auditService services_shared.AuditService) AuditMiddleware {
+	return AuditMiddleware{
+		handler:      handler,
+		logger:       logger,
+		auditService: auditService,
+	}
+}
+
+// Setup registers the audit middleware
+func (m AuditMiddleware) Setup() {
+	m.logger.Info("Setting up audit middleware")
+	m.handler.Gin.Use(m.Handler())
+}
+
+// Handler returns the gin handler of the audit middleware
+func (m AuditMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isMutatingMethod(c.Request.Method) || auditSkippedPaths[c.Request.URL.Path] {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		var body interface{}
+		if c.Request.Body != nil {
+			limited := http.MaxBytesReader(c.Writer, c.Request.Body, MAX_REQUEST_BODY_SIZE)
+			// only what can be recorded is read here, the handlers read the rest
+			raw, err := io.ReadAll(io.LimitReader(limited, AUDIT_MAX_BODY_SIZE+1))
+			if err != nil {
+				m.logger.Warnf("AuditMiddleware.1: unable to read body of %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+			}
+			// put the body back for the handlers
+			c.Request.Body = io.NopCloser(io.MultiReader(bytes.NewReader(raw), limited))
+			body = redactBody(raw)
+		}
+
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		principal, known := getPrincipal(c)
+		if !known && (c.IsAborted() || c.FullPath() == "") {
+			// rejected by the auth middleware before it knew who made the call, or not a route of the API
+			return
+		}
+
+		status := c.Writer.Status()
+		record := iuf.AuditRecord{
+			Time:       start.UTC(),
+			Principal:  principal,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Route:      c.FullPath(),
+			Query:      c.Request.URL.RawQuery,
+			Body:       body,
+			Status:     status,
+			Outcome:    iuf.AuditOutcomeSuccess,
+			ClientIP:   c.ClientIP(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		if status >= http.StatusBadRequest {
+			record.Outcome = iuf.AuditOutcomeFailure
+			record.Error = getErrorMessage(writer.body.Bytes(), c.Errors)
+		}
+
+		// the audit service logs the record itself if it cannot be written, there is nothing more to do here
+		_ = m.auditService.Record(record)
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// redactBody returns the body as JSON with secrets removed, or a placeholder if it cannot be kept
+func redactBody(raw []byte) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	if len(raw) > AUDIT_MAX_BODY_SIZE {
+		return fmt.Sprintf("<body of more than %d bytes not recorded>", AUDIT_MAX_BODY_SIZE)
+	}
+	var body interface{}
+	err := json.Unmarshal(raw, &body)
+	if err != nil {
+		// we cannot tell what is in it, so nothing of it is kept
+		return "<non-JSON body not recorded>"
+	}
+	return redactValue(body)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if auditRedactedKeys.MatchString(key) {
+				v[key] = AUDIT_REDACTED
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// getPrincipal returns who made the call, as verified by the auth middleware, and whether it was verified. The bearer
+//  token is not read here, because a token that was not verified could name anyone.
+func getPrincipal(c *gin.Context) (string, bool) {
+	if principal := c.GetString(PRINCIPAL_CONTEXT_KEY); principal != "" {
+		return principal, true
+	}
+	return AUDIT_UNAUTHENTICATED_PRINCIPAL, false
+}
+
+func getErrorMessage(response []byte, errors []*gin.Error) string {
+	var responseError utils.ResponseError
+	err := json.Unmarshal(response, &responseError)
+	if err == nil && responseError.Message != "" {
+		return responseError.Message
+	}
+	if len(errors) > 0 {
+		return errors[len(errors)-1].Error()
+	}
+	return ""
+}
+
+// auditResponseWriter keeps the start of the response so the error message of a failure can be recorded
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	if remaining := AUDIT_MAX_RESPONSE_SIZE - w.body.Len(); remaining > 0 {
+		if len(data) < remaining {
+			remaining = len(data)
+		}
+		w.body.Write(data[:remaining])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(data string) (int, error) {
+	return w.Write([]byte(data))
+}
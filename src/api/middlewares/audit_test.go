@@ -0,0 +1,171 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAuditService struct {
+	records []iuf.AuditRecord
+}
+
+func (s *fakeAuditService) Record(record iuf.AuditRecord) error {
+	s.records = append(s.records, record)
+	return nil
+}
+
+func (s *fakeAuditService) ListRecords(filter iuf.AuditFilter) ([]iuf.AuditRecord, error) {
+	return s.records, nil
+}
+
+func newTestAuditRouter() (*gin.Engine, *fakeAuditService) {
+	gin.SetMode(gin.TestMode)
+	auditService := &fakeAuditService{}
+	engine := gin.New()
+	middleware := NewAuditMiddleware(utils.RequestHandler{Gin: engine}, utils.GetLogger(), auditService)
+	middleware.Setup()
+
+	engine.POST("/apis/iuf/v1/activities", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusCreated, string(body))
+	})
+	engine.GET("/apis/iuf/v1/activities", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	engine.PATCH("/apis/iuf/v1/activities/:activity_name", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, utils.ResponseError{Message: "activity not found"})
+	})
+	engine.POST("/apis/iuf/v1/session/sync", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return engine, auditService
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	t.Run("It records calls that change something, with secrets redacted", func(t *testing.T) {
+		engine, auditService := newTestAuditRouter()
+		claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1234","preferred_username":"admin"}`))
+		body := `{"name":"admin-230127","input_parameters":{"media_dir":"/a","vault_token":"s3cr3t"},"items":[{"password":"p"}]}`
+
+		req := httptest.NewRequest(http.MethodPost, "/apis/iuf/v1/activities?dry_run=true", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer header."+claims+".signature")
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		// the handler still gets the whole body
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, body, w.Body.String())
+
+		assert.Equal(t, 1, len(auditService.records))
+		record := auditService.records[0]
+		// nothing verified the token, so whoever it names is not trusted
+		assert.Equal(t, AUDIT_UNAUTHENTICATED_PRINCIPAL, record.Principal)
+		assert.Equal(t, http.MethodPost, record.Method)
+		assert.Equal(t, "/apis/iuf/v1/activities", record.Route)
+		assert.Equal(t, "dry_run=true", record.Query)
+		assert.Equal(t, iuf.AuditOutcomeSuccess, record.Outcome)
+		recordedBody := record.Body.(map[string]interface{})
+		assert.Equal(t, "admin-230127", recordedBody["name"])
+		inputParameters := recordedBody["input_parameters"].(map[string]interface{})
+		assert.Equal(t, "/a", inputParameters["media_dir"])
+		assert.Equal(t, AUDIT_REDACTED, inputParameters["vault_token"])
+		items := recordedBody["items"].([]interface{})
+		assert.Equal(t, AUDIT_REDACTED, items[0].(map[string]interface{})["password"])
+	})
+	t.Run("It records the error of a failure", func(t *testing.T) {
+		engine, auditService := newTestAuditRouter()
+		req := httptest.NewRequest(http.MethodPatch, "/apis/iuf/v1/activities/a", strings.NewReader("not json"))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, 1, len(auditService.records))
+		record := auditService.records[0]
+		assert.Equal(t, AUDIT_UNAUTHENTICATED_PRINCIPAL, record.Principal)
+		assert.Equal(t, "/apis/iuf/v1/activities/:activity_name", record.Route)
+		assert.Equal(t, http.StatusNotFound, record.Status)
+		assert.Equal(t, iuf.AuditOutcomeFailure, record.Outcome)
+		assert.Equal(t, "activity not found", record.Error)
+		assert.Equal(t, "<non-JSON body not recorded>", record.Body)
+	})
+	t.Run("It records the principal verified by the auth middleware, and only calls whose caller is known", func(t *testing.T) {
+		jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+		key := newTestSigningKey(t, "key-1")
+		writeTestJwks(t, jwksFile, key)
+		auditService := &fakeAuditService{}
+		engine := newTestAuthRouter(t, jwksFile, NewAuditMiddleware(utils.RequestHandler{}, utils.GetLogger(), auditService).Handler())
+
+		tokens := []string{
+			key.sign(t, testClaims("operator-1", time.Now().Add(time.Hour), "operator")),
+			key.sign(t, testClaims("user-1", time.Now().Add(time.Hour), "user")),
+			"not-a-token",
+		}
+		for _, token := range tokens {
+			req := httptest.NewRequest(http.MethodPost, "/activities/a/run", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			engine.ServeHTTP(httptest.NewRecorder(), req)
+		}
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/not/a/route", strings.NewReader("{}")))
+
+		assert.Equal(t, 2, len(auditService.records))
+		assert.Equal(t, "operator-1", auditService.records[0].Principal)
+		assert.Equal(t, http.StatusOK, auditService.records[0].Status)
+		assert.Equal(t, "user-1", auditService.records[1].Principal)
+		assert.Equal(t, http.StatusForbidden, auditService.records[1].Status)
+	})
+	t.Run("It passes bodies larger than can be recorded on to the handlers, up to the maximum size", func(t *testing.T) {
+		engine, auditService := newTestAuditRouter()
+		body := `{"name":"` + strings.Repeat("a", AUDIT_MAX_BODY_SIZE) + `"}`
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/apis/iuf/v1/activities", strings.NewReader(body)))
+		assert.Equal(t, body, w.Body.String())
+		assert.Equal(t, 1, len(auditService.records))
+		assert.Equal(t, fmt.Sprintf("<body of more than %d bytes not recorded>", AUDIT_MAX_BODY_SIZE), auditService.records[0].Body)
+
+		w = httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/apis/iuf/v1/activities", strings.NewReader(strings.Repeat("a", MAX_REQUEST_BODY_SIZE+1))))
+		assert.Equal(t, MAX_REQUEST_BODY_SIZE, len(w.Body.String()))
+	})
+	t.Run("It does not record reads or session syncs", func(t *testing.T) {
+		engine, auditService := newTestAuditRouter()
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/apis/iuf/v1/activities", nil))
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/apis/iuf/v1/session/sync", strings.NewReader("{}")))
+		assert.Equal(t, 0, len(auditService.records))
+	})
+}
@@ -0,0 +1,275 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+// Permission needed to call a route. Each permission includes the ones before it, except PermissionWebhook.
+type Permission int
+
+const (
+	// anyone can call the route, without a token
+	PermissionPublic Permission = iota
+	// read anything
+	PermissionRead
+	// create activities, run, pause, resume and abort them, and retry or rerun workflows
+	PermissionOperate
+	// delete activities and workflows, and rebuild NCNs
+	PermissionAdmin
+	// only metacontroller can call the route, with the webhook secret instead of a token
+	PermissionWebhook
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermissionPublic:
+		return "public"
+	case PermissionRead:
+		return "read"
+	case PermissionOperate:
+		return "operate"
+	case PermissionAdmin:
+		return "admin"
+	case PermissionWebhook:
+		return "webhook"
+	}
+	return fmt.Sprintf("Permission(%d)", int(p))
+}
+
+// RoutePermissions is the permission needed for each route, by "<method> <route>", e.g. "GET /apis/iuf/v1/activities"
+type RoutePermissions map[string]Permission
+
+const (
+	// gin context key holding the roles of the caller, once they have been authenticated
+	ROLES_CONTEXT_KEY = "roles"
+	// tokens are accepted this long past their expiry, for clocks that are not quite in sync
+	AUTH_CLOCK_LEEWAY = 1 * time.Minute
+	// the webhook secret is sent in this header, or in this query parameter by callers that cannot set headers
+	HEADER_WEBHOOK_SECRET      = "X-Cray-Nls-Webhook-Secret"
+	QUERY_PARAM_WEBHOOK_SECRET = "webhook_secret"
+)
+
+type keycloakClaims struct {
+	jwt.Claims
+	PreferredUsername string `json:"preferred_username"`
+	RealmAccess       struct {
+		Roles []string `json:"roles"`
+	} `json:"realm_access"`
+}
+
+// AuthMiddleware checks the Keycloak token of each call, and that the roles in it allow calling the route
+type AuthMiddleware struct {
+	handler  utils.RequestHandler
+	logger   utils.Logger
+	disabled bool
+	issuer   string
+	// the secret of the webhook routes, which are not protected without it
+	webhookSecret string
+	keySet        *jwksKeySet
+	roles         map[string]Permission
+	permissions   RoutePermissions
+}
+
+// NewAuthMiddleware creates a new AuthMiddleware
+func NewAuthMiddleware(handler utils.RequestHandler, logger utils.Logger, env utils.Env, permissions RoutePermissions) (AuthMiddleware, error) {
+	ttl, err := time.ParseDuration(env.AuthJwksCacheTTL)
+	if err != nil {
+		return AuthMiddleware{}, fmt.Errorf("invalid AUTH_JWKS_CACHE_TTL %q: %v", env.AuthJwksCacheTTL, err)
+	}
+	tlsConfig, err := utils.NewTLSConfig(env)
+	if err != nil {
+		return AuthMiddleware{}, err
+	}
+	if env.Environment == "production" && !env.AuthDisabled && env.SyncWebhookSecret == "" {
+		return AuthMiddleware{}, errors.New("SYNC_WEBHOOK_SECRET must be set in production, the session sync webhooks cannot be protected without it")
+	}
+
+	// when a role is in more than one list, the highest permission wins
+	roles := map[string]Permission{}
+	for _, mapping := range []struct {
+		roles      string
+		permission Permission
+	}{
+		{env.AuthReadOnlyRoles, PermissionRead},
+		{env.AuthOperatorRoles, PermissionOperate},
+		{env.AuthAdminRoles, PermissionAdmin},
+	} {
+		for _, role := range strings.Split(mapping.roles, ",") {
+			role = strings.TrimSpace(role)
+			if role != "" {
+				roles[role] = mapping.permission
+			}
+		}
+	}
+
+	return AuthMiddleware{
+		handler:       handler,
+		logger:        logger,
+		disabled:      env.AuthDisabled,
+		issuer:        env.AuthIssuer,
+		webhookSecret: env.SyncWebhookSecret,
+		keySet:        newJwksKeySet(env.AuthJwksURL, env.AuthJwksFile, ttl, tlsConfig),
+		roles:         roles,
+		permissions:   permissions,
+	}, nil
+}
+
+// Setup registers the auth middleware
+func (m AuthMiddleware) Setup() {
+	if m.disabled {
+		m.logger.Warn("Authentication is disabled by AUTH_DISABLED, anyone can call any API")
+		return
+	}
+	m.logger.Info("Setting up auth middleware")
+	m.handler.Gin.Use(m.Handler())
+}
+
+// Handler returns the gin handler of the auth middleware
+func (m AuthMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// no such route, gin will respond with 404
+			c.Next()
+			return
+		}
+
+		required, found := m.permissions[c.Request.Method+" "+route]
+		if !found {
+			// every route has to say who can call it
+			m.logger.Errorf("AuthMiddleware.1: no permission is defined for %s %s", c.Request.Method, route)
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.ResponseError{Message: "Forbidden"})
+			return
+		}
+		if required == PermissionPublic {
+			c.Next()
+			return
+		}
+		if required == PermissionWebhook {
+			if !m.verifyWebhookSecret(c) {
+				m.logger.Warnf("AuthMiddleware.4: %s %s from %s does not have the webhook secret", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+				c.AbortWithStatusJSON(http.StatusUnauthorized, utils.ResponseError{Message: "Unauthorized: missing or wrong webhook secret"})
+				return
+			}
+			c.Next()
+			return
+		}
+
+		claims, err := m.verifyToken(c.GetHeader("Authorization"))
+		if err != nil {
+			m.logger.Warnf("AuthMiddleware.2: unauthorized %s %s from %s: %v", c.Request.Method, c.Request.URL.Path, c.ClientIP(), err)
+			c.Header("WWW-Authenticate", `Bearer realm="cray-nls"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, utils.ResponseError{Message: "Unauthorized: " + err.Error()})
+			return
+		}
+
+		principal := claims.PreferredUsername
+		if principal == "" {
+			principal = claims.Subject
+		}
+		c.Set(PRINCIPAL_CONTEXT_KEY, principal)
+		c.Set(ROLES_CONTEXT_KEY, claims.RealmAccess.Roles)
+
+		granted := m.permissionOfRoles(claims.RealmAccess.Roles)
+		if granted < required {
+			m.logger.Warnf("AuthMiddleware.3: %s with roles %v needs %s permission for %s %s", principal, claims.RealmAccess.Roles, required, c.Request.Method, route)
+			c.AbortWithStatusJSON(http.StatusForbidden, utils.ResponseError{Message: fmt.Sprintf("Forbidden: %s permission is required", required)})
+			return
+		}
+		c.Next()
+	}
+}
+
+// verifyWebhookSecret returns whether the call has the webhook secret. Without a secret, which is only allowed outside
+//  of production, every call has it.
+func (m AuthMiddleware) verifyWebhookSecret(c *gin.Context) bool {
+	if m.webhookSecret == "" {
+		return true
+	}
+	secret := c.GetHeader(HEADER_WEBHOOK_SECRET)
+	if secret == "" {
+		secret = c.Query(QUERY_PARAM_WEBHOOK_SECRET)
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(m.webhookSecret)) == 1
+}
+
+func (m AuthMiddleware) verifyToken(authorization string) (keycloakClaims, error) {
+	var claims keycloakClaims
+	if !strings.HasPrefix(authorization, "Bearer ") {
+		return claims, errors.New("missing bearer token")
+	}
+	token, err := jwt.ParseSigned(strings.TrimPrefix(authorization, "Bearer "))
+	if err != nil {
+		return claims, fmt.Errorf("invalid token: %v", err)
+	}
+	if len(token.Headers) != 1 {
+		return claims, errors.New("invalid token: expected exactly one signature")
+	}
+	switch jose.SignatureAlgorithm(token.Headers[0].Algorithm) {
+	case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512, jose.ES256, jose.ES384, jose.ES512:
+	default:
+		return claims, fmt.Errorf("invalid token: signing algorithm %s is not allowed", token.Headers[0].Algorithm)
+	}
+
+	key, err := m.keySet.Key(token.Headers[0].KeyID)
+	if err != nil {
+		return claims, err
+	}
+	err = token.Claims(key.Public(), &claims)
+	if err != nil {
+		return claims, fmt.Errorf("invalid token: %v", err)
+	}
+	err = claims.ValidateWithLeeway(jwt.Expected{Issuer: m.issuer, Time: m.keySet.now()}, AUTH_CLOCK_LEEWAY)
+	if err != nil {
+		return claims, fmt.Errorf("invalid token: %v", err)
+	}
+	if claims.Expiry == nil {
+		return claims, errors.New("invalid token: token does not expire")
+	}
+	return claims, nil
+}
+
+func (m AuthMiddleware) permissionOfRoles(roles []string) Permission {
+	granted := PermissionPublic
+	for _, role := range roles {
+		if permission, found := m.roles[role]; found && permission > granted {
+			granted = permission
+		}
+	}
+	return granted
+}
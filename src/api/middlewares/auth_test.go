@@ -0,0 +1,254 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/go-jose/go-jose/v3"
+	"github.com/go-jose/go-jose/v3/jwt"
+)
+
+type testSigningKey struct {
+	kid string
+	key *rsa.PrivateKey
+}
+
+func newTestSigningKey(t *testing.T, kid string) testSigningKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	return testSigningKey{kid: kid, key: key}
+}
+
+func (k testSigningKey) sign(t *testing.T, claims interface{}) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: jose.JSONWebKey{Key: k.key, KeyID: k.kid}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	assert.NoError(t, err)
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	assert.NoError(t, err)
+	return token
+}
+
+func writeTestJwks(t *testing.T, path string, keys ...testSigningKey) {
+	jwks := jose.JSONWebKeySet{}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, jose.JSONWebKey{Key: key.key.Public(), KeyID: key.kid, Algorithm: string(jose.RS256), Use: "sig"})
+	}
+	data, err := json.Marshal(jwks)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data, 0600))
+}
+
+func testClaims(username string, expiry time.Time, roles ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":                "f6f5e7c2-0000-0000-0000-000000000000",
+		"preferred_username": username,
+		"iss":                "https://api-gw-service-nmn.local/keycloak/realms/shasta",
+		"exp":                expiry.Unix(),
+		"iat":                time.Now().Unix(),
+		"realm_access":       map[string]interface{}{"roles": roles},
+	}
+}
+
+func newTestAuthRouter(t *testing.T, jwksFile string, middlewares ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(middlewares...)
+	env := utils.Env{
+		AuthJwksFile:      jwksFile,
+		AuthJwksCacheTTL:  "10m",
+		AuthIssuer:        "https://api-gw-service-nmn.local/keycloak/realms/shasta",
+		AuthAdminRoles:    "admin",
+		AuthOperatorRoles: "operator",
+		AuthReadOnlyRoles: "user, monitor-ro",
+	}
+	middleware, err := NewAuthMiddleware(utils.RequestHandler{Gin: engine}, utils.GetLogger(), env, RoutePermissions{
+		"GET /version":               PermissionPublic,
+		"GET /activities":            PermissionRead,
+		"POST /activities/:name/run": PermissionOperate,
+		"DELETE /activities/:name":   PermissionAdmin,
+	})
+	assert.NoError(t, err)
+	middleware.Setup()
+
+	handler := func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(PRINCIPAL_CONTEXT_KEY))
+	}
+	engine.GET("/version", handler)
+	engine.GET("/activities", handler)
+	engine.POST("/activities/:name/run", handler)
+	engine.DELETE("/activities/:name", handler)
+	engine.GET("/unprotected", handler)
+	return engine
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	key := newTestSigningKey(t, "key-1")
+	writeTestJwks(t, jwksFile, key)
+	engine := newTestAuthRouter(t, jwksFile)
+
+	otherKey := newTestSigningKey(t, "key-1")
+	valid := time.Now().Add(time.Hour)
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{"public route without a token", http.MethodGet, "/version", "", http.StatusOK},
+		{"no token", http.MethodGet, "/activities", "", http.StatusUnauthorized},
+		{"not a token", http.MethodGet, "/activities", "not-a-token", http.StatusUnauthorized},
+		{"token signed by another key", http.MethodGet, "/activities", otherKey.sign(t, testClaims("admin", valid, "admin")), http.StatusUnauthorized},
+		{"token signed by an unknown key", http.MethodGet, "/activities", newTestSigningKey(t, "key-2").sign(t, testClaims("admin", valid, "admin")), http.StatusUnauthorized},
+		{"expired token", http.MethodGet, "/activities", key.sign(t, testClaims("admin", time.Now().Add(-time.Hour), "admin")), http.StatusUnauthorized},
+		{"token of another issuer", http.MethodGet, "/activities", key.sign(t, map[string]interface{}{"iss": "someone", "exp": valid.Unix(), "realm_access": map[string]interface{}{"roles": []string{"admin"}}}), http.StatusUnauthorized},
+		{"no roles", http.MethodGet, "/activities", key.sign(t, testClaims("nobody", valid)), http.StatusForbidden},
+		{"read-only can read", http.MethodGet, "/activities", key.sign(t, testClaims("monitor", valid, "monitor-ro")), http.StatusOK},
+		{"read-only cannot run", http.MethodPost, "/activities/a/run", key.sign(t, testClaims("monitor", valid, "user")), http.StatusForbidden},
+		{"operator can run", http.MethodPost, "/activities/a/run", key.sign(t, testClaims("operator", valid, "user", "operator")), http.StatusOK},
+		{"operator cannot delete", http.MethodDelete, "/activities/a", key.sign(t, testClaims("operator", valid, "operator")), http.StatusForbidden},
+		{"admin can delete", http.MethodDelete, "/activities/a", key.sign(t, testClaims("admin", valid, "admin")), http.StatusOK},
+		{"route without a permission", http.MethodGet, "/unprotected", key.sign(t, testClaims("admin", valid, "admin")), http.StatusForbidden},
+		{"no such route", http.MethodGet, "/nothing", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("It sets the principal from the token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/activities", nil)
+		req.Header.Set("Authorization", "Bearer "+key.sign(t, testClaims("jdoe", valid, "user")))
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		assert.Equal(t, "jdoe", w.Body.String())
+	})
+}
+
+func TestJwksKeySet(t *testing.T) {
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	key := newTestSigningKey(t, "key-1")
+	writeTestJwks(t, jwksFile, key)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	keySet := newJwksKeySet("", jwksFile, 10*time.Minute, nil)
+	keySet.now = func() time.Time { return now }
+
+	_, err := keySet.Key("key-1")
+	assert.NoError(t, err)
+
+	t.Run("It finds new keys once the minimum refresh interval has passed", func(t *testing.T) {
+		newKey := newTestSigningKey(t, "key-2")
+		writeTestJwks(t, jwksFile, key, newKey)
+
+		_, err := keySet.Key("key-2")
+		assert.Error(t, err)
+
+		now = now.Add(JWKS_MIN_REFRESH_INTERVAL)
+		_, err = keySet.Key("key-2")
+		assert.NoError(t, err)
+	})
+	t.Run("It keeps the keys it has when they cannot be loaded again", func(t *testing.T) {
+		assert.NoError(t, os.Remove(jwksFile))
+		now = now.Add(time.Hour)
+		_, err := keySet.Key("key-1")
+		assert.NoError(t, err)
+	})
+	t.Run("It fetches the keys from the JWKS URL", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			data, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.key.Public(), KeyID: "key-1"}}})
+			w.Write(data)
+		}))
+		defer server.Close()
+
+		_, err := newJwksKeySet(server.URL, "", time.Minute, nil).Key("key-1")
+		assert.NoError(t, err)
+	})
+	t.Run("It serves the keys it has while fetching them again, and fetches them once at a time", func(t *testing.T) {
+		var fetches int32
+		refetching := make(chan bool, 1)
+		release := make(chan bool)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&fetches, 1) > 1 {
+				refetching <- true
+				<-release
+			}
+			data, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.key.Public(), KeyID: "key-1"}}})
+			w.Write(data)
+		}))
+		defer server.Close()
+		defer close(release)
+
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		keySet := newJwksKeySet(server.URL, "", time.Minute, nil)
+		keySet.now = func() time.Time { return now }
+		_, err := keySet.Key("key-1")
+		assert.NoError(t, err)
+
+		// the keys expired, and fetching them again hangs until released
+		now = now.Add(time.Hour)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := keySet.Key("key-1")
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		select {
+		case <-refetching:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the keys were not fetched again")
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+	})
+}
@@ -0,0 +1,166 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v3"
+)
+
+// a token signed by a key we do not know causes the keys to be fetched again, but not more often than this
+const JWKS_MIN_REFRESH_INTERVAL = 30 * time.Second
+
+// jwksKeySet holds the keys that sign tokens, from the JWKS of the Keycloak realm or from a local file. Keys are
+//  fetched again once they are older than ttl, or when a token is signed by a key that is not known yet. Only one
+//  fetch runs at a time, without holding the lock, and the keys already known keep being served while it runs.
+type jwksKeySet struct {
+	url        string
+	file       string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mutex     sync.Mutex
+	keys      jose.JSONWebKeySet
+	fetchedAt time.Time
+	lastErr   error
+	// closed when the refresh in progress is done, nil when there is none
+	refreshing chan struct{}
+	now        func() time.Time
+}
+
+func newJwksKeySet(url string, file string, ttl time.Duration, tlsConfig *tls.Config) *jwksKeySet {
+	return &jwksKeySet{
+		url:  url,
+		file: file,
+		ttl:  ttl,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		now: time.Now,
+	}
+}
+
+// Key returns the key with the given key id
+func (k *jwksKeySet) Key(kid string) (*jose.JSONWebKey, error) {
+	k.mutex.Lock()
+	now := k.now()
+	expired := k.fetchedAt.IsZero() || now.Sub(k.fetchedAt) >= k.ttl
+	keys := k.keys.Key(kid)
+	if !expired && (len(keys) > 0 || now.Sub(k.fetchedAt) < JWKS_MIN_REFRESH_INTERVAL) {
+		k.mutex.Unlock()
+		return firstKey(kid, keys)
+	}
+
+	done := k.refreshing
+	if done == nil {
+		done = make(chan struct{})
+		k.refreshing = done
+		go k.refresh(now, done)
+	}
+	k.mutex.Unlock()
+	if len(keys) > 0 {
+		// the key is still good while the keys are fetched again
+		return &keys[0], nil
+	}
+
+	<-done
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	if len(k.keys.Keys) == 0 && k.lastErr != nil {
+		return nil, k.lastErr
+	}
+	return firstKey(kid, k.keys.Key(kid))
+}
+
+func firstKey(kid string, keys []jose.JSONWebKey) (*jose.JSONWebKey, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("token is signed by unknown key %q", kid)
+	}
+	return &keys[0], nil
+}
+
+// refresh loads the keys again and closes done. The lock is not held while the keys are loaded.
+func (k *jwksKeySet) refresh(now time.Time, done chan struct{}) {
+	keys, err := k.load()
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+	k.lastErr = err
+	if err == nil {
+		k.keys = keys
+	}
+	// when the keys cannot be fetched again, the ones we have are still good until the next attempt
+	if err == nil || len(k.keys.Keys) > 0 {
+		k.fetchedAt = now
+	}
+	k.refreshing = nil
+	close(done)
+}
+
+func (k *jwksKeySet) load() (jose.JSONWebKeySet, error) {
+	var keys jose.JSONWebKeySet
+	var data []byte
+	var err error
+	if k.file != "" {
+		data, err = os.ReadFile(k.file)
+	} else {
+		data, err = k.fetch()
+	}
+	if err != nil {
+		return keys, fmt.Errorf("unable to load JWKS: %v", err)
+	}
+
+	err = json.Unmarshal(data, &keys)
+	if err != nil {
+		return keys, fmt.Errorf("unable to parse JWKS: %v", err)
+	}
+	return keys, nil
+}
+
+func (k *jwksKeySet) fetch() ([]byte, error) {
+	resp, err := k.httpClient.Get(k.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected 200 response from %s but instead got %v %v", k.url, resp.StatusCode, string(body))
+	}
+	return body, nil
+}
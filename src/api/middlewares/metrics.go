@@ -0,0 +1,72 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// route label of requests that did not match any route, so that random paths do not create new series
+const METRICS_UNMATCHED_ROUTE = "unmatched"
+
+// MetricsMiddleware counts the HTTP requests and measures their latency for Prometheus
+type MetricsMiddleware struct {
+	handler utils.RequestHandler
+	logger  utils.Logger
+}
+
+// NewMetricsMiddleware creates a new MetricsMiddleware
+func NewMetricsMiddleware(handler utils.RequestHandler, logger utils.Logger) MetricsMiddleware {
+	return MetricsMiddleware{
+		handler: handler,
+		logger:  logger,
+	}
+}
+
+// Setup registers the metrics middleware
+func (m MetricsMiddleware) Setup() {
+	m.logger.Info("Setting up metrics middleware")
+	m.handler.Gin.Use(m.Handler())
+}
+
+// Handler returns the gin handler of the metrics middleware
+func (m MetricsMiddleware) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = METRICS_UNMATCHED_ROUTE
+		}
+		utils.HttpRequestsTotal.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		utils.HttpRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
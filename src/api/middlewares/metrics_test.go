@@ -0,0 +1,65 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	NewMetricsMiddleware(utils.RequestHandler{Gin: engine}, utils.GetLogger()).Setup()
+	engine.GET("/apis/iuf/v1/activities/:activity_name", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+
+	t.Run("It counts requests by route template, not by path", func(t *testing.T) {
+		counter := utils.HttpRequestsTotal.WithLabelValues(http.MethodGet, "/apis/iuf/v1/activities/:activity_name", "404")
+		before := testutil.ToFloat64(counter)
+
+		for _, name := range []string{"a", "b"} {
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/apis/iuf/v1/activities/"+name, nil))
+		}
+
+		assert.Equal(t, before+2, testutil.ToFloat64(counter))
+	})
+	t.Run("It counts requests that match no route under one label", func(t *testing.T) {
+		counter := utils.HttpRequestsTotal.WithLabelValues(http.MethodGet, METRICS_UNMATCHED_ROUTE, "404")
+		before := testutil.ToFloat64(counter)
+
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/no/such/route", nil))
+
+		assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	})
+}
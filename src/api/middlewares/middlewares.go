@@ -27,6 +27,10 @@ import "go.uber.org/fx"
 
 // Module Middleware exported
 var Module = fx.Options(
+	fx.Provide(NewTracingMiddleware),
+	fx.Provide(NewMetricsMiddleware),
+	fx.Provide(NewAuditMiddleware),
+	fx.Provide(NewAuthMiddleware),
 	fx.Provide(NewMiddlewares),
 )
 
@@ -40,8 +44,15 @@ type Middlewares []IMiddleware
 
 // NewMiddlewares creates new middlewares
 // Register the middleware that should be applied directly (globally)
-func NewMiddlewares() Middlewares {
-	return Middlewares{}
+func NewMiddlewares(tracingMiddleware TracingMiddleware, metricsMiddleware MetricsMiddleware, auditMiddleware AuditMiddleware, authMiddleware AuthMiddleware) Middlewares {
+	// the metrics and audit middlewares go first, so calls that are not authorized are counted, and recorded when the
+	//  auth middleware knows who made them
+	return Middlewares{
+		tracingMiddleware,
+		metricsMiddleware,
+		auditMiddleware,
+		authMiddleware,
+	}
 }
 
 // Setup sets up middlewares
@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
@@ -51,39 +52,24 @@ func (mr *MockIufServiceMockRecorder) ConfigMapDataToSession(data interface{}) *
 }
 
 // CreateActivity mocks base method.
-func (m *MockIufService) CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity, error) {
+func (m *MockIufService) CreateActivity(ctx context.Context, req iuf.CreateActivityRequest) (iuf.Activity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateActivity", req)
+	ret := m.ctrl.Call(m, "CreateActivity", ctx, req)
 	ret0, _ := ret[0].(iuf.Activity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateActivity indicates an expected call of CreateActivity.
-func (mr *MockIufServiceMockRecorder) CreateActivity(req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) CreateActivity(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActivity", reflect.TypeOf((*MockIufService)(nil).CreateActivity), req)
-}
-
-// DeleteActivity mocks base method.
-func (m *MockIufService) DeleteActivity(name string) (bool, error) {
-    m.ctrl.T.Helper()
-    ret := m.ctrl.Call(m, "DeleteActivity", name)
-    ret0, _ := ret[0].(bool)
-    ret1, _ := ret[1].(error)
-    return ret0, ret1
-}
-
-// DeleteActivity indicates an expected call of DeleteActivity.
-func (mr *MockIufServiceMockRecorder) DeleteActivity(name interface{}) *gomock.Call {
-    mr.mock.ctrl.T.Helper()
-    return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActivity", reflect.TypeOf((*MockIufService)(nil).DeleteActivity), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateActivity", reflect.TypeOf((*MockIufService)(nil).CreateActivity), ctx, req)
 }
 
 // CreateIufWorkflow mocks base method.
-func (m *MockIufService) CreateIufWorkflow(req *iuf.Session) (*v1alpha1.Workflow, error, bool) {
+func (m *MockIufService) CreateIufWorkflow(ctx context.Context, req *iuf.Session) (*v1alpha1.Workflow, error, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateIufWorkflow", req)
+	ret := m.ctrl.Call(m, "CreateIufWorkflow", ctx, req)
 	ret0, _ := ret[0].(*v1alpha1.Workflow)
 	ret1, _ := ret[1].(error)
 	ret2, _ := ret[2].(bool)
@@ -91,68 +77,99 @@ func (m *MockIufService) CreateIufWorkflow(req *iuf.Session) (*v1alpha1.Workflow
 }
 
 // CreateIufWorkflow indicates an expected call of CreateIufWorkflow.
-func (mr *MockIufServiceMockRecorder) CreateIufWorkflow(req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) CreateIufWorkflow(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIufWorkflow", reflect.TypeOf((*MockIufService)(nil).CreateIufWorkflow), ctx, req)
+}
+
+// DeleteActivity mocks base method.
+func (m *MockIufService) DeleteActivity(ctx context.Context, name string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteActivity", ctx, name)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteActivity indicates an expected call of DeleteActivity.
+func (mr *MockIufServiceMockRecorder) DeleteActivity(ctx, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIufWorkflow", reflect.TypeOf((*MockIufService)(nil).CreateIufWorkflow), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteActivity", reflect.TypeOf((*MockIufService)(nil).DeleteActivity), ctx, name)
 }
 
 // FindLastWorkflowForCurrentStage mocks base method.
-func (m *MockIufService) FindLastWorkflowForCurrentStage(session *iuf.Session) *v1alpha1.Workflow {
+func (m *MockIufService) FindLastWorkflowForCurrentStage(ctx context.Context, session *iuf.Session) *v1alpha1.Workflow {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindLastWorkflowForCurrentStage", session)
+	ret := m.ctrl.Call(m, "FindLastWorkflowForCurrentStage", ctx, session)
 	ret0, _ := ret[0].(*v1alpha1.Workflow)
 	return ret0
 }
 
 // FindLastWorkflowForCurrentStage indicates an expected call of FindLastWorkflowForCurrentStage.
-func (mr *MockIufServiceMockRecorder) FindLastWorkflowForCurrentStage(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) FindLastWorkflowForCurrentStage(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLastWorkflowForCurrentStage", reflect.TypeOf((*MockIufService)(nil).FindLastWorkflowForCurrentStage), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindLastWorkflowForCurrentStage", reflect.TypeOf((*MockIufService)(nil).FindLastWorkflowForCurrentStage), ctx, session)
 }
 
 // GetActivity mocks base method.
-func (m *MockIufService) GetActivity(name string) (iuf.Activity, error) {
+func (m *MockIufService) GetActivity(ctx context.Context, name string) (iuf.Activity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActivity", name)
+	ret := m.ctrl.Call(m, "GetActivity", ctx, name)
 	ret0, _ := ret[0].(iuf.Activity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetActivity indicates an expected call of GetActivity.
-func (mr *MockIufServiceMockRecorder) GetActivity(name interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) GetActivity(ctx, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivity", reflect.TypeOf((*MockIufService)(nil).GetActivity), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivity", reflect.TypeOf((*MockIufService)(nil).GetActivity), ctx, name)
 }
 
 // GetActivityHistory mocks base method.
-func (m *MockIufService) GetActivityHistory(activityName string, startTime int32) (iuf.History, error) {
+func (m *MockIufService) GetActivityHistory(ctx context.Context, activityName string, startTime int32) (iuf.History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetActivityHistory", activityName, startTime)
+	ret := m.ctrl.Call(m, "GetActivityHistory", ctx, activityName, startTime)
 	ret0, _ := ret[0].(iuf.History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetActivityHistory indicates an expected call of GetActivityHistory.
-func (mr *MockIufServiceMockRecorder) GetActivityHistory(activityName, startTime interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) GetActivityHistory(ctx, activityName, startTime interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivityHistory", reflect.TypeOf((*MockIufService)(nil).GetActivityHistory), activityName, startTime)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivityHistory", reflect.TypeOf((*MockIufService)(nil).GetActivityHistory), ctx, activityName, startTime)
 }
 
 // GetSession mocks base method.
-func (m *MockIufService) GetSession(sessionName string) (iuf.Session, error) {
+func (m *MockIufService) GetSession(ctx context.Context, sessionName string) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSession", sessionName)
+	ret := m.ctrl.Call(m, "GetSession", ctx, sessionName)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSession indicates an expected call of GetSession.
-func (mr *MockIufServiceMockRecorder) GetSession(sessionName interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) GetSession(ctx, sessionName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockIufService)(nil).GetSession), sessionName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSession", reflect.TypeOf((*MockIufService)(nil).GetSession), ctx, sessionName)
+}
+
+// GetStageWorkflow mocks base method.
+func (m *MockIufService) GetStageWorkflow(ctx context.Context, session iuf.Session, stageName string) (v1alpha1.Workflow, error, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStageWorkflow", ctx, session, stageName)
+	ret0, _ := ret[0].(v1alpha1.Workflow)
+	ret1, _ := ret[1].(error)
+	ret2, _ := ret[2].(bool)
+	return ret0, ret1, ret2
+}
+
+// GetStageWorkflow indicates an expected call of GetStageWorkflow.
+func (mr *MockIufServiceMockRecorder) GetStageWorkflow(ctx, session, stageName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStageWorkflow", reflect.TypeOf((*MockIufService)(nil).GetStageWorkflow), ctx, session, stageName)
 }
 
 // GetStages mocks base method.
@@ -171,230 +188,230 @@ func (mr *MockIufServiceMockRecorder) GetStages() *gomock.Call {
 }
 
 // HistoryAbortAction mocks base method.
-func (m *MockIufService) HistoryAbortAction(activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryAbortAction(ctx context.Context, activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryAbortAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryAbortAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryAbortAction indicates an expected call of HistoryAbortAction.
-func (mr *MockIufServiceMockRecorder) HistoryAbortAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryAbortAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryAbortAction", reflect.TypeOf((*MockIufService)(nil).HistoryAbortAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryAbortAction", reflect.TypeOf((*MockIufService)(nil).HistoryAbortAction), ctx, activityName, req)
 }
 
 // HistoryBlockedAction mocks base method.
-func (m *MockIufService) HistoryBlockedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryBlockedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryBlockedAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryBlockedAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryBlockedAction indicates an expected call of HistoryBlockedAction.
-func (mr *MockIufServiceMockRecorder) HistoryBlockedAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryBlockedAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryBlockedAction", reflect.TypeOf((*MockIufService)(nil).HistoryBlockedAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryBlockedAction", reflect.TypeOf((*MockIufService)(nil).HistoryBlockedAction), ctx, activityName, req)
 }
 
 // HistoryPausedAction mocks base method.
-func (m *MockIufService) HistoryPausedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryPausedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryPausedAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryPausedAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryPausedAction indicates an expected call of HistoryPausedAction.
-func (mr *MockIufServiceMockRecorder) HistoryPausedAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryPausedAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryPausedAction", reflect.TypeOf((*MockIufService)(nil).HistoryPausedAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryPausedAction", reflect.TypeOf((*MockIufService)(nil).HistoryPausedAction), ctx, activityName, req)
 }
 
 // HistoryRestartAction mocks base method.
-func (m *MockIufService) HistoryRestartAction(activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryRestartAction(ctx context.Context, activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryRestartAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryRestartAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryRestartAction indicates an expected call of HistoryRestartAction.
-func (mr *MockIufServiceMockRecorder) HistoryRestartAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryRestartAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryRestartAction", reflect.TypeOf((*MockIufService)(nil).HistoryRestartAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryRestartAction", reflect.TypeOf((*MockIufService)(nil).HistoryRestartAction), ctx, activityName, req)
 }
 
 // HistoryResumeAction mocks base method.
-func (m *MockIufService) HistoryResumeAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryResumeAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryResumeAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryResumeAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryResumeAction indicates an expected call of HistoryResumeAction.
-func (mr *MockIufServiceMockRecorder) HistoryResumeAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryResumeAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryResumeAction", reflect.TypeOf((*MockIufService)(nil).HistoryResumeAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryResumeAction", reflect.TypeOf((*MockIufService)(nil).HistoryResumeAction), ctx, activityName, req)
 }
 
 // HistoryRunAction mocks base method.
-func (m *MockIufService) HistoryRunAction(activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error) {
+func (m *MockIufService) HistoryRunAction(ctx context.Context, activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "HistoryRunAction", activityName, req)
+	ret := m.ctrl.Call(m, "HistoryRunAction", ctx, activityName, req)
 	ret0, _ := ret[0].(iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // HistoryRunAction indicates an expected call of HistoryRunAction.
-func (mr *MockIufServiceMockRecorder) HistoryRunAction(activityName, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) HistoryRunAction(ctx, activityName, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryRunAction", reflect.TypeOf((*MockIufService)(nil).HistoryRunAction), activityName, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HistoryRunAction", reflect.TypeOf((*MockIufService)(nil).HistoryRunAction), ctx, activityName, req)
 }
 
 // IsSessionLocked mocks base method.
-func (m *MockIufService) IsSessionLocked(session iuf.Session) bool {
+func (m *MockIufService) IsSessionLocked(ctx context.Context, session iuf.Session) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsSessionLocked", session)
+	ret := m.ctrl.Call(m, "IsSessionLocked", ctx, session)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // IsSessionLocked indicates an expected call of IsSessionLocked.
-func (mr *MockIufServiceMockRecorder) IsSessionLocked(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) IsSessionLocked(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionLocked", reflect.TypeOf((*MockIufService)(nil).IsSessionLocked), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSessionLocked", reflect.TypeOf((*MockIufService)(nil).IsSessionLocked), ctx, session)
 }
 
 // ListActivities mocks base method.
-func (m *MockIufService) ListActivities() ([]iuf.Activity, error) {
+func (m *MockIufService) ListActivities(ctx context.Context) ([]iuf.Activity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListActivities")
+	ret := m.ctrl.Call(m, "ListActivities", ctx)
 	ret0, _ := ret[0].([]iuf.Activity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListActivities indicates an expected call of ListActivities.
-func (mr *MockIufServiceMockRecorder) ListActivities() *gomock.Call {
+func (mr *MockIufServiceMockRecorder) ListActivities(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivities", reflect.TypeOf((*MockIufService)(nil).ListActivities))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivities", reflect.TypeOf((*MockIufService)(nil).ListActivities), ctx)
 }
 
 // ListActivityHistory mocks base method.
-func (m *MockIufService) ListActivityHistory(activityName string) ([]iuf.History, error) {
+func (m *MockIufService) ListActivityHistory(ctx context.Context, activityName string) ([]iuf.History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListActivityHistory", activityName)
+	ret := m.ctrl.Call(m, "ListActivityHistory", ctx, activityName)
 	ret0, _ := ret[0].([]iuf.History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListActivityHistory indicates an expected call of ListActivityHistory.
-func (mr *MockIufServiceMockRecorder) ListActivityHistory(activityName interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) ListActivityHistory(ctx, activityName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivityHistory", reflect.TypeOf((*MockIufService)(nil).ListActivityHistory), activityName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListActivityHistory", reflect.TypeOf((*MockIufService)(nil).ListActivityHistory), ctx, activityName)
 }
 
 // ListSessions mocks base method.
-func (m *MockIufService) ListSessions(activityName string) ([]iuf.Session, error) {
+func (m *MockIufService) ListSessions(ctx context.Context, activityName string) ([]iuf.Session, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListSessions", activityName)
+	ret := m.ctrl.Call(m, "ListSessions", ctx, activityName)
 	ret0, _ := ret[0].([]iuf.Session)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListSessions indicates an expected call of ListSessions.
-func (mr *MockIufServiceMockRecorder) ListSessions(activityName interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) ListSessions(ctx, activityName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockIufService)(nil).ListSessions), activityName)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListSessions", reflect.TypeOf((*MockIufService)(nil).ListSessions), ctx, activityName)
 }
 
 // LockSession mocks base method.
-func (m *MockIufService) LockSession(session iuf.Session) bool {
+func (m *MockIufService) LockSession(ctx context.Context, session iuf.Session) bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "LockSession", session)
+	ret := m.ctrl.Call(m, "LockSession", ctx, session)
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
 // LockSession indicates an expected call of LockSession.
-func (mr *MockIufServiceMockRecorder) LockSession(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) LockSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockSession", reflect.TypeOf((*MockIufService)(nil).LockSession), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockSession", reflect.TypeOf((*MockIufService)(nil).LockSession), ctx, session)
 }
 
 // PatchActivity mocks base method.
-func (m *MockIufService) PatchActivity(activity iuf.Activity, req iuf.PatchActivityRequest) (iuf.Activity, error) {
+func (m *MockIufService) PatchActivity(ctx context.Context, activity iuf.Activity, req iuf.PatchActivityRequest) (iuf.Activity, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PatchActivity", activity, req)
+	ret := m.ctrl.Call(m, "PatchActivity", ctx, activity, req)
 	ret0, _ := ret[0].(iuf.Activity)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // PatchActivity indicates an expected call of PatchActivity.
-func (mr *MockIufServiceMockRecorder) PatchActivity(activity, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) PatchActivity(ctx, activity, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchActivity", reflect.TypeOf((*MockIufService)(nil).PatchActivity), activity, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchActivity", reflect.TypeOf((*MockIufService)(nil).PatchActivity), ctx, activity, req)
 }
 
 // ProcessOutput mocks base method.
-func (m *MockIufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workflow) error {
+func (m *MockIufService) ProcessOutput(ctx context.Context, session *iuf.Session, workflow *v1alpha1.Workflow) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ProcessOutput", session, workflow)
+	ret := m.ctrl.Call(m, "ProcessOutput", ctx, session, workflow)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // ProcessOutput indicates an expected call of ProcessOutput.
-func (mr *MockIufServiceMockRecorder) ProcessOutput(session, workflow interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) ProcessOutput(ctx, session, workflow interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOutput", reflect.TypeOf((*MockIufService)(nil).ProcessOutput), session, workflow)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProcessOutput", reflect.TypeOf((*MockIufService)(nil).ProcessOutput), ctx, session, workflow)
 }
 
 // ReplaceHistoryComment mocks base method.
-func (m *MockIufService) ReplaceHistoryComment(activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error) {
+func (m *MockIufService) ReplaceHistoryComment(ctx context.Context, activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReplaceHistoryComment", activityName, startTime, req)
+	ret := m.ctrl.Call(m, "ReplaceHistoryComment", ctx, activityName, startTime, req)
 	ret0, _ := ret[0].(iuf.History)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ReplaceHistoryComment indicates an expected call of ReplaceHistoryComment.
-func (mr *MockIufServiceMockRecorder) ReplaceHistoryComment(activityName, startTime, req interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) ReplaceHistoryComment(ctx, activityName, startTime, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceHistoryComment", reflect.TypeOf((*MockIufService)(nil).ReplaceHistoryComment), activityName, startTime, req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceHistoryComment", reflect.TypeOf((*MockIufService)(nil).ReplaceHistoryComment), ctx, activityName, startTime, req)
 }
 
 // RestartCurrentStage mocks base method.
-func (m *MockIufService) RestartCurrentStage(session *iuf.Session, comment string) error {
+func (m *MockIufService) RestartCurrentStage(ctx context.Context, session *iuf.Session, comment string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RestartCurrentStage", session, comment)
+	ret := m.ctrl.Call(m, "RestartCurrentStage", ctx, session, comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RestartCurrentStage indicates an expected call of RestartCurrentStage.
-func (mr *MockIufServiceMockRecorder) RestartCurrentStage(session, comment interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) RestartCurrentStage(ctx, session, comment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartCurrentStage", reflect.TypeOf((*MockIufService)(nil).RestartCurrentStage), session, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartCurrentStage", reflect.TypeOf((*MockIufService)(nil).RestartCurrentStage), ctx, session, comment)
 }
 
 // RunNextPartialWorkflow mocks base method.
-func (m *MockIufService) RunNextPartialWorkflow(session *iuf.Session) (iuf.SyncResponse, error, bool) {
+func (m *MockIufService) RunNextPartialWorkflow(ctx context.Context, session *iuf.Session) (iuf.SyncResponse, error, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RunNextPartialWorkflow", session)
+	ret := m.ctrl.Call(m, "RunNextPartialWorkflow", ctx, session)
 	ret0, _ := ret[0].(iuf.SyncResponse)
 	ret1, _ := ret[1].(error)
 	ret2, _ := ret[2].(bool)
@@ -402,15 +419,15 @@ func (m *MockIufService) RunNextPartialWorkflow(session *iuf.Session) (iuf.SyncR
 }
 
 // RunNextPartialWorkflow indicates an expected call of RunNextPartialWorkflow.
-func (mr *MockIufServiceMockRecorder) RunNextPartialWorkflow(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) RunNextPartialWorkflow(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunNextPartialWorkflow", reflect.TypeOf((*MockIufService)(nil).RunNextPartialWorkflow), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunNextPartialWorkflow", reflect.TypeOf((*MockIufService)(nil).RunNextPartialWorkflow), ctx, session)
 }
 
 // RunNextStage mocks base method.
-func (m *MockIufService) RunNextStage(session *iuf.Session) (iuf.SyncResponse, error, bool) {
+func (m *MockIufService) RunNextStage(ctx context.Context, session *iuf.Session) (iuf.SyncResponse, error, bool) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RunNextStage", session)
+	ret := m.ctrl.Call(m, "RunNextStage", ctx, session)
 	ret0, _ := ret[0].(iuf.SyncResponse)
 	ret1, _ := ret[1].(error)
 	ret2, _ := ret[2].(bool)
@@ -418,75 +435,90 @@ func (m *MockIufService) RunNextStage(session *iuf.Session) (iuf.SyncResponse, e
 }
 
 // RunNextStage indicates an expected call of RunNextStage.
-func (mr *MockIufServiceMockRecorder) RunNextStage(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) RunNextStage(ctx, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunNextStage", reflect.TypeOf((*MockIufService)(nil).RunNextStage), ctx, session)
+}
+
+// SubscribeToActivityEvents mocks base method.
+func (m *MockIufService) SubscribeToActivityEvents(activityName string) (<-chan iuf.Event, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubscribeToActivityEvents", activityName)
+	ret0, _ := ret[0].(<-chan iuf.Event)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// SubscribeToActivityEvents indicates an expected call of SubscribeToActivityEvents.
+func (mr *MockIufServiceMockRecorder) SubscribeToActivityEvents(activityName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunNextStage", reflect.TypeOf((*MockIufService)(nil).RunNextStage), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeToActivityEvents", reflect.TypeOf((*MockIufService)(nil).SubscribeToActivityEvents), activityName)
 }
 
 // SyncWorkflowsToSession mocks base method.
-func (m *MockIufService) SyncWorkflowsToSession(session *iuf.Session) error {
+func (m *MockIufService) SyncWorkflowsToSession(ctx context.Context, session *iuf.Session) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SyncWorkflowsToSession", session)
+	ret := m.ctrl.Call(m, "SyncWorkflowsToSession", ctx, session)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SyncWorkflowsToSession indicates an expected call of SyncWorkflowsToSession.
-func (mr *MockIufServiceMockRecorder) SyncWorkflowsToSession(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) SyncWorkflowsToSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncWorkflowsToSession", reflect.TypeOf((*MockIufService)(nil).SyncWorkflowsToSession), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncWorkflowsToSession", reflect.TypeOf((*MockIufService)(nil).SyncWorkflowsToSession), ctx, session)
 }
 
 // UnlockSession mocks base method.
-func (m *MockIufService) UnlockSession(session iuf.Session) {
+func (m *MockIufService) UnlockSession(ctx context.Context, session iuf.Session) {
 	m.ctrl.T.Helper()
-	m.ctrl.Call(m, "UnlockSession", session)
+	m.ctrl.Call(m, "UnlockSession", ctx, session)
 }
 
 // UnlockSession indicates an expected call of UnlockSession.
-func (mr *MockIufServiceMockRecorder) UnlockSession(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) UnlockSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockSession", reflect.TypeOf((*MockIufService)(nil).UnlockSession), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockSession", reflect.TypeOf((*MockIufService)(nil).UnlockSession), ctx, session)
 }
 
 // UpdateActivityStateFromSessionState mocks base method.
-func (m *MockIufService) UpdateActivityStateFromSessionState(session iuf.Session, comment string) error {
+func (m *MockIufService) UpdateActivityStateFromSessionState(ctx context.Context, session iuf.Session, comment string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateActivityStateFromSessionState", session, comment)
+	ret := m.ctrl.Call(m, "UpdateActivityStateFromSessionState", ctx, session, comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateActivityStateFromSessionState indicates an expected call of UpdateActivityStateFromSessionState.
-func (mr *MockIufServiceMockRecorder) UpdateActivityStateFromSessionState(session, comment interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) UpdateActivityStateFromSessionState(ctx, session, comment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateActivityStateFromSessionState", reflect.TypeOf((*MockIufService)(nil).UpdateActivityStateFromSessionState), session, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateActivityStateFromSessionState", reflect.TypeOf((*MockIufService)(nil).UpdateActivityStateFromSessionState), ctx, session, comment)
 }
 
 // UpdateSession mocks base method.
-func (m *MockIufService) UpdateSession(session iuf.Session) error {
+func (m *MockIufService) UpdateSession(ctx context.Context, session iuf.Session) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSession", session)
+	ret := m.ctrl.Call(m, "UpdateSession", ctx, session)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateSession indicates an expected call of UpdateSession.
-func (mr *MockIufServiceMockRecorder) UpdateSession(session interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) UpdateSession(ctx, session interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSession", reflect.TypeOf((*MockIufService)(nil).UpdateSession), session)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSession", reflect.TypeOf((*MockIufService)(nil).UpdateSession), ctx, session)
 }
 
 // UpdateSessionAndActivity mocks base method.
-func (m *MockIufService) UpdateSessionAndActivity(session iuf.Session, comment string) error {
+func (m *MockIufService) UpdateSessionAndActivity(ctx context.Context, session iuf.Session, comment string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSessionAndActivity", session, comment)
+	ret := m.ctrl.Call(m, "UpdateSessionAndActivity", ctx, session, comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateSessionAndActivity indicates an expected call of UpdateSessionAndActivity.
-func (mr *MockIufServiceMockRecorder) UpdateSessionAndActivity(session, comment interface{}) *gomock.Call {
+func (mr *MockIufServiceMockRecorder) UpdateSessionAndActivity(ctx, session, comment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSessionAndActivity", reflect.TypeOf((*MockIufService)(nil).UpdateSessionAndActivity), session, comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSessionAndActivity", reflect.TypeOf((*MockIufService)(nil).UpdateSessionAndActivity), ctx, session, comment)
 }
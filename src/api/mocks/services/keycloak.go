@@ -1,10 +1,11 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: src/api/services/nls/keycloak.go
+// Source: src/api/services/shared/keycloak.go
 
-// Package mock_services_shared is a generated GoMock package.
+// Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -34,16 +35,16 @@ func (m *MockKeycloakService) EXPECT() *MockKeycloakServiceMockRecorder {
 }
 
 // NewKeycloakAccessToken mocks base method.
-func (m *MockKeycloakService) NewKeycloakAccessToken() (string, error) {
+func (m *MockKeycloakService) NewKeycloakAccessToken(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "NewKeycloakAccessToken")
+	ret := m.ctrl.Call(m, "NewKeycloakAccessToken", ctx)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // NewKeycloakAccessToken indicates an expected call of NewKeycloakAccessToken.
-func (mr *MockKeycloakServiceMockRecorder) NewKeycloakAccessToken() *gomock.Call {
+func (mr *MockKeycloakServiceMockRecorder) NewKeycloakAccessToken(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewKeycloakAccessToken", reflect.TypeOf((*MockKeycloakService)(nil).NewKeycloakAccessToken))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewKeycloakAccessToken", reflect.TypeOf((*MockKeycloakService)(nil).NewKeycloakAccessToken), ctx)
 }
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: src/api/services/shared/version.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	models "github.com/Cray-HPE/cray-nls/src/api/models/nls"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockVersionService is a mock of VersionService interface.
+type MockVersionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockVersionServiceMockRecorder
+}
+
+// MockVersionServiceMockRecorder is the mock recorder for MockVersionService.
+type MockVersionServiceMockRecorder struct {
+	mock *MockVersionService
+}
+
+// NewMockVersionService creates a new mock instance.
+func NewMockVersionService(ctrl *gomock.Controller) *MockVersionService {
+	mock := &MockVersionService{ctrl: ctrl}
+	mock.recorder = &MockVersionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVersionService) EXPECT() *MockVersionServiceMockRecorder {
+	return m.recorder
+}
+
+// GetVersion mocks base method.
+func (m *MockVersionService) GetVersion(ctx context.Context) models.VersionResponse {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVersion", ctx)
+	ret0, _ := ret[0].(models.VersionResponse)
+	return ret0
+}
+
+// GetVersion indicates an expected call of GetVersion.
+func (mr *MockVersionServiceMockRecorder) GetVersion(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVersion", reflect.TypeOf((*MockVersionService)(nil).GetVersion), ctx)
+}
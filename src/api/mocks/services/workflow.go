@@ -5,11 +5,11 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	models "github.com/Cray-HPE/cray-nls/src/api/models/nls"
 	v1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	gin "github.com/gin-gonic/gin"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -37,102 +37,102 @@ func (m *MockWorkflowService) EXPECT() *MockWorkflowServiceMockRecorder {
 }
 
 // CreateRebuildWorkflow mocks base method.
-func (m *MockWorkflowService) CreateRebuildWorkflow(req models.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error) {
+func (m *MockWorkflowService) CreateRebuildWorkflow(ctx context.Context, req models.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRebuildWorkflow", req)
+	ret := m.ctrl.Call(m, "CreateRebuildWorkflow", ctx, req)
 	ret0, _ := ret[0].(*v1alpha1.Workflow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateRebuildWorkflow indicates an expected call of CreateRebuildWorkflow.
-func (mr *MockWorkflowServiceMockRecorder) CreateRebuildWorkflow(req interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) CreateRebuildWorkflow(ctx, req interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRebuildWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).CreateRebuildWorkflow), req)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRebuildWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).CreateRebuildWorkflow), ctx, req)
 }
 
 // DeleteWorkflow mocks base method.
-func (m *MockWorkflowService) DeleteWorkflow(ctx *gin.Context) error {
+func (m *MockWorkflowService) DeleteWorkflow(ctx context.Context, wfName string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteWorkflow", ctx)
+	ret := m.ctrl.Call(m, "DeleteWorkflow", ctx, wfName)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteWorkflow indicates an expected call of DeleteWorkflow.
-func (mr *MockWorkflowServiceMockRecorder) DeleteWorkflow(ctx interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) DeleteWorkflow(ctx, wfName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).DeleteWorkflow), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).DeleteWorkflow), ctx, wfName)
 }
 
 // GetWorkflowByName mocks base method.
-func (m *MockWorkflowService) GetWorkflowByName(name string, ctx *gin.Context) (*v1alpha1.Workflow, error) {
+func (m *MockWorkflowService) GetWorkflowByName(ctx context.Context, name string) (*v1alpha1.Workflow, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWorkflowByName", name, ctx)
+	ret := m.ctrl.Call(m, "GetWorkflowByName", ctx, name)
 	ret0, _ := ret[0].(*v1alpha1.Workflow)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWorkflowByName indicates an expected call of GetWorkflowByName.
-func (mr *MockWorkflowServiceMockRecorder) GetWorkflowByName(name, ctx interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) GetWorkflowByName(ctx, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkflowByName", reflect.TypeOf((*MockWorkflowService)(nil).GetWorkflowByName), name, ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkflowByName", reflect.TypeOf((*MockWorkflowService)(nil).GetWorkflowByName), ctx, name)
 }
 
 // GetWorkflows mocks base method.
-func (m *MockWorkflowService) GetWorkflows(ctx *gin.Context) (*v1alpha1.WorkflowList, error) {
+func (m *MockWorkflowService) GetWorkflows(ctx context.Context, labelSelector string) (*v1alpha1.WorkflowList, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetWorkflows", ctx)
+	ret := m.ctrl.Call(m, "GetWorkflows", ctx, labelSelector)
 	ret0, _ := ret[0].(*v1alpha1.WorkflowList)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetWorkflows indicates an expected call of GetWorkflows.
-func (mr *MockWorkflowServiceMockRecorder) GetWorkflows(ctx interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) GetWorkflows(ctx, labelSelector interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkflows", reflect.TypeOf((*MockWorkflowService)(nil).GetWorkflows), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWorkflows", reflect.TypeOf((*MockWorkflowService)(nil).GetWorkflows), ctx, labelSelector)
 }
 
 // InitializeWorkflowTemplate mocks base method.
-func (m *MockWorkflowService) InitializeWorkflowTemplate(template []byte) error {
+func (m *MockWorkflowService) InitializeWorkflowTemplate(ctx context.Context, template []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InitializeWorkflowTemplate", template)
+	ret := m.ctrl.Call(m, "InitializeWorkflowTemplate", ctx, template)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InitializeWorkflowTemplate indicates an expected call of InitializeWorkflowTemplate.
-func (mr *MockWorkflowServiceMockRecorder) InitializeWorkflowTemplate(template interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) InitializeWorkflowTemplate(ctx, template interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitializeWorkflowTemplate", reflect.TypeOf((*MockWorkflowService)(nil).InitializeWorkflowTemplate), template)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitializeWorkflowTemplate", reflect.TypeOf((*MockWorkflowService)(nil).InitializeWorkflowTemplate), ctx, template)
 }
 
 // RerunWorkflow mocks base method.
-func (m *MockWorkflowService) RerunWorkflow(ctx *gin.Context) error {
+func (m *MockWorkflowService) RerunWorkflow(ctx context.Context, wfName string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RerunWorkflow", ctx)
+	ret := m.ctrl.Call(m, "RerunWorkflow", ctx, wfName)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RerunWorkflow indicates an expected call of RerunWorkflow.
-func (mr *MockWorkflowServiceMockRecorder) RerunWorkflow(ctx interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) RerunWorkflow(ctx, wfName interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).RerunWorkflow), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RerunWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).RerunWorkflow), ctx, wfName)
 }
 
 // RetryWorkflow mocks base method.
-func (m *MockWorkflowService) RetryWorkflow(ctx *gin.Context) error {
+func (m *MockWorkflowService) RetryWorkflow(ctx context.Context, wfName string, requestBody models.RetryWorkflowRequestBody) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RetryWorkflow", ctx)
+	ret := m.ctrl.Call(m, "RetryWorkflow", ctx, wfName, requestBody)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RetryWorkflow indicates an expected call of RetryWorkflow.
-func (mr *MockWorkflowServiceMockRecorder) RetryWorkflow(ctx interface{}) *gomock.Call {
+func (mr *MockWorkflowServiceMockRecorder) RetryWorkflow(ctx, wfName, requestBody interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).RetryWorkflow), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryWorkflow", reflect.TypeOf((*MockWorkflowService)(nil).RetryWorkflow), ctx, wfName, requestBody)
 }
@@ -0,0 +1,60 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import "time"
+
+// AuditRecord is the record of a call to the API that changed something
+type AuditRecord struct {
+	Time       time.Time   `json:"time"`                            // When the call was received
+	Principal  string      `json:"principal"`                       // Who made the call, from the preferred_username or subject of their token
+	Method     string      `json:"method"`                          // HTTP method
+	Path       string      `json:"path"`                            // Path that was called, e.g. /apis/iuf/v1/activities/admin-230127/history/abort
+	Route      string      `json:"route"`                           // Route that handled the call, e.g. /apis/iuf/v1/activities/:activity_name/history/abort
+	Query      string      `json:"query,omitempty"`                 // Query string of the call
+	Body       interface{} `json:"body,omitempty"`                  // Body of the call, with secrets redacted
+	Status     int         `json:"status"`                          // HTTP status of the response
+	Outcome    string      `json:"outcome" enums:"success,failure"` // success for 2xx and 3xx responses, failure otherwise
+	Error      string      `json:"error,omitempty"`                 // Error message of the response, for failures
+	ClientIP   string      `json:"client_ip"`                       // Address the call came from
+	DurationMs int64       `json:"duration_ms"`                     // How long the call took
+} //	@name	AuditRecord
+
+// AuditFilter selects audit records. Empty fields match everything.
+type AuditFilter struct {
+	Principal string    `form:"principal"`                                     // Only records of this principal
+	Method    string    `form:"method"`                                        // Only records of this HTTP method
+	Path      string    `form:"path"`                                          // Only records whose path starts with this
+	Outcome   string    `form:"outcome"`                                       // success or failure
+	Since     time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"` // Only records at or after this time, in RFC 3339
+	Until     time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"` // Only records before this time, in RFC 3339
+	Limit     int       `form:"limit"`                                         // Maximum number of records to return, newest first. Defaults to 100
+}
+
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+)
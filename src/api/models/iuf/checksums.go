@@ -0,0 +1,162 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package iuf
+
+import (
+	"bufio"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checksums files looked for at the top level of a product release distribution, sha256 before md5. Both use the
+//  format of the sha256sum and md5sum commands, with paths relative to the distribution.
+const (
+	SHA256_CHECKSUMS_FILE string = "SHA256SUMS"
+	MD5_CHECKSUMS_FILE    string = "MD5SUMS"
+)
+
+// ChecksumMismatch is a file of a product release distribution that does not match its checksum
+type ChecksumMismatch struct {
+	File     string `json:"file"`             // The path of the file, relative to the distribution
+	Expected string `json:"expected"`         // The checksum in the checksums file
+	Actual   string `json:"actual,omitempty"` // The checksum of the file, empty when the file cannot be read
+	Error    string `json:"error,omitempty"`  // Why the file cannot be read
+} //	@name	ChecksumMismatch
+
+// ChecksumResult is the result of verifying the checksums file of a product release distribution
+type ChecksumResult struct {
+	File       string             `json:"file"`                 // The name of the checksums file
+	Algorithm  string             `json:"algorithm"`            // sha256 or md5
+	Files      int                `json:"files"`                // The number of files in the checksums file
+	Verified   bool               `json:"verified"`             // Whether every file matches its checksum
+	Mismatches []ChecksumMismatch `json:"mismatches,omitempty"` // The files that do not match their checksum
+	Pending    bool               `json:"pending,omitempty"`    // The files are being verified in the background, and the other fields are not known yet
+} //	@name	ChecksumResult
+
+func (m ChecksumMismatch) String() string {
+	if m.Error != "" {
+		return fmt.Sprintf("checksum of file %s cannot be verified: %s", m.File, m.Error)
+	}
+	return fmt.Sprintf("checksum of file %s does not match: expected %s, got %s", m.File, m.Expected, m.Actual)
+}
+
+// Issues returns an error per mismatch, for validation reports
+func (r ChecksumResult) Issues() []ValidationIssue {
+	var res []ValidationIssue
+	for _, mismatch := range r.Mismatches {
+		res = append(res, ValidationIssue{Severity: SeverityError, Message: fmt.Sprintf("%s (%s)", mismatch, r.File)})
+	}
+	return res
+}
+
+// findChecksumsFile returns the checksums file of a distribution and its algorithm, or an empty name when there is none
+func findChecksumsFile(rootDir string) (string, string, func() hash.Hash) {
+	if _, err := os.Stat(filepath.Join(rootDir, SHA256_CHECKSUMS_FILE)); err == nil {
+		return SHA256_CHECKSUMS_FILE, "sha256", sha256.New
+	}
+	if _, err := os.Stat(filepath.Join(rootDir, MD5_CHECKSUMS_FILE)); err == nil {
+		return MD5_CHECKSUMS_FILE, "md5", md5.New
+	}
+	return "", "", nil
+}
+
+// VerifyChecksums verifies the files of a product release distribution against its checksums file. It returns nil
+//  when the distribution does not have a checksums file, and an error when the checksums file cannot be read or parsed.
+func VerifyChecksums(rootDir string) (*ChecksumResult, error) {
+	name, algorithm, newHash := findChecksumsFile(rootDir)
+	if name == "" {
+		return nil, nil
+	}
+	f, err := os.Open(filepath.Join(rootDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checksums file %s: %v", name, err)
+	}
+	defer f.Close()
+
+	result := &ChecksumResult{File: name, Algorithm: algorithm}
+	hexLen := newHash().Size() * 2
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// "<checksum>  <file>" in text mode, "<checksum> *<file>" in binary mode
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 || len(fields[0]) != hexLen {
+			return nil, fmt.Errorf("line %d of checksums file %s is not a %s checksum followed by a file", lineNumber, name, algorithm)
+		}
+		expected := strings.ToLower(fields[0])
+		if _, err := hex.DecodeString(expected); err != nil {
+			return nil, fmt.Errorf("line %d of checksums file %s is not a %s checksum followed by a file", lineNumber, name, algorithm)
+		}
+		file := strings.TrimPrefix(strings.TrimLeft(fields[1], " "), "*")
+		if file == "" {
+			return nil, fmt.Errorf("line %d of checksums file %s is not a %s checksum followed by a file", lineNumber, name, algorithm)
+		}
+
+		result.Files++
+		mismatch := ChecksumMismatch{File: file, Expected: expected}
+		if clean := filepath.Clean(file); filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			mismatch.Error = "the file is not inside the distribution"
+			result.Mismatches = append(result.Mismatches, mismatch)
+			continue
+		}
+		actual, err := fileChecksum(filepath.Join(rootDir, file), newHash())
+		if err != nil {
+			mismatch.Error = err.Error()
+			result.Mismatches = append(result.Mismatches, mismatch)
+		} else if actual != expected {
+			mismatch.Actual = actual
+			result.Mismatches = append(result.Mismatches, mismatch)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checksums file %s: %v", name, err)
+	}
+	result.Verified = len(result.Mismatches) == 0
+	return result, nil
+}
+
+func fileChecksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,57 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import "time"
+
+type EEventType string
+
+const (
+	EEventTypeHistory       EEventType = "history"        // a history entry was added to the activity
+	EEventTypeSession       EEventType = "session"        // the state of a session was saved
+	EEventTypeStageStarted  EEventType = "stage_started"  // a workflow was created for a stage
+	EEventTypeStageFinished EEventType = "stage_finished" // a stage completed, or its workflow failed
+	EEventTypeOperation     EEventType = "operation"      // an operation of a finished workflow succeeded or failed
+	EEventTypeOutput        EEventType = "output"         // the output of an operation was saved in the activity
+)
+
+// Event is something that happened in an activity, as pushed to the clients of the events endpoint
+type Event struct {
+	Type          EEventType    `json:"type" enums:"history,session,stage_started,stage_finished,operation,output"` // Type of event
+	ActivityName  string        `json:"activity_name"`                                                              // Name of the activity
+	SessionName   string        `json:"session_name,omitempty"`                                                     // Name of the session, if any
+	ActivityState ActivityState `json:"activity_state,omitempty"`                                                   // State of the activity, for history events
+	SessionState  SessionState  `json:"session_state,omitempty"`                                                    // State of the session, for session events
+	Stage         string        `json:"stage,omitempty"`                                                            // Name of the stage, if any
+	Workflow      string        `json:"workflow,omitempty"`                                                         // Name of the Argo workflow, if any
+	Operation     string        `json:"operation,omitempty"`                                                        // Name of the operation, for operation and output events
+	Product       string        `json:"product,omitempty"`                                                          // Product key of the operation, for product stages
+	Step          string        `json:"step,omitempty"`                                                             // Name of the step of the operation, for output events
+	Phase         string        `json:"phase,omitempty"`                                                            // Argo phase of the stage or operation, e.g. Succeeded or Failed
+	Comment       string        `json:"comment,omitempty"`                                                          // Comment of the history entry or session update
+	Output        interface{}   `json:"output,omitempty"`                                                           // Output parameters of the operation, for output events
+	Time          time.Time     `json:"time"`                                                                       // When the event happened
+} //	@name	Event
@@ -0,0 +1,80 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// IufVersionError is returned when the iuf_version of a product manifest is not a valid constraint, or is not
+//  satisfied by the version of the IUF
+type IufVersionError struct {
+	Constraint string // iuf_version of the manifest
+	IufVersion string // version of the IUF, from stages.yaml
+	Reason     string
+}
+
+func (e IufVersionError) Error() string {
+	return fmt.Sprintf("product requires IUF version %q, which is not compatible with IUF version %s: %s", e.Constraint, e.IufVersion, e.Reason)
+}
+
+// CheckIufVersion checks that the iuf_version constraint of a product manifest is satisfied by the given IUF version.
+//  An IufVersionError means that the product is not compatible, any other error that the IUF version is not valid.
+func CheckIufVersion(constraint string, iufVersion string) error {
+	version, err := semver.NewVersion(iufVersion)
+	if err != nil {
+		return fmt.Errorf("IUF version %q is not a semantic version: %v", iufVersion, err)
+	}
+
+	// the schema documents == for an exact version, which semver spells =
+	constraints, err := semver.NewConstraint(strings.ReplaceAll(constraint, "==", "="))
+	if err != nil {
+		return IufVersionError{Constraint: constraint, IufVersion: iufVersion, Reason: fmt.Sprintf("not a valid version constraint: %v", err)}
+	}
+
+	ok, errs := constraints.Validate(version)
+	if !ok {
+		reasons := make([]string, len(errs))
+		for i, err := range errs {
+			reasons[i] = err.Error()
+		}
+		return IufVersionError{Constraint: constraint, IufVersion: iufVersion, Reason: strings.Join(reasons, "; ")}
+	}
+	return nil
+}
+
+// CheckManifestIufVersion checks the iuf_version of a product manifest against the given IUF version. A manifest
+//  without iuf_version is left to the schema validation.
+func CheckManifestIufVersion(manifest map[string]interface{}, iufVersion string) error {
+	constraint, ok := manifest["iuf_version"]
+	if !ok || constraint == nil {
+		return nil
+	}
+	return CheckIufVersion(fmt.Sprintf("%v", constraint), iufVersion)
+}
@@ -0,0 +1,302 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package manifestDataValidation
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	manifestTypes "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestTypes"
+	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
+	"sigs.k8s.io/yaml"
+)
+
+// const for yaml keys of the docker, helm, loftsman and ims content
+const (
+	DOCKER_KEY            string = "docker"
+	DOCKER_PATH_KEY       string = "path"
+	HELM_KEY              string = "helm"
+	HELM_PATH_KEY         string = "path"
+	LOFTSMAN_KEY          string = "loftsman"
+	LOFTSMAN_PATH_KEY     string = "path"
+	IMS_KEY               string = "ims"
+	IMS_RECIPES_KEY       string = "recipes"
+	IMS_IMAGES_KEY        string = "images"
+	IMS_CONTENT_DIRS_KEY  string = "content_dirs"
+	IMS_PATH_KEY          string = "path"
+	DOCKER_IMAGE_MANIFEST string = "manifest.json"
+	HELM_CHART_FILE       string = "Chart.yaml"
+	IMS_CONTENT_MANIFEST  string = "manifest.yaml"
+)
+
+// The artifacts of an IMS image, as declared by the schema
+var imsImageArtifactKeys = []string{"rootfs", "kernel", "initrd"}
+
+// Function to check a path is a directory
+func isDirectory(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// Function to check a path is a regular file
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
+// Function to check a file name is the one of a gzipped tar file
+func isTarball(name string) bool {
+	return strings.HasSuffix(name, ".tgz") || strings.HasSuffix(name, ".tar.gz")
+}
+
+// Function to list the files of a gzipped tar file
+func tarballFiles(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	var res []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, header.Name)
+	}
+}
+
+// Function to list the Loftsman manifest files of a loftsman entry, which is either a file or a directory of .yaml and
+//  .yml files
+func LoftsmanManifestFiles(path string) []string {
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return []string{path}
+	}
+	var res []string
+	for _, dirEntry := range dirEntries {
+		if !dirEntry.IsDir() && (strings.HasSuffix(dirEntry.Name(), ".yaml") || strings.HasSuffix(dirEntry.Name(), ".yml")) {
+			res = append(res, filepath.Join(path, dirEntry.Name()))
+		}
+	}
+	return res
+}
+
+// Method to process docker content, each directory has to contain at least one container image
+func (vs *validators) validateDockerDirectories() {
+	for i, docker := range vs.content.Docker {
+		if docker.Path == "" {
+			continue
+		}
+		pointer := mutils.Pointer(CONTENT_KEY, DOCKER_KEY, i, DOCKER_PATH_KEY)
+		dir_path := filepath.Join(vs.manifestRootDir, docker.Path)
+		if mutils.IsEmptyDirectory(dir_path) {
+			vs.errorf(pointer, "docker directory %v does not exist or is empty", dir_path)
+			continue
+		}
+
+		images := 0
+		filepath.WalkDir(dir_path, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && d.Name() == DOCKER_IMAGE_MANIFEST {
+				images++
+			}
+			return nil
+		})
+		if images == 0 {
+			vs.errorf(pointer, "docker directory %v does not contain any container image with a %v file", dir_path, DOCKER_IMAGE_MANIFEST)
+		}
+	}
+}
+
+// Method to process helm content, each directory has to contain helm charts as gzipped tar files
+func (vs *validators) validateHelmCharts() {
+	for i, helm := range vs.content.Helm {
+		if helm.Path == "" {
+			continue
+		}
+		pointer := mutils.Pointer(CONTENT_KEY, HELM_KEY, i, HELM_PATH_KEY)
+		dir_path := filepath.Join(vs.manifestRootDir, helm.Path)
+		if mutils.IsEmptyDirectory(dir_path) {
+			vs.errorf(pointer, "helm directory %v does not exist or is empty", dir_path)
+			continue
+		}
+
+		var charts []string
+		filepath.WalkDir(dir_path, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && isTarball(d.Name()) {
+				charts = append(charts, path)
+			}
+			return nil
+		})
+		if len(charts) == 0 {
+			vs.errorf(pointer, "helm directory %v does not contain any helm chart as a gzipped tar file", dir_path)
+		}
+
+		for _, chart := range charts {
+			files, err := tarballFiles(chart)
+			if err != nil {
+				vs.errorf(pointer, "helm chart %v is not a gzipped tar file: %v", chart, err)
+				continue
+			}
+			found := false
+			for _, file := range files {
+				if dir, name := path.Split(path.Clean(file)); name == HELM_CHART_FILE && strings.Count(dir, "/") == 1 {
+					found = true
+					break
+				}
+			}
+			if !found {
+				vs.errorf(pointer, "helm chart %v does not contain a %v file", chart, HELM_CHART_FILE)
+			}
+		}
+	}
+}
+
+// Loftsman manifest fields checked by validateLoftsmanManifests
+type loftsmanManifest struct {
+	ApiVersion string `json:"apiVersion"`
+	Metadata   struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec struct {
+		Charts []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"charts"`
+	} `json:"spec"`
+}
+
+// Method to process loftsman content, each file has to parse as a Loftsman manifest
+func (vs *validators) validateLoftsmanManifests() {
+	for i, loftsman := range vs.content.Loftsman {
+		if loftsman.Path == "" {
+			continue
+		}
+		pointer := mutils.Pointer(CONTENT_KEY, LOFTSMAN_KEY, i, LOFTSMAN_PATH_KEY)
+		file_path := filepath.Join(vs.manifestRootDir, loftsman.Path)
+		if !mutils.IsPathExist(file_path) {
+			vs.errorf(pointer, "loftsman manifest %v does not exist", file_path)
+			continue
+		}
+
+		files := LoftsmanManifestFiles(file_path)
+		if len(files) == 0 {
+			vs.errorf(pointer, "loftsman directory %v does not contain any loftsman manifest", file_path)
+		}
+		for _, file := range files {
+			file_contents, err := os.ReadFile(file)
+			if err != nil {
+				vs.errorf(pointer, "failed to open loftsman manifest %v: %v", file, err)
+				continue
+			}
+			var manifest loftsmanManifest
+			if err := yaml.Unmarshal(file_contents, &manifest); err != nil {
+				vs.errorf(pointer, "failed to parse loftsman manifest %v: %v", file, err)
+				continue
+			}
+			if !strings.HasPrefix(manifest.ApiVersion, "manifests/") {
+				vs.errorf(pointer, "loftsman manifest %v has apiVersion %q instead of manifests/<version>", file, manifest.ApiVersion)
+			}
+			if manifest.Metadata.Name == "" {
+				vs.errorf(pointer, "loftsman manifest %v does not have a metadata.name", file)
+			}
+			for j, chart := range manifest.Spec.Charts {
+				if chart.Name == "" || chart.Version == "" {
+					vs.errorf(pointer, "chart %d of loftsman manifest %v does not have a name and a version", j, file)
+				}
+			}
+		}
+	}
+}
+
+// Method to process ims content, the recipes, images with their artifacts and content directories have to exist
+func (vs *validators) validateImsContent() {
+	ims := vs.content.Ims
+	if ims == nil {
+		return
+	}
+
+	for i, recipe := range ims.Recipes {
+		if recipe.Path == "" {
+			continue
+		}
+		pointer := mutils.Pointer(CONTENT_KEY, IMS_KEY, IMS_RECIPES_KEY, i, IMS_PATH_KEY)
+		file_path := filepath.Join(vs.manifestRootDir, recipe.Path)
+		if !isFile(file_path) {
+			vs.errorf(pointer, "IMS recipe %v does not exist", file_path)
+			continue
+		}
+		if _, err := tarballFiles(file_path); err != nil {
+			vs.errorf(pointer, "IMS recipe %v is not a gzipped tar file: %v", file_path, err)
+		}
+	}
+
+	for i, image := range ims.Images {
+		if image.Path == "" {
+			continue
+		}
+		dir_path := filepath.Join(vs.manifestRootDir, image.Path)
+		if !isDirectory(dir_path) {
+			vs.errorf(mutils.Pointer(CONTENT_KEY, IMS_KEY, IMS_IMAGES_KEY, i, IMS_PATH_KEY), "IMS image directory %v does not exist", dir_path)
+			continue
+		}
+		artifacts := map[string]*manifestTypes.ImsArtifact{"rootfs": image.Rootfs, "kernel": image.Kernel, "initrd": image.Initrd}
+		for _, artifact := range imsImageArtifactKeys {
+			if artifacts[artifact] == nil || artifacts[artifact].Path == "" {
+				continue
+			}
+			file_path := filepath.Join(dir_path, artifacts[artifact].Path)
+			if !isFile(file_path) {
+				vs.errorf(mutils.Pointer(CONTENT_KEY, IMS_KEY, IMS_IMAGES_KEY, i, artifact, IMS_PATH_KEY), "IMS image %v artifact %v does not exist", artifact, file_path)
+			}
+		}
+	}
+
+	for i, content_dir := range ims.ContentDirs {
+		file_path := filepath.Join(vs.manifestRootDir, content_dir, IMS_CONTENT_MANIFEST)
+		if !isFile(file_path) {
+			vs.errorf(mutils.Pointer(CONTENT_KEY, IMS_KEY, IMS_CONTENT_DIRS_KEY, i), "IMS content directory manifest %v does not exist", file_path)
+		}
+	}
+}
@@ -28,7 +28,9 @@ package manifestDataValidation
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 
+	manifestTypes "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestTypes"
 	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
 	"sigs.k8s.io/yaml"
 )
@@ -60,173 +62,144 @@ var FileReader = mutils.ReadYamFile
 
 // Struct with list of validators
 type validators struct {
-	content           map[string]interface{}
+	content           manifestTypes.Content
 	nexusRepoFileName string
 	hostedRepoNames   []string
 	manifestRootDir   string
+	issues            []mutils.ValidationIssue
 }
 
-// Method to process s3 content, returns error in case of issues
-func (vs *validators) validateS3FilePath() error {
-
-	s3, s3_present := vs.content[S3_KEY] // extracting s3 key
+func (vs *validators) errorf(pointer string, format string, args ...interface{}) {
+	vs.issues = append(vs.issues, mutils.Errorf(pointer, format, args...))
+}
 
-	if !s3_present {
-		return nil // s3 key missing
-	}
+// Method to process s3 content
+func (vs *validators) validateS3FilePath() {
 
-	s3_array := s3.([]interface{}) // assuming array, is it validated in schema??
-	for _, s3 := range s3_array {
-		s3_element := s3.(map[string]interface{})
-		file_path := filepath.Join(vs.manifestRootDir, s3_element[S3_PATH_KEY].(string))
+	for i, s3 := range vs.content.S3 {
+		if s3.Path == "" {
+			continue // missing path is reported by the schema validation
+		}
+		file_path := filepath.Join(vs.manifestRootDir, s3.Path)
 
-		exist := mutils.IsPathExist(file_path) // do we need error details??
-		if !exist {                            // if path is invalid
-			return fmt.Errorf("error in processing s3 file %v", file_path)
+		exist := mutils.IsPathExist(file_path)
+		if !exist { // if path is invalid
+			vs.errorf(mutils.Pointer(CONTENT_KEY, S3_KEY, i, S3_PATH_KEY), "s3 file %v does not exist", file_path)
 		}
 	}
-	return nil
 }
 
-// Method to process nexus repo content, returns repo file path and error(in case of issues),
-func (vs *validators) validateNexusRepoFilePath() error {
-	nr, nr_present := vs.content[NEXUS_REPO_KEY] // extracting nexus repo key
-
-	if !nr_present {
-		return nil // nexus repo key missing
+// Method to process nexus repo content, remembers the repo file path for validateNexusRepoFileContent
+func (vs *validators) validateNexusRepoFilePath() {
+	if vs.content.NexusRepositories == nil || vs.content.NexusRepositories.YamlPath == "" {
+		return // nexus repo key missing
 	}
 
-	nr_map := nr.(map[string]interface{}) // assuming map, is it validated in schema??
-	file_path := filepath.Join(vs.manifestRootDir, nr_map[NEXUS_REPO_PATH_KEY].(string))
-	vs.nexusRepoFileName = file_path
+	file_path := filepath.Join(vs.manifestRootDir, vs.content.NexusRepositories.YamlPath)
 
-	exist := mutils.IsPathExist(file_path) // do we need error details??
-	if !exist {                            // if path is invalid
-		return fmt.Errorf("error in processing file %v, as it does not exist", file_path)
+	exist := mutils.IsPathExist(file_path)
+	if !exist { // if path is invalid
+		vs.errorf(mutils.Pointer(CONTENT_KEY, NEXUS_REPO_KEY, NEXUS_REPO_PATH_KEY), "nexus repositories file %v does not exist", file_path)
+		return
 	}
-
-	return nil
+	vs.nexusRepoFileName = file_path
 }
 
 // Method to process nexus repo file and get hosted repo names
-func (vs *validators) validateNexusRepoFileContent() error {
+func (vs *validators) validateNexusRepoFileContent() {
 
 	if vs.nexusRepoFileName == "" {
-		return nil // skip processing of nexus repo file
+		return // skip processing of nexus repo file
 	}
-
-	//var logger utils.Logger
+	pointer := mutils.Pointer(CONTENT_KEY, NEXUS_REPO_KEY, NEXUS_REPO_PATH_KEY)
 
 	nexusFile_contents, err := FileReader(vs.nexusRepoFileName)
-
-	var temp_repo_names []string
-
 	if err != nil {
-		return fmt.Errorf("failed to open Nexus Repository file: %v", err)
+		vs.errorf(pointer, "failed to open nexus repositories file %v: %v", vs.nexusRepoFileName, err)
+		return
 	}
 
+	var temp_repo_names []string
+
 	docs := mutils.SplitMultiYamlFile(nexusFile_contents)
 
-	for _, doc := range docs {
+	for i, doc := range docs {
 
 		var nexusContentRaw interface{}
 		err = yaml.Unmarshal(doc, &nexusContentRaw)
 		if err != nil {
-			return fmt.Errorf("failed to parse Nexus Repositorty as YAML: %v", err)
+			vs.errorf(pointer, "failed to parse document %d of nexus repositories file %v as YAML: %v", i+1, vs.nexusRepoFileName, err)
+			continue
 		}
 
-		nexusContent := nexusContentRaw.(map[string]interface{})
+		nexusContent, ok := nexusContentRaw.(map[string]interface{})
+		if !ok {
+			vs.errorf(pointer, "document %d of nexus repositories file %v is not a repository", i+1, vs.nexusRepoFileName)
+			continue
+		}
 
-		format := nexusContent[FORMAT].(string)
+		format, _ := nexusContent[FORMAT].(string)
 
 		isFormatToBeSkipped, _ := mutils.StringFoundInArray(skipFormatsForNexusRepo, format)
 
 		if isFormatToBeSkipped {
-			//logger.Infof("validateNexusRepoFileContent: Format %s skipped", format) // this line leading to panic
-			//Need to add logger to print which format is skipped
 			continue //Skip doc which has format that does not require validataion
 		}
 
+		name, _ := nexusContent[HOSTED_REPO_NAME].(string)
 		if nexusContent[REPO_TYPE] == "hosted" {
 
-			vs.hostedRepoNames = append(vs.hostedRepoNames, nexusContent["name"].(string))
-			temp_repo_names = append(temp_repo_names, nexusContent["name"].(string))
+			vs.hostedRepoNames = append(vs.hostedRepoNames, name)
+			temp_repo_names = append(temp_repo_names, name)
 
 		} else if nexusContent[REPO_TYPE] == "group" {
-			group_map := nexusContent["group"].(map[string]interface{})
+			group_map, _ := nexusContent["group"].(map[string]interface{})
 
 			for _, v := range group_map {
-				memNames_array := v.([]interface{})
+				memNames_array, _ := v.([]interface{})
 				for _, m := range memNames_array {
 
-					memberRepo := m.(string)
+					memberRepo := fmt.Sprintf("%v", m)
 
 					isHostedRepo, index := mutils.StringFoundInArray(temp_repo_names, memberRepo)
 					if isHostedRepo {
-						temp_repo_names, err = mutils.Delete(temp_repo_names, index)
-
-						if err != nil {
-							fmt.Println("repo defined in host repo is not listed in group repo")
-						}
-
+						temp_repo_names, _ = mutils.Delete(temp_repo_names, index)
 					} else {
-						return fmt.Errorf("repo referenced in group does not match hosted repo or Hosted Repos are not listed before group repos")
+						vs.errorf(pointer, "repository %v of group repository %v in nexus repositories file %v does not match a hosted repository listed before the group", memberRepo, name, vs.nexusRepoFileName)
 					}
 				}
 			}
 		}
 
 	}
-
-	return nil
 }
 
-// Method to process nexus blob content, returns error in case of issues
-func (vs *validators) validateNexusBlobFilePath() error {
-	nb, nb_present := vs.content[NEXUS_BLOB_KEY] // extracting nexus blob key
-
-	if !nb_present {
-		return nil // nexus blob key missing
+// Method to process nexus blob content
+func (vs *validators) validateNexusBlobFilePath() {
+	if vs.content.NexusBlobStores == nil || vs.content.NexusBlobStores.YamlPath == "" {
+		return // nexus blob key missing
 	}
 
-	nb_map := nb.(map[string]interface{})
-	file_path := filepath.Join(vs.manifestRootDir, nb_map[NEXUS_BLOB_PATH_KEY].(string))
+	file_path := filepath.Join(vs.manifestRootDir, vs.content.NexusBlobStores.YamlPath)
 
 	exist := mutils.IsPathExist(file_path)
 	if !exist { // if path is invalid
-		return fmt.Errorf("error in processing file %v as it does not exist", file_path)
+		vs.errorf(mutils.Pointer(CONTENT_KEY, NEXUS_BLOB_KEY, NEXUS_BLOB_PATH_KEY), "nexus blob stores file %v does not exist", file_path)
 	}
-	return nil
 }
 
-// Method to process vcs content, return error in case of issues
-func (vs *validators) validateVcsFilePath() error {
+// Method to process vcs content
+func (vs *validators) validateVcsFilePath() {
 
-	vcs, vcs_present := vs.content[VCS_KEY] // extracting nexus repo key
-
-	if !vcs_present {
-		return nil // vcs key missing
+	if vs.content.Vcs == nil || vs.content.Vcs.Path == "" {
+		return // vcs key missing
 	}
-	vcs_map := vcs.(map[string]interface{})
 
-	dir_path := filepath.Join(vs.manifestRootDir, vcs_map[VCS_PATH_KEY].(string))
+	dir_path := filepath.Join(vs.manifestRootDir, vs.content.Vcs.Path)
 	empty := mutils.IsEmptyDirectory(dir_path)
 	if empty { // if path is invalid
-		return fmt.Errorf("error in processing vcs directory %v", dir_path)
-	}
-	return nil
-}
-
-func isRpmHosted(hostedRepoNames []string, repoName string) error {
-
-	found := false
-
-	found, _ = mutils.StringFoundInArray(hostedRepoNames, repoName)
-	if !found {
-		return fmt.Errorf("repo %v referenced in rpms section is not a hosted repo", repoName)
+		vs.errorf(mutils.Pointer(CONTENT_KEY, VCS_KEY, VCS_PATH_KEY), "vcs directory %v does not exist or is empty", dir_path)
 	}
-
-	return nil
 }
 
 func isRpmExist(rootDir string, rpmName string) bool {
@@ -234,43 +207,46 @@ func isRpmExist(rootDir string, rpmName string) bool {
 	return mutils.IsEmptyDirectory(dirPath)
 }
 
-// Method to process rpm content, return error in case of issues
-func (vs *validators) validateRpmFiles() error {
-
-	rpm, rpm_present := vs.content[RPM_KEY] // extracting rpm key
-
-	if !rpm_present {
-		return nil // rpm key missing
-	}
+// Method to process rpm content
+func (vs *validators) validateRpmFiles() {
 
-	rpm_array := rpm.([]interface{})
+	for i, rpm := range vs.content.Rpms {
 
-	for _, rpm := range rpm_array {
-
-		rpm_map := rpm.(map[string]interface{})
-		rpmFile := rpm_map[RPM_PATH_KEY].(string)
-		if isRpmExist(vs.manifestRootDir, rpmFile) { // if path is invalid
-			return fmt.Errorf("error in processing rpm file %v in directory %v", rpmFile, vs.manifestRootDir)
+		if rpm.Path != "" && isRpmExist(vs.manifestRootDir, rpm.Path) { // if path is invalid
+			vs.errorf(mutils.Pointer(CONTENT_KEY, RPM_KEY, i, RPM_PATH_KEY), "rpm directory %v does not exist or is empty in directory %v", rpm.Path, vs.manifestRootDir)
 		}
 
-		repoName := rpm_map[REPO_NAME].(string)
-		err := isRpmHosted(vs.hostedRepoNames, repoName) // Checking for hosted repo
-
-		if err != nil {
-			return fmt.Errorf("%#v", err)
+		if rpm.RepositoryName == "" {
+			continue
+		}
+		if found, _ := mutils.StringFoundInArray(vs.hostedRepoNames, rpm.RepositoryName); !found { // Checking for hosted repo
+			vs.errorf(mutils.Pointer(CONTENT_KEY, RPM_KEY, i, REPO_NAME), "repo %v referenced in rpms section is not a hosted repo", rpm.RepositoryName)
 		}
 	}
-	return nil
 }
 
-// Function to extract content data
-// Assumtion: Schema validation is done be the data comes to this function
+// Function to extract content data, nil when the manifest has no content
 func getManifestContentMap(manifest interface{}) map[string]interface{} {
-	manifest_map := manifest.(map[string]interface{})
-	content_map := manifest_map[CONTENT_KEY].(map[string]interface{})
+	manifest_map, _ := manifest.(map[string]interface{})
+	content_map, _ := manifest_map[CONTENT_KEY].(map[string]interface{})
 	return content_map
 }
 
+// Method to read the content sections, the ones that do not follow the schema are reported and not checked
+func (vs *validators) readContent(content_map map[string]interface{}) {
+	content, errs := manifestTypes.ContentFromObject(content_map)
+	vs.content = content
+
+	var keys []string
+	for key := range errs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		vs.errorf(mutils.Pointer(CONTENT_KEY, key), "content %v cannot be read, so it is not checked: %v", key, errs[key])
+	}
+}
+
 var root_dir string
 
 // Function of set manifest root path
@@ -278,41 +254,38 @@ func SetManifestRootDir(root_path string) {
 	root_dir = root_path
 }
 
-// Function to validate manifest data post schema validation
+// Function to validate manifest data, returns an error listing every issue of error severity
 func Validate(manifest interface{}) error {
-	var pipeline *validators = &validators{}
-	pipeline.manifestRootDir = root_dir
-	pipeline.content = getManifestContentMap(manifest)
-
-	// content.s3 checks
-	if err := pipeline.validateS3FilePath(); err != nil {
-		return fmt.Errorf("issue in processing s3 content, details %v", err)
-	}
-
-	// content.nexus_repositories checks
-	if err := pipeline.validateNexusRepoFilePath(); err != nil {
-		return fmt.Errorf("issue in validating nexus repo file path, details %v", err)
-	}
-
-	// Validate content of nexus_repositories.yaml
-	if err := pipeline.validateNexusRepoFileContent(); err != nil {
-		return fmt.Errorf("issue in validating nexus repo file content, details %v", err)
-	}
-
-	// content.nexus_blob_stores checks
-	if err := pipeline.validateNexusBlobFilePath(); err != nil {
-		return fmt.Errorf("issue in validating nexus blob file path, details %v", err)
-	}
+	return mutils.IssuesError(ValidateIssues(manifest))
+}
 
-	// contents.vcs checks
-	if err := pipeline.validateVcsFilePath(); err != nil {
-		return fmt.Errorf("issue in validating vcs directory content, details %v", err)
-	}
+// Function to validate manifest data, returns every issue found. The content sections of the manifest that do not have
+// the types of the schema are reported once and skipped
+func ValidateIssues(manifest interface{}) []mutils.ValidationIssue {
+	return ValidateIssuesInDir(manifest, root_dir)
+}
 
-	// contents.rpms checks
-	if err := pipeline.validateRpmFiles(); err != nil {
-		return fmt.Errorf("issue in validating rpm directory content, details %v", err)
+// Function to validate manifest data against the content of rootDir, the directory of the manifest. Unlike
+// SetManifestRootDir and ValidateIssues, it can be called for several products at the same time
+func ValidateIssuesInDir(manifest interface{}, rootDir string) []mutils.ValidationIssue {
+	var pipeline *validators = &validators{}
+	pipeline.manifestRootDir = rootDir
+	content_map := getManifestContentMap(manifest)
+	if content_map == nil {
+		return nil
 	}
-
-	return nil
+	pipeline.readContent(content_map)
+
+	pipeline.validateS3FilePath()           // content.s3 checks
+	pipeline.validateNexusRepoFilePath()    // content.nexus_repositories checks
+	pipeline.validateNexusRepoFileContent() // Validate content of nexus_repositories.yaml
+	pipeline.validateNexusBlobFilePath()    // content.nexus_blob_stores checks
+	pipeline.validateVcsFilePath()          // contents.vcs checks
+	pipeline.validateRpmFiles()             // contents.rpms checks
+	pipeline.validateDockerDirectories()    // content.docker checks
+	pipeline.validateHelmCharts()           // content.helm checks
+	pipeline.validateLoftsmanManifests()    // content.loftsman checks
+	pipeline.validateImsContent()           // content.ims checks
+
+	return pipeline.issues
 }
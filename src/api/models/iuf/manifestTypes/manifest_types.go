@@ -0,0 +1,195 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+// Package manifestTypes has the Go types of the IUF Product Manifest schema, so that manifests are read once with their
+//  errors instead of by type assertions wherever they are used.
+package manifestTypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Manifest is an IUF Product Manifest. The fields follow the properties of the newest schema, which is a superset of
+//  the older ones, so that a manifest of any supported schema round-trips.
+type Manifest struct {
+	IufVersion    string                `json:"iuf_version"`
+	SchemaVersion string                `json:"schema_version,omitempty"`
+	Name          string                `json:"name"`
+	Description   string                `json:"description,omitempty"`
+	Version       string                `json:"version,omitempty"`
+	Content       *Content              `json:"content,omitempty"`
+	Hooks         map[string]StageHooks `json:"hooks,omitempty"`  // by stage name, with underscores instead of dashes
+	OnExit        map[string]HookScript `json:"onExit,omitempty"` // by stage name, with underscores instead of dashes
+}
+
+// Content is the content delivered by a product
+type Content struct {
+	Docker            []Path     `json:"docker,omitempty"`
+	Helm              []Path     `json:"helm,omitempty"`
+	Loftsman          []Loftsman `json:"loftsman,omitempty"`
+	NexusBlobStores   *YamlPath  `json:"nexus_blob_stores,omitempty"`
+	NexusRepositories *YamlPath  `json:"nexus_repositories,omitempty"`
+	Rpms              []Rpms     `json:"rpms,omitempty"`
+	Vcs               *Vcs       `json:"vcs,omitempty"`
+	Ims               *Ims       `json:"ims,omitempty"`
+	S3                []S3       `json:"s3,omitempty"`
+}
+
+// Path is a docker or helm directory of a product
+type Path struct {
+	Path string `json:"path"`
+}
+
+// YamlPath is the YAML file of the Nexus blob stores or repositories of a product
+type YamlPath struct {
+	YamlPath string `json:"yaml_path"`
+}
+
+// Loftsman is a Loftsman manifest file, or a directory of them
+type Loftsman struct {
+	Path           string `json:"path"`
+	UseManifestgen *bool  `json:"use_manifestgen,omitempty"`
+	Deploy         *bool  `json:"deploy,omitempty"`
+}
+
+// Rpms is a directory of RPMs to upload to a Nexus repository
+type Rpms struct {
+	Path           string `json:"path"`
+	RepositoryName string `json:"repository_name"`
+	RepositoryType string `json:"repository_type,omitempty"`
+}
+
+// Vcs is the directory to upload to a repository in VCS
+type Vcs struct {
+	RepoName string `json:"repo_name,omitempty"`
+	Path     string `json:"path"`
+}
+
+// Ims is the IMS images and recipes of a product
+type Ims struct {
+	Recipes     []ImsRecipe `json:"recipes,omitempty"`
+	Images      []ImsImage  `json:"images,omitempty"`
+	ContentDirs []string    `json:"content_dirs,omitempty"`
+}
+
+// ImsRecipe is an IMS recipe as a gzipped tar file
+type ImsRecipe struct {
+	Path               string            `json:"path"`
+	Name               string            `json:"name,omitempty"`
+	RecipeType         string            `json:"recipe_type"`
+	LinuxDistribution  string            `json:"linux_distribution"`
+	TemplateDictionary map[string]string `json:"template_dictionary,omitempty"`
+	Md5sum             string            `json:"md5sum,omitempty"`
+	Arch               string            `json:"arch,omitempty"`
+	RequireDkms        *bool             `json:"require_dkms,omitempty"`
+}
+
+// ImsImage is a directory of IMS image artifacts
+type ImsImage struct {
+	Path   string       `json:"path"`
+	Name   string       `json:"name,omitempty"`
+	Rootfs *ImsArtifact `json:"rootfs,omitempty"`
+	Kernel *ImsArtifact `json:"kernel,omitempty"`
+	Initrd *ImsArtifact `json:"initrd,omitempty"`
+	Arch   string       `json:"arch,omitempty"`
+}
+
+// ImsArtifact is a file of an IMS image, relative to the directory of the image
+type ImsArtifact struct {
+	Path   string `json:"path"`
+	Md5sum string `json:"md5sum,omitempty"`
+}
+
+// S3 is a file to upload to S3
+type S3 struct {
+	Path   string      `json:"path"`
+	Bucket string      `json:"bucket"`
+	Key    interface{} `json:"key"` // the schema does not give it a type
+}
+
+// StageHooks is the hook scripts run before and after a stage
+type StageHooks struct {
+	Pre  *HookScript `json:"pre,omitempty"`
+	Post *HookScript `json:"post,omitempty"`
+}
+
+// HookScript is a hook or exit handler script of a stage
+type HookScript struct {
+	ExecutionContext string `json:"execution_context,omitempty"`
+	ScriptPath       string `json:"script_path,omitempty"`
+}
+
+// Parse reads an IUF Product Manifest in YAML or JSON. The error says which field does not have the type of the
+//  schema, which is what schema validation does not guarantee for a manifest that was not validated.
+func Parse(file_contents []byte) (Manifest, error) {
+	var manifest Manifest
+	err := yaml.Unmarshal(file_contents, &manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read IUF Product Manifest: %v", err)
+	}
+	return manifest, nil
+}
+
+// FromObject reads an IUF Product Manifest that was already parsed into maps and slices
+func FromObject(manifest interface{}) (Manifest, error) {
+	data, err := yaml.Marshal(manifest)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read IUF Product Manifest: %v", err)
+	}
+	return Parse(data)
+}
+
+// ContentFromObject reads the content of a manifest that was already parsed into maps and slices, section by section,
+//  so that a section that does not have the type of the schema does not keep the others from being read. The errors
+//  are by the name of the section.
+func ContentFromObject(content map[string]interface{}) (Content, map[string]error) {
+	var res Content
+	errs := map[string]error{}
+	resValue := reflect.ValueOf(&res).Elem()
+	for key, value := range content {
+		data, err := json.Marshal(map[string]interface{}{key: value})
+		if err != nil {
+			errs[key] = err
+			continue
+		}
+		var section Content
+		if err := json.Unmarshal(data, &section); err != nil {
+			errs[key] = err
+			continue
+		}
+		sectionValue := reflect.ValueOf(section)
+		for i := 0; i < sectionValue.NumField(); i++ {
+			if !sectionValue.Field(i).IsZero() {
+				resValue.Field(i).Set(sectionValue.Field(i))
+			}
+		}
+	}
+	return res, errs
+}
@@ -0,0 +1,125 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package iuf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	sv "github.com/Cray-HPE/cray-nls/src/api/models/iuf/schemaValidator"
+	"github.com/Masterminds/semver/v3"
+)
+
+// manifestSchema is a version of the IUF Product Manifest schema
+type manifestSchema struct {
+	version string
+	file    string
+	// the IUF version from which this is the schema of manifests without schema_version. The IUF version is the
+	//  version of stages.yaml in IUF_INSTALL_WORKFLOW_FILES, the one that the iuf_version of manifests must satisfy.
+	iufVersion string
+	// whether the schema defines schema_version, which 0.7.2 does not allow
+	definesSchemaVersion bool
+}
+
+// manifestSchemas are the IUF Product Manifest schemas understood by this version of the IUF, oldest first. The last
+//  one is the newest, and the one in schemas/iuf-manifest-schema.yaml.
+//
+// 0.7.2 is the schema that every IUF release so far validates manifests against, whatever the version of its
+//  stages.yaml, so it applies from IUF version 0.0.0. When the schema changes, the older one is kept as
+//  schemas/iuf-manifest-schema-<version>.yaml, and the new one is added here with the version of stages.yaml of the
+//  first IUF release that ships it.
+var manifestSchemas = []manifestSchema{
+	{version: "0.7.2", file: "schemas/iuf-manifest-schema.yaml", iufVersion: "0.0.0"},
+}
+
+// the first version in an iuf_version constraint, which is the lowest one for the usual ^x, ~x, >= x < y and x
+var constraintVersionRegexp = regexp.MustCompile(`v?[0-9]+(\.[0-9]+)?(\.[0-9]+)?(-[0-9A-Za-z.-]+)?`)
+
+// ManifestSchemaVersion returns the version of the newest IUF Product Manifest schema
+func ManifestSchemaVersion() (string, error) {
+	return sv.SchemaVersion(newestManifestSchema().file)
+}
+
+// ManifestSchemaVersions returns the versions of the IUF Product Manifest schemas that manifests can follow, oldest
+//  first
+func ManifestSchemaVersions() []string {
+	var res []string
+	for _, schema := range manifestSchemas {
+		res = append(res, schema.version)
+	}
+	return res
+}
+
+func newestManifestSchema() manifestSchema {
+	return manifestSchemas[len(manifestSchemas)-1]
+}
+
+// selectManifestSchema returns the schema that a manifest follows: the one of its schema_version, or else the one of
+//  the IUF version named by its iuf_version, or else the newest one. The error is for a schema_version that is not
+//  a supported one, and comes with the newest schema.
+func selectManifestSchema(manifest map[string]interface{}) (manifestSchema, error) {
+	if schemaVersion, ok := manifest["schema_version"]; ok {
+		for _, schema := range manifestSchemas {
+			if fmt.Sprintf("%v", schemaVersion) == schema.version {
+				return schema, nil
+			}
+		}
+		return newestManifestSchema(), fmt.Errorf("schema version %v is not one of the supported versions %s", schemaVersion, strings.Join(ManifestSchemaVersions(), ", "))
+	}
+
+	constraint, ok := manifest["iuf_version"].(string)
+	if !ok {
+		return newestManifestSchema(), nil
+	}
+	iufVersion, err := semver.NewVersion(constraintVersionRegexp.FindString(constraint))
+	if err != nil {
+		return newestManifestSchema(), nil
+	}
+	res := manifestSchemas[0]
+	for _, schema := range manifestSchemas {
+		if !iufVersion.LessThan(semver.MustParse(schema.iufVersion)) {
+			res = schema
+		}
+	}
+	return res, nil
+}
+
+// schemaManifest returns the manifest to validate against a schema, without schema_version if the schema does not
+//  define it
+func schemaManifest(manifest map[string]interface{}, schema manifestSchema) map[string]interface{} {
+	if _, ok := manifest["schema_version"]; !ok || schema.definesSchemaVersion {
+		return manifest
+	}
+	res := make(map[string]interface{}, len(manifest))
+	for key, value := range manifest {
+		if key != "schema_version" {
+			res[key] = value
+		}
+	}
+	return res
+}
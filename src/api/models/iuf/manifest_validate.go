@@ -26,63 +26,179 @@
 package iuf
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	manifestDataValidator "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestDataValidation"
+	manifestTypes "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestTypes"
+	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
 	sv "github.com/Cray-HPE/cray-nls/src/api/models/iuf/schemaValidator"
 	"sigs.k8s.io/yaml"
 )
 
-const iuf_manifest_schema_file string = "schemas/iuf-manifest-schema.yaml"
+// Manifest is an IUF Product Manifest read into the types of its schema
+type Manifest = manifestTypes.Manifest
 
-var manifestRootPath string
+// ParseManifest reads an IUF Product Manifest into the types of its schema, with an error that says which field does
+//  not have the type of the schema
+func ParseManifest(file_contents []byte) (Manifest, error) {
+	return manifestTypes.Parse(file_contents)
+}
+
+// ValidationIssue is a problem found when validating an IUF Product Manifest, at the JSON pointer of what it is about
+type ValidationIssue = mutils.ValidationIssue
+
+type Severity = mutils.Severity
+
+const (
+	SeverityError   = mutils.SeverityError   // the manifest cannot be used as it is
+	SeverityWarning = mutils.SeverityWarning // the manifest can be used, but should be looked at
+)
+
+// ValidationReport is every issue found when validating an IUF Product Manifest
+type ValidationReport struct {
+	File   string            `json:"file,omitempty"` // Path of the manifest file, if validated from a file
+	Valid  bool              `json:"valid"`          // Whether there is no issue of error severity
+	Issues []ValidationIssue `json:"issues"`
+	// The version of the schema that the manifest was validated against
+	SchemaVersion string `json:"schema_version,omitempty"`
+	// The result of verifying the checksums file next to the manifest file, if there is one
+	Checksums *ChecksumResult `json:"checksums,omitempty"`
+}
+
+func (r *ValidationReport) add(issues ...ValidationIssue) {
+	r.Issues = append(r.Issues, issues...)
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			r.Valid = false
+		}
+	}
+}
 
-func extractDirPath(filePath string, storageVariable *string) {
-	*storageVariable = filepath.Dir(filePath)
+// Errors returns the issues of error severity
+func (r ValidationReport) Errors() []ValidationIssue {
+	var res []ValidationIssue
+	for _, issue := range r.Issues {
+		if issue.Severity == SeverityError {
+			res = append(res, issue)
+		}
+	}
+	return res
+}
+
+// Err returns an error listing the issues of error severity, or nil if the manifest is valid
+func (r ValidationReport) Err() error {
+	err := mutils.IssuesError(r.Issues)
+	if err != nil {
+		return fmt.Errorf("failed to validate IUF Product Manifest: %v", err)
+	}
+	return nil
 }
 
-func ValidateFile(file_path string) error {
-	fmt.Printf("Validating file %v against IUF Product Manifest Schema.\n", file_path)
+// ValidateFile validates an IUF Product Manifest file against the schema and the content of its directory. When
+//  iufVersion is set, the iuf_version of the manifest must also be satisfied by it.
+func ValidateFile(file_path string, iufVersion string) error {
+	return ValidateFileReport(file_path, iufVersion).Err()
+}
 
-	// Extracting root dir of manifest
-	extractDirPath(file_path, &manifestRootPath)
+// ValidateFileReport is ValidateFile, with every issue that was found
+func ValidateFileReport(file_path string, iufVersion string) ValidationReport {
+	// the content of the manifest is relative to its directory
+	rootDir := filepath.Dir(file_path)
 
 	file_contents, err := os.ReadFile(file_path)
+	if err != nil {
+		report := ValidationReport{File: file_path, Valid: true}
+		report.add(mutils.Errorf("", "failed to open IUF Product Manifest file: %v", err))
+		return report
+	}
+
+	report := ValidateReport(file_contents, rootDir, iufVersion)
+	report.File = file_path
 
+	checksums, err := VerifyChecksums(rootDir)
 	if err != nil {
-		return fmt.Errorf("failed to open IUF Product Manifest file: %v", err)
+		report.add(mutils.Errorf("", "%v", err))
+	} else if checksums != nil {
+		report.Checksums = checksums
+		report.add(checksums.Issues()...)
 	}
+	return report
+}
+
+// ValidateSchemaReport validates an IUF Product Manifest against the schema that it follows only, for manifests that
+//  are not next to their content
+func ValidateSchemaReport(file_contents []byte) ValidationReport {
+	report := ValidationReport{Valid: true}
 
-	err = Validate(file_contents)
+	var manifest interface{}
+	err := yaml.Unmarshal(file_contents, &manifest)
 	if err != nil {
-		return fmt.Errorf("failed to validate IUF Product Manifest: %#v", err)
+		report.add(mutils.Errorf("", "failed to parse IUF Product Manifest as YAML: %v", err))
+		return report
 	}
+	report.addSchemaIssues(manifest)
+	return report
+}
 
-	fmt.Printf("File %v is valid against IUF Product Manifest schema and data.\n", file_path)
-	return nil
+// addSchemaIssues validates a manifest against the schema of its schema_version or iuf_version
+func (r *ValidationReport) addSchemaIssues(manifest interface{}) {
+	manifestMap, _ := manifest.(map[string]interface{})
+	schema, err := selectManifestSchema(manifestMap)
+	if err != nil {
+		r.add(mutils.Errorf("/schema_version", "%v", err))
+	}
+	r.SchemaVersion = schema.version
+	var schemaIssues []ValidationIssue
+	if manifestMap != nil {
+		schemaIssues, err = sv.ValidateIssues(schemaManifest(manifestMap, schema), schema.file)
+	} else {
+		schemaIssues, err = sv.ValidateIssues(manifest, schema.file)
+	}
+	if err != nil {
+		r.add(mutils.Errorf("", "failed to validate IUF Product Manifest schema: %v", err))
+	}
+	sort.SliceStable(schemaIssues, func(i, j int) bool { return schemaIssues[i].Pointer < schemaIssues[j].Pointer })
+	r.add(schemaIssues...)
 }
 
 func Validate(file_contents []byte) error {
+	return ValidateReport(file_contents, "", "").Err()
+}
+
+// ValidateReport validates an IUF Product Manifest against the schema and the content of rootDir, the directory of the
+//  manifest, and returns every issue found. When iufVersion is set, the iuf_version of the manifest must also be
+//  satisfied by it, otherwise there is a warning that it was not checked.
+func ValidateReport(file_contents []byte, rootDir string, iufVersion string) ValidationReport {
+	report := ValidationReport{Valid: true}
 
 	var manifest interface{}
 	err := yaml.Unmarshal(file_contents, &manifest)
 	if err != nil {
-		return fmt.Errorf("failed to parse IUF Product Manifest as YAML: %v", err)
+		report.add(mutils.Errorf("", "failed to parse IUF Product Manifest as YAML: %v", err))
+		return report
 	}
 
-	// manifest schema validation
-	err = sv.Validate(manifest, iuf_manifest_schema_file)
-	if err != nil {
-		return fmt.Errorf("failed to validate IUF Product Manifest schema: %#v", err)
-	}
+	// manifest schema validation, against the schema that the manifest follows
+	report.addSchemaIssues(manifest)
+	manifestMap, _ := manifest.(map[string]interface{})
 
 	// manifest data validation
-	manifestDataValidator.SetManifestRootDir(manifestRootPath)
-	err = manifestDataValidator.Validate(manifest)
-	if err != nil {
-		return fmt.Errorf("failed to validate IUF Product Manifest data: %#v", err)
+	report.add(manifestDataValidator.ValidateIssuesInDir(manifest, rootDir)...)
+
+	if iufVersion == "" {
+		report.add(mutils.Warningf("/iuf_version", "not checked, because the IUF version is not known"))
+	} else if err := CheckManifestIufVersion(manifestMap, iufVersion); err != nil {
+		var versionErr IufVersionError
+		if errors.As(err, &versionErr) {
+			report.add(mutils.Errorf("/iuf_version", "%v", err))
+		} else {
+			report.add(mutils.Warningf("/iuf_version", "not checked: %v", err))
+		}
 	}
-	return nil
+
+	return report
 }
@@ -0,0 +1,232 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package iuf
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	manifestDataValidator "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestDataValidation"
+	manifestTypes "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestTypes"
+	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
+	"sigs.k8s.io/yaml"
+)
+
+// IUF_PRODUCT_MANIFEST_FILE is the name of the manifest at the top level of each product release distribution
+const IUF_PRODUCT_MANIFEST_FILE string = "iuf-product-manifest.yaml"
+
+// CrossProductIssue is a problem between the manifests of several products of the same media
+type CrossProductIssue struct {
+	ValidationIssue
+	Files []string `json:"files"` // Manifest files that the issue is about
+}
+
+// MediaValidationReport is every issue found when validating the products of a media directory
+type MediaValidationReport struct {
+	MediaDir  string              `json:"media_dir"`
+	Valid     bool                `json:"valid"`     // Whether there is no issue of error severity, in any manifest or between them
+	Manifests []ValidationReport  `json:"manifests"` // Report of each manifest found
+	Issues    []CrossProductIssue `json:"issues"`    // Issues between the products
+}
+
+func (r *MediaValidationReport) add(issue ValidationIssue, files ...string) {
+	sort.Strings(files)
+	r.Issues = append(r.Issues, CrossProductIssue{ValidationIssue: issue, Files: files})
+	if issue.Severity == SeverityError {
+		r.Valid = false
+	}
+}
+
+// FindManifestFiles returns the IUF Product Manifest files under a media directory, one for each extracted product
+//  release distribution. The directories of a product are not searched for more manifests.
+func FindManifestFiles(mediaDir string) ([]string, error) {
+	var res []string
+	err := filepath.WalkDir(mediaDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		manifestFile := filepath.Join(path, IUF_PRODUCT_MANIFEST_FILE)
+		if mutils.IsPathExist(manifestFile) {
+			res = append(res, manifestFile)
+			if path != mediaDir {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	return res, err
+}
+
+// ValidateMedia validates every IUF Product Manifest under a media directory against its own product directory, and
+//  then checks that the products can be installed together: no two products have the same name and version, define the
+//  same hosted Nexus repository or the same Loftsman manifest.
+func ValidateMedia(mediaDir string, iufVersion string) MediaValidationReport {
+	report := MediaValidationReport{MediaDir: mediaDir, Valid: true, Manifests: []ValidationReport{}}
+
+	manifestFiles, err := FindManifestFiles(mediaDir)
+	if err != nil {
+		report.add(mutils.Errorf("", "failed to search media directory %v: %v", mediaDir, err))
+		return report
+	}
+	if len(manifestFiles) == 0 {
+		report.add(mutils.Errorf("", "no %v found in media directory %v", IUF_PRODUCT_MANIFEST_FILE, mediaDir))
+		return report
+	}
+
+	productFiles := map[string][]string{}
+	hostedRepoFiles := map[string][]string{}
+	loftsmanManifestFiles := map[string][]string{}
+	for _, manifestFile := range manifestFiles {
+		manifestReport := ValidateFileReport(manifestFile, iufVersion)
+		report.Manifests = append(report.Manifests, manifestReport)
+		if !manifestReport.Valid {
+			report.Valid = false
+		}
+
+		manifest, ok := readManifest(manifestFile)
+		if !ok {
+			continue
+		}
+		if manifest.Name != "" {
+			product := strings.TrimSpace(manifest.Name + " " + manifest.Version)
+			productFiles[product] = append(productFiles[product], manifestFile)
+		}
+		rootDir := filepath.Dir(manifestFile)
+		content := manifest.Content
+		if content == nil {
+			continue
+		}
+		for _, repoName := range manifestHostedRepoNames(rootDir, content) {
+			hostedRepoFiles[repoName] = append(hostedRepoFiles[repoName], manifestFile)
+		}
+		for _, loftsmanName := range manifestLoftsmanNames(rootDir, content) {
+			loftsmanManifestFiles[loftsmanName] = append(loftsmanManifestFiles[loftsmanName], manifestFile)
+		}
+	}
+
+	for _, product := range sortedKeys(productFiles) {
+		if len(productFiles[product]) > 1 {
+			report.add(mutils.Errorf("", "product %v is in the media more than once", product), productFiles[product]...)
+		}
+	}
+	for _, repoName := range sortedKeys(hostedRepoFiles) {
+		if len(hostedRepoFiles[repoName]) > 1 {
+			report.add(mutils.Errorf("/content/nexus_repositories/yaml_path", "hosted Nexus repository %v is defined by more than one product", repoName), hostedRepoFiles[repoName]...)
+		}
+	}
+	for _, loftsmanName := range sortedKeys(loftsmanManifestFiles) {
+		if len(loftsmanManifestFiles[loftsmanName]) > 1 {
+			report.add(mutils.Errorf("/content/loftsman", "Loftsman manifest %v is provided by more than one product", loftsmanName), loftsmanManifestFiles[loftsmanName]...)
+		}
+	}
+
+	return report
+}
+
+func sortedKeys(m map[string][]string) []string {
+	var res []string
+	for key := range m {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// readManifest returns the parsed manifest file, or false when it cannot be read, which the validation of the file
+//  reports
+func readManifest(manifestFile string) (Manifest, bool) {
+	file_contents, err := os.ReadFile(manifestFile)
+	if err != nil {
+		return Manifest{}, false
+	}
+	manifest, err := ParseManifest(file_contents)
+	return manifest, err == nil
+}
+
+// manifestHostedRepoNames returns the names of the hosted repositories in the nexus_repositories file of a manifest.
+//  Once per name, since the validation of the file reports when it cannot be read.
+func manifestHostedRepoNames(rootDir string, content *manifestTypes.Content) []string {
+	if content.NexusRepositories == nil || content.NexusRepositories.YamlPath == "" {
+		return nil
+	}
+	file_contents, err := os.ReadFile(filepath.Join(rootDir, content.NexusRepositories.YamlPath))
+	if err != nil {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var res []string
+	for _, doc := range mutils.SplitMultiYamlFile(file_contents) {
+		var repository struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if yaml.Unmarshal(doc, &repository) != nil || repository.Type != "hosted" || seen[repository.Name] {
+			continue
+		}
+		seen[repository.Name] = true
+		res = append(res, repository.Name)
+	}
+	return res
+}
+
+// manifestLoftsmanNames returns the metadata.name of the Loftsman manifests of a manifest, given either as files or as
+//  directories of files. Once per name, and leaving out the files that cannot be read.
+func manifestLoftsmanNames(rootDir string, content *manifestTypes.Content) []string {
+	var files []string
+	for _, loftsman := range content.Loftsman {
+		if loftsman.Path == "" {
+			continue
+		}
+		files = append(files, manifestDataValidator.LoftsmanManifestFiles(filepath.Join(rootDir, loftsman.Path))...)
+	}
+
+	seen := map[string]bool{}
+	var res []string
+	for _, file := range files {
+		file_contents, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var loftsmanManifest struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+		}
+		if yaml.Unmarshal(file_contents, &loftsmanManifest) != nil || loftsmanManifest.Metadata.Name == "" || seen[loftsmanManifest.Metadata.Name] {
+			continue
+		}
+		seen[loftsmanManifest.Metadata.Name] = true
+		res = append(res, loftsmanManifest.Metadata.Name)
+	}
+	return res
+}
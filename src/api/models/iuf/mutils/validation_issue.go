@@ -0,0 +1,86 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package mutils
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the manifest cannot be used as it is
+	SeverityWarning Severity = "warning" // the manifest can be used, but should be looked at
+)
+
+// ValidationIssue is a problem found when validating a manifest
+type ValidationIssue struct {
+	Pointer  string   `json:"pointer"`  // JSON pointer to the part of the manifest that the issue is about, empty for the whole manifest
+	Severity Severity `json:"severity"` // error or warning
+	Message  string   `json:"message"`
+}
+
+func (i ValidationIssue) String() string {
+	if i.Pointer == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Pointer, i.Message)
+}
+
+// Errorf returns an issue of error severity
+func Errorf(pointer string, format string, args ...interface{}) ValidationIssue {
+	return ValidationIssue{Pointer: pointer, Severity: SeverityError, Message: fmt.Sprintf(format, args...)}
+}
+
+// Warningf returns an issue of warning severity
+func Warningf(pointer string, format string, args ...interface{}) ValidationIssue {
+	return ValidationIssue{Pointer: pointer, Severity: SeverityWarning, Message: fmt.Sprintf(format, args...)}
+}
+
+// Pointer joins JSON pointer tokens, escaping them as RFC 6901 says
+func Pointer(tokens ...interface{}) string {
+	var res strings.Builder
+	for _, token := range tokens {
+		escaped := strings.ReplaceAll(fmt.Sprintf("%v", token), "~", "~0")
+		res.WriteString("/" + strings.ReplaceAll(escaped, "/", "~1"))
+	}
+	return res.String()
+}
+
+// IssuesError returns an error listing the issues of error severity, or nil if there are none
+func IssuesError(issues []ValidationIssue) error {
+	var messages []string
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			messages = append(messages, issue.String())
+		}
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(messages, "; "))
+}
@@ -27,8 +27,10 @@ package schemaValidator
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 
+	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
 	"github.com/santhosh-tekuri/jsonschema/v5"
 	"sigs.k8s.io/yaml"
 )
@@ -39,26 +41,75 @@ var schemas embed.FS
 // Validates schema of a yaml file
 // Provide the yaml data as object and schema file to process
 func Validate(yamlObject interface{}, schemaFile string) error {
+	issues, err := ValidateIssues(yamlObject, schemaFile)
+	if err != nil {
+		return err
+	}
+	err = mutils.IssuesError(issues)
+	if err != nil {
+		return fmt.Errorf("failed to validate yaml: %v", err)
+	}
+	return nil
+}
 
+// ValidateIssues validates a yaml object against a schema file, and returns every place where it does not follow the
+//  schema. The error is for when the schema itself cannot be used.
+func ValidateIssues(yamlObject interface{}, schemaFile string) ([]mutils.ValidationIssue, error) {
 	schema_file_contents, err := schemas.ReadFile(schemaFile)
 	if err != nil {
-		return fmt.Errorf("failed to load schema file: %s, error: %v", schemaFile, err)
+		return nil, fmt.Errorf("failed to load schema file: %s, error: %v", schemaFile, err)
 	}
 
 	schema_json, err := yaml.YAMLToJSON(schema_file_contents)
 	if err != nil {
-		return fmt.Errorf("failed to convert schema file: %s from YAML to JSON: %v", schemaFile, err)
+		return nil, fmt.Errorf("failed to convert schema file: %s from YAML to JSON: %v", schemaFile, err)
 	}
 
 	schema, err := jsonschema.CompileString(schemaFile, string(schema_json))
 	if err != nil {
-		return fmt.Errorf("failed to validate schema file: %s JSON Schema: %v", schemaFile, err)
+		return nil, fmt.Errorf("failed to validate schema file: %s JSON Schema: %v", schemaFile, err)
 	}
 
 	err = schema.Validate(yamlObject)
+	var validationErr *jsonschema.ValidationError
+	if errors.As(err, &validationErr) {
+		return leafIssues(validationErr), nil
+	} else if err != nil {
+		return []mutils.ValidationIssue{mutils.Errorf("", "failed to validate yaml: %v", err)}, nil
+	}
+
+	return nil, nil
+}
+
+// leafIssues flattens a validation error into its causes that have no causes of their own, which are the ones that
+//  say what is wrong rather than which part of the schema was not followed
+func leafIssues(validationErr *jsonschema.ValidationError) []mutils.ValidationIssue {
+	if len(validationErr.Causes) == 0 {
+		return []mutils.ValidationIssue{mutils.Errorf(validationErr.InstanceLocation, "%s", validationErr.Message)}
+	}
+	var res []mutils.ValidationIssue
+	for _, cause := range validationErr.Causes {
+		res = append(res, leafIssues(cause)...)
+	}
+	return res
+}
+
+// SchemaVersion returns the version that a schema file gives itself
+func SchemaVersion(schemaFile string) (string, error) {
+	schema_file_contents, err := schemas.ReadFile(schemaFile)
 	if err != nil {
-		return fmt.Errorf("failed to validate yaml: %#v", err)
+		return "", fmt.Errorf("failed to load schema file: %s, error: %v", schemaFile, err)
 	}
 
-	return nil
+	var schema struct {
+		Version string `json:"version"`
+	}
+	err = yaml.Unmarshal(schema_file_contents, &schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse schema file: %s, error: %v", schemaFile, err)
+	}
+	if schema.Version == "" {
+		return "", fmt.Errorf("schema file: %s has no version", schemaFile)
+	}
+	return schema.Version, nil
 }
@@ -35,7 +35,9 @@ type Session struct {
 	InputParameters InputParameters   `json:"input_parameters"`
 	SiteParameters  SiteParameters    `json:"site_parameters"`
 	CurrentState    SessionState      `json:"current_state" enums:"paused,in_progress,debug,completed,aborted"`
-	CurrentStage    string            `json:"stage"`
+	CurrentStage    string            `json:"stage"`          // The stage that was last started or synced
+	CurrentStages   []string          `json:"current_stages"` // The stages that are currently running. Stages that do not depend on each other run in parallel
+	CompletedStages []string          `json:"completed_stages"`
 	Workflows       []SessionWorkflow `json:"workflows"`
 	Products        []Product         `json:"products" validate:"required"`
 
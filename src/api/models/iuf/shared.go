@@ -26,11 +26,14 @@
 package iuf
 
 type Product struct {
-	Name             string `json:"name" validate:"required"`               // The name of the product
-	Version          string `json:"version" validate:"required"`            // The version of the product.
-	OriginalLocation string `json:"original_location"  validate:"required"` // The original location of the extracted tar in on the physical storage.
-	Validated        bool   `json:"validated"  validate:"required"`         // The flag indicates md5 of a product tarball file has been validated
-	Manifest         string `json:"manifest"`                               // the content of manifest
+	Name               string          `json:"name" validate:"required"`               // The name of the product
+	Version            string          `json:"version" validate:"required"`            // The version of the product.
+	OriginalLocation   string          `json:"original_location"  validate:"required"` // The original location of the extracted tar in on the physical storage.
+	Validated          bool            `json:"validated"  validate:"required"`         // The flag indicates the manifest is valid and the files of the product match its checksums file, if there is one
+	ValidationErrors   []string        `json:"validation_errors,omitempty"`            // Why the product is not validated, e.g. its manifest does not follow the schema or is not compatible with the IUF version
+	ValidationWarnings []string        `json:"validation_warnings,omitempty"`          // What should be looked at although the product is validated, e.g. it has no checksums file
+	Checksums          *ChecksumResult `json:"checksums,omitempty"`                    // The result of verifying the checksums file of the product, if there is one. Verified once process-media succeeded
+	Manifest           string          `json:"manifest"`                               // the content of manifest
 } //	@name	Product
 
 type InputParameters struct {
@@ -48,7 +51,11 @@ type InputParameters struct {
 	CfsConfigurationManagement            string                     `json:"cfs_configuration_management"`                       // The name of the cfs configuration for management nodes
 	BootImageManagement                   string                     `json:"boot_image_management"`                              // The name of the boot image to be used for management nodes
 	Stages                                []string                   `json:"stages"`                                             // Stages to execute
+	Operations                            []string                   `json:"operations"`                                         // Operations to execute within the stages. When set, only these operations are executed and product hooks are not run. Empty means all operations.
+	Products                              []string                   `json:"products"`                                           // Products to execute the stages for, either by name or by <name>-<version>. Empty means all products.
 	Force                                 bool                       `json:"force"`                                              // Force re-execution of stage operations
+	BlockOnInvalidProducts                bool                       `json:"block_on_invalid_products"`                          // Do not run any stage after process-media while a product to execute the stages for is not validated
+	OperationPolicies                     map[string]OperationPolicy `json:"operation_policies"`                                 // Timeout and retry policies by operation name, overriding the ones in stages.yaml
 } //	@name	InputParameters
 
 type InputParametersPatch struct {
@@ -66,7 +73,11 @@ type InputParametersPatch struct {
 	CfsConfigurationManagement            *string                     `json:"cfs_configuration_management"`                       // The name of the cfs configuration for management nodes
 	BootImageManagement                   *string                     `json:"boot_image_management"`                              // The name of the boot image to be used for management nodes
 	Stages                                *[]string                   `json:"stages"`                                             // Stages to execute
+	Operations                            *[]string                   `json:"operations"`                                         // Operations to execute within the stages. When set, only these operations are executed and product hooks are not run. Empty means all operations.
+	Products                              *[]string                   `json:"products"`                                           // Products to execute the stages for, either by name or by <name>-<version>. Empty means all products.
 	Force                                 *bool                       `json:"force"`                                              // Force re-execution of stage operations
+	BlockOnInvalidProducts                *bool                       `json:"block_on_invalid_products"`                          // Do not run any stage after process-media while a product to execute the stages for is not validated
+	OperationPolicies                     *map[string]OperationPolicy `json:"operation_policies"`                                 // Timeout and retry policies by operation name, overriding the ones in stages.yaml
 } //	@name	InputParameters
 
 type EManagementRolloutStrategy string
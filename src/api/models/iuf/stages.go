@@ -32,6 +32,8 @@ type Stage struct {
 	Operations                         []Operations `yaml:"operations" json:"operations" binding:"required"`                                    // operations
 	NoHooks                            bool         `yaml:"no-hooks" json:"no-hooks"`                                                           // no-hook indicates that there are no hooks that should be run for this stage
 	ProcessProductVariantsSequentially bool         `yaml:"process-product-variants-sequentially" json:"process-product-variants-sequentially"` // this stage wants to make sure all products with the same name (but different versions) are processed sequentially, not in parallel, to avoid operational race conditions
+	DependsOn                          []string     `yaml:"depends-on" json:"depends-on"`                                                       // stages that must complete before this stage can run. When not set, the stage depends on the stage before it
+	Timeout                            string       `yaml:"timeout" json:"timeout"`                                                             // how long each workflow of this stage may run, as a duration such as 2h
 } //	@name	Stage
 
 type Stages struct {
@@ -45,4 +47,15 @@ type Operations struct {
 	StaticParameters                  map[string]interface{} `yaml:"static-parameters" json:"static-parameters" binding:"required"`
 	RequiredManifestAttributes        []string               `yaml:"required-manifest-attributes" json:"required-manifest-attributes"`
 	IncludeDefaultProductInSiteParams bool                   `yaml:"include-default-product-in-site-params" json:"include-default-product-in-site-params"`
+	NoRetry                           bool                   `yaml:"no-retry" json:"no-retry,omitempty"` // never retry the operation automatically, whatever its retry-limit, because running it again could do harm
+	OperationPolicy
 } //	@name	Operations
+
+// OperationPolicy is the timeout and retry policy of an operation. Operations are not retried unless retry-limit is set.
+type OperationPolicy struct {
+	Timeout      string `yaml:"timeout" json:"timeout,omitempty"`             // how long each attempt of an operation may run, as a duration such as 1h
+	RetryWindow  string `yaml:"retry-window" json:"retry-window,omitempty"`   // how long after its first attempt started an operation may still be retried, as a duration such as 6h
+	RetryLimit   *int   `yaml:"retry-limit" json:"retry-limit,omitempty"`     // how many times to retry a failed operation
+	RetryBackoff string `yaml:"retry-backoff" json:"retry-backoff,omitempty"` // how long to wait before the first retry, as a duration such as 1m. Doubled for each retry after that
+	RetryPolicy  string `yaml:"retry-policy" json:"retry-policy,omitempty"`   // when to retry: Always, OnFailure, OnError or OnTransientError. Defaults to OnFailure
+} //	@name	OperationPolicy
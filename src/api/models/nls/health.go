@@ -0,0 +1,50 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+//
+package models
+
+// HealthResponse is the readiness of NLS, by dependency
+type HealthResponse struct {
+	Healthy bool              `json:"healthy"`
+	Checks  map[string]string `json:"checks"` // ok or failed, by the name of the dependency
+}
+
+// HealthDetailResponse explains the readiness of NLS to humans
+type HealthDetailResponse struct {
+	Healthy bool          `json:"healthy"`
+	Version string        `json:"version"`
+	Checks  []HealthCheck `json:"checks"`
+}
+
+type HealthCheck struct {
+	Name     string `json:"name"`
+	Healthy  bool   `json:"healthy"`
+	Target   string `json:"target,omitempty"` // what was checked, such as a URL or a directory
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+const (
+	HEALTH_CHECK_OK     = "ok"
+	HEALTH_CHECK_FAILED = "failed"
+)
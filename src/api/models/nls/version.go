@@ -0,0 +1,41 @@
+//
+//  MIT License
+//
+//  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+//
+//  Permission is hereby granted, free of charge, to any person obtaining a
+//  copy of this software and associated documentation files (the "Software"),
+//  to deal in the Software without restriction, including without limitation
+//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+//  and/or sell copies of the Software, and to permit persons to whom the
+//  Software is furnished to do so, subject to the following conditions:
+//
+//  The above copyright notice and this permission notice shall be included
+//  in all copies or substantial portions of the Software.
+//
+//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+//  OTHER DEALINGS IN THE SOFTWARE.
+//
+package models
+
+// VersionResponse has the version of NLS and of what it depends on, for support cases
+type VersionResponse struct {
+	Message                string            `json:"message"` // the version of NLS, where it has always been
+	Version                string            `json:"version"`
+	GitCommit              string            `json:"gitCommit"`
+	BuildDate              string            `json:"buildDate"`
+	GoVersion              string            `json:"goVersion"`
+	StagesVersion          string            `json:"stagesVersion"`          // of the IUF stages.yaml
+	ManifestSchemaVersion  string            `json:"manifestSchemaVersion"`  // of the newest IUF Product Manifest schema
+	ManifestSchemaVersions []string          `json:"manifestSchemaVersions"` // of every IUF Product Manifest schema that manifests can follow
+	ArgoServerVersion      string            `json:"argoServerVersion"`
+	WorkflowTemplates      map[string]string `json:"workflowTemplates"` // version by name, of the workflow templates that have a version label
+}
+
+// VERSION_UNKNOWN is the version of what NLS could not get the version of
+const VERSION_UNKNOWN = "unknown"
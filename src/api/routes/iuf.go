@@ -32,9 +32,10 @@ import (
 
 // IufRoutes struct
 type IufRoutes struct {
-	logger        utils.Logger
-	handler       utils.RequestHandler
-	iufController iuf.IufController
+	logger          utils.Logger
+	handler         utils.RequestHandler
+	iufController   iuf.IufController
+	auditController iuf.AuditController
 }
 
 // Setup Iuf routes
@@ -48,6 +49,7 @@ func (s IufRoutes) Setup() {
 		api.GET("/activities/:activity_name", s.iufController.GetActivity)
 		api.PATCH("/activities/:activity_name", s.iufController.PatchActivity)
 		api.DELETE("/activities/:activity_name", s.iufController.DeleteActivity)
+		api.GET("/activities/:activity_name/events", s.iufController.ActivityEvents)
 		// history CRUD
 		api.GET("/activities/:activity_name/history", s.iufController.ListHistory)
 		api.GET("/activities/:activity_name/history/:start_time", s.iufController.GetHistory)
@@ -61,10 +63,13 @@ func (s IufRoutes) Setup() {
 		// session CRUD
 		api.GET("/activities/:activity_name/sessions", s.iufController.ListSessions)
 		api.GET("/activities/:activity_name/sessions/:session_name", s.iufController.GetSession)
+		api.GET("/activities/:activity_name/sessions/:session_name/stages/:stage/workflow", s.iufController.GetSessionStageWorkflow)
 		api.POST("/session/sync", s.iufController.Sync)
 		api.POST("/session/workflowsync", s.iufController.WorkflowSync)
 		// stages
 		api.GET("/stages", s.iufController.GetStages)
+		// audit
+		api.GET("/audit", s.auditController.ListAudit)
 	}
 }
 
@@ -73,10 +78,12 @@ func NewIufRoutes(
 	logger utils.Logger,
 	handler utils.RequestHandler,
 	iufController iuf.IufController,
+	auditController iuf.AuditController,
 ) IufRoutes {
 	return IufRoutes{
-		handler:       handler,
-		logger:        logger,
-		iufController: iufController,
+		handler:         handler,
+		logger:          logger,
+		iufController:   iufController,
+		auditController: auditController,
 	}
 }
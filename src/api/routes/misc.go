@@ -26,6 +26,8 @@ package routes
 import (
 	misc_controllers "github.com/Cray-HPE/cray-nls/src/api/controllers/v1/misc"
 	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // MiscRoutes struct
@@ -42,9 +44,11 @@ func (s MiscRoutes) Setup() {
 	{
 		api.GET("/liveness", s.miscController.GetLiveness)
 		api.GET("/readiness", s.miscController.GetReadiness)
+		api.GET("/health/detail", s.miscController.GetHealthDetail)
 		api.GET("/version", s.miscController.GetVersion)
 
 	}
+	s.handler.Gin.GET("/metrics", gin.WrapH(promhttp.Handler()))
 }
 
 // NewMiscRoutes creates new Misc controller
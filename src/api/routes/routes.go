@@ -1,29 +1,30 @@
+// MIT License
 //
-//  MIT License
+// (C) Copyright 2022 Hewlett Packard Enterprise Development LP
 //
-//  (C) Copyright 2022 Hewlett Packard Enterprise Development LP
+// Permission is hereby granted, free of charge, to any person obtaining a
+// copy of this software and associated documentation files (the "Software"),
+// to deal in the Software without restriction, including without limitation
+// the rights to use, copy, modify, merge, publish, distribute, sublicense,
+// and/or sell copies of the Software, and to permit persons to whom the
+// Software is furnished to do so, subject to the following conditions:
 //
-//  Permission is hereby granted, free of charge, to any person obtaining a
-//  copy of this software and associated documentation files (the "Software"),
-//  to deal in the Software without restriction, including without limitation
-//  the rights to use, copy, modify, merge, publish, distribute, sublicense,
-//  and/or sell copies of the Software, and to permit persons to whom the
-//  Software is furnished to do so, subject to the following conditions:
-//
-//  The above copyright notice and this permission notice shall be included
-//  in all copies or substantial portions of the Software.
-//
-//  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
-//  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
-//  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
-//  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
-//  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
-//  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
-//  OTHER DEALINGS IN THE SOFTWARE.
+// The above copyright notice and this permission notice shall be included
+// in all copies or substantial portions of the Software.
 //
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+// THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+// OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+// ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+// OTHER DEALINGS IN THE SOFTWARE.
 package routes
 
-import "go.uber.org/fx"
+import (
+	"github.com/Cray-HPE/cray-nls/src/api/middlewares"
+	"go.uber.org/fx"
+)
 
 // Module exports dependency to container
 var Module = fx.Options(
@@ -32,6 +33,7 @@ var Module = fx.Options(
 	fx.Provide(NewRoutes),
 	fx.Provide(NewMiscRoutes),
 	fx.Provide(NewIufRoutes),
+	fx.Provide(NewRoutePermissions),
 )
 
 // Routes contains multiple routes
@@ -63,3 +65,57 @@ func (r Routes) Setup() {
 		route.Setup()
 	}
 }
+
+// NewRoutePermissions returns the permission needed to call each route. Routes that are not here cannot be called.
+func NewRoutePermissions() middlewares.RoutePermissions {
+	return middlewares.RoutePermissions{
+		// docs
+		"GET /apis/nls/openapi/*any": middlewares.PermissionPublic,
+		"GET /apis/iuf/openapi/*any": middlewares.PermissionPublic,
+		// misc
+		"GET /apis/nls/v1/liveness":  middlewares.PermissionPublic,
+		"GET /apis/nls/v1/readiness": middlewares.PermissionPublic,
+		"GET /apis/nls/v1/version":   middlewares.PermissionPublic,
+		// the errors of the dependencies are for operators only
+		"GET /apis/nls/v1/health/detail": middlewares.PermissionRead,
+		// scraped by Prometheus, which has no token
+		"GET /metrics": middlewares.PermissionPublic,
+		// ncns
+		"POST /apis/nls/v1/ncns/rebuild": middlewares.PermissionAdmin,
+		"POST /apis/nls/v1/ncns/hooks":   middlewares.PermissionAdmin,
+		// workflows
+		"GET /apis/nls/v1/workflows":             middlewares.PermissionRead,
+		"GET /apis/nls/v1/workflows/:name":       middlewares.PermissionRead,
+		"PUT /apis/nls/v1/workflows/:name/retry": middlewares.PermissionOperate,
+		"PUT /apis/nls/v1/workflows/:name/rerun": middlewares.PermissionOperate,
+		"DELETE /apis/nls/v1/workflows/:name":    middlewares.PermissionAdmin,
+		// iuf activities
+		"POST /apis/iuf/v1/activities":                       middlewares.PermissionOperate,
+		"GET /apis/iuf/v1/activities":                        middlewares.PermissionRead,
+		"GET /apis/iuf/v1/activities/:activity_name":         middlewares.PermissionRead,
+		"PATCH /apis/iuf/v1/activities/:activity_name":       middlewares.PermissionOperate,
+		"DELETE /apis/iuf/v1/activities/:activity_name":      middlewares.PermissionAdmin,
+		"GET /apis/iuf/v1/activities/:activity_name/events":  middlewares.PermissionRead,
+		"GET /apis/iuf/v1/activities/:activity_name/history": middlewares.PermissionRead,
+		// iuf history
+		"GET /apis/iuf/v1/activities/:activity_name/history/:start_time":   middlewares.PermissionRead,
+		"POST /apis/iuf/v1/activities/:activity_name/history/run":          middlewares.PermissionOperate,
+		"POST /apis/iuf/v1/activities/:activity_name/history/restart":      middlewares.PermissionOperate,
+		"POST /apis/iuf/v1/activities/:activity_name/history/abort":        middlewares.PermissionOperate,
+		"POST /apis/iuf/v1/activities/:activity_name/history/blocked":      middlewares.PermissionOperate,
+		"POST /apis/iuf/v1/activities/:activity_name/history/paused":       middlewares.PermissionOperate,
+		"POST /apis/iuf/v1/activities/:activity_name/history/resume":       middlewares.PermissionOperate,
+		"PATCH /apis/iuf/v1/activities/:activity_name/history/:start_time": middlewares.PermissionOperate,
+		// iuf sessions
+		"GET /apis/iuf/v1/activities/:activity_name/sessions":                                      middlewares.PermissionRead,
+		"GET /apis/iuf/v1/activities/:activity_name/sessions/:session_name":                        middlewares.PermissionRead,
+		"GET /apis/iuf/v1/activities/:activity_name/sessions/:session_name/stages/:stage/workflow": middlewares.PermissionRead,
+		// called by metacontroller, which has no token
+		"POST /apis/iuf/v1/session/sync":         middlewares.PermissionWebhook,
+		"POST /apis/iuf/v1/session/workflowsync": middlewares.PermissionWebhook,
+		// iuf stages
+		"GET /apis/iuf/v1/stages": middlewares.PermissionRead,
+		// audit
+		"GET /apis/iuf/v1/audit": middlewares.PermissionAdmin,
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	iuf_controllers "github.com/Cray-HPE/cray-nls/src/api/controllers/v1/iuf"
+	misc_controllers "github.com/Cray-HPE/cray-nls/src/api/controllers/v1/misc"
+	controllers_v1 "github.com/Cray-HPE/cray-nls/src/api/controllers/v1/nls"
+	"github.com/Cray-HPE/cray-nls/src/api/middlewares"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoutePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := utils.GetLogger()
+	handler := utils.NewRequestHandler(logger, utils.Env{})
+	NewRoutes(
+		NewNcnRoutes(logger, handler, controllers_v1.NcnController{}, controllers_v1.HookController{}),
+		NewWorkflowRoutes(logger, handler, controllers_v1.WorkflowController{}),
+		NewMiscRoutes(logger, handler, misc_controllers.MiscController{}),
+		NewIufRoutes(logger, handler, iuf_controllers.IufController{}, iuf_controllers.AuditController{}),
+	).Setup()
+
+	permissions := NewRoutePermissions()
+	registered := map[string]bool{}
+	for _, route := range handler.Gin.Routes() {
+		key := route.Method + " " + route.Path
+		registered[key] = true
+		_, found := permissions[key]
+		assert.True(t, found, "no permission is defined for %s", key)
+	}
+	for key := range permissions {
+		assert.True(t, registered[key], "permission is defined for %s, which is not a route", key)
+	}
+}
+
+func TestSyncWithoutToken(t *testing.T) {
+	newHandler := func(webhookSecret string) utils.RequestHandler {
+		gin.SetMode(gin.TestMode)
+		logger := utils.GetLogger()
+		handler := utils.NewRequestHandler(logger, utils.Env{})
+		authMiddleware, err := middlewares.NewAuthMiddleware(handler, logger, utils.Env{
+			AuthJwksURL:       "http://127.0.0.1:1/certs",
+			AuthJwksCacheTTL:  "10m",
+			SyncWebhookSecret: webhookSecret,
+		}, NewRoutePermissions())
+		assert.NoError(t, err)
+		authMiddleware.Setup()
+
+		ok := func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		}
+		handler.Gin.POST("/apis/iuf/v1/session/sync", ok)
+		handler.Gin.POST("/apis/iuf/v1/session/workflowsync", ok)
+		handler.Gin.POST("/apis/iuf/v1/activities", ok)
+		return handler
+	}
+
+	tests := []struct {
+		name           string
+		webhookSecret  string
+		path           string
+		header         string
+		expectedStatus int
+	}{
+		{"sync without a secret to check", "", "/apis/iuf/v1/session/sync", "", http.StatusOK},
+		{"sync without the secret", "s3cr3t", "/apis/iuf/v1/session/sync", "", http.StatusUnauthorized},
+		{"sync with another secret", "s3cr3t", "/apis/iuf/v1/session/sync", "other", http.StatusUnauthorized},
+		{"sync with the secret in the header", "s3cr3t", "/apis/iuf/v1/session/sync", "s3cr3t", http.StatusOK},
+		{"sync with the secret in the query", "s3cr3t", "/apis/iuf/v1/session/workflowsync?webhook_secret=s3cr3t", "", http.StatusOK},
+		{"other routes still need a token", "s3cr3t", "/apis/iuf/v1/activities?webhook_secret=s3cr3t", "s3cr3t", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newHandler(tt.webhookSecret)
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader("{}"))
+			if tt.header != "" {
+				req.Header.Set(middlewares.HEADER_WEBHOOK_SECRET, tt.header)
+			}
+			w := httptest.NewRecorder()
+			handler.Gin.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	t.Run("It does not start in production without a webhook secret", func(t *testing.T) {
+		_, err := middlewares.NewAuthMiddleware(utils.RequestHandler{}, utils.GetLogger(), utils.Env{
+			Environment:           "production",
+			AuthJwksURL:           "http://127.0.0.1:1/certs",
+			AuthJwksCacheTTL:      "10m",
+			TLSInsecureSkipVerify: true,
+		}, NewRoutePermissions())
+		assert.Error(t, err)
+	})
+}
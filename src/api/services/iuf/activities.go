@@ -37,6 +37,7 @@ import (
 	"sort"
 	"time"
 	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -67,7 +68,7 @@ func (s iufService) retryDelete(operation string, deleteFn func() error, maxRetr
     return lastErr
 }
 
-func (s iufService) CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity, error) {
+func (s iufService) CreateActivity(ctx context.Context, req iuf.CreateActivityRequest) (iuf.Activity, error) {
 	// construct activity object from create req
 	reqBytes, _ := json.Marshal(req)
 	var activity iuf.Activity
@@ -93,7 +94,7 @@ func (s iufService) CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity,
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Create(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.CreateOptions{},
 		)
@@ -103,7 +104,7 @@ func (s iufService) CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity,
 	}
 
 	// store history
-	err = s.CreateHistoryEntry(activity.Name, iuf.ActivityStateWaitForAdmin, "Activity created")
+	err = s.CreateHistoryEntry(ctx, activity.Name, iuf.ActivityStateWaitForAdmin, "Activity created")
 	if err != nil {
 		return iuf.Activity{}, err
 	}
@@ -111,7 +112,7 @@ func (s iufService) CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity,
 	return activity, nil
 }
 
-func (s iufService) CreateHistoryEntry(activityName string, activityState iuf.ActivityState, comment string) error {
+func (s iufService) CreateHistoryEntry(ctx context.Context, activityName string, activityState iuf.ActivityState, comment string) error {
 	name := utils.GenerateName(activityName)
 	iufHistory := iuf.History{
 		ActivityState: activityState,
@@ -129,7 +130,7 @@ func (s iufService) CreateHistoryEntry(activityName string, activityState iuf.Ac
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Create(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.CreateOptions{},
 		)
@@ -138,15 +139,38 @@ func (s iufService) CreateHistoryEntry(activityName string, activityState iuf.Ac
 		return err
 	}
 
+	s.publishEvent(iuf.Event{
+		Type:          iuf.EEventTypeHistory,
+		ActivityName:  activityName,
+		ActivityState: activityState,
+		Comment:       comment,
+	})
 	return nil
 }
 
-func (s iufService) GetActivity(name string) (iuf.Activity, error) {
+// notifyActivityState lets the configured webhooks know that the state of the activity changed
+func (s iufService) notifyActivityState(activityName string, sessionName string, previousState iuf.ActivityState, state iuf.ActivityState, sessionState iuf.SessionState, comment string) {
+	if s.notifier == nil || previousState == state {
+		return
+	}
+	s.notifier.Notify(services_shared.Notification{
+		Source:        services_shared.NOTIFICATION_SOURCE_IUF,
+		Type:          services_shared.NOTIFICATION_TYPE_ACTIVITY_STATE,
+		Name:          activityName,
+		Session:       sessionName,
+		PreviousState: string(previousState),
+		State:         string(state),
+		SessionState:  string(sessionState),
+		Message:       comment,
+	})
+}
+
+func (s iufService) GetActivity(ctx context.Context, name string) (iuf.Activity, error) {
 	rawConfigMapData, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Get(
-			context.TODO(),
+			ctx,
 			name,
 			v1.GetOptions{},
 		)
@@ -162,7 +186,7 @@ func (s iufService) GetActivity(name string) (iuf.Activity, error) {
 	return res, err
 }
 
-func (s iufService) DeleteActivity(activityName string) (bool, error) {
+func (s iufService) DeleteActivity(ctx context.Context, activityName string) (bool, error) {
 	// Delete all metadata for the activity: workflows, sessions configmaps, history configmaps and activity configmap
     s.logger.Infof("DeleteActivity: Deleting activity %s", activityName)
     
@@ -178,7 +202,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
         },
     }
     
-    workflowList, err := s.workflowClient.ListWorkflows(context.TODO(), workflowListReq)
+    workflowList, err := s.workflowClient.ListWorkflows(ctx, workflowListReq)
     if err != nil {
         s.logger.Errorf("DeleteActivity: error listing workflows for activity %s: %v", activityName, err)
 		return false, err
@@ -188,7 +212,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
         
         for _, wf := range workflowList.Items {
             err := s.retryDelete(fmt.Sprintf("Delete workflow %s", wf.Name), func() error {
-                _, err := s.workflowClient.DeleteWorkflow(context.TODO(), &workflow.WorkflowDeleteRequest{
+                _, err := s.workflowClient.DeleteWorkflow(ctx, &workflow.WorkflowDeleteRequest{
                     Name:      wf.Name,
                     Namespace: DEFAULT_NAMESPACE,
                 })
@@ -210,7 +234,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
         CoreV1().
         ConfigMaps(DEFAULT_NAMESPACE).
         List(
-            context.TODO(),
+            ctx,
             v1.ListOptions{
                 LabelSelector: fmt.Sprintf("type=%s,%s=%s", LABEL_SESSION, LABEL_ACTIVITY_REF, activityName),
             },
@@ -229,7 +253,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
                 CoreV1().
                 ConfigMaps(DEFAULT_NAMESPACE).
                 Delete(
-                    context.TODO(),
+                    ctx,
                     session.Name,
                     v1.DeleteOptions{},
                 )
@@ -249,7 +273,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
         CoreV1().
         ConfigMaps(DEFAULT_NAMESPACE).
         List(
-            context.TODO(),
+            ctx,
             v1.ListOptions{
                 LabelSelector: fmt.Sprintf("type=%s,%s=%s", LABEL_HISTORY, LABEL_ACTIVITY_REF, activityName),
             },
@@ -268,7 +292,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
                 CoreV1().
                 ConfigMaps(DEFAULT_NAMESPACE).
                 Delete(
-                    context.TODO(),
+                    ctx,
                     history.Name,
                     v1.DeleteOptions{},
                 )
@@ -287,7 +311,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
             CoreV1().
             ConfigMaps(DEFAULT_NAMESPACE).
             Delete(
-                context.TODO(),
+                ctx,
                 activityName,
                 v1.DeleteOptions{},
             )
@@ -303,7 +327,7 @@ func (s iufService) DeleteActivity(activityName string) (bool, error) {
     return true, nil
 }
 
-func (s iufService) PatchActivity(activity iuf.Activity, patchParams iuf.PatchActivityRequest) (iuf.Activity, error) {
+func (s iufService) PatchActivity(ctx context.Context, activity iuf.Activity, patchParams iuf.PatchActivityRequest) (iuf.Activity, error) {
 	s.logger.Infof("Called: PatchActivity(activity: %v, patchParams: %v)", activity, patchParams)
 
 	if patchParams.InputParameters.MediaDir != nil {
@@ -342,9 +366,21 @@ func (s iufService) PatchActivity(activity iuf.Activity, patchParams iuf.PatchAc
 	if patchParams.InputParameters.Stages != nil {
 		activity.InputParameters.Stages = *(patchParams.InputParameters.Stages)
 	}
+	if patchParams.InputParameters.Operations != nil {
+		activity.InputParameters.Operations = *(patchParams.InputParameters.Operations)
+	}
+	if patchParams.InputParameters.Products != nil {
+		activity.InputParameters.Products = *(patchParams.InputParameters.Products)
+	}
 	if patchParams.InputParameters.Force != nil {
 		activity.InputParameters.Force = *(patchParams.InputParameters.Force)
 	}
+	if patchParams.InputParameters.BlockOnInvalidProducts != nil {
+		activity.InputParameters.BlockOnInvalidProducts = *(patchParams.InputParameters.BlockOnInvalidProducts)
+	}
+	if patchParams.InputParameters.OperationPolicies != nil {
+		activity.InputParameters.OperationPolicies = *(patchParams.InputParameters.OperationPolicies)
+	}
 
 	// Add new parameters
 	if patchParams.InputParameters.CfsConfigurationManagement != nil {
@@ -395,27 +431,27 @@ func (s iufService) PatchActivity(activity iuf.Activity, patchParams iuf.PatchAc
 	// when you update site or input parameters of an activity, you also have to update all the Sessions that have not
 	// already completed. This is so that the next time a workflow for a stage is created, that workflow can pick up
 	// the input and site parameters from the session
-	sessions, _ := s.ListSessions(activity.Name)
+	sessions, _ := s.ListSessions(ctx, activity.Name)
 	for _, session := range sessions {
 		if session.CurrentState != iuf.SessionStateCompleted {
 			session.InputParameters = activity.InputParameters
 			session.SiteParameters = activity.SiteParameters
-			err := s.UpdateSession(session)
+			err := s.UpdateSession(ctx, session)
 			if err != nil {
 				return iuf.Activity{}, err
 			}
 		}
 	}
 
-	return s.updateActivity(activity)
+	return s.updateActivity(ctx, activity)
 }
 
-func (s iufService) ListActivities() ([]iuf.Activity, error) {
+func (s iufService) ListActivities(ctx context.Context) ([]iuf.Activity, error) {
 	rawConfigMapList, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		List(
-			context.TODO(),
+			ctx,
 			v1.ListOptions{
 				LabelSelector: fmt.Sprintf("type=%s", LABEL_ACTIVITY),
 			},
@@ -451,7 +487,7 @@ func (s iufService) configMapDataToActivity(data string) (iuf.Activity, error) {
 	return res, err
 }
 
-func (s iufService) updateActivity(activity iuf.Activity) (iuf.Activity, error) {
+func (s iufService) updateActivity(ctx context.Context, activity iuf.Activity) (iuf.Activity, error) {
 	configmap, err := s.iufObjectToConfigMapData(activity, activity.Name, LABEL_ACTIVITY)
 	if err != nil {
 		return iuf.Activity{}, err
@@ -461,7 +497,7 @@ func (s iufService) updateActivity(activity iuf.Activity) (iuf.Activity, error)
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Update(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.UpdateOptions{},
 		)
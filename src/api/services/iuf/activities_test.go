@@ -64,7 +64,7 @@ func TestCreateActivity(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			_, err := mySvc.CreateActivity(tt.req)
+			_, err := mySvc.CreateActivity(context.TODO(), tt.req)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("got %v, wantErr %v", err, tt.wantErr)
 				return
@@ -195,9 +195,10 @@ func TestPatchActivity(t *testing.T) {
 					BootprepConfigManagement: "BootprepConfigManagement",
 					BootprepConfigManaged:    "BootprepConfigManaged",
 					Force:                    true,
+					BlockOnInvalidProducts:   true,
 				},
 			},
-			req:     toPatchRequest(`{"input_parameters": {"limit_management_nodes": ["Management_Worker"], "force": true, "bootprep_config_managed": "BootprepConfigManaged"}}`),
+			req:     toPatchRequest(`{"input_parameters": {"limit_management_nodes": ["Management_Worker"], "force": true, "block_on_invalid_products": true, "bootprep_config_managed": "BootprepConfigManaged"}}`),
 			wantErr: false,
 		},
 		{
@@ -313,7 +314,7 @@ func TestPatchActivity(t *testing.T) {
 			test.expectActivity.Name = activityName
 		}
 
-		_, err := mySvc.CreateActivity(iuf.CreateActivityRequest{
+		_, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{
 			Name: activityName,
 		})
 		if err != nil {
@@ -321,7 +322,7 @@ func TestPatchActivity(t *testing.T) {
 			return false
 		}
 
-		patchedActivity, err := mySvc.PatchActivity(startActivity, test.req)
+		patchedActivity, err := mySvc.PatchActivity(context.TODO(), startActivity, test.req)
 		if (err != nil) != test.wantErr {
 			t.Errorf("got %v, wantErr %v", err, test.wantErr)
 			return false
@@ -372,7 +373,7 @@ func TestDeleteActivity(t *testing.T) {
             name: "Successfully delete existing activity",
             setup: func() string {
                 activityName := "test-delete-activity"
-                _, err := mySvc.CreateActivity(iuf.CreateActivityRequest{Name: activityName})
+                _, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{Name: activityName})
                 if err != nil {
                     t.Fatalf("Setup failed: %v", err)
                 }
@@ -391,7 +392,7 @@ func TestDeleteActivity(t *testing.T) {
             name: "Delete activity with history entries",
             setup: func() string {
                 activityName := "test-with-history"
-                _, err := mySvc.CreateActivity(iuf.CreateActivityRequest{Name: activityName})
+                _, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{Name: activityName})
                 if err != nil {
                     t.Fatalf("Setup failed: %v", err)
                 }
@@ -404,7 +405,7 @@ func TestDeleteActivity(t *testing.T) {
             name: "Delete activity with sessions",
             setup: func() string {
                 activityName := "test-with-sessions"
-                activity, err := mySvc.CreateActivity(iuf.CreateActivityRequest{Name: activityName})
+                activity, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{Name: activityName})
                 if err != nil {
                     t.Fatalf("Setup failed: %v", err)
                 }
@@ -413,7 +414,7 @@ func TestDeleteActivity(t *testing.T) {
                     Name:            utils.GenerateName(activityName),
                     InputParameters: iuf.InputParameters{},
                 }
-                _, err = mySvc.CreateSession(session, "Test session", activity)
+                _, err = mySvc.CreateSession(context.TODO(), session, "Test session", activity)
                 if err != nil {
                     t.Fatalf("Setup failed creating session: %v", err)
                 }
@@ -425,13 +426,13 @@ func TestDeleteActivity(t *testing.T) {
             name: "Delete activity with multiple history and sessions",
             setup: func() string {
                 activityName := "test-multiple-resources"
-                activity, err := mySvc.CreateActivity(iuf.CreateActivityRequest{Name: activityName})
+                activity, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{Name: activityName})
                 if err != nil {
                     t.Fatalf("Setup failed: %v", err)
                 }
                 // Create multiple history entries
                 for i := 0; i < 3; i++ {
-                    err = mySvc.CreateHistoryEntry(activityName, iuf.ActivityStateWaitForAdmin, fmt.Sprintf("Test history %d", i))
+                    err = mySvc.CreateHistoryEntry(context.TODO(), activityName, iuf.ActivityStateWaitForAdmin, fmt.Sprintf("Test history %d", i))
                     if err != nil {
                         t.Fatalf("Setup failed creating history: %v", err)
                     }
@@ -442,7 +443,7 @@ func TestDeleteActivity(t *testing.T) {
                         Name:            utils.GenerateName(fmt.Sprintf("%s-session-%d", activityName, i)),
                         InputParameters: iuf.InputParameters{},
                     }
-                    _, err = mySvc.CreateSession(session, fmt.Sprintf("Test session %d", i), activity)
+                    _, err = mySvc.CreateSession(context.TODO(), session, fmt.Sprintf("Test session %d", i), activity)
                     if err != nil {
                         t.Fatalf("Setup failed creating session: %v", err)
                     }
@@ -457,7 +458,7 @@ func TestDeleteActivity(t *testing.T) {
         t.Run(tt.name, func(t *testing.T) {
             activityName := tt.setup()
             
-            success, err := mySvc.DeleteActivity(activityName)
+            success, err := mySvc.DeleteActivity(context.TODO(), activityName)
             if (err != nil) != tt.wantErr {
                 t.Errorf("DeleteActivity() error = %v, wantErr %v", err, tt.wantErr)
                 return
@@ -470,7 +471,7 @@ func TestDeleteActivity(t *testing.T) {
             
             // Verify activity was deleted
             if !tt.wantErr {
-                _, err := mySvc.GetActivity(activityName)
+                _, err := mySvc.GetActivity(context.TODO(), activityName)
                 if err == nil {
                     t.Errorf("Activity %s still exists after deletion", activityName)
                 }
@@ -526,7 +527,7 @@ func TestDeleteActivityRetryLogic(t *testing.T) {
     t.Run("Retry on not found should succeed", func(t *testing.T) {
         // Try to delete an activity that doesn't exist
         // Should succeed with warning, not error
-        success, err := mySvc.DeleteActivity("non-existent")
+        success, err := mySvc.DeleteActivity(context.TODO(), "non-existent")
         if err != nil {
             t.Errorf("DeleteActivity() should not error on not found, got: %v", err)
         }
@@ -555,14 +556,14 @@ func TestDeleteActivityVerifyResourceCleanup(t *testing.T) {
     activityName := "test-cleanup-verification"
     
     // Create activity with all associated resources
-    activity, err := mySvc.CreateActivity(iuf.CreateActivityRequest{Name: activityName})
+    activity, err := mySvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{Name: activityName})
     if err != nil {
         t.Fatalf("Setup failed: %v", err)
     }
     
     // Add extra history
     for i := 0; i < 3; i++ {
-        err = mySvc.CreateHistoryEntry(activityName, iuf.ActivityStateInProgress, fmt.Sprintf("Entry %d", i))
+        err = mySvc.CreateHistoryEntry(context.TODO(), activityName, iuf.ActivityStateInProgress, fmt.Sprintf("Entry %d", i))
         if err != nil {
             t.Fatalf("Setup failed: %v", err)
         }
@@ -574,7 +575,7 @@ func TestDeleteActivityVerifyResourceCleanup(t *testing.T) {
             Name:            utils.GenerateName(fmt.Sprintf("%s-session-%d", activityName, i)),
             InputParameters: iuf.InputParameters{},
         }
-        _, err = mySvc.CreateSession(session, fmt.Sprintf("Test session %d", i), activity)
+        _, err = mySvc.CreateSession(context.TODO(), session, fmt.Sprintf("Test session %d", i), activity)
         if err != nil {
             t.Fatalf("Setup failed: %v", err)
         }
@@ -610,7 +611,7 @@ func TestDeleteActivityVerifyResourceCleanup(t *testing.T) {
     }
     
     // Delete activity
-    success, err := mySvc.DeleteActivity(activityName)
+    success, err := mySvc.DeleteActivity(context.TODO(), activityName)
     if err != nil {
         t.Fatalf("DeleteActivity failed: %v", err)
     }
@@ -648,7 +649,7 @@ func TestDeleteActivityVerifyResourceCleanup(t *testing.T) {
     }
     
     // Verify activity itself is gone
-    _, err = mySvc.GetActivity(activityName)
+    _, err = mySvc.GetActivity(context.TODO(), activityName)
     if err == nil {
         t.Errorf("Activity should not exist after deletion")
     }
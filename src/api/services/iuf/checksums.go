@@ -0,0 +1,92 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"sync"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+)
+
+// NO_CHECKSUMS_FILE_WARNING is recorded on a product whose distribution does not have a checksums file
+const NO_CHECKSUMS_FILE_WARNING = "no checksums file (" + iuf.SHA256_CHECKSUMS_FILE + " or " + iuf.MD5_CHECKSUMS_FILE + "), so the files of the product are not verified"
+
+// checksumVerifier verifies the checksums of product distributions in the background, because hashing every file of a
+//  product takes much longer than a sync of the session may.
+type checksumVerifier struct {
+	mu sync.Mutex
+	// by the location of the product distribution
+	verifications map[string]*checksumVerification
+}
+
+type checksumVerification struct {
+	workflow string        // the process-media workflow that extracted the product
+	done     chan struct{} // closed once result and err are set
+	result   *iuf.ChecksumResult
+	err      error
+}
+
+func newChecksumVerifier() *checksumVerifier {
+	return &checksumVerifier{verifications: map[string]*checksumVerification{}}
+}
+
+// verify returns the verification of the checksums of the product distribution at location, that process-media
+//  workflow extracted. It is started the first time, and done later. A new run of process-media verifies the product
+//  again, since its files may have been fixed.
+func (v *checksumVerifier) verify(workflowName string, location string) *checksumVerification {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	verification, ok := v.verifications[location]
+	if !ok || verification.workflow != workflowName {
+		verification = &checksumVerification{workflow: workflowName, done: make(chan struct{})}
+		v.verifications[location] = verification
+		go func() {
+			defer close(verification.done)
+			verification.result, verification.err = iuf.VerifyChecksums(location)
+		}()
+	}
+	return verification
+}
+
+func (c *checksumVerification) finished() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// HasPendingChecksums tells whether the checksums of one of the products are still being verified, in which case the
+//  session should not move on from process-media yet
+func HasPendingChecksums(products []iuf.Product) bool {
+	for _, product := range products {
+		if product.Checksums != nil && product.Checksums.Pending {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,385 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// how many events a subscriber may fall behind before events get dropped for it
+	EVENTS_SUBSCRIBER_BUFFER_SIZE = 100
+	// how many events may wait to be published before events get dropped
+	EVENTS_PUBLISH_QUEUE_SIZE = 1000
+	// how long to wait before watching the Kubernetes events again after the watch failed
+	EVENTS_WATCH_RETRY_INTERVAL = 5 * time.Second
+	// how long the API server keeps a watch of the Kubernetes events open before it ends it, to be watched again
+	EVENTS_WATCH_TIMEOUT_SECONDS int64 = 300
+	// the value of the type label and the name of the annotation that hold the IUF event in a Kubernetes event
+	IUF_EVENT = "iuf_event"
+)
+
+// eventBroker publishes the events of activities as Kubernetes events on the ConfigMap of the activity, and fans them
+//  out to the subscribers of each activity from a watch on those Kubernetes events. Every instance of this service
+//  watches the same Kubernetes events, so a subscriber gets the events of an activity whichever instance caused them.
+//  Kubernetes expires the events after the event TTL of the API server, 1h by default.
+type eventBroker struct {
+	ctx         context.Context
+	logger      utils.Logger
+	client      kubernetes.Interface
+	watchClient kubernetes.Interface
+	queue       chan iuf.Event
+	mutex       sync.Mutex
+	subscribers map[string]map[chan iuf.Event]bool
+	// the resource version of the last Kubernetes event watched, to watch again from where the last watch ended
+	resourceVersion string
+}
+
+// newEventBroker creates an event broker that publishes through the given Kubernetes client and watches through the
+//  given watch client, which must not have a request timeout, until the context is done. Without a client, events only
+//  reach the subscribers of this instance.
+func newEventBroker(ctx context.Context, logger utils.Logger, client kubernetes.Interface, watchClient kubernetes.Interface) *eventBroker {
+	broker := &eventBroker{
+		ctx:         ctx,
+		logger:      logger,
+		client:      client,
+		watchClient: watchClient,
+		subscribers: map[string]map[chan iuf.Event]bool{},
+	}
+	if client != nil {
+		broker.queue = make(chan iuf.Event, EVENTS_PUBLISH_QUEUE_SIZE)
+		// watch before returning so that no event published after this is missed, unless Kubernetes cannot be reached yet
+		watcher, err := broker.watch()
+		if err != nil {
+			logger.Errorf("newEventBroker: IUF events cannot be watched yet: %v", err)
+		}
+		go broker.dispatchWatchedEvents(watcher)
+		go broker.publishQueuedEvents()
+	}
+	return broker
+}
+
+// SubscribeToActivityEvents returns a channel of the events of the given activity, and a function to call to stop
+//  receiving them, which closes the channel.
+func (s iufService) SubscribeToActivityEvents(activityName string) (<-chan iuf.Event, func()) {
+	events := make(chan iuf.Event, EVENTS_SUBSCRIBER_BUFFER_SIZE)
+	if s.events == nil {
+		close(events)
+		return events, func() {}
+	}
+
+	s.events.mutex.Lock()
+	if s.events.subscribers[activityName] == nil {
+		s.events.subscribers[activityName] = map[chan iuf.Event]bool{}
+	}
+	s.events.subscribers[activityName][events] = true
+	s.events.mutex.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			s.events.mutex.Lock()
+			defer s.events.mutex.Unlock()
+			delete(s.events.subscribers[activityName], events)
+			if len(s.events.subscribers[activityName]) == 0 {
+				delete(s.events.subscribers, activityName)
+			}
+			close(events)
+		})
+	}
+	return events, unsubscribe
+}
+
+// publishEvent queues the event to be published to the subscribers of its activity on every instance. Events are
+//  dropped rather than holding up the sync of the session when they cannot be published fast enough.
+func (s iufService) publishEvent(event iuf.Event) {
+	if s.events == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	if s.events.queue == nil {
+		s.events.dispatch(event)
+		return
+	}
+	select {
+	case s.events.queue <- event:
+	default:
+		s.logger.Warnf("publishEvent: dropping %s event for activity %s because too many events are waiting to be published", event.Type, event.ActivityName)
+	}
+}
+
+// publishQueuedEvents creates a Kubernetes event for each queued event, one after the other so that they keep their order.
+func (b *eventBroker) publishQueuedEvents() {
+	for {
+		var event iuf.Event
+		select {
+		case <-b.ctx.Done():
+			return
+		case event = <-b.queue:
+		}
+		data, err := json.Marshal(event)
+		if err != nil {
+			b.logger.Errorf("publishQueuedEvents.1: dropping %s event for activity %s, it cannot be converted to json: %v", event.Type, event.ActivityName, err)
+			continue
+		}
+		k8sEvent := core_v1.Event{
+			ObjectMeta: v1.ObjectMeta{
+				Name: utils.GenerateName("iuf-event-" + event.ActivityName),
+				Labels: map[string]string{
+					"type":             IUF_EVENT,
+					LABEL_ACTIVITY_REF: event.ActivityName,
+				},
+				Annotations: map[string]string{IUF_EVENT: string(data)},
+			},
+			InvolvedObject: core_v1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+				Namespace:  DEFAULT_NAMESPACE,
+				Name:       event.ActivityName,
+			},
+			Reason:         string(event.Type),
+			Message:        fmt.Sprintf("IUF %s event of activity %s", event.Type, event.ActivityName),
+			Type:           core_v1.EventTypeNormal,
+			Source:         core_v1.EventSource{Component: "cray-nls"},
+			FirstTimestamp: v1.NewTime(event.Time),
+			LastTimestamp:  v1.NewTime(event.Time),
+			Count:          1,
+		}
+		ctx, cancel := context.WithTimeout(b.ctx, 10*time.Second)
+		_, err = b.client.CoreV1().Events(DEFAULT_NAMESPACE).Create(ctx, &k8sEvent, v1.CreateOptions{})
+		cancel()
+		if err != nil {
+			b.logger.Errorf("publishQueuedEvents.2: dropping %s event for activity %s, it cannot be published: %v", event.Type, event.ActivityName, err)
+		}
+	}
+}
+
+// watch starts watching the IUF events from the last one watched, or from now on when none was watched yet.
+func (b *eventBroker) watch() (watch.Interface, error) {
+	selector := fmt.Sprintf("type=%s", IUF_EVENT)
+	if b.resourceVersion == "" {
+		// only list a single event, to find the resource version of now and not get the events that happened before it.
+		list, err := b.client.CoreV1().Events(DEFAULT_NAMESPACE).List(b.ctx, v1.ListOptions{LabelSelector: selector, Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		b.resourceVersion = list.ResourceVersion
+	}
+	timeoutSeconds := EVENTS_WATCH_TIMEOUT_SECONDS
+	return b.watchClient.CoreV1().Events(DEFAULT_NAMESPACE).Watch(b.ctx, v1.ListOptions{
+		LabelSelector:   selector,
+		ResourceVersion: b.resourceVersion,
+		TimeoutSeconds:  &timeoutSeconds,
+	})
+}
+
+// dispatchWatchedEvents sends the IUF events of the watch to the subscribers of their activity, and watches again
+//  from the last event it got whenever the watch ends, until the context of the broker is done. Events are only missed
+//  when Kubernetes no longer has the events after the last one, in which case it watches from now on.
+func (b *eventBroker) dispatchWatchedEvents(watcher watch.Interface) {
+	for {
+		if watcher == nil {
+			var err error
+			watcher, err = b.watch()
+			if err != nil {
+				if b.ctx.Err() != nil {
+					return
+				}
+				b.logger.Errorf("dispatchWatchedEvents.1: IUF events cannot be watched, retrying in %s: %v", EVENTS_WATCH_RETRY_INTERVAL, err)
+				select {
+				case <-b.ctx.Done():
+					return
+				case <-time.After(EVENTS_WATCH_RETRY_INTERVAL):
+				}
+				continue
+			}
+		}
+		b.dispatchUntilStopped(watcher)
+		watcher.Stop()
+		watcher = nil
+		if b.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// dispatchUntilStopped sends the IUF events of the watch to the subscribers of their activity until the watch ends or
+//  the context of the broker is done.
+func (b *eventBroker) dispatchUntilStopped(watcher watch.Interface) {
+	for {
+		var result watch.Event
+		var open bool
+		select {
+		case <-b.ctx.Done():
+			return
+		case result, open = <-watcher.ResultChan():
+			if !open {
+				return
+			}
+		}
+		if result.Type == watch.Error {
+			status := k8s_errors.FromObject(result.Object)
+			if k8s_errors.IsResourceExpired(status) || k8s_errors.IsGone(status) {
+				b.logger.Warnf("dispatchWatchedEvents.2: IUF events after resource version %s are no longer available, some events were missed", b.resourceVersion)
+				b.resourceVersion = ""
+			}
+			return
+		}
+		k8sEvent, ok := result.Object.(*core_v1.Event)
+		if !ok {
+			continue
+		}
+		b.resourceVersion = k8sEvent.ResourceVersion
+		if result.Type != watch.Added || k8sEvent.Labels["type"] != IUF_EVENT {
+			continue
+		}
+		var event iuf.Event
+		err := json.Unmarshal([]byte(k8sEvent.Annotations[IUF_EVENT]), &event)
+		if err != nil {
+			b.logger.Errorf("dispatchWatchedEvents.3: ignoring Kubernetes event %s, it does not hold an IUF event: %v", k8sEvent.Name, err)
+			continue
+		}
+		b.dispatch(event)
+	}
+}
+
+// dispatch sends the event to the subscribers of its activity on this instance. A subscriber that does not keep up
+//  misses events.
+func (b *eventBroker) dispatch(event iuf.Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for subscriber := range b.subscribers[event.ActivityName] {
+		select {
+		case subscriber <- event:
+		default:
+			b.logger.Warnf("dispatch: dropping %s event for activity %s because a subscriber is not keeping up", event.Type, event.ActivityName)
+		}
+	}
+}
+
+func (s iufService) publishEvents(events []iuf.Event) {
+	for _, event := range events {
+		s.publishEvent(event)
+	}
+}
+
+// newOutputEvent creates the event for the output of a step of an operation that was saved in the activity.
+func (s iufService) newOutputEvent(session *iuf.Session, workflow *v1alpha1.Workflow, nodeStatus *v1alpha1.NodeStatus, operationName string, stepName string, productKey string) iuf.Event {
+	output := map[string]string{}
+	for _, param := range nodeStatus.Outputs.Parameters {
+		if param.Value != nil && param.Value.String() != "skipped" {
+			output[param.Name] = param.Value.String()
+		}
+	}
+	return iuf.Event{
+		Type:         iuf.EEventTypeOutput,
+		ActivityName: session.ActivityRef,
+		SessionName:  session.Name,
+		Stage:        session.CurrentStage,
+		Workflow:     workflow.Name,
+		Operation:    operationName,
+		Product:      productKey,
+		Step:         stepName,
+		Output:       output,
+	}
+}
+
+// publishOperationEvents publishes whether each operation in the given finished workflow succeeded or failed.
+func (s iufService) publishOperationEvents(session *iuf.Session, workflow *v1alpha1.Workflow) {
+	if s.events == nil {
+		return
+	}
+
+	templates := map[string]v1alpha1.Template{}
+	for _, template := range workflow.Spec.Templates {
+		templates[template.Name] = template
+	}
+	mainTemplate, exists := templates[workflow.Spec.Entrypoint]
+	if !exists || mainTemplate.DAG == nil {
+		return
+	}
+
+	for _, task := range mainTemplate.DAG.Tasks {
+		// operations with a timeout or a retry strategy are referenced from a template of their own, see setOperationTemplate
+		templateRef := task.TemplateRef
+		if templateRef == nil {
+			if template, exists := templates[task.Template]; exists && len(template.Steps) > 0 && len(template.Steps[0].Steps) > 0 {
+				templateRef = template.Steps[0].Steps[0].TemplateRef
+			}
+		}
+		if templateRef == nil || templateRef.Name == "echo-template" {
+			continue
+		}
+
+		// the retried steps of an operation have the same display name as the task, so look for the task by its full name
+		node := workflow.Status.Nodes.Find(func(node v1alpha1.NodeStatus) bool {
+			return node.Name == workflow.Name+"."+task.Name
+		})
+		if node == nil || !node.Fulfilled() {
+			continue
+		}
+
+		// tasks of product stages get the global params of their product, which are named after its product key
+		var productKey string
+		for _, param := range task.Arguments.Parameters {
+			if param.Name == "global_params" && param.Value != nil {
+				productKey = strings.TrimSuffix(strings.TrimPrefix(param.Value.String(), "{{workflow.parameters."), "}}")
+			}
+		}
+		if productKey == "global_params" {
+			productKey = ""
+		}
+
+		s.publishEvent(iuf.Event{
+			Type:         iuf.EEventTypeOperation,
+			ActivityName: session.ActivityRef,
+			SessionName:  session.Name,
+			Stage:        workflow.Labels["stage"],
+			Workflow:     workflow.Name,
+			Operation:    templateRef.Name,
+			Product:      productKey,
+			Phase:        string(node.Phase),
+			Comment:      node.Message,
+		})
+	}
+}
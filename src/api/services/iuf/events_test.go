@@ -0,0 +1,194 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	core_v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	fake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestActivityEvents(t *testing.T) {
+	t.Run("It should only send events of the subscribed activity", func(t *testing.T) {
+		mySvc := iufService{logger: utils.GetLogger(), events: newEventBroker(context.TODO(), utils.GetLogger(), nil, nil)}
+		events, unsubscribe := mySvc.SubscribeToActivityEvents("activity-a")
+		mySvc.publishEvent(iuf.Event{Type: iuf.EEventTypeSession, ActivityName: "activity-b"})
+		mySvc.publishEvent(iuf.Event{Type: iuf.EEventTypeSession, ActivityName: "activity-a", SessionState: iuf.SessionStateInProgress})
+		unsubscribe()
+		unsubscribe()
+
+		var received []iuf.Event
+		for event := range events {
+			received = append(received, event)
+		}
+		assert.Equal(t, 1, len(received))
+		assert.Equal(t, iuf.SessionStateInProgress, received[0].SessionState)
+		assert.False(t, received[0].Time.IsZero())
+		assert.Equal(t, 0, len(mySvc.events.subscribers))
+	})
+
+	t.Run("It should drop events for subscribers that do not keep up", func(t *testing.T) {
+		mySvc := iufService{logger: utils.GetLogger(), events: newEventBroker(context.TODO(), utils.GetLogger(), nil, nil)}
+		events, unsubscribe := mySvc.SubscribeToActivityEvents("activity-a")
+		for i := 0; i < EVENTS_SUBSCRIBER_BUFFER_SIZE+10; i++ {
+			mySvc.publishEvent(iuf.Event{Type: iuf.EEventTypeHistory, ActivityName: "activity-a"})
+		}
+		unsubscribe()
+		count := 0
+		for range events {
+			count++
+		}
+		assert.Equal(t, EVENTS_SUBSCRIBER_BUFFER_SIZE, count)
+	})
+
+	t.Run("It should send events published by another instance", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		client := fake.NewSimpleClientset()
+		publisher := iufService{logger: utils.GetLogger(), events: newEventBroker(ctx, utils.GetLogger(), client, client)}
+		subscriber := iufService{logger: utils.GetLogger(), events: newEventBroker(ctx, utils.GetLogger(), client, client)}
+		events, unsubscribe := subscriber.SubscribeToActivityEvents("activity-a")
+		defer unsubscribe()
+
+		publisher.publishEvent(iuf.Event{Type: iuf.EEventTypeSession, ActivityName: "activity-b"})
+		publisher.publishEvent(iuf.Event{Type: iuf.EEventTypeSession, ActivityName: "activity-a", SessionState: iuf.SessionStateInProgress})
+		select {
+		case event := <-events:
+			assert.Equal(t, "activity-a", event.ActivityName)
+			assert.Equal(t, iuf.SessionStateInProgress, event.SessionState)
+			assert.False(t, event.Time.IsZero())
+		case <-time.After(5 * time.Second):
+			t.Fatal("the event was not received")
+		}
+
+		k8sEvents, err := client.CoreV1().Events(DEFAULT_NAMESPACE).List(context.TODO(), v1.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(k8sEvents.Items))
+		for _, k8sEvent := range k8sEvents.Items {
+			assert.Equal(t, k8sEvent.Labels[LABEL_ACTIVITY_REF], k8sEvent.InvolvedObject.Name)
+		}
+	})
+
+	t.Run("It should watch again from the last event it got until it is stopped", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		client := fake.NewSimpleClientset()
+		watchers := make(chan *watch.FakeWatcher, 10)
+		resourceVersions := make(chan string, 10)
+		client.PrependWatchReactor("events", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			watcher := watch.NewFake()
+			resourceVersions <- action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion
+			watchers <- watcher
+			return true, watcher, nil
+		})
+		newEventBroker(ctx, utils.GetLogger(), client, client)
+		assert.Equal(t, "", <-resourceVersions)
+		watcher := <-watchers
+		watcher.Add(&core_v1.Event{ObjectMeta: v1.ObjectMeta{Name: "iuf-event-activity-a", ResourceVersion: "42"}})
+		watcher.Stop()
+
+		select {
+		case resourceVersion := <-resourceVersions:
+			assert.Equal(t, "42", resourceVersion)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the events were not watched again")
+		}
+		watcher = <-watchers
+		cancel()
+		for deadline := time.Now().Add(5 * time.Second); !watcher.IsStopped(); time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatal("the watch was not stopped")
+			}
+		}
+		assert.Equal(t, 0, len(resourceVersions))
+	})
+
+	t.Run("It should send an event when a history entry is created", func(t *testing.T) {
+		mySvc := iufService{logger: utils.GetLogger(), k8sRestClientSet: fake.NewSimpleClientset(), events: newEventBroker(context.TODO(), utils.GetLogger(), nil, nil)}
+		events, unsubscribe := mySvc.SubscribeToActivityEvents("activity-a")
+		defer unsubscribe()
+		err := mySvc.CreateHistoryEntry(context.TODO(), "activity-a", iuf.ActivityStateInProgress, "Running deliver-product")
+		assert.NoError(t, err)
+		event := <-events
+		assert.Equal(t, iuf.EEventTypeHistory, event.Type)
+		assert.Equal(t, iuf.ActivityStateInProgress, event.ActivityState)
+		assert.Equal(t, "Running deliver-product", event.Comment)
+	})
+
+	t.Run("It should send the result of each operation of a finished workflow", func(t *testing.T) {
+		mySvc := iufService{logger: utils.GetLogger(), events: newEventBroker(context.TODO(), utils.GetLogger(), nil, nil)}
+		session := iuf.Session{Name: "session-a", ActivityRef: "activity-a"}
+		workflow := v1alpha1.Workflow{ObjectMeta: v1.ObjectMeta{Name: "wf", Labels: map[string]string{"stage": "deliver-product"}}}
+		workflow.Spec.Entrypoint = "main"
+		tasks := []v1alpha1.DAGTask{
+			{
+				Name:        "cos-2-3-s3-upload-abcde",
+				TemplateRef: &v1alpha1.TemplateRef{Name: "s3-upload", Template: "main"},
+				Arguments:   v1alpha1.Arguments{Parameters: []v1alpha1.Parameter{{Name: "global_params", Value: v1alpha1.AnyStringPtr("{{workflow.parameters.cos-2-3}}")}}},
+			},
+			{
+				Name:        "cos-2-3-nexus-docker-upload-abcde",
+				TemplateRef: &v1alpha1.TemplateRef{Name: "nexus-docker-upload", Template: "main"},
+				Arguments:   v1alpha1.Arguments{Parameters: []v1alpha1.Parameter{{Name: "global_params", Value: v1alpha1.AnyStringPtr("{{workflow.parameters.cos-2-3}}")}}},
+			},
+			{Name: "cos-2-3-vcs-upload-abcde"},
+		}
+		mySvc.setOperationTemplate(&tasks[1], &v1alpha1.RetryStrategy{}, nil, &workflow)
+		mySvc.setEchoTemplate(false, &tasks[2], "skipping")
+		workflow.Spec.Templates = append([]v1alpha1.Template{{Name: "main", DAG: &v1alpha1.DAGTemplate{Tasks: tasks}}}, workflow.Spec.Templates...)
+		workflow.Status.Nodes = v1alpha1.Nodes{
+			"wf-1": {Name: "wf.cos-2-3-s3-upload-abcde", DisplayName: "cos-2-3-s3-upload-abcde", Phase: v1alpha1.NodeSucceeded},
+			"wf-2": {Name: "wf.cos-2-3-nexus-docker-upload-abcde", DisplayName: "cos-2-3-nexus-docker-upload-abcde", Phase: v1alpha1.NodeFailed, Message: "No more retries left"},
+			"wf-3": {Name: "wf.cos-2-3-nexus-docker-upload-abcde(0).cos-2-3-nexus-docker-upload-abcde", DisplayName: "cos-2-3-nexus-docker-upload-abcde", Phase: v1alpha1.NodeFailed},
+			"wf-4": {Name: "wf.cos-2-3-vcs-upload-abcde", DisplayName: "cos-2-3-vcs-upload-abcde", Phase: v1alpha1.NodeSucceeded},
+		}
+
+		events, unsubscribe := mySvc.SubscribeToActivityEvents("activity-a")
+		mySvc.publishOperationEvents(&session, &workflow)
+		unsubscribe()
+
+		received := map[string]iuf.Event{}
+		for event := range events {
+			received[event.Operation] = event
+		}
+		assert.Equal(t, 2, len(received))
+		assert.Equal(t, "Succeeded", received["s3-upload"].Phase)
+		assert.Equal(t, "cos-2-3", received["s3-upload"].Product)
+		assert.Equal(t, "deliver-product", received["s3-upload"].Stage)
+		assert.Equal(t, "Failed", received["nexus-docker-upload"].Phase)
+		assert.Equal(t, "No more retries left", received["nexus-docker-upload"].Comment)
+	})
+}
@@ -39,12 +39,12 @@ import (
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func (s iufService) ListActivityHistory(activityName string) ([]iuf.History, error) {
+func (s iufService) ListActivityHistory(ctx context.Context, activityName string) ([]iuf.History, error) {
 	rawConfigMapList, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		List(
-			context.TODO(),
+			ctx,
 			v1.ListOptions{
 				LabelSelector: fmt.Sprintf("type=%s,%s=%s", LABEL_HISTORY, LABEL_ACTIVITY_REF, activityName),
 			},
@@ -69,12 +69,12 @@ func (s iufService) ListActivityHistory(activityName string) ([]iuf.History, err
 	return res, nil
 }
 
-func (s iufService) GetActivityHistory(activityName string, startTime int32) (iuf.History, error) {
+func (s iufService) GetActivityHistory(ctx context.Context, activityName string, startTime int32) (iuf.History, error) {
 	rawConfigMapList, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		List(
-			context.TODO(),
+			ctx,
 			v1.ListOptions{
 				LabelSelector: fmt.Sprintf("type=%s,%s=%s", LABEL_HISTORY, LABEL_ACTIVITY_REF, activityName),
 			},
@@ -98,8 +98,8 @@ func (s iufService) GetActivityHistory(activityName string, startTime int32) (iu
 	return res, nil
 }
 
-func (s iufService) ReplaceHistoryComment(activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error) {
-	history, err := s.GetActivityHistory(activityName, startTime)
+func (s iufService) ReplaceHistoryComment(ctx context.Context, activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error) {
+	history, err := s.GetActivityHistory(ctx, activityName, startTime)
 	if err != nil {
 		s.logger.Error(err)
 		return iuf.History{}, err
@@ -117,7 +117,7 @@ func (s iufService) ReplaceHistoryComment(activityName string, startTime int32,
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Update(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.UpdateOptions{},
 		)
@@ -128,14 +128,36 @@ func (s iufService) ReplaceHistoryComment(activityName string, startTime int32,
 	return history, nil
 }
 
-func (s iufService) HistoryRunAction(activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error) {
-	activity, err := s.GetActivity(activityName)
+func (s iufService) HistoryRunAction(ctx context.Context, activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error) {
+	activity, err := s.GetActivity(ctx, activityName)
 	if err != nil {
 		s.logger.Errorf("HistoryRunAction.1: an error occurred while creating a new session for activity %s: %v", activityName, err)
 		return iuf.Session{}, err
 	}
 
-	sessions, err := s.ListSessions(activityName)
+	err = s.validateStagesToRun(activity, req.InputParameters.Stages)
+	if err != nil {
+		s.logger.Errorf("HistoryRunAction.6: for activity %s, invalid stages %v: %v", activityName, req.InputParameters.Stages, err)
+		return iuf.Session{}, err
+	}
+
+	err = s.validateOperationsAndProducts(activity, req.InputParameters.Stages, req.InputParameters.Operations, req.InputParameters.Products)
+	if err != nil {
+		s.logger.Errorf("HistoryRunAction.8: for activity %s, invalid operations %v or products %v: %v", activityName, req.InputParameters.Operations, req.InputParameters.Products, err)
+		return iuf.Session{}, err
+	}
+
+	if req.InputParameters.BlockOnInvalidProducts && req.InputParameters.Stages[0] != "process-media" {
+		invalidProducts := s.getInvalidProducts(&iuf.Session{InputParameters: req.InputParameters, Products: activity.Products})
+		if len(invalidProducts) > 0 {
+			err = StageValidationError{Message: fmt.Sprintf("The activity %s has products that are not validated, so the stage %s cannot run: %s. Run the stage process-media again once they are fixed, or do not set block_on_invalid_products.",
+				activityName, req.InputParameters.Stages[0], s.describeInvalidProducts(invalidProducts))}
+			s.logger.Errorf("HistoryRunAction.7: for activity %s: %v", activityName, err)
+			return iuf.Session{}, err
+		}
+	}
+
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		s.logger.Errorf("HistoryRunAction.2: an error occurred while creating a new session for activity %s: %v", activityName, err)
 		return iuf.Session{}, err
@@ -157,8 +179,11 @@ func (s iufService) HistoryRunAction(activityName string, req iuf.HistoryRunActi
 		s.logger.Errorf("HistoryRunAction.5: for activity %s, error while parsing input parameters: %#v", activityName, req.InputParameters)
 		return iuf.Session{}, err
 	}
+	// operation and product filters only apply to this run, so never carry over the ones from a previous run
+	inputParamsForPatch.Operations = &req.InputParameters.Operations
+	inputParamsForPatch.Products = &req.InputParameters.Products
 
-	activity, err = s.PatchActivity(activity, iuf.PatchActivityRequest{
+	activity, err = s.PatchActivity(ctx, activity, iuf.PatchActivityRequest{
 		InputParameters: inputParamsForPatch,
 		SiteParameters:  req.SiteParameters,
 	})
@@ -177,13 +202,13 @@ func (s iufService) HistoryRunAction(activityName string, req iuf.HistoryRunActi
 		Name:            name,
 		ActivityRef:     activityName,
 	}
-	return s.CreateSession(session, name, activity)
+	return s.CreateSession(ctx, session, name, activity)
 }
 
-func (s iufService) HistoryAbortAction(activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error) {
+func (s iufService) HistoryAbortAction(ctx context.Context, activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error) {
 	// go through the sessions and if there is any session that is not completed or aborted, then mark it as aborted
 	// and terminate its workflows.
-	sessions, err := s.ListSessions(activityName)
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		s.logger.Errorf("HistoryAbortAction: An error occurred while listing sessions for activity %s: %v", activityName, err)
 		return iuf.Session{}, err
@@ -197,7 +222,7 @@ func (s iufService) HistoryAbortAction(activityName string, req iuf.HistoryAbort
 		// if this session still has workflows running, then this is a good session for abort irrespective of its
 		//  current state (i.e. even if it was aborted in the past).
 
-		abortable, workflows, err := s.isSessionAbortable(session)
+		abortable, workflows, err := s.isSessionAbortable(ctx, session)
 
 		if err != nil {
 			return iuf.Session{}, err
@@ -210,7 +235,7 @@ func (s iufService) HistoryAbortAction(activityName string, req iuf.HistoryAbort
 				comment = fmt.Sprintf("Aborted at stage %s", session.CurrentStage)
 			}
 
-			err := s.AbortSession(&session, comment, req.Force, workflows)
+			err := s.AbortSession(ctx, &session, comment, req.Force, workflows)
 			if err != nil {
 				errors = append(errors, err)
 			}
@@ -232,10 +257,10 @@ func (s iufService) HistoryAbortAction(activityName string, req iuf.HistoryAbort
 // Check whether the workflows associated with the session / activity is abortable. Returns all the workflows related to
 //
 //	the session/activity irrespective.
-func (s iufService) isSessionAbortable(session iuf.Session) (bool, *v1alpha1.WorkflowList, error) {
+func (s iufService) isSessionAbortable(ctx context.Context, session iuf.Session) (bool, *v1alpha1.WorkflowList, error) {
 	isAbortable := session.CurrentState != iuf.SessionStateCompleted && session.CurrentState != iuf.SessionStateAborted
 
-	workflows, err := s.workflowClient.ListWorkflows(context.TODO(), &workflow.WorkflowListRequest{
+	workflows, err := s.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
 		Namespace: "argo",
 		ListOptions: &v1.ListOptions{
 			// note that we do not use iuf=true label selector here because we also want to include the IUF node worker
@@ -260,9 +285,9 @@ func (s iufService) isSessionAbortable(session iuf.Session) (bool, *v1alpha1.Wor
 	return isAbortable, workflows, nil
 }
 
-func (s iufService) HistoryPausedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (s iufService) HistoryPausedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	// go through the sessions and if there is any session that is in_progress state, then mark it as paused
-	sessions, err := s.ListSessions(activityName)
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		s.logger.Errorf("HistoryPausedAction: An error occurred while listing sessions for activity %s: %v", activityName, err)
 		return iuf.Session{}, err
@@ -278,7 +303,7 @@ func (s iufService) HistoryPausedAction(activityName string, req iuf.HistoryActi
 				comment = fmt.Sprintf("Paused at stage %s", session.CurrentStage)
 			}
 
-			err := s.PauseSession(&session, comment)
+			err := s.PauseSession(ctx, &session, comment)
 			if err != nil {
 				errors = append(errors, err)
 			}
@@ -299,9 +324,9 @@ func (s iufService) HistoryPausedAction(activityName string, req iuf.HistoryActi
 	}
 }
 
-func (s iufService) HistoryResumeAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (s iufService) HistoryResumeAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	// go through the sessions and if there is any session that is in_progress state, then mark it as paused
-	sessions, err := s.ListSessions(activityName)
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		return iuf.Session{}, err
 	}
@@ -326,11 +351,18 @@ func (s iufService) HistoryResumeAction(activityName string, req iuf.HistoryActi
 		err := utils.GenericError{Message: fmt.Sprintf("HistoryResumeAction.3: The session %s in activity %s cannot be resumed because it is either Completed or Aborted. Try restarting or running a new session.", session.Name, activityName)}
 		s.logger.Error(err)
 		return session, err
-	} else if s.IsSessionLocked(session) {
+	} else if s.IsSessionLocked(ctx, session) {
 		// do nothing, we don't want to overlap when the session is transitioning to the next stage.
 		return session, err
 	} else {
-		err := s.ResumeSession(&session, comment)
+		// stages.yaml may have changed since the session was created
+		err := s.validateStagesToRun(iuf.Activity{Name: activityName, Products: session.Products}, session.InputParameters.Stages)
+		if err != nil {
+			s.logger.Errorf("HistoryResumeAction.4: for activity %s, invalid stages %v: %v", activityName, session.InputParameters.Stages, err)
+			return iuf.Session{}, err
+		}
+
+		err = s.ResumeSession(ctx, &session, comment)
 		if err != nil {
 			return iuf.Session{}, err
 		}
@@ -339,9 +371,9 @@ func (s iufService) HistoryResumeAction(activityName string, req iuf.HistoryActi
 	}
 }
 
-func (s iufService) HistoryRestartAction(activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error) {
+func (s iufService) HistoryRestartAction(ctx context.Context, activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error) {
 	// go through the sessions and if there is any session that is abortable, abort it first.
-	sessions, err := s.ListSessions(activityName)
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		return iuf.Session{}, err
 	}
@@ -356,7 +388,14 @@ func (s iufService) HistoryRestartAction(activityName string, req iuf.HistoryRes
 
 	session := sessions[len(sessions)-1]
 
-	abortable, workflows, err := s.isSessionAbortable(session)
+	// stages.yaml may have changed since the session was created
+	err = s.validateStagesToRun(iuf.Activity{Name: activityName, Products: session.Products}, session.InputParameters.Stages)
+	if err != nil {
+		s.logger.Errorf("HistoryRestartAction.3: for activity %s, invalid stages %v: %v", activityName, session.InputParameters.Stages, err)
+		return iuf.Session{}, err
+	}
+
+	abortable, workflows, err := s.isSessionAbortable(ctx, session)
 
 	if err != nil {
 		return iuf.Session{}, err
@@ -364,7 +403,7 @@ func (s iufService) HistoryRestartAction(activityName string, req iuf.HistoryRes
 
 	if abortable {
 		// first abort this session
-		err := s.AbortSession(&session, "Aborting before restart", true, workflows)
+		err := s.AbortSession(ctx, &session, "Aborting before restart", true, workflows)
 		if err != nil {
 			// just print the warning. We don't care if it doesn't abort
 			s.logger.Warnf("HistoryRestartAction.2: There was an error aborting the current session before restarting. Session name %s in activity %s. Error: %v", session.Name, session.ActivityRef, err)
@@ -379,21 +418,23 @@ func (s iufService) HistoryRestartAction(activityName string, req iuf.HistoryRes
 
 	// now modify the session so the current stage is blank (so it can be restarted)
 	session.CurrentStage = ""
+	session.CurrentStages = nil
+	session.CompletedStages = nil
 	session.CurrentState = ""
 	session.InputParameters.Force = req.Force
-	err = s.UpdateSessionAndActivity(session, comment)
+	err = s.UpdateSessionAndActivity(ctx, session, comment)
 
 	return session, err
 }
 
-func (s iufService) HistoryBlockedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
+func (s iufService) HistoryBlockedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error) {
 	// this is only allowed when activity is in debug, paused, or wait_for_admin state.
-	activity, err := s.GetActivity(activityName)
+	activity, err := s.GetActivity(ctx, activityName)
 	if err != nil {
 		return iuf.Session{}, err
 	}
 
-	sessions, err := s.ListSessions(activityName)
+	sessions, err := s.ListSessions(ctx, activityName)
 	if err != nil {
 		return iuf.Session{}, err
 	}
@@ -412,10 +453,11 @@ func (s iufService) HistoryBlockedAction(activityName string, req iuf.HistoryAct
 		lastSession = session
 	}
 
+	previousActivityState := activity.ActivityState
 	switch activity.ActivityState {
 	case iuf.ActivityStateWaitForAdmin, iuf.ActivityStateDebug:
 		activity.ActivityState = iuf.ActivityStateBlocked
-		_, err := s.updateActivity(activity)
+		_, err := s.updateActivity(ctx, activity)
 		if err != nil {
 			return iuf.Session{}, err
 		}
@@ -436,10 +478,11 @@ func (s iufService) HistoryBlockedAction(activityName string, req iuf.HistoryAct
 	}
 
 	// add a history entry for blocked activity
-	err = s.CreateHistoryEntry(activityName, iuf.ActivityStateBlocked, comment)
+	err = s.CreateHistoryEntry(ctx, activityName, iuf.ActivityStateBlocked, comment)
 	if err != nil {
 		return iuf.Session{}, err
 	}
+	s.notifyActivityState(activityName, lastSession.Name, previousActivityState, iuf.ActivityStateBlocked, lastSession.CurrentState, comment)
 
 	return lastSession, nil
 }
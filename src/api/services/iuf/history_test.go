@@ -26,6 +26,7 @@
 package services_iuf
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"reflect"
@@ -96,7 +97,7 @@ func TestGetActivityHistory(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			history, err := mySvc.GetActivityHistory(tt.activityName, tt.startTime)
+			history, err := mySvc.GetActivityHistory(context.TODO(), tt.activityName, tt.startTime)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("got %v, wantErr %v", err, tt.wantErr)
 				return
@@ -105,3 +106,232 @@ func TestGetActivityHistory(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStagesToRun(t *testing.T) {
+	_, _, iufSvc := setup(t)
+	activityWithProducts := iuf.Activity{Name: "activity", Products: []iuf.Product{{Name: "cos", Version: "1.2.3"}}}
+	activityWithoutProducts := iuf.Activity{Name: "activity"}
+
+	var tests = []struct {
+		name     string
+		activity iuf.Activity
+		stages   []string
+		wantErr  bool
+	}{
+		{
+			name:     "valid stages in order",
+			activity: activityWithProducts,
+			stages:   []string{"process-media", "deliver-product", "update-vcs-config"},
+			wantErr:  false,
+		},
+		{
+			name:     "non-contiguous stages in order",
+			activity: activityWithProducts,
+			stages:   []string{"pre-install-check", "prepare-images"},
+			wantErr:  false,
+		},
+		{
+			name:     "no stages",
+			activity: activityWithProducts,
+			stages:   []string{},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown stage",
+			activity: activityWithProducts,
+			stages:   []string{"process-media", "no-such-stage"},
+			wantErr:  true,
+		},
+		{
+			name:     "stages out of order",
+			activity: activityWithProducts,
+			stages:   []string{"deliver-product", "process-media"},
+			wantErr:  true,
+		},
+		{
+			name:     "repeated stage",
+			activity: activityWithProducts,
+			stages:   []string{"deliver-product", "deliver-product"},
+			wantErr:  true,
+		},
+		{
+			name:     "no products and no process-media",
+			activity: activityWithoutProducts,
+			stages:   []string{"deliver-product"},
+			wantErr:  true,
+		},
+		{
+			name:     "no products but process-media runs first",
+			activity: activityWithoutProducts,
+			stages:   []string{"process-media", "deliver-product"},
+			wantErr:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := iufSvc.validateStagesToRun(tt.activity, tt.stages)
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.IsType(t, StageValidationError{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateStagesGraph(t *testing.T) {
+	_, _, iufSvc := setup(t)
+
+	var tests = []struct {
+		name    string
+		stages  []iuf.Stage
+		wantErr bool
+	}{
+		{
+			name:   "dependencies defined before",
+			stages: []iuf.Stage{{Name: "a"}, {Name: "b", DependsOn: []string{"a"}}, {Name: "c", DependsOn: []string{"a"}}, {Name: "d", DependsOn: []string{"b", "c"}}},
+		},
+		{
+			name:    "unknown dependency",
+			stages:  []iuf.Stage{{Name: "a"}, {Name: "b", DependsOn: []string{"x"}}},
+			wantErr: true,
+		},
+		{
+			name:    "dependency defined after",
+			stages:  []iuf.Stage{{Name: "a", DependsOn: []string{"b"}}, {Name: "b"}},
+			wantErr: true,
+		},
+		{
+			name:    "dependency on itself",
+			stages:  []iuf.Stage{{Name: "a", DependsOn: []string{"a"}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := iufSvc.validateStagesGraph(iuf.Stages{Stages: tt.stages})
+			if tt.wantErr {
+				assert.IsType(t, StageValidationError{}, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("parallel stages.yaml is valid", func(t *testing.T) {
+		iufSvc.env.IufInstallWorkflowFiles = "./_test_data_/parallel"
+		stages, err := iufSvc.GetStages()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"update-vcs-config", "prepare-images"}, stages.Stages[4].DependsOn)
+	})
+}
+
+func TestValidateOperationsAndProducts(t *testing.T) {
+	_, _, iufSvc := setup(t)
+	activityWithProducts := iuf.Activity{Name: "activity", Products: []iuf.Product{{Name: "cos", Version: "1.2.3"}, {Name: "sdu", Version: "3.4.5"}}}
+	activityWithoutProducts := iuf.Activity{Name: "activity"}
+
+	var tests = []struct {
+		name       string
+		activity   iuf.Activity
+		stages     []string
+		operations []string
+		products   []string
+		wantErr    string
+	}{
+		{
+			name:     "nothing selected",
+			activity: activityWithProducts,
+			stages:   []string{"deliver-product"},
+		},
+		{
+			name:       "operations of the stages and products by name or version",
+			activity:   activityWithProducts,
+			stages:     []string{"process-media", "deliver-product"},
+			operations: []string{"extract-release-distributions", "s3-upload"},
+			products:   []string{"cos", "sdu-3.4.5"},
+		},
+		{
+			name:       "operation of another stage",
+			activity:   activityWithProducts,
+			stages:     []string{"deliver-product"},
+			operations: []string{"s3-upload", "extract-release-distributions"},
+			wantErr:    "Unknown operations: extract-release-distributions.",
+		},
+		{
+			name:     "unknown product",
+			activity: activityWithProducts,
+			stages:   []string{"deliver-product"},
+			products: []string{"cos", "sud", "cos-9.9.9"},
+			wantErr:  "Unknown products: sud, cos-9.9.9. Products of the activity activity are: cos-1.2.3, sdu-3.4.5.",
+		},
+		{
+			name:     "products are not known before process-media",
+			activity: activityWithoutProducts,
+			stages:   []string{"process-media", "deliver-product"},
+			products: []string{"cos"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := iufSvc.validateOperationsAndProducts(tt.activity, tt.stages, tt.operations, tt.products)
+			if tt.wantErr != "" {
+				assert.IsType(t, StageValidationError{}, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHistoryRunActionBlockOnInvalidProducts(t *testing.T) {
+	activityName, _, iufSvc := setup(t)
+	activity, err := iufSvc.GetActivity(context.TODO(), activityName)
+	assert.NoError(t, err)
+	activity.Products = []iuf.Product{
+		{Name: "cos", Version: "1.2.3", Validated: true},
+		{Name: "sdu", Version: "2.3.4", ValidationErrors: []string{"failed to validate IUF Product Manifest schema"}},
+	}
+	_, err = iufSvc.updateActivity(context.TODO(), activity)
+	assert.NoError(t, err)
+
+	var tests = []struct {
+		name            string
+		inputParameters iuf.InputParameters
+		wantErr         bool
+	}{
+		{
+			name:            "blocked by a product that is not validated",
+			inputParameters: iuf.InputParameters{Stages: []string{"deliver-product"}, BlockOnInvalidProducts: true},
+			wantErr:         true,
+		},
+		{
+			name:            "not blocked when the option is not set",
+			inputParameters: iuf.InputParameters{Stages: []string{"deliver-product"}},
+			wantErr:         false,
+		},
+		{
+			name:            "not blocked when process-media runs again",
+			inputParameters: iuf.InputParameters{Stages: []string{"process-media", "deliver-product"}, BlockOnInvalidProducts: true},
+			wantErr:         false,
+		},
+		{
+			name:            "not blocked by products that are not selected",
+			inputParameters: iuf.InputParameters{Stages: []string{"deliver-product"}, Products: []string{"cos"}, BlockOnInvalidProducts: true},
+			wantErr:         false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := iufSvc.HistoryRunAction(context.TODO(), activityName, iuf.HistoryRunActionRequest{InputParameters: tt.inputParameters})
+			if tt.wantErr {
+				assert.IsType(t, StageValidationError{}, err)
+				assert.Contains(t, err.Error(), "sdu 2.3.4 (failed to validate IUF Product Manifest schema)")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
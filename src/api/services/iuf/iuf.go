@@ -28,6 +28,7 @@ package services_iuf
 //go:generate mockgen -destination=../mocks/services/iuf.go -package=mocks -source=iuf.go
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
@@ -37,6 +38,9 @@ import (
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
 	core_v1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -52,40 +56,43 @@ const (
 )
 
 type IufService interface {
-	CreateActivity(req iuf.CreateActivityRequest) (iuf.Activity, error)
-	PatchActivity(activity iuf.Activity, req iuf.PatchActivityRequest) (iuf.Activity, error)
-	ListActivities() ([]iuf.Activity, error)
-	GetActivity(name string) (iuf.Activity, error)
-	DeleteActivity(name string) (bool, error)
+	CreateActivity(ctx context.Context, req iuf.CreateActivityRequest) (iuf.Activity, error)
+	PatchActivity(ctx context.Context, activity iuf.Activity, req iuf.PatchActivityRequest) (iuf.Activity, error)
+	ListActivities(ctx context.Context) ([]iuf.Activity, error)
+	GetActivity(ctx context.Context, name string) (iuf.Activity, error)
+	DeleteActivity(ctx context.Context, name string) (bool, error)
 	// history
-	ListActivityHistory(activityName string) ([]iuf.History, error)
-	HistoryRunAction(activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error)
-	HistoryAbortAction(activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error)
-	HistoryPausedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
-	HistoryResumeAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
-	HistoryRestartAction(activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error)
-	HistoryBlockedAction(activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
-	GetActivityHistory(activityName string, startTime int32) (iuf.History, error)
-	ReplaceHistoryComment(activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error)
+	ListActivityHistory(ctx context.Context, activityName string) ([]iuf.History, error)
+	HistoryRunAction(ctx context.Context, activityName string, req iuf.HistoryRunActionRequest) (iuf.Session, error)
+	HistoryAbortAction(ctx context.Context, activityName string, req iuf.HistoryAbortRequest) (iuf.Session, error)
+	HistoryPausedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
+	HistoryResumeAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
+	HistoryRestartAction(ctx context.Context, activityName string, req iuf.HistoryRestartRequest) (iuf.Session, error)
+	HistoryBlockedAction(ctx context.Context, activityName string, req iuf.HistoryActionRequest) (iuf.Session, error)
+	GetActivityHistory(ctx context.Context, activityName string, startTime int32) (iuf.History, error)
+	ReplaceHistoryComment(ctx context.Context, activityName string, startTime int32, req iuf.ReplaceHistoryCommentRequest) (iuf.History, error)
 	// session
-	ListSessions(activityName string) ([]iuf.Session, error)
-	GetSession(sessionName string) (iuf.Session, error)
-	SyncWorkflowsToSession(session *iuf.Session) error
-	FindLastWorkflowForCurrentStage(session *iuf.Session) *v1alpha1.Workflow
-	RestartCurrentStage(session *iuf.Session, comment string) error
+	ListSessions(ctx context.Context, activityName string) ([]iuf.Session, error)
+	GetSession(ctx context.Context, sessionName string) (iuf.Session, error)
+	SyncWorkflowsToSession(ctx context.Context, session *iuf.Session) error
+	FindLastWorkflowForCurrentStage(ctx context.Context, session *iuf.Session) *v1alpha1.Workflow
+	RestartCurrentStage(ctx context.Context, session *iuf.Session, comment string) error
+	GetStageWorkflow(ctx context.Context, session iuf.Session, stageName string) (workflow v1alpha1.Workflow, err error, skipStage bool)
 	// session operator
 	ConfigMapDataToSession(data string) (iuf.Session, error)
-	UpdateActivityStateFromSessionState(session iuf.Session, comment string) error
-	UpdateSession(session iuf.Session) error
-	UpdateSessionAndActivity(session iuf.Session, comment string) error
-	IsSessionLocked(session iuf.Session) bool
-	LockSession(session iuf.Session) bool
-	UnlockSession(session iuf.Session)
-	CreateIufWorkflow(req *iuf.Session) (retWorkflow *v1alpha1.Workflow, err error, skipStage bool)
-	RunNextPartialWorkflow(session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool)
-	RunNextStage(session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool)
-	ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workflow) error
+	UpdateActivityStateFromSessionState(ctx context.Context, session iuf.Session, comment string) error
+	UpdateSession(ctx context.Context, session iuf.Session) error
+	UpdateSessionAndActivity(ctx context.Context, session iuf.Session, comment string) error
+	IsSessionLocked(ctx context.Context, session iuf.Session) bool
+	LockSession(ctx context.Context, session iuf.Session) bool
+	UnlockSession(ctx context.Context, session iuf.Session)
+	CreateIufWorkflow(ctx context.Context, req *iuf.Session) (retWorkflow *v1alpha1.Workflow, err error, skipStage bool)
+	RunNextPartialWorkflow(ctx context.Context, session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool)
+	RunNextStage(ctx context.Context, session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool)
+	ProcessOutput(ctx context.Context, session *iuf.Session, workflow *v1alpha1.Workflow) error
 	GetStages() (iuf.Stages, error)
+	// events
+	SubscribeToActivityEvents(activityName string) (<-chan iuf.Event, func())
 }
 
 // IufService service layer
@@ -96,16 +103,27 @@ type iufService struct {
 	k8sRestClientSet       kubernetes.Interface
 	keycloakService        services_shared.KeycloakService
 	env                    utils.Env
+	events                 *eventBroker
+	notifier               services_shared.NotifierService
+	checksums              *checksumVerifier
 }
 
-// NewIufService creates a new Iufservice
-func NewIufService(logger utils.Logger, argoService services_shared.ArgoService, k8sSvc services_shared.K8sService, keycloakService services_shared.KeycloakService, env utils.Env) IufService {
+// NewIufService creates a new Iufservice. The events of activities stop being published and watched when the service stops.
+func NewIufService(lifecycle fx.Lifecycle, logger utils.Logger, argoService services_shared.ArgoService, k8sSvc services_shared.K8sService, keycloakService services_shared.KeycloakService, notifier services_shared.NotifierService, env utils.Env) IufService {
 
 	workflowTemplateClient, err := argoService.Client.NewWorkflowTemplateServiceClient()
 	if err != nil {
 		panic(err.Error)
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+
 	iufSvc := iufService{
 		logger:                 logger,
 		workflowClient:         argoService.Client.NewWorkflowServiceClient(),
@@ -113,10 +131,22 @@ func NewIufService(logger utils.Logger, argoService services_shared.ArgoService,
 		k8sRestClientSet:       k8sSvc.Client,
 		keycloakService:        keycloakService,
 		env:                    env,
+		events:                 newEventBroker(ctx, logger, k8sSvc.Client, k8sSvc.WatchClient),
+		notifier:               notifier,
+		checksums:              newChecksumVerifier(),
 	}
 	return iufSvc
 }
 
+// startSessionSpan starts a span for work on the given session
+func startSessionSpan(ctx context.Context, name string, session *iuf.Session) (context.Context, trace.Span) {
+	return utils.Tracer().Start(ctx, "iuf."+name, trace.WithAttributes(
+		attribute.String("iuf.activity", session.ActivityRef),
+		attribute.String("iuf.session", session.Name),
+		attribute.String("iuf.stage", session.CurrentStage),
+	))
+}
+
 func (s iufService) iufObjectToConfigMapData(activity interface{}, name string, iufType string) (core_v1.ConfigMap, error) {
 	reqBytes, err := json.Marshal(activity)
 	if err != nil {
@@ -0,0 +1,139 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// how long a scrape waits for the activity and session ConfigMaps
+const IUF_METRICS_LIST_TIMEOUT = 10 * time.Second
+
+var (
+	iufActivitiesDesc = prometheus.NewDesc(prometheus.BuildFQName(utils.METRICS_NAMESPACE, "iuf", "activities"),
+		"Number of IUF activities, by activity state.", []string{"state"}, nil)
+	iufSessionsDesc = prometheus.NewDesc(prometheus.BuildFQName(utils.METRICS_NAMESPACE, "iuf", "sessions"),
+		"Number of IUF sessions, by session state.", []string{"state"}, nil)
+)
+
+// RegisterIufMetrics registers the gauges of IUF activities and sessions. They are counted from their ConfigMaps on
+//  every scrape, so they are right whichever replica serves the scrape.
+func RegisterIufMetrics(logger utils.Logger, k8sSvc services_shared.K8sService) {
+	err := prometheus.Register(iufStateCollector{logger: logger, k8sRestClientSet: k8sSvc.Client})
+	if err != nil {
+		logger.Warnf("RegisterIufMetrics: unable to register IUF metrics: %v", err)
+	}
+}
+
+type iufStateCollector struct {
+	logger           utils.Logger
+	k8sRestClientSet kubernetes.Interface
+}
+
+func (c iufStateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- iufActivitiesDesc
+	ch <- iufSessionsDesc
+}
+
+func (c iufStateCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), IUF_METRICS_LIST_TIMEOUT)
+	defer cancel()
+
+	activities := map[string]float64{}
+	for _, state := range []iuf.ActivityState{iuf.ActivityStateInProgress, iuf.ActivityStatePaused, iuf.ActivityStateDebug,
+		iuf.ActivityStateBlocked, iuf.ActivityStateWaitForAdmin} {
+		activities[string(state)] = 0
+	}
+	err := c.countStates(ctx, LABEL_ACTIVITY, activities, func(data string) (string, error) {
+		var activity iuf.Activity
+		err := json.Unmarshal([]byte(data), &activity)
+		return string(activity.ActivityState), err
+	})
+	if err != nil {
+		c.logger.Warnf("iufStateCollector.1: unable to count IUF activities: %v", err)
+	} else {
+		for state, count := range activities {
+			ch <- prometheus.MustNewConstMetric(iufActivitiesDesc, prometheus.GaugeValue, count, state)
+		}
+	}
+
+	sessions := map[string]float64{}
+	for _, state := range []iuf.SessionState{iuf.SessionStateInProgress, iuf.SessionStatePaused, iuf.SessionStateDebug,
+		iuf.SessionStateCompleted, iuf.SessionStateAborted} {
+		sessions[string(state)] = 0
+	}
+	err = c.countStates(ctx, LABEL_SESSION, sessions, func(data string) (string, error) {
+		var session iuf.Session
+		err := json.Unmarshal([]byte(data), &session)
+		return string(session.CurrentState), err
+	})
+	if err != nil {
+		c.logger.Warnf("iufStateCollector.2: unable to count IUF sessions: %v", err)
+	} else {
+		for state, count := range sessions {
+			ch <- prometheus.MustNewConstMetric(iufSessionsDesc, prometheus.GaugeValue, count, state)
+		}
+	}
+}
+
+// countStates adds the state of each ConfigMap of the given type to counts. ConfigMaps that cannot be parsed are skipped.
+func (c iufStateCollector) countStates(ctx context.Context, objectType string, counts map[string]float64, state func(data string) (string, error)) error {
+	configMaps, err := c.k8sRestClientSet.CoreV1().ConfigMaps(DEFAULT_NAMESPACE).List(ctx, v1.ListOptions{
+		LabelSelector: fmt.Sprintf("type=%s", objectType),
+	})
+	if err != nil {
+		return err
+	}
+	for _, configMap := range configMaps.Items {
+		value, err := state(configMap.Data[objectType])
+		if err != nil {
+			c.logger.Warnf("iufStateCollector.3: unable to parse %s %s: %v", objectType, configMap.Name, err)
+			continue
+		}
+		counts[value]++
+	}
+	return nil
+}
+
+// operationCounts counts the operations of a generated stage workflow, by whether they are executed or skipped. They
+//  are only added to the IUF operations counter once the workflow has been submitted.
+type operationCounts map[string]int
+
+func (c operationCounts) record(stage string) {
+	for result, count := range c {
+		utils.IufOperationsTotal.WithLabelValues(stage, result).Add(float64(count))
+	}
+}
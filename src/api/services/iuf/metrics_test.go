@@ -0,0 +1,79 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	core_v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIufStateCollector(t *testing.T) {
+	newConfigMap := func(name string, objectType string, object interface{}) *core_v1.ConfigMap {
+		data, _ := json.Marshal(object)
+		return &core_v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: DEFAULT_NAMESPACE, Labels: map[string]string{"type": objectType}},
+			Data:       map[string]string{objectType: string(data)},
+		}
+	}
+	collector := iufStateCollector{
+		logger: utils.GetLogger(),
+		k8sRestClientSet: fake.NewSimpleClientset(
+			newConfigMap("a1", LABEL_ACTIVITY, iuf.Activity{Name: "a1", ActivityState: iuf.ActivityStateInProgress}),
+			newConfigMap("a2", LABEL_ACTIVITY, iuf.Activity{Name: "a2", ActivityState: iuf.ActivityStateWaitForAdmin}),
+			newConfigMap("a3", LABEL_ACTIVITY, iuf.Activity{Name: "a3", ActivityState: iuf.ActivityStateWaitForAdmin}),
+			newConfigMap("s1", LABEL_SESSION, iuf.Session{Name: "s1", CurrentState: iuf.SessionStateCompleted}),
+			newConfigMap("s2", LABEL_SESSION, iuf.Session{Name: "s2", CurrentState: iuf.SessionStateInProgress}),
+		),
+	}
+
+	expected := `
+# HELP cray_nls_iuf_activities Number of IUF activities, by activity state.
+# TYPE cray_nls_iuf_activities gauge
+cray_nls_iuf_activities{state="blocked"} 0
+cray_nls_iuf_activities{state="debug"} 0
+cray_nls_iuf_activities{state="in_progress"} 1
+cray_nls_iuf_activities{state="paused"} 0
+cray_nls_iuf_activities{state="wait_for_admin"} 2
+# HELP cray_nls_iuf_sessions Number of IUF sessions, by session state.
+# TYPE cray_nls_iuf_sessions gauge
+cray_nls_iuf_sessions{state="aborted"} 0
+cray_nls_iuf_sessions{state="completed"} 1
+cray_nls_iuf_sessions{state="debug"} 0
+cray_nls_iuf_sessions{state="in_progress"} 1
+cray_nls_iuf_sessions{state="paused"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
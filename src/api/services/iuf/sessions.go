@@ -38,17 +38,23 @@ import (
 	"time"
 
 	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/exp/slices"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
 
-func (s iufService) GetSession(sessionName string) (iuf.Session, error) {
+func (s iufService) GetSession(ctx context.Context, sessionName string) (iuf.Session, error) {
 	rawConfigMapData, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Get(
-			context.TODO(),
+			ctx,
 			sessionName,
 			v1.GetOptions{},
 		)
@@ -65,12 +71,12 @@ func (s iufService) GetSession(sessionName string) (iuf.Session, error) {
 	return res, err
 }
 
-func (s iufService) ListSessions(activityName string) ([]iuf.Session, error) {
+func (s iufService) ListSessions(ctx context.Context, activityName string) ([]iuf.Session, error) {
 	rawConfigMapList, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		List(
-			context.TODO(),
+			ctx,
 			v1.ListOptions{
 				LabelSelector: fmt.Sprintf("type=%s,%s=%s", LABEL_SESSION, LABEL_ACTIVITY_REF, activityName),
 			},
@@ -102,10 +108,48 @@ func (s iufService) ConfigMapDataToSession(data string) (iuf.Session, error) {
 		s.logger.Errorf("ConfigMapDataToSession: An error occurred while parsing JSON: %s: %v", data, err)
 		return res, err
 	}
+	s.migrateStageTracking(&res)
 	return res, err
 }
 
-func (s iufService) CreateSession(session iuf.Session, name string, activity iuf.Activity) (iuf.Session, error) {
+// migrateStageTracking fills in the running and completed stages of sessions that were created before stages could run
+//  in parallel, and that only tracked the current stage.
+func (s iufService) migrateStageTracking(session *iuf.Session) {
+	if session.CurrentStage == "" || len(session.CurrentStages) > 0 || len(session.CompletedStages) > 0 {
+		return
+	}
+
+	idx := slices.Index(session.InputParameters.Stages, session.CurrentStage)
+	if idx < 0 {
+		return
+	}
+	session.CompletedStages = append([]string{}, session.InputParameters.Stages[:idx]...)
+	session.CurrentStages = []string{session.CurrentStage}
+}
+
+// completeStage moves the given stage from the running stages to the completed stages of the session.
+func (s iufService) completeStage(session *iuf.Session, stageName string) {
+	s.removeCurrentStage(session, stageName)
+	if !slices.Contains(session.CompletedStages, stageName) {
+		session.CompletedStages = append(session.CompletedStages, stageName)
+		s.publishEvent(iuf.Event{
+			Type:         iuf.EEventTypeStageFinished,
+			ActivityName: session.ActivityRef,
+			SessionName:  session.Name,
+			Stage:        stageName,
+			Phase:        string(v1alpha1.NodeSucceeded),
+		})
+	}
+}
+
+// removeCurrentStage removes the given stage from the running stages of the session.
+func (s iufService) removeCurrentStage(session *iuf.Session, stageName string) {
+	if idx := slices.Index(session.CurrentStages, stageName); idx >= 0 {
+		session.CurrentStages = slices.Delete(session.CurrentStages, idx, idx+1)
+	}
+}
+
+func (s iufService) CreateSession(ctx context.Context, session iuf.Session, name string, activity iuf.Activity) (iuf.Session, error) {
 	configmap, err := s.iufObjectToConfigMapData(session, name, LABEL_SESSION)
 	if err != nil {
 		s.logger.Error(err)
@@ -116,22 +160,30 @@ func (s iufService) CreateSession(session iuf.Session, name string, activity iuf
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Create(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.CreateOptions{},
 		)
 	return session, err
 }
 
-func (s iufService) UpdateSessionAndActivity(session iuf.Session, comment string) error {
-	err := s.UpdateSession(session)
+func (s iufService) UpdateSessionAndActivity(ctx context.Context, session iuf.Session, comment string) error {
+	err := s.UpdateSession(ctx, session)
 	if err != nil {
 		return err
 	}
+	s.publishEvent(iuf.Event{
+		Type:         iuf.EEventTypeSession,
+		ActivityName: session.ActivityRef,
+		SessionName:  session.Name,
+		SessionState: session.CurrentState,
+		Stage:        session.CurrentStage,
+		Comment:      comment,
+	})
 
 	// if the session update was successful, we also want to update the activity
 	s.logger.Infof("UpdateSessionAndActivity.1: update activity activity %s from session %s with comment %s: %#v", session.ActivityRef, session.Name, comment, session)
-	err = s.UpdateActivityStateFromSessionState(session, comment)
+	err = s.UpdateActivityStateFromSessionState(ctx, session, comment)
 	if err != nil {
 		return err
 	}
@@ -140,12 +192,12 @@ func (s iufService) UpdateSessionAndActivity(session iuf.Session, comment string
 }
 
 // IsSessionLocked is the session locked by another worker? See LockSession
-func (s iufService) IsSessionLocked(session iuf.Session) bool {
+func (s iufService) IsSessionLocked(ctx context.Context, session iuf.Session) bool {
 	lockName := session.Name + "-lock"
 	_, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
-		Get(context.TODO(), lockName, v1.GetOptions{})
+		Get(ctx, lockName, v1.GetOptions{})
 
 	return err == nil
 }
@@ -154,8 +206,8 @@ func (s iufService) IsSessionLocked(session iuf.Session) bool {
 //
 //	transactional database, we are going to have to make do with locking using configmaps.
 //	But note that the configmap is stored in etcd, which is eventually consistent :\
-func (s iufService) LockSession(session iuf.Session) bool {
-	if s.IsSessionLocked(session) {
+func (s iufService) LockSession(ctx context.Context, session iuf.Session) bool {
+	if s.IsSessionLocked(ctx, session) {
 		return false
 	}
 
@@ -172,7 +224,7 @@ func (s iufService) LockSession(session iuf.Session) bool {
 	_, err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
-		Create(context.TODO(), &configmap, v1.CreateOptions{})
+		Create(ctx, &configmap, v1.CreateOptions{})
 
 	if err != nil {
 		s.logger.Errorf("LockSession.1: error while creating a lock configmap resource %s %s in activity %s: %v", configmap.Name, session.Name, session.ActivityRef, err)
@@ -183,12 +235,12 @@ func (s iufService) LockSession(session iuf.Session) bool {
 }
 
 // UnlockSession unlocks the session. See LockSession
-func (s iufService) UnlockSession(session iuf.Session) {
+func (s iufService) UnlockSession(ctx context.Context, session iuf.Session) {
 	lockName := session.Name + "-lock"
 	err := s.k8sRestClientSet.
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
-		Delete(context.TODO(), lockName, v1.DeleteOptions{})
+		Delete(ctx, lockName, v1.DeleteOptions{})
 
 	if err != nil {
 		s.logger.Errorf("UnlockSession.1: error while deleting a lock configmap resource %s for session %s in activity %s: %v", lockName, session.Name, session.ActivityRef, err)
@@ -197,7 +249,7 @@ func (s iufService) UnlockSession(session iuf.Session) {
 	}
 }
 
-func (s iufService) UpdateSession(session iuf.Session) error {
+func (s iufService) UpdateSession(ctx context.Context, session iuf.Session) error {
 	configmap, err := s.iufObjectToConfigMapData(session, session.Name, LABEL_SESSION)
 	if err != nil {
 		s.logger.Errorf("UpdateSession.1: error while update session %s in activity %s with contents %#v: %v", session.Name, session.ActivityRef, session, err)
@@ -212,7 +264,7 @@ func (s iufService) UpdateSession(session iuf.Session) error {
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Update(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.UpdateOptions{},
 		)
@@ -221,12 +273,12 @@ func (s iufService) UpdateSession(session iuf.Session) error {
 		_, err2 := s.k8sRestClientSet.
 			CoreV1().
 			ConfigMaps(DEFAULT_NAMESPACE).
-			Get(context.TODO(), configmap.Name, v1.GetOptions{})
+			Get(ctx, configmap.Name, v1.GetOptions{})
 		if err2 != nil {
 			_, err3 := s.k8sRestClientSet.
 				CoreV1().
 				ConfigMaps(DEFAULT_NAMESPACE).
-				Create(context.TODO(), &configmap, v1.CreateOptions{})
+				Create(ctx, &configmap, v1.CreateOptions{})
 			if err3 != nil {
 				s.logger.Errorf("UpdateSession.2: error while creating a new session %s in activity %s with contents %#v: %v", session.Name, session.ActivityRef, session, err3)
 				return err
@@ -240,18 +292,19 @@ func (s iufService) UpdateSession(session iuf.Session) error {
 	return nil
 }
 
-func (s iufService) UpdateActivityStateFromSessionState(session iuf.Session, comment string) error {
+func (s iufService) UpdateActivityStateFromSessionState(ctx context.Context, session iuf.Session, comment string) error {
 	var activityState iuf.ActivityState
 	if session.CurrentState == iuf.SessionStateCompleted || session.CurrentState == iuf.SessionStateAborted {
 		activityState = iuf.ActivityStateWaitForAdmin
 	} else {
 		activityState = iuf.ActivityState(session.CurrentState)
 	}
-	activity, err := s.GetActivity(session.ActivityRef)
+	activity, err := s.GetActivity(ctx, session.ActivityRef)
 	if err != nil {
 		return err
 	}
 
+	previousActivityState := activity.ActivityState
 	activity.ActivityState = activityState
 	configmap, err := s.iufObjectToConfigMapData(activity, activity.Name, LABEL_ACTIVITY)
 	if err != nil {
@@ -261,7 +314,7 @@ func (s iufService) UpdateActivityStateFromSessionState(session iuf.Session, com
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Update(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.UpdateOptions{},
 		)
@@ -269,6 +322,7 @@ func (s iufService) UpdateActivityStateFromSessionState(session iuf.Session, com
 		s.logger.Errorf("UpdateActivityStateFromSessionState.1: An error occurred while trying to save activity %s with contents %#v: %v", activity.Name, activity, err)
 		return err
 	}
+	s.notifyActivityState(activity.Name, session.Name, previousActivityState, activityState, session.CurrentState, comment)
 
 	// store history
 	name := utils.GenerateName(activity.Name)
@@ -288,20 +342,31 @@ func (s iufService) UpdateActivityStateFromSessionState(session iuf.Session, com
 		CoreV1().
 		ConfigMaps(DEFAULT_NAMESPACE).
 		Create(
-			context.TODO(),
+			ctx,
 			&configmap,
 			v1.CreateOptions{},
 		)
 
 	if err != nil {
 		s.logger.Errorf("UpdateActivityStateFromSessionState.2: An error occurred while trying to save activity %s with contents %#v: %v", activity.Name, activity, err)
+	} else {
+		s.publishEvent(iuf.Event{
+			Type:          iuf.EEventTypeHistory,
+			ActivityName:  activity.Name,
+			SessionName:   session.Name,
+			ActivityState: activityState,
+			Comment:       comment,
+		})
 	}
 
 	return err
 }
 
-func (s iufService) CreateIufWorkflow(session *iuf.Session) (retWorkflow *v1alpha1.Workflow, err error, skipStage bool) {
-	myWorkflow, err, skipStage := s.workflowGen(session)
+func (s iufService) CreateIufWorkflow(ctx context.Context, session *iuf.Session) (retWorkflow *v1alpha1.Workflow, err error, skipStage bool) {
+	ctx, span := startSessionSpan(ctx, "CreateIufWorkflow", session)
+	defer func() { utils.EndSpan(span, err) }()
+	counts := operationCounts{}
+	myWorkflow, err, skipStage := s.workflowGen(ctx, session, counts)
 	if err != nil {
 		s.logger.Error(err)
 		return nil, err, false
@@ -309,20 +374,110 @@ func (s iufService) CreateIufWorkflow(session *iuf.Session) (retWorkflow *v1alph
 		return nil, nil, true
 	}
 
-	res, err := s.workflowClient.CreateWorkflow(context.TODO(), &workflow.WorkflowCreateRequest{
+	// the token goes into a Secret of the workflow, so that it does not show in the workflow itself
+	authToken, err := s.keycloakService.NewKeycloakAccessToken(ctx)
+	if err != nil {
+		tokenErr := utils.GenericError{Message: fmt.Sprintf("Could not generate authToken %v", err)}
+		s.logger.Error(tokenErr)
+		return nil, tokenErr, false
+	}
+	secretName := myWorkflow.Labels[LABEL_AUTH_TOKEN_SECRET]
+	secret := newAuthTokenSecret(secretName, authToken)
+	_, err = s.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Create(ctx, &secret, v1.CreateOptions{})
+	if err != nil {
+		s.logger.Errorf("CreateIufWorkflow.1: unable to create auth token secret %s for session %s: %v", secretName, session.Name, err)
+		return nil, err, false
+	}
+
+	res, err := s.workflowClient.CreateWorkflow(ctx, &workflow.WorkflowCreateRequest{
 		Namespace: "argo",
 		Workflow:  &myWorkflow,
 	})
+	if err != nil && isArgoTimeout(err) {
+		// the Argo server may have created the workflow anyway, in which case it must not be created again
+		res, err = s.findCreatedWorkflow(secretName, err)
+	}
 	if err != nil {
 		s.logger.Errorf("Creating workflow for: %v FAILED", session)
 		s.logger.Error(err)
+		deleteErr := s.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Delete(ctx, secretName, v1.DeleteOptions{})
+		if deleteErr != nil {
+			s.logger.Warnf("CreateIufWorkflow.2: unable to delete auth token secret %s: %v", secretName, deleteErr)
+		}
 		return nil, err, false
 	}
+	counts.record(session.CurrentStage)
+
+	// the Secret is kept for as long as the workflow exists, since the workflow may be retried. It goes away with the workflow.
+	secret.OwnerReferences = authTokenSecretOwner(res)
+	_, err = s.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Update(ctx, &secret, v1.UpdateOptions{})
+	if err != nil {
+		s.logger.Warnf("CreateIufWorkflow.3: unable to make workflow %s the owner of auth token secret %s: %v", res.Name, secretName, err)
+	}
 	return res, nil, false
 }
 
+// isArgoTimeout returns true if a call to the Argo server gave up before the Argo server answered
+func isArgoTimeout(err error) bool {
+	code := status.Code(err)
+	return code == codes.DeadlineExceeded || code == codes.Canceled
+}
+
+// findCreatedWorkflow looks up a workflow whose creation timed out by the name of its auth token Secret, which no other
+//  workflow has. It returns createErr if there is no such workflow.
+func (s iufService) findCreatedWorkflow(secretName string, createErr error) (*v1alpha1.Workflow, error) {
+	// the context of the call may be done already
+	ctx, cancel := context.WithTimeout(context.Background(), services_shared.ARGO_CALL_TIMEOUT)
+	defer cancel()
+	workflows, err := s.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
+		Namespace: "argo",
+		ListOptions: &v1.ListOptions{
+			LabelSelector: fmt.Sprintf("%s=%s", LABEL_AUTH_TOKEN_SECRET, secretName),
+		},
+	})
+	if err != nil {
+		s.logger.Warnf("findCreatedWorkflow.1: unable to check whether workflow with auth token secret %s was created: %v", secretName, err)
+		return nil, createErr
+	}
+	if len(workflows.Items) == 0 {
+		return nil, createErr
+	}
+	s.logger.Infof("findCreatedWorkflow: workflow %s was created although the call to create it timed out", workflows.Items[0].Name)
+	return &workflows.Items[0], nil
+}
+
+// ensureAuthTokenSecret puts a new token in the auth token Secret of a workflow that is about to be retried, and
+//  creates the Secret again if it is gone. Workflows from before the Secret was introduced have no Secret to update.
+func (s iufService) ensureAuthTokenSecret(ctx context.Context, workflow *v1alpha1.Workflow) error {
+	secretName := workflow.Labels[LABEL_AUTH_TOKEN_SECRET]
+	if secretName == "" {
+		return nil
+	}
+	authToken, err := s.keycloakService.NewKeycloakAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	secrets := s.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE)
+	existing, err := secrets.Get(ctx, secretName, v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		secret := newAuthTokenSecret(secretName, authToken)
+		secret.OwnerReferences = authTokenSecretOwner(workflow)
+		_, err = secrets.Create(ctx, &secret, v1.CreateOptions{})
+		return err
+	} else if err != nil {
+		return err
+	}
+	// the refresher does not delete the Secret of the completed workflow now that it has a new token
+	setAuthToken(existing, authToken, time.Now())
+	_, err = secrets.Update(ctx, existing, v1.UpdateOptions{})
+	return err
+}
+
 // RunNextPartialWorkflow Runs another workflow for the same stage with the remaining set of products.
-func (s iufService) RunNextPartialWorkflow(session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool) {
+func (s iufService) RunNextPartialWorkflow(ctx context.Context, session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool) {
+	ctx, span := startSessionSpan(ctx, "RunNextPartialWorkflow", session)
+	defer func() { utils.EndSpan(span, err) }()
 	s.logger.Infof("RunNextPartialWorkflow.1: About to run the next partial workflow for session %s in activity %s", session.Name, session.ActivityRef)
 
 	// need to figure out what products are remaining
@@ -335,7 +490,7 @@ func (s iufService) RunNextPartialWorkflow(session *iuf.Session) (response iuf.S
 		//  or put the session into DEBUG state. We will only proceed to the next stage if all partial workflows have been
 		//  successful. And we will put the session into DEBUG state if any of the workflows had failed or had errors.
 
-		workflows := s.FindAllPartialWorkflowForCurrentStage(session)
+		workflows := s.FindAllPartialWorkflowForCurrentStage(ctx, session)
 		allWorkflowsSuccessful := true
 		for _, workflow := range workflows {
 			if workflow.Status.Phase != v1alpha1.WorkflowSucceeded {
@@ -346,14 +501,14 @@ func (s iufService) RunNextPartialWorkflow(session *iuf.Session) (response iuf.S
 
 		if allWorkflowsSuccessful {
 			s.logger.Infof("RunNextPartialWorkflow.3: After no remaining products were found, since all partial workflows for this session %s in activity %s have completed successfully, going to next stage if possible.", session.Name, session.ActivityRef)
-			return s.RunNextStage(session)
+			return s.RunNextStage(ctx, session)
 		}
 
 		s.logger.Infof("RunNextPartialWorkflow.4: After no remaining products were found, since some partial workflow(s) for this session %s in activity %s were not completed successfully, putting session into DEBUG.", session.Name, session.ActivityRef)
 
 		// other workflow(s) have been unsuccessful, so we'll have to mark this as being DEBUG state
 		session.CurrentState = iuf.SessionStateDebug
-		err = s.UpdateSessionAndActivity(*session, fmt.Sprintf("At least one partial workflow failed %s", workflows[0].Name))
+		err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("At least one partial workflow failed %s", workflows[0].Name))
 		if err != nil {
 			response = iuf.SyncResponse{
 				ResyncAfterSeconds: 30,
@@ -368,14 +523,14 @@ func (s iufService) RunNextPartialWorkflow(session *iuf.Session) (response iuf.S
 	s.logger.Infof("RunNextPartialWorkflow.5: Found %#v remaining products for session %s in activity %s, hence will try to run the next partial workflow", len(remainingProducts), session.Name, session.ActivityRef)
 
 	// the run stage will automatically pick up the remaining products.
-	return s.RunStage(session, session.CurrentStage)
+	return s.RunStage(ctx, session, session.CurrentStage)
 }
 
-// getRemainingProducts gets the products that have not been processed yet for the current stage.
+// getRemainingProducts gets the selected products that have not been processed yet for the current stage.
 func (s iufService) getRemainingProducts(session *iuf.Session) []iuf.Product {
 	var remainingProducts []iuf.Product
 	processedProducts := session.ProcessedProductsByStage[session.CurrentStage]
-	for _, product := range session.Products {
+	for _, product := range s.getSelectedProducts(session) {
 		if !processedProducts[s.getProductVersionKey(product)] {
 			remainingProducts = append(remainingProducts, product)
 		}
@@ -383,52 +538,78 @@ func (s iufService) getRemainingProducts(session *iuf.Session) []iuf.Product {
 	return remainingProducts
 }
 
-// RunNextStage Runs the next stage in the list of stages to execute.
-func (s iufService) RunNextStage(session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool) {
-	// find the current stage in the list of stages, and use the next one
-	var currentStage string
-	found := false
-	if session.CurrentStage != "" {
-		for _, stage := range session.InputParameters.Stages {
-			if !found {
-				if stage == session.CurrentStage {
-					found = true
-				}
+// RunNextStage Marks the current stage as completed, and runs all the stages whose dependencies have completed. Stages
+//  that do not depend on each other run in parallel, each in its own workflow.
+func (s iufService) RunNextStage(ctx context.Context, session *iuf.Session) (response iuf.SyncResponse, err error, sessionCompleted bool) {
+	ctx, span := startSessionSpan(ctx, "RunNextStage", session)
+	defer func() { utils.EndSpan(span, err) }()
+	s.migrateStageTracking(session)
+
+	completedStage := ""
+	if slices.Contains(session.CurrentStages, session.CurrentStage) {
+		completedStage = session.CurrentStage
+		s.completeStage(session, completedStage)
+	}
+
+	stages, err := s.GetStages()
+	if err != nil {
+		s.logger.Errorf("RunNextStage.1: cannot read stages for session %s in activity %s: %v", session.Name, session.ActivityRef, err)
+		session.CurrentState = iuf.SessionStateDebug
+		err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Error in reading stages %s", err))
+		return iuf.SyncResponse{}, err, false
+	}
+
+	startedStage := false
+	for {
+		// stages without any operations are skipped, which can make more stages ready to run
+		stagesSkipped := false
+		for _, stageToRun := range s.getStagesReadyToRun(session, stages) {
+			var skipStage bool
+			response, err, skipStage = s.RunStage(ctx, session, stageToRun)
+			if err != nil || session.CurrentState == iuf.SessionStateDebug {
+				return response, err, false
+			}
+
+			if skipStage {
+				s.completeStage(session, stageToRun)
+				stagesSkipped = true
 			} else {
-				currentStage = stage
-				break
+				startedStage = true
 			}
 		}
-	}
 
-	if !found {
-		if len(session.InputParameters.Stages) > 0 {
-			// Someone updated the input parameters, perhaps. Restart from the beginning because we don't know where we are
-			//  anymore
-			currentStage = session.InputParameters.Stages[0]
-		} else {
-			// this session is done because we don't have anything to run
-			s.logger.Infof("Session completed. No stages to run")
-			return s.SetSessionToCompleted(session)
+		if !stagesSkipped {
+			break
 		}
-	} else if currentStage == "" { // we found the last stage
-		// this session is done
-		return s.SetSessionToCompleted(session)
 	}
 
-	stage, err, skipStage := s.RunStage(session, currentStage)
-	if skipStage {
-		return s.RunNextStage(session)
-	} else {
-		return stage, err, false
+	if len(session.CurrentStages) == 0 {
+		// this session is done because nothing is running and there is nothing left to run
+		return s.SetSessionToCompleted(ctx, session)
 	}
+
+	if !startedStage {
+		// other stages are still running, so wait for them to complete
+		s.logger.Infof("RunNextStage.2: stages %v are still running for session %s in activity %s", session.CurrentStages, session.Name, session.ActivityRef)
+		session.CurrentState = iuf.SessionStateInProgress
+		err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Completed %s", completedStage))
+		if err != nil {
+			s.logger.Error(err)
+			return iuf.SyncResponse{}, err, false
+		}
+		response = iuf.SyncResponse{
+			ResyncAfterSeconds: 5,
+		}
+	}
+
+	return response, nil, false
 }
 
-func (s iufService) SetSessionToCompleted(session *iuf.Session) (iuf.SyncResponse, error, bool) {
+func (s iufService) SetSessionToCompleted(ctx context.Context, session *iuf.Session) (iuf.SyncResponse, error, bool) {
 	session.CurrentState = iuf.SessionStateCompleted
 	s.logger.Infof("Session completed. Last stage was %s", session.CurrentStage)
 
-	err := s.UpdateSessionAndActivity(*session, fmt.Sprintf("Completed %s", session.CurrentStage))
+	err := s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Completed %s", session.CurrentStage))
 	if err != nil {
 		s.logger.Errorf("Error while updating the session %v", err)
 		return iuf.SyncResponse{}, err, false
@@ -438,7 +619,10 @@ func (s iufService) SetSessionToCompleted(session *iuf.Session) (iuf.SyncRespons
 }
 
 // RunStage Runs a specific stage for the given session. Creates a new Argo workflow behind the scenes for this stage.
-func (s iufService) RunStage(session *iuf.Session, stageToRun string) (ret iuf.SyncResponse, err error, skipStage bool) {
+func (s iufService) RunStage(ctx context.Context, session *iuf.Session, stageToRun string) (ret iuf.SyncResponse, err error, skipStage bool) {
+	ctx, span := startSessionSpan(ctx, "RunStage", session)
+	defer func() { utils.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("iuf.stage_to_run", stageToRun))
 	if stageToRun == "" {
 		// this session is done
 		s.logger.Infof("No stage specified to run. Last stage was %s and list of all stages are %v",
@@ -448,23 +632,45 @@ func (s iufService) RunStage(session *iuf.Session, stageToRun string) (ret iuf.S
 
 	session.CurrentStage = stageToRun
 	session.CurrentState = iuf.SessionStateInProgress
+	if !slices.Contains(session.CurrentStages, stageToRun) {
+		session.CurrentStages = append(session.CurrentStages, stageToRun)
+	}
+
+	// process-media is what validates the products, so only the stages after it are blocked by invalid products
+	if session.InputParameters.BlockOnInvalidProducts && stageToRun != "process-media" {
+		invalidProducts := s.getInvalidProducts(session)
+		if len(invalidProducts) > 0 {
+			s.logger.Warnf("RunStage.1: not running stage %s in session %s in activity %s, because products are not validated: %s",
+				stageToRun, session.Name, session.ActivityRef, s.describeInvalidProducts(invalidProducts))
+			session.CurrentState = iuf.SessionStateDebug
+			err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Blocked %s because products are not validated: %s", stageToRun, s.describeInvalidProducts(invalidProducts)))
+			return iuf.SyncResponse{}, err, false
+		}
+	}
 
-	workflow, err, skipStage := s.CreateIufWorkflow(session)
+	workflow, err, skipStage := s.CreateIufWorkflow(ctx, session)
 	if err != nil {
 		s.logger.Error(err)
 
 		session.CurrentState = iuf.SessionStateDebug
 		s.logger.Infof("Update session: %v", session)
-		err = s.UpdateSessionAndActivity(*session, fmt.Sprintf("Error in creating workflow %s", err))
+		err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Error in creating workflow %s", err))
 
 		return iuf.SyncResponse{}, err, skipStage
 	} else if !skipStage {
 		s.logger.Infof("workflow: %s has been created", workflow.Name)
 		session.Workflows = append(session.Workflows, iuf.SessionWorkflow{Id: workflow.Name})
+		s.publishEvent(iuf.Event{
+			Type:         iuf.EEventTypeStageStarted,
+			ActivityName: session.ActivityRef,
+			SessionName:  session.Name,
+			Stage:        stageToRun,
+			Workflow:     workflow.Name,
+		})
 	}
 
 	s.logger.Infof("Update session: %v", session)
-	err = s.UpdateSessionAndActivity(*session, fmt.Sprintf("Running %s", stageToRun))
+	err = s.UpdateSessionAndActivity(ctx, *session, fmt.Sprintf("Running %s", stageToRun))
 	if err != nil {
 		s.logger.Error(err)
 		return iuf.SyncResponse{}, err, skipStage
@@ -476,9 +682,30 @@ func (s iufService) RunStage(session *iuf.Session, stageToRun string) (ret iuf.S
 	return response, nil, skipStage
 }
 
-func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workflow) error {
+func (s iufService) ProcessOutput(ctx context.Context, session *iuf.Session, workflow *v1alpha1.Workflow) (err error) {
+	ctx, span := startSessionSpan(ctx, "ProcessOutput", session)
+	defer func() { utils.EndSpan(span, err) }()
+	span.SetAttributes(attribute.String("iuf.workflow", workflow.Name))
+	s.publishOperationEvents(session, workflow)
+	if !workflow.Status.StartedAt.IsZero() && !workflow.Status.FinishedAt.IsZero() {
+		utils.IufStageDuration.WithLabelValues(workflow.Labels["stage"], string(workflow.Status.Phase)).
+			Observe(workflow.Status.FinishedAt.Sub(workflow.Status.StartedAt.Time).Seconds())
+	}
+	if (workflow.Status.Phase == v1alpha1.WorkflowFailed || workflow.Status.Phase == v1alpha1.WorkflowError) &&
+		workflow.Labels[LABEL_PARTIAL_WORKFLOW] != "true" {
+		s.publishEvent(iuf.Event{
+			Type:         iuf.EEventTypeStageFinished,
+			ActivityName: session.ActivityRef,
+			SessionName:  session.Name,
+			Stage:        workflow.Labels["stage"],
+			Workflow:     workflow.Name,
+			Phase:        string(workflow.Status.Phase),
+			Comment:      workflow.Status.Message,
+		})
+	}
+
 	// get activity
-	activity, err := s.GetActivity(session.ActivityRef)
+	activity, err := s.GetActivity(ctx, session.ActivityRef)
 	if err != nil {
 		s.logger.Error(err)
 		return err
@@ -493,6 +720,7 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 
 		// now go through all the nodeStatus items
 		changed := false
+		var outputEvents []iuf.Event
 		for _, nodeStatus := range workflow.Status.Nodes {
 			if nodeStatus.Type == v1alpha1.NodeTypePod &&
 				strings.HasPrefix(nodeStatus.TemplateScope, "namespaced/") &&
@@ -510,6 +738,7 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 							s.logger.Infof("An error occurred while processing output for Activity %s, Operation %s, step %s with value %v: %v", activity.Name, operationName, stepName, nodeStatus.Outputs, err)
 						} else if stepChanged {
 							changed = true
+							outputEvents = append(outputEvents, s.newOutputEvent(session, workflow, &nodeStatus, operationName, stepName, productKey))
 						}
 					}
 
@@ -519,7 +748,10 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 		}
 
 		if changed {
-			_, err := s.updateActivity(activity)
+			_, err := s.updateActivity(ctx, activity)
+			if err == nil {
+				s.publishEvents(outputEvents)
+			}
 			return err
 		} else {
 			return nil
@@ -534,7 +766,7 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 			}
 			session.Products = activity.Products
 			// update activity
-			_, err = s.updateActivity(activity)
+			_, err = s.updateActivity(ctx, activity)
 			if err != nil {
 				s.logger.Error(err)
 				return err
@@ -542,6 +774,7 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 			return nil
 		} else {
 			changed := false
+			var outputEvents []iuf.Event
 			for _, nodeStatus := range workflow.Status.Nodes {
 				if nodeStatus.Type == v1alpha1.NodeTypePod &&
 					strings.HasPrefix(nodeStatus.TemplateScope, "namespaced/") &&
@@ -558,12 +791,16 @@ func (s iufService) ProcessOutput(session *iuf.Session, workflow *v1alpha1.Workf
 						s.logger.Infof("An error occurred while processing output for Activity %s, Operation %s, step %s with value %v: %v", activity.Name, operationName, stepName, nodeStatus.Outputs, err)
 					} else if stepChanged {
 						changed = true
+						outputEvents = append(outputEvents, s.newOutputEvent(session, workflow, &nodeStatus, operationName, stepName, ""))
 					}
 				}
 			}
 
 			if changed {
-				_, err := s.updateActivity(activity)
+				_, err := s.updateActivity(ctx, activity)
+				if err == nil {
+					s.publishEvents(outputEvents)
+				}
 				return err
 			} else {
 				return nil
@@ -600,6 +837,13 @@ func (s iufService) processOutputOfProcessMedia(activity *iuf.Activity, workflow
 	outputStage["products"] = map[string]interface{}{}
 	productsMap := outputStage["products"].(map[string]interface{})
 
+	// products are checked for compatibility with the iuf_version of their manifest only when the IUF version is known
+	iufVersion := ""
+	stages, err := s.GetStages()
+	if err == nil {
+		iufVersion = stages.Version
+	}
+
 	activity.Products = []iuf.Product{}
 	for _, nodeStatus := range nodesWithOutputs {
 		var manifest map[string]interface{}
@@ -612,13 +856,43 @@ func (s iufService) processOutputOfProcessMedia(activity *iuf.Activity, workflow
 			s.logger.Error(err)
 			return err
 		}
-		// validate iuf product manifest
+		originalLocation := ""
+		if nodeStatus.Outputs.Parameters[1].Value != nil {
+			originalLocation = nodeStatus.Outputs.Parameters[1].Value.String()
+		}
+		// validate iuf product manifest, against the content of the directory that the product was extracted to
 		data, _ := yaml.Marshal(manifest)
-		validated := true
-		err = iuf.Validate(data)
-		if err != nil {
-			s.logger.Error(err)
-			validated = false
+		var validationErrors []string
+		report := iuf.ValidateReport(data, originalLocation, iufVersion)
+		for _, issue := range report.Issues {
+			if issue.Severity == iuf.SeverityError {
+				s.logger.Errorf("processOutputOfProcessMedia: manifest of product %v %v is not valid: %v", manifest["name"], manifest["version"], issue)
+				validationErrors = append(validationErrors, issue.String())
+			} else {
+				s.logger.Warnf("processOutputOfProcessMedia: manifest of product %v %v: %v", manifest["name"], manifest["version"], issue)
+			}
+		}
+		// the checksums are verified once the products are extracted, in the background since hashing a product takes
+		//  longer than a sync may. The session waits at process-media until they are, see HasPendingChecksums.
+		var checksums *iuf.ChecksumResult
+		var validationWarnings []string
+		if workflow.Status.Phase == v1alpha1.WorkflowSucceeded && originalLocation != "" {
+			verification := s.checksums.verify(workflow.Name, originalLocation)
+			if !verification.finished() {
+				checksums = &iuf.ChecksumResult{Pending: true}
+			} else if verification.err != nil {
+				s.logger.Errorf("processOutputOfProcessMedia: checksums of product %v %v cannot be verified: %v", manifest["name"], manifest["version"], verification.err)
+				validationErrors = append(validationErrors, verification.err.Error())
+			} else if verification.result == nil {
+				s.logger.Warnf("processOutputOfProcessMedia: product %v %v has %s", manifest["name"], manifest["version"], NO_CHECKSUMS_FILE_WARNING)
+				validationWarnings = append(validationWarnings, NO_CHECKSUMS_FILE_WARNING)
+			} else {
+				checksums = verification.result
+				for _, mismatch := range checksums.Mismatches {
+					s.logger.Errorf("processOutputOfProcessMedia: product %v %v is not valid: %v", manifest["name"], manifest["version"], mismatch)
+					validationErrors = append(validationErrors, mismatch.String())
+				}
+			}
 		}
 		jsonManifest, _ := json.Marshal(manifest)
 		if manifest["name"] != nil && manifest["version"] != nil {
@@ -628,17 +902,20 @@ func (s iufService) processOutputOfProcessMedia(activity *iuf.Activity, workflow
 			s.logger.Infof("manifest: %s - %s", manifest["name"], manifest["version"])
 			// add product to activity object
 			activity.Products = append(activity.Products, iuf.Product{
-				Name:             fmt.Sprintf("%v", manifest["name"]),
-				Version:          productVersion,
-				Validated:        validated,
-				Manifest:         string(jsonManifest),
-				OriginalLocation: nodeStatus.Outputs.Parameters[1].Value.String(),
+				Name:               fmt.Sprintf("%v", manifest["name"]),
+				Version:            productVersion,
+				Validated:          len(validationErrors) == 0 && (checksums == nil || !checksums.Pending),
+				ValidationErrors:   validationErrors,
+				ValidationWarnings: validationWarnings,
+				Checksums:          checksums,
+				Manifest:           string(jsonManifest),
+				OriginalLocation:   originalLocation,
 			})
 			productKey := s.getProductVersionKeyFromNameAndVersion(manifest["name"].(string), manifest["version"].(string))
 
 			productsMap[fmt.Sprintf("%v", productKey)] = make(map[string]interface{})
 
-			productsMap[fmt.Sprintf("%v", productKey)].(map[string]interface{})["parent_directory"] = nodeStatus.Outputs.Parameters[1].Value.String()
+			productsMap[fmt.Sprintf("%v", productKey)].(map[string]interface{})["parent_directory"] = originalLocation
 		}
 	}
 
@@ -649,6 +926,31 @@ func (s iufService) processOutputOfProcessMedia(activity *iuf.Activity, workflow
 	return nil
 }
 
+// getInvalidProducts returns the products of the session that are not validated, leaving out the ones that were not
+//  selected to run.
+func (s iufService) getInvalidProducts(session *iuf.Session) []iuf.Product {
+	var res []iuf.Product
+	for _, product := range session.Products {
+		if !product.Validated && s.isProductSelected(session, product) {
+			res = append(res, product)
+		}
+	}
+	return res
+}
+
+// describeInvalidProducts lists the products with why they are not validated, for history comments and errors
+func (s iufService) describeInvalidProducts(products []iuf.Product) string {
+	var descriptions []string
+	for _, product := range products {
+		description := product.Name + " " + product.Version
+		if len(product.ValidationErrors) > 0 {
+			description += " (" + strings.Join(product.ValidationErrors, "; ") + ")"
+		}
+		descriptions = append(descriptions, description)
+	}
+	return strings.Join(descriptions, ", ")
+}
+
 func (s iufService) updateActivityOperationOutputFromWorkflow(
 	activity *iuf.Activity,
 	session *iuf.Session,
@@ -724,11 +1026,11 @@ func (s iufService) updateActivityOperationOutputFromWorkflow(
 	return changed, nil
 }
 
-func (s iufService) PauseSession(session *iuf.Session, comment string) error {
+func (s iufService) PauseSession(ctx context.Context, session *iuf.Session, comment string) error {
 	// first, set session and activity to paused state
 	session.CurrentState = iuf.SessionStatePaused
 
-	err := s.UpdateSessionAndActivity(*session, comment)
+	err := s.UpdateSessionAndActivity(ctx, *session, comment)
 	if err != nil {
 		s.logger.Errorf("PauseSession: An error(s) occurred while setting session %s to Paused: %v", session.Name, err)
 		return err
@@ -737,7 +1039,7 @@ func (s iufService) PauseSession(session *iuf.Session, comment string) error {
 	// now pause the workflows
 	var errors []error
 	for _, workflowRef := range session.Workflows {
-		_, err := s.workflowClient.SuspendWorkflow(context.TODO(), &workflow.WorkflowSuspendRequest{
+		_, err := s.workflowClient.SuspendWorkflow(ctx, &workflow.WorkflowSuspendRequest{
 			Name:      workflowRef.Id,
 			Namespace: "argo",
 		})
@@ -755,83 +1057,115 @@ func (s iufService) PauseSession(session *iuf.Session, comment string) error {
 	}
 }
 
-func (s iufService) ResumeSession(session *iuf.Session, comment string) error {
+func (s iufService) ResumeSession(ctx context.Context, session *iuf.Session, comment string) error {
 	var err error
-	lastWorkflowIndex := len(session.Workflows) - 1
-	if lastWorkflowIndex < 0 {
-		// how can there be no workflows for the current stage? Only explanation here
-		//  is that workflows were deleted either manually or through forced abort. In this case,
-		//  let's go rerun the current stage
-		err = utils.GenericError{Message: fmt.Sprintf("ResumeSession.1: There are no workflows to resume for session %s in activity %s: %v", session.Name, session.ActivityRef, err)}
-		s.logger.Warn(err)
-
-		return s.RestartCurrentStage(session, comment)
-	}
-
-	workflows := s.FindAllPartialWorkflowForCurrentStage(session)
-	if len(workflows) == 0 {
-		lastWorkflow := s.FindLastWorkflowForCurrentStage(session)
-		if lastWorkflow != nil {
-			workflows = append(workflows, lastWorkflow)
-		}
+	// sessions from before stages could run in parallel only track the current stage
+	s.migrateStageTracking(session)
+
+	currentStages := append([]string{}, session.CurrentStages...)
+	if len(currentStages) == 0 {
+		currentStages = []string{session.CurrentStage}
 	}
+	currentStage := session.CurrentStage
 
-	if len(workflows) == 0 {
-		err = utils.GenericError{Message: fmt.Sprintf("ResumeSession.2: There are no workflows to resume for session %s in activity %s: %v", session.Name, session.ActivityRef, err)}
-		s.logger.Warn(err)
+	var stagesToRestart []string
+	var stagesCompleted []string
 
-		return s.RestartCurrentStage(session, comment)
-	}
+	// the workflows are looked up for each of the stages that were running, since stages that do not depend on each
+	//  other run in parallel and each one may have failed, succeeded or still be running.
+	for _, stageName := range currentStages {
+		session.CurrentStage = stageName
 
-	allSuccessful := true
-	restartCurrentStage := false
+		workflows := s.FindAllPartialWorkflowForCurrentStage(ctx, session)
+		if len(workflows) == 0 {
+			lastWorkflow := s.FindLastWorkflowForCurrentStage(ctx, session)
+			if lastWorkflow != nil {
+				workflows = append(workflows, lastWorkflow)
+			}
+		}
 
-	// if there are some workflows, then let's attempt the last failed or error workflow that belongs to the current stage
-	for _, lastWorkflow := range workflows {
-		if lastWorkflow.Status.Phase == v1alpha1.WorkflowFailed ||
-			lastWorkflow.Status.Phase == v1alpha1.WorkflowError {
-			allSuccessful = false
+		if len(workflows) == 0 {
+			// how can there be no workflows for the current stage? Only explanation here
+			//  is that workflows were deleted either manually or through forced abort. In this case,
+			//  let's go rerun the current stage
+			err = utils.GenericError{Message: fmt.Sprintf("ResumeSession.1: There are no workflows to resume for stage %s in session %s in activity %s", stageName, session.Name, session.ActivityRef)}
+			s.logger.Warn(err)
 
-			// not successful? retry that error workflow
-			_, err = s.workflowClient.RetryWorkflow(context.TODO(), &workflow.WorkflowRetryRequest{
-				Name:              lastWorkflow.Name,
-				Namespace:         "argo",
-				RestartSuccessful: false,
-			})
+			stagesToRestart = append(stagesToRestart, stageName)
+			continue
+		}
 
-			// if there was an error with retrying, then let's resubmit
-			if err != nil {
-				s.logger.Errorf("ResumeSession.2: An error occurred while retrying workflow %s in session %s in activity %s: %v. Going to try resubmit instead", lastWorkflow.Name, session.Name, session.ActivityRef, err)
-				restartCurrentStage = true
-				break
+		allSuccessful := true
+		restartCurrentStage := false
 
-				//  Note: we used to have more code that would try to resubmit the workflow. This would create a new workflow.
-				//  However, with the introduction of partial workflows to split up large number of products,
-				//  this resubmitting workflows will mess up the determination if the stage has been successful or not,
-				//  since that relies upon aggregate status of all the workflows belonging to a stage.
+		// if there are some workflows, then let's attempt the last failed or error workflow that belongs to the current stage
+		for _, lastWorkflow := range workflows {
+			if lastWorkflow.Status.Phase != v1alpha1.WorkflowSucceeded {
+				allSuccessful = false
 			}
-		} else if lastWorkflow.Status.Phase == v1alpha1.WorkflowRunning {
-			// try resuming the workflow...if there is an error, that's ok, let it complete on its own
-			s.workflowClient.ResumeWorkflow(context.TODO(), &workflow.WorkflowResumeRequest{
-				Name:      lastWorkflow.Name,
-				Namespace: "argo",
-			})
-		} // other states are Pending, both for which we do nothing except update session and activity as below
-	}
 
-	if allSuccessful {
-		// umm...the last workflows were actually successful. We need to go to the next stage instead.
-		return s.GotoNextStage(session, comment)
+			if lastWorkflow.Status.Phase == v1alpha1.WorkflowFailed ||
+				lastWorkflow.Status.Phase == v1alpha1.WorkflowError {
+				// not successful? retry that error workflow, with a new token since the old one may have expired
+				err = s.ensureAuthTokenSecret(ctx, lastWorkflow)
+				if err != nil {
+					s.logger.Errorf("ResumeSession.3: unable to refresh the auth token secret of workflow %s in session %s in activity %s: %v. Going to try resubmit instead", lastWorkflow.Name, session.Name, session.ActivityRef, err)
+					restartCurrentStage = true
+					break
+				}
+				_, err = s.workflowClient.RetryWorkflow(ctx, &workflow.WorkflowRetryRequest{
+					Name:              lastWorkflow.Name,
+					Namespace:         "argo",
+					RestartSuccessful: false,
+				})
+
+				// if there was an error with retrying, then let's resubmit
+				if err != nil {
+					s.logger.Errorf("ResumeSession.2: An error occurred while retrying workflow %s in session %s in activity %s: %v. Going to try resubmit instead", lastWorkflow.Name, session.Name, session.ActivityRef, err)
+					restartCurrentStage = true
+					break
+
+					//  Note: we used to have more code that would try to resubmit the workflow. This would create a new workflow.
+					//  However, with the introduction of partial workflows to split up large number of products,
+					//  this resubmitting workflows will mess up the determination if the stage has been successful or not,
+					//  since that relies upon aggregate status of all the workflows belonging to a stage.
+				}
+			} else if lastWorkflow.Status.Phase == v1alpha1.WorkflowRunning {
+				// try resuming the workflow...if there is an error, that's ok, let it complete on its own
+				s.workflowClient.ResumeWorkflow(ctx, &workflow.WorkflowResumeRequest{
+					Name:      lastWorkflow.Name,
+					Namespace: "argo",
+				})
+			} // other states are Pending, both for which we do nothing except update session and activity as below
+		}
+
+		if restartCurrentStage {
+			stagesToRestart = append(stagesToRestart, stageName)
+		} else if allSuccessful {
+			// umm...the last workflows were actually successful. We need to go to the next stage instead.
+			stagesCompleted = append(stagesCompleted, stageName)
+		}
 	}
 
-	if restartCurrentStage {
-		return s.RestartCurrentStage(session, comment)
+	if len(stagesToRestart) > 0 || len(stagesCompleted) > 0 {
+		for _, stageName := range stagesCompleted {
+			s.completeStage(session, stageName)
+		}
+		for _, stageName := range stagesToRestart {
+			s.removeCurrentStage(session, stageName)
+		}
+
+		// set the current state to empty so that the Sync call to RunNextStage runs the stages that are now ready to run
+		session.CurrentStage = ""
+		session.CurrentState = ""
+		return s.UpdateSessionAndActivity(ctx, *session, comment)
 	}
 
 	// set session and activity to in progress state
+	session.CurrentStage = currentStage
 	session.CurrentState = iuf.SessionStateInProgress
 
-	err = s.UpdateSessionAndActivity(*session, comment)
+	err = s.UpdateSessionAndActivity(ctx, *session, comment)
 	if err != nil {
 		return err
 	}
@@ -839,7 +1173,7 @@ func (s iufService) ResumeSession(session *iuf.Session, comment string) error {
 	return nil
 }
 
-func (s iufService) FindLastWorkflowForCurrentStage(session *iuf.Session) *v1alpha1.Workflow {
+func (s iufService) FindLastWorkflowForCurrentStage(ctx context.Context, session *iuf.Session) *v1alpha1.Workflow {
 	if len(session.Workflows) == 0 {
 		return nil
 	}
@@ -848,7 +1182,7 @@ func (s iufService) FindLastWorkflowForCurrentStage(session *iuf.Session) *v1alp
 	for i := len(session.Workflows) - 1; i >= 0; i-- {
 		w := session.Workflows[i]
 
-		lastWorkflowObj, err := s.workflowClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{
+		lastWorkflowObj, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 			Name:      w.Id,
 			Namespace: "argo",
 		})
@@ -862,7 +1196,7 @@ func (s iufService) FindLastWorkflowForCurrentStage(session *iuf.Session) *v1alp
 	return lastWorkflow
 }
 
-func (s iufService) FindAllPartialWorkflowForCurrentStage(session *iuf.Session) []*v1alpha1.Workflow {
+func (s iufService) FindAllPartialWorkflowForCurrentStage(ctx context.Context, session *iuf.Session) []*v1alpha1.Workflow {
 	if len(session.Workflows) == 0 {
 		return nil
 	}
@@ -871,7 +1205,7 @@ func (s iufService) FindAllPartialWorkflowForCurrentStage(session *iuf.Session)
 	for i := len(session.Workflows) - 1; i >= 0; i-- {
 		w := session.Workflows[i]
 
-		lastWorkflowObj, err := s.workflowClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{
+		lastWorkflowObj, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 			Name:      w.Id,
 			Namespace: "argo",
 		})
@@ -886,9 +1220,11 @@ func (s iufService) FindAllPartialWorkflowForCurrentStage(session *iuf.Session)
 	return workflows
 }
 
-func (s iufService) GotoNextStage(session *iuf.Session, comment string) error {
+func (s iufService) GotoNextStage(ctx context.Context, session *iuf.Session, comment string) error {
+	s.completeStage(session, session.CurrentStage)
+	session.CurrentStage = ""
 	session.CurrentState = ""
-	err := s.UpdateSessionAndActivity(*session, comment)
+	err := s.UpdateSessionAndActivity(ctx, *session, comment)
 	if err != nil {
 		return err
 	}
@@ -896,7 +1232,7 @@ func (s iufService) GotoNextStage(session *iuf.Session, comment string) error {
 	return nil
 }
 
-func (s iufService) RestartCurrentStage(session *iuf.Session, comment string) error {
+func (s iufService) RestartCurrentStage(ctx context.Context, session *iuf.Session, comment string) error {
 	if len(session.InputParameters.Stages) == 0 {
 		// should have never happened. This is just a bad request.
 		err := utils.GenericError{Message: fmt.Sprintf("RestartCurrentStage.1: There are no stages to resume for session %s in activity %s", session.Name, session.ActivityRef)}
@@ -904,33 +1240,13 @@ func (s iufService) RestartCurrentStage(session *iuf.Session, comment string) er
 		return err
 	}
 
-	if session.CurrentStage == "" || session.CurrentStage == session.InputParameters.Stages[0] {
-		// if we are still on the current stage, then restart
-		session.CurrentState = ""
-		session.CurrentStage = ""
-		err := s.UpdateSessionAndActivity(*session, comment)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
-
-	// set the current state to empty so that it can get picked up by the Sync call to RunNextStage.
+	// take the stage out of the running stages, and set the current state to empty so that it gets picked up again by
+	//  the Sync call to RunNextStage, along with any other stage that is ready to run.
+	s.removeCurrentStage(session, session.CurrentStage)
+	session.CurrentStage = ""
 	session.CurrentState = ""
 
-	// find the previous stage
-	session.CurrentStage = session.InputParameters.Stages[0]
-
-	for i := 1; i < len(session.InputParameters.Stages); i++ {
-		if session.CurrentStage == session.InputParameters.Stages[i] {
-			break
-		} else {
-			session.CurrentStage = session.InputParameters.Stages[i]
-		}
-	}
-
-	err := s.UpdateSessionAndActivity(*session, comment)
+	err := s.UpdateSessionAndActivity(ctx, *session, comment)
 	if err != nil {
 		return err
 	}
@@ -938,11 +1254,11 @@ func (s iufService) RestartCurrentStage(session *iuf.Session, comment string) er
 	return nil
 }
 
-func (s iufService) AbortSession(session *iuf.Session, comment string, force bool, workflowList *v1alpha1.WorkflowList) error {
+func (s iufService) AbortSession(ctx context.Context, session *iuf.Session, comment string, force bool, workflowList *v1alpha1.WorkflowList) error {
 	// first, set session and activity to aborted state
 	session.CurrentState = iuf.SessionStateAborted
 
-	err := s.UpdateSessionAndActivity(*session, comment)
+	err := s.UpdateSessionAndActivity(ctx, *session, comment)
 	if err != nil {
 		s.logger.Errorf("AbortSession: An error(s) occurred while setting session %s to aborted: %v", session.Name, err)
 		return err
@@ -957,14 +1273,14 @@ func (s iufService) AbortSession(session *iuf.Session, comment string, force boo
 	var errors []error
 	var workflowIDsToCheck []string
 	for _, workflowObj := range workflowList.Items {
-		_, err := s.workflowClient.TerminateWorkflow(context.TODO(), &workflow.WorkflowTerminateRequest{
+		_, err := s.workflowClient.TerminateWorkflow(ctx, &workflow.WorkflowTerminateRequest{
 			Name:      workflowObj.Name,
 			Namespace: "argo",
 		})
 
 		if err != nil {
 			// delete the workflow right away.
-			_, err := s.workflowClient.DeleteWorkflow(context.TODO(), &workflow.WorkflowDeleteRequest{
+			_, err := s.workflowClient.DeleteWorkflow(ctx, &workflow.WorkflowDeleteRequest{
 				Name:      workflowObj.Name,
 				Namespace: "argo",
 			})
@@ -980,7 +1296,7 @@ func (s iufService) AbortSession(session *iuf.Session, comment string, force boo
 	// do a check again before going for a more aggressive delete workflow option.
 	terminatedAll := true
 	for _, workflowToCheckId := range workflowIDsToCheck {
-		workflowToCheck, err := s.workflowClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{
+		workflowToCheck, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 			Name:      workflowToCheckId,
 			Namespace: "argo",
 			Fields:    "status.phase",
@@ -999,7 +1315,7 @@ func (s iufService) AbortSession(session *iuf.Session, comment string, force boo
 	time.Sleep(30 * time.Second)
 
 	for _, workflowToCheckId := range workflowIDsToCheck {
-		workflowToCheck, err := s.workflowClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{
+		workflowToCheck, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 			Name:      workflowToCheckId,
 			Namespace: "argo",
 			Fields:    "status.phase",
@@ -1007,7 +1323,7 @@ func (s iufService) AbortSession(session *iuf.Session, comment string, force boo
 
 		if err != nil || workflowToCheck.Status.Phase == v1alpha1.WorkflowPending || workflowToCheck.Status.Phase == v1alpha1.WorkflowRunning {
 			// good candidate to nuke the workflow.
-			_, err := s.workflowClient.DeleteWorkflow(context.TODO(), &workflow.WorkflowDeleteRequest{
+			_, err := s.workflowClient.DeleteWorkflow(ctx, &workflow.WorkflowDeleteRequest{
 				Name:      workflowToCheckId,
 				Namespace: "argo",
 			})
@@ -1028,8 +1344,10 @@ func (s iufService) AbortSession(session *iuf.Session, comment string, force boo
 	}
 }
 
-func (s iufService) SyncWorkflowsToSession(session *iuf.Session) error {
-	workflows, err := s.workflowClient.ListWorkflows(context.TODO(), &workflow.WorkflowListRequest{
+func (s iufService) SyncWorkflowsToSession(ctx context.Context, session *iuf.Session) (err error) {
+	ctx, span := startSessionSpan(ctx, "SyncWorkflowsToSession", session)
+	defer func() { utils.EndSpan(span, err) }()
+	workflows, err := s.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
 		Namespace: "argo",
 		ListOptions: &v1.ListOptions{
 			LabelSelector: fmt.Sprintf("session=%s,iuf=true", session.Name),
@@ -1063,7 +1381,7 @@ func (s iufService) SyncWorkflowsToSession(session *iuf.Session) error {
 		}
 
 		// try to update the session and ignore errors because this is meant to be eventually persistent
-		s.UpdateSession(*session)
+		s.UpdateSession(ctx, *session)
 	}
 
 	return nil
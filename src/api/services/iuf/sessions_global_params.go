@@ -26,6 +26,7 @@
 package services_iuf
 
 import (
+	"context"
 	_ "embed"
 	"encoding/json"
 	"path"
@@ -37,12 +38,12 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-func (s iufService) getGlobalParams(session iuf.Session, in_product iuf.Product, stages iuf.Stages) map[string]interface{} {
+func (s iufService) getGlobalParams(ctx context.Context, session iuf.Session, in_product iuf.Product, stages iuf.Stages) map[string]interface{} {
 	return map[string]interface{}{
 		"product_manifest": s.getGlobalParamsProductManifest(session, in_product),
 		"input_params":     s.getGlobalParamsInputParams(session, in_product),
 		"site_params":      s.getGlobalParamsSiteParams(session, in_product, stages),
-		"stage_params":     s.getGlobalParamsStageParams(session, in_product, stages),
+		"stage_params":     s.getGlobalParamsStageParams(ctx, session, in_product, stages),
 	}
 }
 
@@ -121,9 +122,9 @@ func (s iufService) getGlobalParamsInputParams(session iuf.Session, in_product i
 	}
 }
 
-func (s iufService) getGlobalParamsStageParams(session iuf.Session, in_product iuf.Product, stages iuf.Stages) map[string]interface{} {
+func (s iufService) getGlobalParamsStageParams(ctx context.Context, session iuf.Session, in_product iuf.Product, stages iuf.Stages) map[string]interface{} {
 	res := make(map[string]interface{})
-	activity, _ := s.GetActivity(session.ActivityRef)
+	activity, _ := s.GetActivity(ctx, session.ActivityRef)
 	if activity.OperationOutputs == nil || activity.OperationOutputs["stage_params"] == nil {
 		return map[string]interface{}{}
 	}
@@ -29,14 +29,11 @@
 package services_iuf
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	"github.com/oliveagle/jsonpath"
 	"path/filepath"
-	"sigs.k8s.io/yaml"
 	"strings"
 )
 
@@ -44,7 +41,7 @@ import (
 func (s iufService) getProductHookTasks(session iuf.Session, stage iuf.Stage, stages iuf.Stages,
 	prevStepsCompleted map[string]map[string]string,
 	allTemplatesByName map[string]bool,
-	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthToken string) (preSteps map[string]v1alpha1.DAGTask,
+	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthTokenSecret string) (preSteps map[string]v1alpha1.DAGTask,
 	postSteps map[string]v1alpha1.DAGTask) {
 
 	if stage.NoHooks {
@@ -63,7 +60,7 @@ func (s iufService) getProductHookTasks(session iuf.Session, stage iuf.Stage, st
 	for productKey, productHooks := range hooks {
 		hook := productHooks.PreHook
 		if hook.ScriptPath != "" {
-			task, err := s.createHookDAGTask(true, hook, productKey, session, stage, prevStepsCompleted, stages.Hooks, allTemplatesByName, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthToken)
+			task, err := s.createHookDAGTask(true, hook, productKey, session, stage, prevStepsCompleted, stages.Hooks, allTemplatesByName, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthTokenSecret)
 			if err == nil {
 				if task.Name != "" { // empty name means we are skipping this task
 					preSteps[productKey] = task
@@ -75,7 +72,7 @@ func (s iufService) getProductHookTasks(session iuf.Session, stage iuf.Stage, st
 
 		hook = productHooks.PostHook
 		if hook.ScriptPath != "" {
-			task, err := s.createHookDAGTask(false, hook, productKey, session, stage, prevStepsCompleted, stages.Hooks, allTemplatesByName, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthToken)
+			task, err := s.createHookDAGTask(false, hook, productKey, session, stage, prevStepsCompleted, stages.Hooks, allTemplatesByName, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthTokenSecret)
 			if err == nil {
 				if task.Name != "" { // empty name means we are skipping this task
 					postSteps[productKey] = task
@@ -92,14 +89,22 @@ func (s iufService) getProductHookTasks(session iuf.Session, stage iuf.Stage, st
 // Returns a map of the name of the product vs its hooks for the given stage
 func (s iufService) getProductHooks(session iuf.Session, stage iuf.Stage) map[string]iuf.ManifestStageHooks {
 	ret := map[string]iuf.ManifestStageHooks{}
+	if len(session.InputParameters.Operations) > 0 {
+		// hooks are not run when only a subset of operations was asked for
+		return ret
+	}
 	for _, product := range session.Products {
-		manifest, err := s.getProductManifestAsInterface(product)
+		if !s.isProductSelected(&session, product) {
+			continue
+		}
+		manifest, err := s.getProductManifest(product)
 		if err != nil {
+			s.logger.Errorf("getProductHooks: hooks in stage %s are not run: %v", stage.Name, err)
 			continue
 		}
 
-		preHook := s.extractPathAndExecutionContext(stage.Name, &manifest, true)
-		postHook := s.extractPathAndExecutionContext(stage.Name, &manifest, false)
+		preHook := s.extractPathAndExecutionContext(stage.Name, manifest, true)
+		postHook := s.extractPathAndExecutionContext(stage.Name, manifest, false)
 
 		if preHook.ScriptPath != "" || postHook.ScriptPath != "" {
 			ret[s.getProductVersionKey(product)] = iuf.ManifestStageHooks{
@@ -113,30 +118,24 @@ func (s iufService) getProductHooks(session iuf.Session, stage iuf.Stage) map[st
 }
 
 // Returns ManifestHookScript for the given stageName and parsed IUF manifest
-func (s iufService) extractPathAndExecutionContext(stageName string, manifest *interface{}, pre bool) iuf.ManifestHookScript {
+func (s iufService) extractPathAndExecutionContext(stageName string, manifest iuf.Manifest, pre bool) iuf.ManifestHookScript {
 	stageName = strings.Replace(stageName, "-", "_", -1)
 
-	preOrPost := "pre"
+	hooks := manifest.Hooks[stageName]
+	script := hooks.Pre
 	if !pre {
-		preOrPost = "post"
+		script = hooks.Post
+	}
+	if script == nil || script.ScriptPath == "" {
+		return iuf.ManifestHookScript{}
 	}
 
 	ret := iuf.ManifestHookScript{
-		ScriptPath:       "",
+		ScriptPath:       script.ScriptPath,
 		ExecutionContext: "master_host",
 	}
-
-	jsonPath := fmt.Sprintf("$.hooks.%s.%s.script_path", stageName, preOrPost)
-	scriptPathInterface, err := jsonpath.JsonPathLookup(*manifest, jsonPath)
-	if err != nil || scriptPathInterface == nil {
-		return iuf.ManifestHookScript{}
-	}
-	ret.ScriptPath = fmt.Sprintf("%s", scriptPathInterface)
-
-	jsonPath = fmt.Sprintf("$.hooks.%s.%s.execution_context", stageName, preOrPost)
-	executionContextInterface, err := jsonpath.JsonPathLookup(*manifest, jsonPath)
-	if err == nil && executionContextInterface != nil {
-		ret.ExecutionContext = fmt.Sprintf("%s", executionContextInterface)
+	if script.ExecutionContext != "" {
+		ret.ExecutionContext = script.ExecutionContext
 	}
 
 	return ret
@@ -146,7 +145,7 @@ func (s iufService) extractPathAndExecutionContext(stageName string, manifest *i
 func (s iufService) createHookDAGTask(pre bool, hook iuf.ManifestHookScript, productKey string, session iuf.Session, stage iuf.Stage,
 	prevStepsCompleted map[string]map[string]string,
 	hookTemplateMap map[string]string, allTemplatesByName map[string]bool,
-	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthToken string) (v1alpha1.DAGTask, error) {
+	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthTokenSecret string) (v1alpha1.DAGTask, error) {
 
 	// find the original location
 	var originalLocation string
@@ -201,8 +200,8 @@ func (s iufService) createHookDAGTask(pre bool, hook iuf.ManifestHookScript, pro
 	task.Arguments = v1alpha1.Arguments{
 		Parameters: []v1alpha1.Parameter{
 			{
-				Name:  "auth_token",
-				Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthToken)),
+				Name:  WORKFLOW_PARAM_AUTH_TOKEN_SECRET,
+				Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthTokenSecret)),
 			},
 			{
 				Name:  "global_params",
@@ -218,18 +217,11 @@ func (s iufService) createHookDAGTask(pre bool, hook iuf.ManifestHookScript, pro
 	return task, nil
 }
 
-// Gets the product manifest as an interface
-func (s iufService) getProductManifestAsInterface(product iuf.Product) (i interface{}, e error) {
-	jsonBytes, err := yaml.YAMLToJSON([]byte(product.Manifest))
-	if err != nil {
-		return i, err
-	}
-
-	var manifest interface{}
-	err = json.Unmarshal([]byte(jsonBytes), &manifest)
+// Gets the product manifest in the types of its schema
+func (s iufService) getProductManifest(product iuf.Product) (iuf.Manifest, error) {
+	manifest, err := iuf.ParseManifest([]byte(product.Manifest))
 	if err != nil {
-		return i, err
+		return iuf.Manifest{}, fmt.Errorf("manifest of product %s cannot be read: %v", s.getProductVersionKey(product), err)
 	}
-
 	return manifest, nil
 }
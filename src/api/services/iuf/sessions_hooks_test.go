@@ -54,7 +54,7 @@ func TestGetProductHookTasks(t *testing.T) {
 		"sdu-3-4-5": "sdu-3-4-5",
 	}
 
-	authToken := "auth_token"
+	authToken := "auth_token_secret"
 
 	// we only want to check the length of the preSteps and postSteps.
 	// The actual validation of these is done in individual unit tests below.
@@ -208,58 +208,61 @@ func TestExtractPathAndExecutionContext(t *testing.T) {
 		logger: utils.GetLogger(),
 	}
 
-	cosProductManifest, _ := iufService.getProductManifestAsInterface(iuf.Product{
+	cosProductManifest, err := iufService.getProductManifest(iuf.Product{
 		Manifest: cosManifest, Name: "cos",
 	})
-	incorrectSchemaProduct, _ := iufService.getProductManifestAsInterface(iuf.Product{
+	assert.NoError(t, err)
+	incorrectSchemaProduct, err := iufService.getProductManifest(iuf.Product{
 		Manifest: incorrectSchemaManifest, Name: "incorrectSchema",
 	})
-	incorrectYamlSyntaxProduct, _ := iufService.getProductManifestAsInterface(iuf.Product{
+	assert.Error(t, err)
+	incorrectYamlSyntaxProduct, err := iufService.getProductManifest(iuf.Product{
 		Manifest: incorrectYamlSyntaxManifest, Name: "incorrectYamlSyntax",
 	})
+	assert.Error(t, err)
 
 	t.Run("extractPathAndExecutionContext can correctly parse pre and post hook stages", func(t *testing.T) {
-		preHookScript := iufService.extractPathAndExecutionContext("pre-install-check", &cosProductManifest, true)
+		preHookScript := iufService.extractPathAndExecutionContext("pre-install-check", cosProductManifest, true)
 		assert.Equal(t, "hooks/pre-pre-install-check.sh", preHookScript.ScriptPath)
 		assert.Equal(t, "master_host", preHookScript.ExecutionContext)
 
-		postHookScript := iufService.extractPathAndExecutionContext("pre-install-check", &cosProductManifest, false)
+		postHookScript := iufService.extractPathAndExecutionContext("pre-install-check", cosProductManifest, false)
 		assert.Equal(t, "hooks/post-pre-install-check.sh", postHookScript.ScriptPath)
 		assert.Equal(t, "worker_host", postHookScript.ExecutionContext)
 
-		preHookScript = iufService.extractPathAndExecutionContext("deliver-product", &cosProductManifest, true)
+		preHookScript = iufService.extractPathAndExecutionContext("deliver-product", cosProductManifest, true)
 		assert.Equal(t, "", preHookScript.ScriptPath)
 		assert.Equal(t, "", preHookScript.ExecutionContext)
 
-		postHookScript = iufService.extractPathAndExecutionContext("deliver-product", &cosProductManifest, false)
+		postHookScript = iufService.extractPathAndExecutionContext("deliver-product", cosProductManifest, false)
 		assert.Equal(t, "hooks/post-deliver-product.sh", postHookScript.ScriptPath)
 		assert.Equal(t, "storage_host", postHookScript.ExecutionContext)
 
-		preHookScript = iufService.extractPathAndExecutionContext("non_existent_stage", &cosProductManifest, true)
+		preHookScript = iufService.extractPathAndExecutionContext("non_existent_stage", cosProductManifest, true)
 		assert.Equal(t, "", preHookScript.ScriptPath)
 		assert.Equal(t, "", preHookScript.ExecutionContext)
 
-		postHookScript = iufService.extractPathAndExecutionContext("non_existent_stage", &cosProductManifest, false)
+		postHookScript = iufService.extractPathAndExecutionContext("non_existent_stage", cosProductManifest, false)
 		assert.Equal(t, "", postHookScript.ScriptPath)
 		assert.Equal(t, "", postHookScript.ExecutionContext)
 	})
 
 	t.Run("extractPathAndExecutionContext can correctly ignore manifests with invalid schemas", func(t *testing.T) {
-		preHookScript := iufService.extractPathAndExecutionContext("deliver-product", &incorrectSchemaProduct, true)
+		preHookScript := iufService.extractPathAndExecutionContext("deliver-product", incorrectSchemaProduct, true)
 		assert.Equal(t, "", preHookScript.ScriptPath)
 		assert.Equal(t, "", preHookScript.ExecutionContext)
 
-		postHookScript := iufService.extractPathAndExecutionContext("deliver-product", &incorrectSchemaProduct, false)
+		postHookScript := iufService.extractPathAndExecutionContext("deliver-product", incorrectSchemaProduct, false)
 		assert.Equal(t, "", postHookScript.ScriptPath)
 		assert.Equal(t, "", postHookScript.ExecutionContext)
 	})
 
 	t.Run("extractPathAndExecutionContext can correctly ignore manifests with bad YAML syntax", func(t *testing.T) {
-		preHookScript := iufService.extractPathAndExecutionContext("deliver-product", &incorrectYamlSyntaxProduct, true)
+		preHookScript := iufService.extractPathAndExecutionContext("deliver-product", incorrectYamlSyntaxProduct, true)
 		assert.Equal(t, "", preHookScript.ScriptPath)
 		assert.Equal(t, "", preHookScript.ExecutionContext)
 
-		postHookScript := iufService.extractPathAndExecutionContext("deliver-product", &incorrectYamlSyntaxProduct, false)
+		postHookScript := iufService.extractPathAndExecutionContext("deliver-product", incorrectYamlSyntaxProduct, false)
 		assert.Equal(t, "", postHookScript.ScriptPath)
 		assert.Equal(t, "", postHookScript.ExecutionContext)
 	})
@@ -275,8 +278,8 @@ func TestCreateHookDAGTask(t *testing.T) {
 		"sdu-3-4-5": "sdu-3-4-5",
 	}
 
-	authTokenName := "auth_token"
-	authTokenValue := "{{workflow.parameters.auth_token}}"
+	authTokenName := "auth_token_secret"
+	authTokenValue := "{{workflow.parameters.auth_token_secret}}"
 
 	stage := iuf.Stage{
 		Name: "deliver-product",
@@ -303,7 +306,7 @@ func TestCreateHookDAGTask(t *testing.T) {
 		assert.True(t, strings.HasPrefix(task.Name, fmt.Sprintf("cos-1-2-3-pre-hook-%s", stage.Name)))
 		assert.Equal(t, "master-host-hook-script", task.TemplateRef.Name)
 		assert.Equal(t, "main", task.TemplateRef.Template)
-		assert.Equal(t, v1alpha1.AnyStringPtr(authTokenValue), task.Arguments.GetParameterByName("auth_token").Value)
+		assert.Equal(t, v1alpha1.AnyStringPtr(authTokenValue), task.Arguments.GetParameterByName("auth_token_secret").Value)
 		assert.Equal(t, v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", globalParamsPerProduct["cos-1-2-3"])), task.Arguments.GetParameterByName("global_params").Value)
 		assert.Equal(t, v1alpha1.AnyStringPtr(filepath.Join(cosOriginalLocation, "/something/something/something/darkside")), task.Arguments.GetParameterByName("script_path").Value)
 	})
@@ -318,7 +321,7 @@ func TestCreateHookDAGTask(t *testing.T) {
 		assert.True(t, strings.HasPrefix(task.Name, fmt.Sprintf("cos-1-2-3-post-hook-%s", stage.Name)))
 		assert.Equal(t, "worker-host-hook-script", task.TemplateRef.Name)
 		assert.Equal(t, "main", task.TemplateRef.Template)
-		assert.Equal(t, v1alpha1.AnyStringPtr(authTokenValue), task.Arguments.GetParameterByName("auth_token").Value)
+		assert.Equal(t, v1alpha1.AnyStringPtr(authTokenValue), task.Arguments.GetParameterByName("auth_token_secret").Value)
 		assert.Equal(t, v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", globalParamsPerProduct["cos-1-2-3"])), task.Arguments.GetParameterByName("global_params").Value)
 		assert.Equal(t, v1alpha1.AnyStringPtr(filepath.Join(cosOriginalLocation, "/something/something/something/darkside")), task.Arguments.GetParameterByName("script_path").Value)
 	})
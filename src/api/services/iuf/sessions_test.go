@@ -25,21 +25,28 @@
 package services_iuf
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"regexp"
+	"strings"
 	"testing"
 
 	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
 	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
 	workflowmocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow/mocks"
 	workflowtemplatemocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate/mocks"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	fake "k8s.io/client-go/kubernetes/fake"
@@ -51,7 +58,7 @@ func TestCreateIufWorkflow(t *testing.T) {
 	defer ctrl.Finish()
 	mockTokenValue := "mock_token"
 	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
-	keycloakServiceMock.EXPECT().NewKeycloakAccessToken().Return(mockTokenValue, nil).AnyTimes()
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return(mockTokenValue, nil).AnyTimes()
 
 	t.Run("It can create a new iuf workflow", func(t *testing.T) {
 		// setup mocks
@@ -81,7 +88,7 @@ func TestCreateIufWorkflow(t *testing.T) {
 			k8sRestClientSet:       fakeClient,
 			env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./_test_data_"},
 		}
-		_, err, _ := workflowSvc.CreateIufWorkflow(&iuf.Session{
+		_, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{
 			CurrentStage: "process-media",
 			InputParameters: iuf.InputParameters{
 				Stages: []string{"process-media"},
@@ -92,6 +99,105 @@ func TestCreateIufWorkflow(t *testing.T) {
 		// this is tested in the render package
 		assert.Nil(t, err)
 	})
+	t.Run("It keeps the auth token in a secret owned by the workflow", func(t *testing.T) {
+		activityName, _, workflowSvc := setup(t)
+		wfServiceClientMock := workflowSvc.workflowClient.(*workflowmocks.WorkflowServiceClient)
+		wfServiceClientMock.On(
+			"CreateWorkflow",
+			mock.Anything,
+			mock.Anything,
+		).Return(&v1alpha1.Workflow{ObjectMeta: metav1.ObjectMeta{Name: "session-management-nodes-rollout-abcde", UID: "1234"}}, nil)
+		executed := utils.IufOperationsTotal.WithLabelValues("management-nodes-rollout", utils.METRICS_OPERATION_EXECUTED)
+		executedBefore := testutil.ToFloat64(executed)
+
+		_, err, skipStage := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{
+			Name:            "session",
+			ActivityRef:     activityName,
+			Products:        []iuf.Product{{Name: "product_A"}},
+			CurrentStage:    "management-nodes-rollout",
+			InputParameters: iuf.InputParameters{Stages: []string{"management-nodes-rollout"}, LimitManagementNodes: []string{"ncn-m001"}},
+		})
+		assert.NoError(t, err)
+		assert.False(t, skipStage)
+		// the operations are counted once the workflow is submitted
+		assert.Equal(t, executedBefore+1, testutil.ToFloat64(executed))
+
+		createdWorkflow := wfServiceClientMock.Calls[len(wfServiceClientMock.Calls)-1].Arguments.Get(1).(*workflow.WorkflowCreateRequest).Workflow
+		// the token is only in the Secret, never in the workflow
+		workflowJson, err := json.Marshal(createdWorkflow)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(workflowJson), "mock_token")
+		assert.Nil(t, createdWorkflow.Spec.Arguments.GetParameterByName("auth_token"))
+		secretName := createdWorkflow.Labels[LABEL_AUTH_TOKEN_SECRET]
+		secret, err := workflowSvc.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Get(context.TODO(), secretName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "mock_token", string(secret.Data[AUTH_TOKEN_SECRET_KEY]))
+		assert.Equal(t, 1, len(secret.OwnerReferences))
+		assert.Equal(t, "session-management-nodes-rollout-abcde", secret.OwnerReferences[0].Name)
+	})
+	t.Run("It does not create the workflow again when Argo created it although the call timed out", func(t *testing.T) {
+		activityName, _, workflowSvc := setup(t)
+		wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+		wfServiceClientMock.On(
+			"CreateWorkflow",
+			mock.Anything,
+			mock.Anything,
+		).Return(nil, status.Error(codes.DeadlineExceeded, "context deadline exceeded"))
+		wfServiceClientMock.On(
+			"ListWorkflows",
+			mock.Anything,
+			mock.MatchedBy(func(req *workflow.WorkflowListRequest) bool {
+				return strings.HasPrefix(req.ListOptions.LabelSelector, LABEL_AUTH_TOKEN_SECRET+"=")
+			}),
+		).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{{ObjectMeta: metav1.ObjectMeta{Name: "session-management-nodes-rollout-abcde", UID: "1234"}}}}, nil)
+		wfServiceClientMock.On(
+			"ListWorkflows",
+			mock.Anything,
+			mock.Anything,
+		).Return(new(v1alpha1.WorkflowList), nil)
+		workflowSvc.workflowClient = wfServiceClientMock
+
+		res, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{
+			Name:            "session",
+			ActivityRef:     activityName,
+			Products:        []iuf.Product{{Name: "product_A"}},
+			CurrentStage:    "management-nodes-rollout",
+			InputParameters: iuf.InputParameters{Stages: []string{"management-nodes-rollout"}, LimitManagementNodes: []string{"ncn-m001"}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "session-management-nodes-rollout-abcde", res.Name)
+		wfServiceClientMock.AssertNumberOfCalls(t, "CreateWorkflow", 1)
+
+		secrets, err := workflowSvc.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).List(context.TODO(), metav1.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(secrets.Items))
+		assert.Equal(t, "session-management-nodes-rollout-abcde", secrets.Items[0].OwnerReferences[0].Name)
+	})
+	t.Run("It deletes the auth token secret when the workflow cannot be created", func(t *testing.T) {
+		activityName, _, workflowSvc := setup(t)
+		wfServiceClientMock := workflowSvc.workflowClient.(*workflowmocks.WorkflowServiceClient)
+		wfServiceClientMock.On(
+			"CreateWorkflow",
+			mock.Anything,
+			mock.Anything,
+		).Return(nil, errors.New("argo is down"))
+		executed := utils.IufOperationsTotal.WithLabelValues("management-nodes-rollout", utils.METRICS_OPERATION_EXECUTED)
+		executedBefore := testutil.ToFloat64(executed)
+
+		_, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{
+			Name:            "session",
+			ActivityRef:     activityName,
+			Products:        []iuf.Product{{Name: "product_A"}},
+			CurrentStage:    "management-nodes-rollout",
+			InputParameters: iuf.InputParameters{Stages: []string{"management-nodes-rollout"}, LimitManagementNodes: []string{"ncn-m001"}},
+		})
+		assert.Error(t, err)
+		assert.Equal(t, executedBefore, testutil.ToFloat64(executed))
+
+		secrets, err := workflowSvc.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).List(context.TODO(), metav1.ListOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(secrets.Items))
+	})
 	t.Run("It should not create a new iuf workflow with wrong stages.yaml", func(t *testing.T) {
 		// setup mocks
 		wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
@@ -115,7 +221,7 @@ func TestCreateIufWorkflow(t *testing.T) {
 			k8sRestClientSet:       fakeClient,
 			env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./_test_data_"},
 		}
-		_, err, _ := workflowSvc.CreateIufWorkflow(&iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"unsupported_stage"}}})
+		_, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"unsupported_stage"}}})
 
 		// we don't actually test the template render/upload
 		// this is tested in the render package
@@ -144,7 +250,7 @@ func TestCreateIufWorkflow(t *testing.T) {
 			k8sRestClientSet:       fakeClient,
 			env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./nowhere_to_be_found"},
 		}
-		_, err, _ := workflowSvc.CreateIufWorkflow(&iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"process-media"}}})
+		_, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"process-media"}}})
 
 		// we don't actually test the template render/upload
 		// this is tested in the render package
@@ -172,7 +278,7 @@ func TestCreateIufWorkflow(t *testing.T) {
 			keycloakService:        keycloakServiceMock,
 			env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./_test_data_"},
 		}
-		_, err, _ := workflowSvc.CreateIufWorkflow(&iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"break_it"}}})
+		_, err, _ := workflowSvc.CreateIufWorkflow(context.TODO(), &iuf.Session{InputParameters: iuf.InputParameters{Stages: []string{"break_it"}}})
 
 		// we don't actually test the template render/upload
 		// this is tested in the render package
@@ -220,7 +326,7 @@ func TestRunNextStage(t *testing.T) {
 
 	mockTokenValue := "mock_token"
 	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
-	keycloakServiceMock.EXPECT().NewKeycloakAccessToken().Return(mockTokenValue, nil).AnyTimes()
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return(mockTokenValue, nil).AnyTimes()
 
 	fakeClient := fake.NewSimpleClientset()
 	workflowSvc := iufService{
@@ -231,7 +337,7 @@ func TestRunNextStage(t *testing.T) {
 		keycloakService:        keycloakServiceMock,
 		env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./_test_data_"},
 	}
-	activity, err := workflowSvc.CreateActivity(iuf.CreateActivityRequest{
+	activity, err := workflowSvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{
 		Name:          "test",
 		ActivityState: iuf.ActivityStateWaitForAdmin,
 	})
@@ -296,6 +402,51 @@ func TestRunNextStage(t *testing.T) {
 				sessionStage: "deliver-product",
 			},
 		},
+		{
+			name: "block the next stage when products are not validated",
+			session: iuf.Session{
+				CurrentStage: "process-media",
+				ActivityRef:  activity.Name,
+				Products: []iuf.Product{
+					{Name: "cos", Version: "1.2.3", Validated: true},
+					{Name: "sdu", Version: "2.3.4", ValidationErrors: []string{"failed to validate IUF Product Manifest schema"}},
+				},
+				InputParameters: iuf.InputParameters{
+					Stages:                 []string{"process-media", "deliver-product"},
+					BlockOnInvalidProducts: true,
+				},
+				Workflows: []iuf.SessionWorkflow{{Id: "asdf"}},
+			},
+			wanted: wanted{
+				err:          false,
+				isCompleted:  false,
+				sessionState: iuf.SessionStateDebug,
+				sessionStage: "deliver-product",
+			},
+		},
+		{
+			name: "do not block the next stage on products that are not selected",
+			session: iuf.Session{
+				CurrentStage: "process-media",
+				ActivityRef:  activity.Name,
+				Products: []iuf.Product{
+					{Name: "cos", Version: "1.2.3", Validated: true},
+					{Name: "sdu", Version: "2.3.4", ValidationErrors: []string{"failed to validate IUF Product Manifest schema"}},
+				},
+				InputParameters: iuf.InputParameters{
+					Stages:                 []string{"process-media", "deliver-product"},
+					Products:               []string{"cos"},
+					BlockOnInvalidProducts: true,
+				},
+				Workflows: []iuf.SessionWorkflow{{Id: "asdf"}},
+			},
+			wanted: wanted{
+				err:          false,
+				isCompleted:  false,
+				sessionState: iuf.SessionStateInProgress,
+				sessionStage: "deliver-product",
+			},
+		},
 		{
 			name: "before last stage",
 			session: iuf.Session{
@@ -411,13 +562,13 @@ func TestRunNextStage(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.session.Name = utils.GenerateName(m1.ReplaceAllString(tt.name, "-"))
-			_, err := workflowSvc.CreateSession(tt.session, tt.session.Name, activity)
+			_, err := workflowSvc.CreateSession(context.TODO(), tt.session, tt.session.Name, activity)
 			if err != nil {
 				t.Errorf("got unexpted error while creating session %v", err)
 				return
 			}
 
-			_, err, completed := workflowSvc.RunNextStage(&tt.session)
+			_, err, completed := workflowSvc.RunNextStage(context.TODO(), &tt.session)
 			if (err != nil) != tt.wanted.err {
 				t.Errorf("got %v, wantErr %v", err, tt.wanted.err)
 				return
@@ -429,6 +580,296 @@ func TestRunNextStage(t *testing.T) {
 	}
 }
 
+func TestRunNextStageInParallel(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+	wfServiceClientMock.On(
+		"GetWorkflow",
+		mock.Anything,
+		mock.Anything,
+	).Return(new(v1alpha1.Workflow), nil)
+	wfServiceClientMock.On(
+		"ListWorkflows",
+		mock.Anything,
+		mock.Anything,
+	).Return(new(v1alpha1.WorkflowList), nil)
+	wfServiceClientMock.On(
+		"CreateWorkflow",
+		mock.Anything,
+		mock.Anything,
+	).Return(new(v1alpha1.Workflow), nil)
+	wfTemplateServiceClientMock := &workflowtemplatemocks.WorkflowTemplateServiceClient{}
+	wt1 := v1alpha1.WorkflowTemplate{}
+	wt1.Name = "loftsman-manifest-upload"
+	wt2 := v1alpha1.WorkflowTemplate{}
+	wt2.Name = "loftsman-manifest-deploy"
+	wfTemplateServiceClientMock.On(
+		"ListWorkflowTemplates",
+		mock.Anything,
+		mock.Anything,
+	).Return(&v1alpha1.WorkflowTemplateList{Items: v1alpha1.WorkflowTemplates{wt1, wt2}}, nil)
+
+	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return("mock_token", nil).AnyTimes()
+
+	workflowSvc := iufService{
+		logger:                 utils.GetLogger(),
+		workflowClient:         wfServiceClientMock,
+		workflowTemplateClient: wfTemplateServiceClientMock,
+		k8sRestClientSet:       fake.NewSimpleClientset(),
+		keycloakService:        keycloakServiceMock,
+		env:                    utils.Env{IufInstallWorkflowFiles: "./_test_data_/parallel"},
+	}
+	activity, err := workflowSvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{
+		Name:          "test-parallel",
+		ActivityState: iuf.ActivityStateWaitForAdmin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	session := iuf.Session{
+		Name:            "test-parallel-session",
+		ActivityRef:     activity.Name,
+		Products:        []iuf.Product{{Name: "cos", Version: "1.2.3"}},
+		CurrentStage:    "deliver-product",
+		CurrentStages:   []string{"deliver-product"},
+		CompletedStages: []string{"process-media"},
+		CurrentState:    iuf.SessionStateInProgress,
+		InputParameters: iuf.InputParameters{
+			Stages: []string{"process-media", "deliver-product", "update-vcs-config", "prepare-images", "post-install-check"},
+		},
+	}
+	_, err = workflowSvc.CreateSession(context.TODO(), session, session.Name, activity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("It should run independent stages in parallel once their dependencies complete", func(t *testing.T) {
+		_, err, completed := workflowSvc.RunNextStage(context.TODO(), &session)
+		assert.NoError(t, err)
+		assert.False(t, completed)
+		assert.Equal(t, iuf.SessionStateInProgress, session.CurrentState)
+		assert.Equal(t, []string{"process-media", "deliver-product"}, session.CompletedStages)
+		assert.Equal(t, []string{"update-vcs-config", "prepare-images"}, session.CurrentStages)
+		assert.Equal(t, 2, len(session.Workflows))
+	})
+
+	t.Run("It should wait for all dependencies before running the next stage", func(t *testing.T) {
+		session.CurrentStage = "prepare-images"
+		_, err, completed := workflowSvc.RunNextStage(context.TODO(), &session)
+		assert.NoError(t, err)
+		assert.False(t, completed)
+		assert.Equal(t, iuf.SessionStateInProgress, session.CurrentState)
+		assert.Equal(t, []string{"update-vcs-config"}, session.CurrentStages)
+		assert.Equal(t, 2, len(session.Workflows))
+	})
+
+	t.Run("It should run the next stage once all of its dependencies complete", func(t *testing.T) {
+		session.CurrentStage = "update-vcs-config"
+		_, err, completed := workflowSvc.RunNextStage(context.TODO(), &session)
+		assert.NoError(t, err)
+		assert.False(t, completed)
+		assert.Equal(t, []string{"post-install-check"}, session.CurrentStages)
+		assert.Equal(t, 3, len(session.Workflows))
+	})
+
+	t.Run("It should complete the session once all stages complete", func(t *testing.T) {
+		_, err, completed := workflowSvc.RunNextStage(context.TODO(), &session)
+		assert.NoError(t, err)
+		assert.True(t, completed)
+		assert.Equal(t, iuf.SessionStateCompleted, session.CurrentState)
+		assert.Equal(t, 0, len(session.CurrentStages))
+	})
+}
+
+func TestResumeSessionInParallel(t *testing.T) {
+	newWorkflow := func(name string, stage string, phase v1alpha1.WorkflowPhase) *v1alpha1.Workflow {
+		wf := v1alpha1.Workflow{}
+		wf.Name = name
+		wf.Labels = map[string]string{"stage": stage}
+		wf.Status.Phase = phase
+		return &wf
+	}
+	workflowsByName := map[string]*v1alpha1.Workflow{
+		"vcs-failed":       newWorkflow("vcs-failed", "update-vcs-config", v1alpha1.WorkflowFailed),
+		"images-succeeded": newWorkflow("images-succeeded", "prepare-images", v1alpha1.WorkflowSucceeded),
+	}
+	// the auth token secret of the failed workflow is gone, so it has to be created again before the workflow is retried
+	workflowsByName["vcs-failed"].UID = "1234"
+	workflowsByName["vcs-failed"].Labels[LABEL_AUTH_TOKEN_SECRET] = "vcs-failed-token"
+
+	wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+	for name, wf := range workflowsByName {
+		wfServiceClientMock.On(
+			"GetWorkflow",
+			mock.Anything,
+			&workflow.WorkflowGetRequest{Name: name, Namespace: "argo"},
+		).Return(wf, nil)
+	}
+	wfServiceClientMock.On(
+		"RetryWorkflow",
+		mock.Anything,
+		mock.Anything,
+	).Return(new(v1alpha1.Workflow), nil)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return("new_token", nil).AnyTimes()
+
+	workflowSvc := iufService{
+		logger:           utils.GetLogger(),
+		workflowClient:   wfServiceClientMock,
+		k8sRestClientSet: fake.NewSimpleClientset(),
+		keycloakService:  keycloakServiceMock,
+		env:              utils.Env{IufInstallWorkflowFiles: "./_test_data_/parallel"},
+	}
+	activity, err := workflowSvc.CreateActivity(context.TODO(), iuf.CreateActivityRequest{
+		Name:          "test-resume-parallel",
+		ActivityState: iuf.ActivityStateWaitForAdmin,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newSession := func(workflows ...string) iuf.Session {
+		session := iuf.Session{
+			Name:            "test-resume-parallel-session",
+			ActivityRef:     activity.Name,
+			Products:        []iuf.Product{{Name: "cos", Version: "1.2.3"}},
+			CurrentStage:    "update-vcs-config",
+			CurrentStages:   []string{"update-vcs-config", "prepare-images"},
+			CompletedStages: []string{"process-media", "deliver-product"},
+			CurrentState:    iuf.SessionStateDebug,
+			InputParameters: iuf.InputParameters{
+				Stages: []string{"process-media", "deliver-product", "update-vcs-config", "prepare-images", "post-install-check"},
+			},
+		}
+		for _, name := range workflows {
+			session.Workflows = append(session.Workflows, iuf.SessionWorkflow{Id: name})
+		}
+		_, err := workflowSvc.CreateSession(context.TODO(), session, session.Name, activity)
+		if err != nil {
+			err = workflowSvc.UpdateSession(context.TODO(), session)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return session
+	}
+
+	t.Run("It should retry the failed stage and complete the succeeded stage", func(t *testing.T) {
+		session := newSession("vcs-failed", "images-succeeded")
+		err := workflowSvc.ResumeSession(context.TODO(), &session, "resume")
+		assert.NoError(t, err)
+		wfServiceClientMock.AssertCalled(t, "RetryWorkflow", mock.Anything, &workflow.WorkflowRetryRequest{Name: "vcs-failed", Namespace: "argo"})
+		assert.Equal(t, []string{"update-vcs-config"}, session.CurrentStages)
+		assert.Equal(t, []string{"process-media", "deliver-product", "prepare-images"}, session.CompletedStages)
+		// the next stage waits for the stage that is retried, so it is up to Sync to run it once that one completes
+		assert.Equal(t, iuf.SessionState(""), session.CurrentState)
+	})
+
+	t.Run("It should recreate the auth token secret of the workflow it retries", func(t *testing.T) {
+		secret, err := workflowSvc.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Get(context.TODO(), "vcs-failed-token", metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "new_token", string(secret.Data[AUTH_TOKEN_SECRET_KEY]))
+		assert.Equal(t, 1, len(secret.OwnerReferences))
+		assert.Equal(t, "vcs-failed", secret.OwnerReferences[0].Name)
+	})
+
+	t.Run("It should only rerun the stage that has no workflows", func(t *testing.T) {
+		session := newSession("images-succeeded")
+		err := workflowSvc.ResumeSession(context.TODO(), &session, "resume")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(session.CurrentStages))
+		assert.Equal(t, []string{"process-media", "deliver-product", "prepare-images"}, session.CompletedStages)
+		assert.Equal(t, iuf.SessionState(""), session.CurrentState)
+	})
+
+	t.Run("It should keep the completed stages of a session that only tracks its current stage", func(t *testing.T) {
+		session := newSession("images-succeeded")
+		session.CurrentStage = "prepare-images"
+		session.CurrentStages = nil
+		session.CompletedStages = nil
+		err := workflowSvc.ResumeSession(context.TODO(), &session, "resume")
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(session.CurrentStages))
+		assert.Equal(t, []string{"process-media", "deliver-product", "update-vcs-config", "prepare-images"}, session.CompletedStages)
+	})
+}
+
+func TestGetStagesReadyToRun(t *testing.T) {
+	iufSvc := iufService{logger: utils.GetLogger()}
+	stages := iuf.Stages{
+		Stages: []iuf.Stage{
+			{Name: "a"},
+			{Name: "b", DependsOn: []string{"a"}},
+			{Name: "c", DependsOn: []string{"a"}},
+			{Name: "d"},
+			{Name: "e", DependsOn: []string{"b", "d"}},
+		},
+	}
+
+	var tests = []struct {
+		name      string
+		requested []string
+		current   []string
+		completed []string
+		wanted    []string
+	}{
+		{
+			name:      "only the first stage is ready at the start",
+			requested: []string{"a", "b", "c", "d", "e"},
+			wanted:    []string{"a"},
+		},
+		{
+			name:      "stages depending on the same stage run in parallel",
+			requested: []string{"a", "b", "c", "d", "e"},
+			completed: []string{"a"},
+			wanted:    []string{"b", "c"},
+		},
+		{
+			name:      "stages without depends-on depend on the stage before them",
+			requested: []string{"a", "b", "c", "d", "e"},
+			current:   []string{"b"},
+			completed: []string{"a", "c"},
+			wanted:    []string{"d"},
+		},
+		{
+			name:      "stages wait for all of their dependencies",
+			requested: []string{"a", "b", "c", "d", "e"},
+			current:   []string{"d"},
+			completed: []string{"a", "b", "c"},
+			wanted:    nil,
+		},
+		{
+			name:      "dependencies on stages that were not requested are followed to requested stages",
+			requested: []string{"a", "d", "e"},
+			completed: []string{"a"},
+			wanted:    []string{"d"},
+		},
+		{
+			name:      "nothing is ready when every stage has completed",
+			requested: []string{"a", "b"},
+			completed: []string{"a", "b"},
+			wanted:    nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := iuf.Session{
+				InputParameters: iuf.InputParameters{Stages: tt.requested},
+				CurrentStages:   tt.current,
+				CompletedStages: tt.completed,
+			}
+			assert.Equal(t, tt.wanted, iufSvc.getStagesReadyToRun(&session, stages))
+		})
+	}
+}
+
 func TestProcessOutput(t *testing.T) {
 	wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
 	name := uuid.NewString()
@@ -1125,7 +1566,7 @@ func TestProcessOutput(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := mySvc.ProcessOutput(&tt.session, tt.workflow)
+			err := mySvc.ProcessOutput(context.TODO(), &tt.session, tt.workflow)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("got %v, wantErr %v", err, tt.wantErr)
 				return
@@ -1157,6 +1598,7 @@ func TestProcessOutputOfProcessMedia(t *testing.T) {
 		logger:           utils.GetLogger(),
 		workflowClient:   wfServiceClientMock,
 		k8sRestClientSet: fakeClient,
+		env:              utils.Env{IufInstallWorkflowFiles: "./_test_data_"},
 	}
 
 	var tests = []struct {
@@ -1316,12 +1758,158 @@ func TestProcessOutputOfProcessMedia(t *testing.T) {
 						Version:          "this-is-a-version",
 						OriginalLocation: "this_is_the_value_of_parent-directory",
 						Validated:        false,
+						ValidationErrors: []string{"missing properties: 'iuf_version'"},
 						Manifest:         `{"name":"this-is-a-name","version":"this-is-a-version"}`,
 					},
 				},
 			},
 			wantErr: false,
 		},
+		{
+			name: "two outputs - manifest compatible with the IUF version",
+			workflow: &v1alpha1.Workflow{
+				Status: v1alpha1.WorkflowStatus{
+					Nodes: v1alpha1.Nodes{
+						"this-is-a-name-of-templateRef": v1alpha1.NodeStatus{
+							DisplayName: "product-name-dash-operation-name",
+							Outputs: &v1alpha1.Outputs{
+								Parameters: []v1alpha1.Parameter{
+									{
+										Name:  "this_is_the_name_of_product",
+										Value: v1alpha1.AnyStringPtr("iuf_version: ^0.1.0\nname: compatible\nversion: 1.0.0\ncontent: {}"),
+									},
+									{
+										Name:  "this_is_the_parent-directory",
+										Value: v1alpha1.AnyStringPtr("this_is_the_value_of_parent-directory"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantActivity: iuf.Activity{
+				Name: activity.Name,
+				OperationOutputs: map[string]interface{}{
+					"stage_params": map[string]interface{}{
+						"process-media": map[string]interface{}{
+							"products": map[string]interface{}{
+								"compatible-1-0-0": map[string]interface{}{
+									"parent_directory": "this_is_the_value_of_parent-directory",
+								},
+							},
+						},
+					},
+				},
+				Products: []iuf.Product{
+					{
+						Name:             "compatible",
+						Version:          "1.0.0",
+						OriginalLocation: "this_is_the_value_of_parent-directory",
+						Validated:        true,
+						Manifest:         `{"content":{},"iuf_version":"^0.1.0","name":"compatible","version":"1.0.0"}`,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "two outputs - manifest with a tilde range of IUF versions",
+			workflow: &v1alpha1.Workflow{
+				Status: v1alpha1.WorkflowStatus{
+					Nodes: v1alpha1.Nodes{
+						"this-is-a-name-of-templateRef": v1alpha1.NodeStatus{
+							DisplayName: "product-name-dash-operation-name",
+							Outputs: &v1alpha1.Outputs{
+								Parameters: []v1alpha1.Parameter{
+									{
+										Name:  "this_is_the_name_of_product",
+										Value: v1alpha1.AnyStringPtr("iuf_version: \"~0.1.0\"\nname: in-range\nversion: 1.0.0\ncontent: {}"),
+									},
+									{
+										Name:  "this_is_the_parent-directory",
+										Value: v1alpha1.AnyStringPtr("this_is_the_value_of_parent-directory"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantActivity: iuf.Activity{
+				Name: activity.Name,
+				OperationOutputs: map[string]interface{}{
+					"stage_params": map[string]interface{}{
+						"process-media": map[string]interface{}{
+							"products": map[string]interface{}{
+								"in-range-1-0-0": map[string]interface{}{
+									"parent_directory": "this_is_the_value_of_parent-directory",
+								},
+							},
+						},
+					},
+				},
+				Products: []iuf.Product{
+					{
+						Name:             "in-range",
+						Version:          "1.0.0",
+						OriginalLocation: "this_is_the_value_of_parent-directory",
+						Validated:        true,
+						Manifest:         `{"content":{},"iuf_version":"~0.1.0","name":"in-range","version":"1.0.0"}`,
+					},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "two outputs - manifest not compatible with the IUF version",
+			workflow: &v1alpha1.Workflow{
+				Status: v1alpha1.WorkflowStatus{
+					Nodes: v1alpha1.Nodes{
+						"this-is-a-name-of-templateRef": v1alpha1.NodeStatus{
+							DisplayName: "product-name-dash-operation-name",
+							Outputs: &v1alpha1.Outputs{
+								Parameters: []v1alpha1.Parameter{
+									{
+										Name:  "this_is_the_name_of_product",
+										Value: v1alpha1.AnyStringPtr("iuf_version: ^0.5.0\nname: incompatible\nversion: 1.0.0\ncontent: {}"),
+									},
+									{
+										Name:  "this_is_the_parent-directory",
+										Value: v1alpha1.AnyStringPtr("this_is_the_value_of_parent-directory"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantActivity: iuf.Activity{
+				Name: activity.Name,
+				OperationOutputs: map[string]interface{}{
+					"stage_params": map[string]interface{}{
+						"process-media": map[string]interface{}{
+							"products": map[string]interface{}{
+								"incompatible-1-0-0": map[string]interface{}{
+									"parent_directory": "this_is_the_value_of_parent-directory",
+								},
+							},
+						},
+					},
+				},
+				Products: []iuf.Product{
+					{
+						Name:             "incompatible",
+						Version:          "1.0.0",
+						OriginalLocation: "this_is_the_value_of_parent-directory",
+						Validated:        false,
+						ValidationErrors: []string{`/iuf_version: product requires IUF version "^0.5.0", which is not compatible with IUF version 0.1.0: 0.1.0 is less than 0.5.0`},
+						Manifest:         `{"content":{},"iuf_version":"^0.5.0","name":"incompatible","version":"1.0.0"}`,
+					},
+				},
+			},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -1336,3 +1924,148 @@ func TestProcessOutputOfProcessMedia(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessOutputOfProcessMediaVerifiesChecksums(t *testing.T) {
+	mySvc := iufService{
+		logger:    utils.GetLogger(),
+		env:       utils.Env{IufInstallWorkflowFiles: "./_test_data_"},
+		checksums: newChecksumVerifier(),
+	}
+	processMedia := func(workflowName string, phase v1alpha1.WorkflowPhase, location string) iuf.Product {
+		activity := iuf.Activity{}
+		workflow := &v1alpha1.Workflow{
+			ObjectMeta: metav1.ObjectMeta{Name: workflowName},
+			Status: v1alpha1.WorkflowStatus{
+				Phase: phase,
+				Nodes: v1alpha1.Nodes{
+					"this-is-a-name-of-templateRef": v1alpha1.NodeStatus{
+						Outputs: &v1alpha1.Outputs{
+							Parameters: []v1alpha1.Parameter{
+								{Name: "manifest", Value: v1alpha1.AnyStringPtr("iuf_version: ^0.1.0\nname: checksums\nversion: 1.0.0\ncontent: {}")},
+								{Name: "parent_directory", Value: v1alpha1.AnyStringPtr(location)},
+							},
+						},
+					},
+				},
+			},
+		}
+		err := mySvc.processOutputOfProcessMedia(&activity, workflow)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(activity.Products))
+		return activity.Products[0]
+	}
+	mismatches := []iuf.ChecksumMismatch{
+		{
+			File:     "changed.txt",
+			Expected: "25718360e05d3c2d0963d1381e9dd4dae5fca789244ee4b9f861adcc0cc96218",
+			Actual:   "7f8b1dfc466b6249f06cbe55c9174df2578e7754da793fded244ef5cba2a38f1",
+		},
+	}
+
+	t.Run("It does not verify the checksums before process-media succeeded", func(t *testing.T) {
+		product := processMedia("process-media-running", v1alpha1.WorkflowRunning, "./_test_data_/checksums")
+
+		assert.Nil(t, product.Checksums)
+		assert.Equal(t, 0, len(mySvc.checksums.verifications))
+	})
+	t.Run("It verifies the checksums in the background, and the product is not validated until they are", func(t *testing.T) {
+		mySvc.checksums.verifications["./_test_data_/checksums"] = &checksumVerification{workflow: "process-media-1", done: make(chan struct{})}
+
+		product := processMedia("process-media-1", v1alpha1.WorkflowSucceeded, "./_test_data_/checksums")
+
+		assert.Equal(t, &iuf.ChecksumResult{Pending: true}, product.Checksums)
+		assert.False(t, product.Validated)
+		assert.True(t, HasPendingChecksums([]iuf.Product{product}))
+	})
+	t.Run("It records the mismatches once the checksums are verified", func(t *testing.T) {
+		verification := mySvc.checksums.verifications["./_test_data_/checksums"]
+		verification.result, verification.err = iuf.VerifyChecksums("./_test_data_/checksums")
+		close(verification.done)
+
+		product := processMedia("process-media-1", v1alpha1.WorkflowSucceeded, "./_test_data_/checksums")
+
+		assert.False(t, product.Validated)
+		assert.False(t, HasPendingChecksums([]iuf.Product{product}))
+		assert.Equal(t, &iuf.ChecksumResult{File: "SHA256SUMS", Algorithm: "sha256", Files: 2, Mismatches: mismatches}, product.Checksums)
+		assert.Equal(t, []string{"checksum of file changed.txt does not match: expected 25718360e05d3c2d0963d1381e9dd4dae5fca789244ee4b9f861adcc0cc96218, got 7f8b1dfc466b6249f06cbe55c9174df2578e7754da793fded244ef5cba2a38f1"}, product.ValidationErrors)
+	})
+	t.Run("A new run of process-media verifies the checksums again", func(t *testing.T) {
+		processMedia("process-media-2", v1alpha1.WorkflowSucceeded, "./_test_data_/checksums")
+		verification := mySvc.checksums.verifications["./_test_data_/checksums"]
+		assert.Equal(t, "process-media-2", verification.workflow)
+		<-verification.done
+
+		product := processMedia("process-media-2", v1alpha1.WorkflowSucceeded, "./_test_data_/checksums")
+
+		assert.Equal(t, mismatches, product.Checksums.Mismatches)
+	})
+	t.Run("A product without checksums file is validated with a warning", func(t *testing.T) {
+		processMedia("process-media-2", v1alpha1.WorkflowSucceeded, "./_test_data_/product")
+		<-mySvc.checksums.verifications["./_test_data_/product"].done
+
+		product := processMedia("process-media-2", v1alpha1.WorkflowSucceeded, "./_test_data_/product")
+
+		assert.True(t, product.Validated)
+		assert.Nil(t, product.Checksums)
+		assert.Equal(t, []string{NO_CHECKSUMS_FILE_WARNING}, product.ValidationWarnings)
+	})
+}
+
+func TestProcessOutputOfProcessMediaValidatesContent(t *testing.T) {
+	mySvc := iufService{
+		logger: utils.GetLogger(),
+		env:    utils.Env{IufInstallWorkflowFiles: "./_test_data_"},
+	}
+	processMedia := func(manifest string) iuf.Product {
+		activity := iuf.Activity{}
+		workflow := &v1alpha1.Workflow{
+			Status: v1alpha1.WorkflowStatus{
+				Nodes: v1alpha1.Nodes{
+					"this-is-a-name-of-templateRef": v1alpha1.NodeStatus{
+						Outputs: &v1alpha1.Outputs{
+							Parameters: []v1alpha1.Parameter{
+								{Name: "manifest", Value: v1alpha1.AnyStringPtr(manifest)},
+								{Name: "parent_directory", Value: v1alpha1.AnyStringPtr("./_test_data_/product")},
+							},
+						},
+					},
+				},
+			},
+		}
+		err := mySvc.processOutputOfProcessMedia(&activity, workflow)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(activity.Products))
+		return activity.Products[0]
+	}
+
+	t.Run("The content is looked up in the directory of the product", func(t *testing.T) {
+		product := processMedia("iuf_version: ^0.1.0\nname: product\nversion: 1.0.0\ncontent:\n  s3:\n  - path: files/artifact.txt\n    bucket: fw-update\n    key: product/artifact.txt")
+
+		assert.True(t, product.Validated)
+		assert.Equal(t, 0, len(product.ValidationErrors))
+	})
+	t.Run("Content missing from the directory of the product is a validation error", func(t *testing.T) {
+		product := processMedia("iuf_version: ^0.1.0\nname: product\nversion: 1.0.0\ncontent:\n  s3:\n  - path: files/artifact.txt\n    bucket: fw-update\n    key: product/artifact.txt\n  - path: files/missing.txt\n    bucket: fw-update\n    key: product/missing.txt")
+
+		assert.False(t, product.Validated)
+		assert.Equal(t, []string{"/content/s3/1/path: s3 file _test_data_/product/files/missing.txt does not exist"}, product.ValidationErrors)
+	})
+}
+
+func TestMigrateStageTracking(t *testing.T) {
+	iufSvc := iufService{logger: utils.GetLogger()}
+
+	t.Run("It should fill in running and completed stages for sessions that only track the current stage", func(t *testing.T) {
+		session, err := iufSvc.ConfigMapDataToSession(`{"stage":"deliver-product","input_parameters":{"stages":["process-media","pre-install-check","deliver-product","deploy-product"]}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"deliver-product"}, session.CurrentStages)
+		assert.Equal(t, []string{"process-media", "pre-install-check"}, session.CompletedStages)
+	})
+
+	t.Run("It should not change sessions that already track running stages", func(t *testing.T) {
+		session, err := iufSvc.ConfigMapDataToSession(`{"stage":"deliver-product","current_stages":["deliver-product","deploy-product"],"input_parameters":{"stages":["process-media","deliver-product","deploy-product"]}}`)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"deliver-product", "deploy-product"}, session.CurrentStages)
+		assert.Equal(t, 0, len(session.CompletedStages))
+	})
+}
@@ -34,15 +34,16 @@ import (
 	"reflect"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	"github.com/oliveagle/jsonpath"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/yaml"
 )
 
@@ -50,7 +51,40 @@ const ARGO_TASKS_SIZE_LIMIT = 120
 const LABEL_PRODUCT_PREFIX = "product_"
 const LABEL_PARTIAL_WORKFLOW = "partial_workflow"
 
-func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflow, err error, skipStage bool) {
+// The Keycloak token that operations and hooks use is kept in a Secret of each workflow, so it never shows in the
+//  workflow itself. The name of the Secret is in the auth_token_secret parameter of every operation and hook, and the
+//  Secret is also a volume of the workflow that templates can mount. The token is under the "token" key.
+const (
+	WORKFLOW_PARAM_AUTH_TOKEN_SECRET = "auth_token_secret"
+	AUTH_TOKEN_VOLUME                = "iuf-auth-token"
+	AUTH_TOKEN_SECRET_KEY            = "token"
+	// label of the workflow holding the name of its Secret
+	LABEL_AUTH_TOKEN_SECRET = "iuf_auth_token_secret"
+	// type of the Secrets
+	LABEL_AUTH_TOKEN = "iuf_auth_token"
+)
+
+// GetStageWorkflow renders the workflow that workflowGen would create for the given stage of the session, without
+//  submitting it to Argo. The Secret with the auth token is not created.
+func (s iufService) GetStageWorkflow(ctx context.Context, session iuf.Session, stageName string) (workflow v1alpha1.Workflow, err error, skipStage bool) {
+	// workflowGen updates the processed products of the session, so work on a copy of that map.
+	processedProductsByStage := make(map[string]map[string]bool)
+	for stage, products := range session.ProcessedProductsByStage {
+		processedProductsByStage[stage] = make(map[string]bool)
+		for productKey, processed := range products {
+			processedProductsByStage[stage][productKey] = processed
+		}
+	}
+	session.ProcessedProductsByStage = processedProductsByStage
+	session.CurrentStage = stageName
+
+	// nothing is submitted, so the operations are not counted
+	return s.workflowGen(ctx, &session, operationCounts{})
+}
+
+func (s iufService) workflowGen(ctx context.Context, session *iuf.Session, counts operationCounts) (workflow v1alpha1.Workflow, err error, skipStage bool) {
+	ctx, span := startSessionSpan(ctx, "workflowGen", session)
+	defer func() { utils.EndSpan(span, err) }()
 	stageName := session.CurrentStage
 	if stageName == "" {
 		noStageError := utils.GenericError{Message: "No current stage to run."}
@@ -58,7 +92,13 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 		return v1alpha1.Workflow{}, noStageError, false
 	}
 
+	// the stages come with a StageValidationError when stages.yaml is not valid, and a workflow is not generated from
+	//  what could be read of them
 	stagesMetadata, err := s.GetStages()
+	if err != nil {
+		s.logger.Errorf("workflowGen.1: cannot read stages to generate stage %s of session %s: %v", stageName, session.Name, err)
+		return v1alpha1.Workflow{}, err, false
+	}
 	var stageMetadata iuf.Stage
 	for _, stage := range stagesMetadata.Stages {
 		if stage.Name == stageName {
@@ -122,19 +162,17 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 
 	res.Spec.Parallelism = &concurrency
 
-	// TODO: commenting this out because devs are finding it confusing why tasks are being retried automatically.
-	//retryLimit := intstr.FromInt(3)
-	//retryBackoffFactor := intstr.FromInt(2)
-	//
-	//res.Spec.RetryStrategy = &v1alpha1.RetryStrategy{
-	//	Limit:       &retryLimit,
-	//	RetryPolicy: v1alpha1.RetryPolicyAlways,
-	//	Backoff: &v1alpha1.Backoff{
-	//		Duration:    "1m",
-	//		Factor:      &retryBackoffFactor,
-	//		MaxDuration: "10m",
-	//	},
-	//}
+	// operations are only retried or timed out when stages.yaml or the session asks for it, see setOperationPolicy.
+	if stageMetadata.Timeout != "" {
+		timeout, err := time.ParseDuration(stageMetadata.Timeout)
+		if err != nil || timeout <= 0 {
+			timeoutErr := utils.GenericError{Message: fmt.Sprintf("Invalid timeout %s for stage %s: %v", stageMetadata.Timeout, stageName, err)}
+			s.logger.Error(timeoutErr)
+			return v1alpha1.Workflow{}, timeoutErr, false
+		}
+		activeDeadlineSeconds := int64(timeout.Seconds())
+		res.Spec.ActiveDeadlineSeconds = &activeDeadlineSeconds
+	}
 
 	res.Spec.PodGC = &v1alpha1.PodGC{Strategy: v1alpha1.PodGCOnPodCompletion}
 
@@ -177,7 +215,7 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 	res.Spec.Entrypoint = "main"
 
 	// global stages have product-less global parameters.
-	globalParams := s.getGlobalParams(*session, iuf.Product{}, stagesMetadata)
+	globalParams := s.getGlobalParams(ctx, *session, iuf.Product{}, stagesMetadata)
 	globalParamsContent, err := json.Marshal(globalParams)
 	if err != nil {
 		marshalErr := utils.GenericError{Message: fmt.Sprintf("Could not marshal globalParams %v %v", globalParams, err)}
@@ -192,7 +230,7 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 	globalParamsPerProduct := map[string]string{}
 	globalParamsNamesPerProduct := map[string]string{}
 	for _, product := range session.Products {
-		productGlobalParams := s.getGlobalParams(*session, product, stagesMetadata)
+		productGlobalParams := s.getGlobalParams(ctx, *session, product, stagesMetadata)
 		b, err := json.Marshal(productGlobalParams)
 		if err != nil {
 			marshalErr := utils.GenericError{Message: fmt.Sprintf("Could not marshal globalParams %v %v", productGlobalParams, err)}
@@ -204,16 +242,15 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 		globalParamsNamesPerProduct[productKey] = productKey
 	}
 
-	// generate auth token in advance
-	authToken, err := s.keycloakService.NewKeycloakAccessToken()
-	if err != nil {
-		marshalErr := utils.GenericError{Message: fmt.Sprintf("Could not generate authToken %v", err)}
-		s.logger.Error(marshalErr)
-		return v1alpha1.Workflow{}, marshalErr, false
-	}
-	const authTokenName = "auth_token"
+	// the Secret itself is created with the workflow, see CreateIufWorkflow
+	authTokenSecretName := utils.GenerateName(session.Name + "-" + stageName + "-token")
+	labels[LABEL_AUTH_TOKEN_SECRET] = authTokenSecretName
+	res.Spec.Volumes = append(res.Spec.Volumes, corev1.Volume{
+		Name:         AUTH_TOKEN_VOLUME,
+		VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: authTokenSecretName}},
+	})
 
-	dagTasks, products, err := s.getDAGTasks(session, stageMetadata, stagesMetadata, globalParamsNamesPerProduct, globalParamsName, authTokenName, &res)
+	dagTasks, products, err := s.getDAGTasks(ctx, session, stageMetadata, stagesMetadata, globalParamsNamesPerProduct, globalParamsName, WORKFLOW_PARAM_AUTH_TOKEN_SECRET, &res, counts)
 	if err != nil {
 		s.logger.Error(err)
 		return v1alpha1.Workflow{}, err, false
@@ -229,7 +266,8 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 		labels[key] = value
 	}
 
-	if len(products) != len(session.Products) {
+	selectedProducts := s.getSelectedProducts(session)
+	if len(products) != len(selectedProducts) {
 		labels[LABEL_PARTIAL_WORKFLOW] = "true"
 
 		// update the set of products in the session. Note that the session is a pointer, so this eventually gets saved.
@@ -252,6 +290,8 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 
 	failFast := false
 
+	// getDAGTasks adds a template for each operation with a retry strategy, these go after main and the exit handlers.
+	retryTemplates := res.Spec.Templates
 	res.Spec.Templates = []v1alpha1.Template{
 		{
 			Name: "main",
@@ -262,10 +302,10 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 		},
 	}
 
-	exitHandlers := s.getOnExitHandlers(session, stageMetadata, stagesMetadata.Hooks, globalParamsNamesPerProduct, authTokenName)
+	exitHandlers := s.getOnExitHandlers(ctx, session, stageMetadata, stagesMetadata.Hooks, globalParamsNamesPerProduct, WORKFLOW_PARAM_AUTH_TOKEN_SECRET)
 
 	// only run add this field IF this is the last workflow in a set of partial workflows that we need to execute.
-	if len(exitHandlers) > 0 && (labels[LABEL_PARTIAL_WORKFLOW] == "" || len(session.ProcessedProductsByStage[session.CurrentStage]) == len(session.Products)) {
+	if len(exitHandlers) > 0 && (labels[LABEL_PARTIAL_WORKFLOW] == "" || len(session.ProcessedProductsByStage[session.CurrentStage]) == len(selectedProducts)) {
 		res.Spec.OnExit = "onExitHandlers"
 		// list of all the tasks that we picked from the products onExit field
 		// only add this template IF this is the last workflow in a set of partial workflows that we need to execute.
@@ -281,6 +321,7 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 		}
 		res.Spec.Templates = append(res.Spec.Templates, onexit)
 	}
+	res.Spec.Templates = append(res.Spec.Templates, retryTemplates...)
 
 	var specArgumentsParameters []v1alpha1.Parameter
 	for productKey, globalParams := range globalParamsPerProduct {
@@ -292,8 +333,8 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 	}
 
 	specArgumentsParameters = append(specArgumentsParameters, v1alpha1.Parameter{
-		Name:  authTokenName,
-		Value: v1alpha1.AnyStringPtr(authToken),
+		Name:  WORKFLOW_PARAM_AUTH_TOKEN_SECRET,
+		Value: v1alpha1.AnyStringPtr(authTokenSecretName),
 	})
 
 	specArgumentsParameters = append(specArgumentsParameters, v1alpha1.Parameter{
@@ -308,16 +349,16 @@ func (s iufService) workflowGen(session *iuf.Session) (workflow v1alpha1.Workflo
 	return res, nil, false
 }
 
-func (s iufService) getOnExitHandlers(session *iuf.Session, stage iuf.Stage,
+func (s iufService) getOnExitHandlers(ctx context.Context, session *iuf.Session, stage iuf.Stage,
 	hookTemplateMap map[string]string,
-	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthToken string) []v1alpha1.WorkflowStep {
+	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthTokenSecret string) []v1alpha1.WorkflowStep {
 
 	var workflowSteps []v1alpha1.WorkflowStep
 
 	listTemplates := workflowtemplate.WorkflowTemplateListRequest{
 		Namespace: DEFAULT_NAMESPACE,
 	}
-	templates, err := s.workflowTemplateClient.ListWorkflowTemplates(context.TODO(), &listTemplates)
+	templates, err := s.workflowTemplateClient.ListWorkflowTemplates(ctx, &listTemplates)
 	if err != nil {
 		return []v1alpha1.WorkflowStep{}
 	}
@@ -327,32 +368,35 @@ func (s iufService) getOnExitHandlers(session *iuf.Session, stage iuf.Stage,
 		existingArgoUploadedTemplateMap[t.Name] = true
 	}
 
+	if len(session.InputParameters.Operations) > 0 {
+		// hooks are not run when only a subset of operations was asked for
+		return []v1alpha1.WorkflowStep{}
+	}
+
 	for _, product := range session.Products {
+		if !s.isProductSelected(session, product) {
+			continue
+		}
 		s.logger.Infof("Processing exit handler for %v - %v", product.Name, product.Version)
-		manifest, err := s.getProductManifestAsInterface(product)
+		manifest, err := s.getProductManifest(product)
 		if err != nil {
 			s.logger.Errorf("Manifest not found or error while parsing manifest for %v - %v, %v", product.Name, product.Version, err)
 			continue
 		}
 
 		stageName := strings.Replace(stage.Name, "-", "_", -1)
-		jsonPath := fmt.Sprintf("$.onExit.%s.script_path", stageName)
-		scriptPathInterface, err := jsonpath.JsonPathLookup(manifest, jsonPath)
-		if err != nil || scriptPathInterface == nil {
+		exitHook, found := manifest.OnExit[stageName]
+		if !found || exitHook.ScriptPath == "" {
 			s.logger.Debugf("No exit handler found for %v - %v", product.Name, product.Version)
 			continue
 		}
 
-		executionHost := "master_host"
-		jsonExecutionHostPath := fmt.Sprintf("$.onExit.%s.execution_context", stage.Name)
-		executionHostInterface, err := jsonpath.JsonPathLookup(manifest, jsonExecutionHostPath)
-		if err == nil && executionHostInterface != nil {
-			executionHost = fmt.Sprintf("%s", executionHostInterface)
-		}
-
 		script := iuf.ManifestHookScript{
-			ScriptPath:       fmt.Sprintf("%s", scriptPathInterface),
-			ExecutionContext: executionHost,
+			ScriptPath:       exitHook.ScriptPath,
+			ExecutionContext: "master_host",
+		}
+		if exitHook.ExecutionContext != "" {
+			script.ExecutionContext = exitHook.ExecutionContext
 		}
 
 		// now we assemble the Argo task
@@ -377,7 +421,7 @@ func (s iufService) getOnExitHandlers(session *iuf.Session, stage iuf.Stage,
 
 		name := utils.GenerateName("onExitHandler-" + productKey)
 
-		hookTemplateName := hookTemplateMap[executionHost]
+		hookTemplateName := hookTemplateMap[script.ExecutionContext]
 
 		templateExists := existingArgoUploadedTemplateMap[hookTemplateName]
 		if hookTemplateName == "" || !templateExists {
@@ -391,8 +435,8 @@ func (s iufService) getOnExitHandlers(session *iuf.Session, stage iuf.Stage,
 			Arguments: v1alpha1.Arguments{
 				Parameters: []v1alpha1.Parameter{
 					{
-						Name:  "auth_token",
-						Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthToken)),
+						Name:  WORKFLOW_PARAM_AUTH_TOKEN_SECRET,
+						Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthTokenSecret)),
 					},
 					{
 						Name:  "global_params",
@@ -417,9 +461,9 @@ func (s iufService) getOnExitHandlers(session *iuf.Session, stage iuf.Stage,
 }
 
 // Gets DAG tasks for the given session and stage
-func (s iufService) getDAGTasks(session *iuf.Session, stageInfo iuf.Stage, stages iuf.Stages,
+func (s iufService) getDAGTasks(ctx context.Context, session *iuf.Session, stageInfo iuf.Stage, stages iuf.Stages,
 	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameGlobalParamsForGlobalStage string,
-	workflowParamNameAuthToken string, currentWorkflow *v1alpha1.Workflow) ([]v1alpha1.DAGTask, []iuf.Product, error) {
+	workflowParamNameAuthTokenSecret string, currentWorkflow *v1alpha1.Workflow, counts operationCounts) ([]v1alpha1.DAGTask, []iuf.Product, error) {
 	var res []v1alpha1.DAGTask
 	stage := stageInfo.Name
 	s.logger.Infof("getDAGTasks: create workflow DAG for stage %s in session %s in activity %s", stage, session.Name, session.ActivityRef)
@@ -428,7 +472,7 @@ func (s iufService) getDAGTasks(session *iuf.Session, stageInfo iuf.Stage, stage
 	listTemplates := workflowtemplate.WorkflowTemplateListRequest{
 		Namespace: DEFAULT_NAMESPACE,
 	}
-	templates, err := s.workflowTemplateClient.ListWorkflowTemplates(context.TODO(), &listTemplates)
+	templates, err := s.workflowTemplateClient.ListWorkflowTemplates(ctx, &listTemplates)
 	if err != nil {
 		return res, nil, err
 	}
@@ -450,7 +494,7 @@ func (s iufService) getDAGTasks(session *iuf.Session, stageInfo iuf.Stage, stage
 	//  stages that are global, because product content may have changed.
 	if !session.InputParameters.Force && stageInfo.Type == "product" {
 		// go through all the previous sessions of the activity, and see if we can pick up something that is already completed.
-		workflows, err := s.workflowClient.ListWorkflows(context.TODO(), &workflow.WorkflowListRequest{
+		workflows, err := s.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
 			Namespace: "argo",
 			ListOptions: &v1.ListOptions{
 				LabelSelector: fmt.Sprintf("activity=%s,stage=%s,iuf=true", session.ActivityRef, stage),
@@ -465,7 +509,7 @@ func (s iufService) getDAGTasks(session *iuf.Session, stageInfo iuf.Stage, stage
 			})
 
 			for _, workflowObjWithName := range workflows.Items {
-				workflowObj, err := s.workflowClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{
+				workflowObj, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 					Name:      workflowObjWithName.Name,
 					Namespace: "argo",
 				})
@@ -566,13 +610,13 @@ func (s iufService) getDAGTasks(session *iuf.Session, stageInfo iuf.Stage, stage
 		s.logger.Infof("getDAGTasks: For session %s in activity %s, when generating a DAG for stage %s, not attempting to skip previously successful operations because force=%v and stage-type=%s", session.Name, session.ActivityRef, stage, session.InputParameters.Force, stageInfo.Type)
 	}
 
-	preSteps, postSteps := s.getProductHookTasks(*session, stageInfo, stages, prevStepsSuccessful, existingArgoUploadedTemplateMap, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthToken)
+	preSteps, postSteps := s.getProductHookTasks(*session, stageInfo, stages, prevStepsSuccessful, existingArgoUploadedTemplateMap, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthTokenSecret)
 
 	if stageInfo.Type == "product" {
-		return s.getDAGTasksForProductStage(*session, s.getRemainingProducts(session), stageInfo, prevStepsSuccessful, existingArgoUploadedTemplateMap, preSteps, postSteps, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthToken, currentWorkflow)
+		return s.getDAGTasksForProductStage(*session, s.getRemainingProducts(session), stageInfo, prevStepsSuccessful, existingArgoUploadedTemplateMap, preSteps, postSteps, workflowParamNamesGlobalParamsPerProduct, workflowParamNameAuthTokenSecret, currentWorkflow, counts)
 	} else {
-		res, err = s.getDAGTasksForGlobalStage(*session, stageInfo, stages, existingArgoUploadedTemplateMap, preSteps, postSteps, workflowParamNameGlobalParamsForGlobalStage, workflowParamNameAuthToken, currentWorkflow)
-		return res, session.Products, err
+		res, err = s.getDAGTasksForGlobalStage(*session, stageInfo, stages, existingArgoUploadedTemplateMap, preSteps, postSteps, workflowParamNameGlobalParamsForGlobalStage, workflowParamNameAuthTokenSecret, currentWorkflow, counts)
+		return res, s.getSelectedProducts(session), err
 	}
 }
 
@@ -583,7 +627,7 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 	prevStepsCompleted map[string]map[string]string,
 	templateMap map[string]bool,
 	preSteps map[string]v1alpha1.DAGTask, postSteps map[string]v1alpha1.DAGTask,
-	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthToken string, workflow *v1alpha1.Workflow) (res []v1alpha1.DAGTask, retProducts []iuf.Product, err error) {
+	workflowParamNamesGlobalParamsPerProduct map[string]string, workflowParamNameAuthTokenSecret string, workflow *v1alpha1.Workflow, counts operationCounts) (res []v1alpha1.DAGTask, retProducts []iuf.Product, err error) {
 
 	var resPtrs []*v1alpha1.DAGTask
 
@@ -603,6 +647,10 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 
 	for i := 0; i < maxProducts; i++ {
 		product := products[i]
+		if !s.isProductSelected(&session, product) {
+			s.logger.Infof("getDAGTasksForProductStage: product %s was not selected in session %s, skipping", s.getProductVersionKey(product), session.Name)
+			continue
+		}
 		retProducts = append(retProducts, product)
 		// the initial dependency is the name of the hook script for that product, if any.
 		productKey := s.getProductVersionKey(product)
@@ -616,6 +664,9 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 		isFirstOp := true
 
 		for _, operation := range stageInfo.Operations {
+			if !s.isOperationSelected(&session, operation.Name) {
+				continue
+			}
 
 			opName := utils.GenerateName(productKey + "-" + operation.Name)
 
@@ -629,6 +680,7 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 			if prevStepsCompleted[productKey][operation.Name] != "" {
 				s.setEchoTemplate(false, &task, fmt.Sprintf("No action needed in %s for product %s because it was previously completed successfully in workflow %s, skipping", operation.Name, productKey, prevStepsCompleted[productKey][operation.Name]))
 				hasEchoTemplate = true
+				counts[utils.METRICS_OPERATION_SKIPPED]++
 			} else if !templateMap[operation.Name] {
 				// this is a backend error so we don't use a template to inform the user here.
 				s.logger.Errorf("getDAGTasksForProductStage: The template %v cannot be found in Argo. Make sure you have run upload-rebuild-templates.sh from docs-csm", operation.Name)
@@ -668,6 +720,7 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 						if !found {
 							s.setEchoTemplate(false, &task, fmt.Sprintf("No action needed in operation %s for product %s, skipping", operation.Name, s.getProductVersionKey(product)))
 							hasEchoTemplate = true
+							counts[utils.METRICS_OPERATION_SKIPPED]++
 						}
 					}
 				}
@@ -677,8 +730,8 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 				task.Arguments = v1alpha1.Arguments{
 					Parameters: []v1alpha1.Parameter{
 						{
-							Name:  "auth_token",
-							Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthToken)),
+							Name:  WORKFLOW_PARAM_AUTH_TOKEN_SECRET,
+							Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthTokenSecret)),
 						},
 						{
 							Name:  "global_params",
@@ -690,6 +743,13 @@ func (s iufService) getDAGTasksForProductStage(session iuf.Session,
 					Name:     operation.Name,
 					Template: "main",
 				}
+
+				err := s.setOperationPolicy(session, operation, &task, workflow)
+				if err != nil {
+					s.setEchoTemplate(true, &task, fmt.Sprintf("Invalid timeout or retry policy for operation %s during session %s in activity %s: %v", operation.Name, session.Name, session.ActivityRef, err))
+				} else {
+					counts[utils.METRICS_OPERATION_EXECUTED]++
+				}
 			}
 
 			task.Dependencies = []string{}
@@ -759,11 +819,202 @@ func (s iufService) setEchoTemplate(isError bool, task *v1alpha1.DAGTask, messag
 	}
 }
 
+// Gets the timeout and retry policy of an operation from stages.yaml, overridden by whatever the session sets for it.
+func (s iufService) getOperationPolicy(session iuf.Session, operation iuf.Operations) iuf.OperationPolicy {
+	policy := operation.OperationPolicy
+	override, exists := session.InputParameters.OperationPolicies[operation.Name]
+	if !exists {
+		return policy
+	}
+	if override.Timeout != "" {
+		policy.Timeout = override.Timeout
+	}
+	if override.RetryLimit != nil {
+		policy.RetryLimit = override.RetryLimit
+	}
+	if override.RetryBackoff != "" {
+		policy.RetryBackoff = override.RetryBackoff
+	}
+	if override.RetryPolicy != "" {
+		policy.RetryPolicy = override.RetryPolicy
+	}
+	return policy
+}
+
+// Applies the timeout and retry policy of an operation to its task. An operation with either of them is moved into a
+//  template of its own, see setOperationTemplate.
+func (s iufService) setOperationPolicy(session iuf.Session, operation iuf.Operations, task *v1alpha1.DAGTask, workflow *v1alpha1.Workflow) error {
+	policy := s.getOperationPolicy(session, operation)
+	activeDeadlineSeconds, err := s.getActiveDeadlineSeconds(policy)
+	if err != nil {
+		return err
+	}
+	retryStrategy, err := s.getRetryStrategy(operation, policy)
+	if err != nil {
+		return err
+	}
+	if activeDeadlineSeconds != nil || retryStrategy != nil {
+		s.setOperationTemplate(task, retryStrategy, activeDeadlineSeconds, workflow)
+	}
+	return nil
+}
+
+// Gets how many seconds each attempt of an operation may run, or nil if the operation has no timeout.
+func (s iufService) getActiveDeadlineSeconds(policy iuf.OperationPolicy) (*intstr.IntOrString, error) {
+	if policy.Timeout == "" {
+		return nil, nil
+	}
+	timeout, err := time.ParseDuration(policy.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout %s: %v", policy.Timeout, err)
+	}
+	if timeout < time.Second {
+		return nil, fmt.Errorf("invalid timeout %s: must be at least 1s", policy.Timeout)
+	}
+	activeDeadlineSeconds := intstr.FromInt(int(timeout.Seconds()))
+	return &activeDeadlineSeconds, nil
+}
+
+// Gets the Argo retry strategy for an operation, or nil if the operation should not be retried. The retry window of
+//  the operation is the maximum duration of its retries: Argo does not start another attempt once that much time has
+//  passed since the first attempt started.
+func (s iufService) getRetryStrategy(operation iuf.Operations, policy iuf.OperationPolicy) (*v1alpha1.RetryStrategy, error) {
+	if policy.RetryBackoff != "" {
+		if _, err := time.ParseDuration(policy.RetryBackoff); err != nil {
+			return nil, fmt.Errorf("invalid retry-backoff %s: %v", policy.RetryBackoff, err)
+		}
+	}
+	if policy.RetryWindow != "" {
+		if _, err := time.ParseDuration(policy.RetryWindow); err != nil {
+			return nil, fmt.Errorf("invalid retry-window %s: %v", policy.RetryWindow, err)
+		}
+	}
+
+	retryPolicy := v1alpha1.RetryPolicy(policy.RetryPolicy)
+	switch retryPolicy {
+	case "":
+		retryPolicy = v1alpha1.RetryPolicyOnFailure
+	case v1alpha1.RetryPolicyAlways, v1alpha1.RetryPolicyOnFailure, v1alpha1.RetryPolicyOnError, v1alpha1.RetryPolicyOnTransientError:
+	default:
+		return nil, fmt.Errorf("invalid retry-policy %s, must be one of %s, %s, %s or %s", policy.RetryPolicy,
+			v1alpha1.RetryPolicyAlways, v1alpha1.RetryPolicyOnFailure, v1alpha1.RetryPolicyOnError, v1alpha1.RetryPolicyOnTransientError)
+	}
+
+	if policy.RetryLimit == nil || *policy.RetryLimit <= 0 {
+		if policy.RetryWindow != "" {
+			s.logger.Warnf("getRetryStrategy: operation %s has a retry-window of %s but no retry-limit, so the retry-window has no effect", operation.Name, policy.RetryWindow)
+		}
+		return nil, nil
+	}
+
+	if operation.NoRetry {
+		s.logger.Warnf("getRetryStrategy: ignoring retry-limit of %d for operation %s, stages.yaml says it is never retried automatically", *policy.RetryLimit, operation.Name)
+		return nil, nil
+	}
+
+	limit := intstr.FromInt(*policy.RetryLimit)
+	retryStrategy := v1alpha1.RetryStrategy{
+		Limit:       &limit,
+		RetryPolicy: retryPolicy,
+	}
+	if policy.RetryBackoff != "" || policy.RetryWindow != "" {
+		backoffFactor := intstr.FromInt(2)
+		retryStrategy.Backoff = &v1alpha1.Backoff{
+			Duration:    policy.RetryBackoff,
+			Factor:      &backoffFactor,
+			MaxDuration: policy.RetryWindow,
+		}
+		if retryStrategy.Backoff.Duration == "" {
+			retryStrategy.Backoff.Duration = "0s"
+		}
+	}
+	return &retryStrategy, nil
+}
+
+// Argo only allows a retry strategy or a deadline on a template and not on a DAG task, so this moves the template
+//  reference of the task into a template of its own in the workflow that carries them. The template has the same name
+//  as the task, and passes the arguments of the task on to the operation.
+func (s iufService) setOperationTemplate(task *v1alpha1.DAGTask, retryStrategy *v1alpha1.RetryStrategy, activeDeadlineSeconds *intstr.IntOrString, workflow *v1alpha1.Workflow) {
+	var inputs []v1alpha1.Parameter
+	var arguments []v1alpha1.Parameter
+	for _, param := range task.Arguments.Parameters {
+		inputs = append(inputs, v1alpha1.Parameter{Name: param.Name})
+		arguments = append(arguments, v1alpha1.Parameter{
+			Name:  param.Name,
+			Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{inputs.parameters.%s}}", param.Name)),
+		})
+	}
+
+	workflow.Spec.Templates = append(workflow.Spec.Templates, v1alpha1.Template{
+		Name:                  task.Name,
+		Inputs:                v1alpha1.Inputs{Parameters: inputs},
+		RetryStrategy:         retryStrategy,
+		ActiveDeadlineSeconds: activeDeadlineSeconds,
+		Steps: []v1alpha1.ParallelSteps{
+			{
+				Steps: []v1alpha1.WorkflowStep{
+					{
+						Name:        task.Name,
+						TemplateRef: task.TemplateRef,
+						Arguments:   v1alpha1.Arguments{Parameters: arguments},
+					},
+				},
+			},
+		},
+	})
+
+	task.Template = task.Name
+	task.TemplateRef = nil
+}
+
+// Returns true if the operation should be run, i.e. no operations were selected in the session or the operation is one of them.
+func (s iufService) isOperationSelected(session *iuf.Session, operationName string) bool {
+	if len(session.InputParameters.Operations) == 0 {
+		return true
+	}
+	for _, selected := range session.InputParameters.Operations {
+		if selected == operationName {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the product should be processed, i.e. no products were selected in the session or the product is
+//  one of them, either by name or by <name>-<version>.
+func (s iufService) isProductSelected(session *iuf.Session, product iuf.Product) bool {
+	if len(session.InputParameters.Products) == 0 {
+		return true
+	}
+	for _, selected := range session.InputParameters.Products {
+		if s.productMatches(product, selected) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if the given name selects the product, either by name or by <name>-<version>.
+func (s iufService) productMatches(product iuf.Product, name string) bool {
+	return name == product.Name || name == product.Name+"-"+product.Version || name == s.getProductVersionKey(product)
+}
+
+// Gets the products of the session that are processed, i.e. all of them unless some products were selected.
+func (s iufService) getSelectedProducts(session *iuf.Session) []iuf.Product {
+	var res []iuf.Product
+	for _, product := range session.Products {
+		if s.isProductSelected(session, product) {
+			res = append(res, product)
+		}
+	}
+	return res
+}
+
 // Gets the DAG tasks for a global stage
 func (s iufService) getDAGTasksForGlobalStage(session iuf.Session, stageInfo iuf.Stage, stages iuf.Stages,
 	existingArgoUploadedTemplateMap map[string]bool,
 	preSteps map[string]v1alpha1.DAGTask, postSteps map[string]v1alpha1.DAGTask,
-	workflowParamNameGlobalParamsForGlobalStage string, workflowParamNameAuthToken string, workflow *v1alpha1.Workflow) (res []v1alpha1.DAGTask, err error) {
+	workflowParamNameGlobalParamsForGlobalStage string, workflowParamNameAuthTokenSecret string, workflow *v1alpha1.Workflow, counts operationCounts) (res []v1alpha1.DAGTask, err error) {
 
 	var lastOpDependencies []string
 
@@ -777,6 +1028,10 @@ func (s iufService) getDAGTasksForGlobalStage(session iuf.Session, stageInfo iuf
 	}
 
 	for _, operation := range stageInfo.Operations {
+		if !s.isOperationSelected(&session, operation.Name) {
+			s.logger.Infof("getDAGTasksForGlobalStage: operation %s was not selected in session %s, skipping", operation.Name, session.Name)
+			continue
+		}
 
 		if !existingArgoUploadedTemplateMap[operation.Name] && operation.Name != "management-nodes-rollout" {
 			s.logger.Warnf("The template %v cannot be found in Argo. Make sure you have run upload-rebuild-templates.sh from docs-csm", operation.Name)
@@ -793,8 +1048,8 @@ func (s iufService) getDAGTasksForGlobalStage(session iuf.Session, stageInfo iuf
 		task.Arguments = v1alpha1.Arguments{
 			Parameters: []v1alpha1.Parameter{
 				{
-					Name:  "auth_token",
-					Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthToken)),
+					Name:  WORKFLOW_PARAM_AUTH_TOKEN_SECRET,
+					Value: v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", workflowParamNameAuthTokenSecret)),
 				},
 				{
 					Name:  "global_params",
@@ -822,6 +1077,15 @@ func (s iufService) getDAGTasksForGlobalStage(session iuf.Session, stageInfo iuf
 				Template: "main",
 			}
 		}
+
+		if task.TemplateRef.Name != "echo-template" {
+			err := s.setOperationPolicy(session, operation, &task, workflow)
+			if err != nil {
+				s.setEchoTemplate(true, &task, fmt.Sprintf("Invalid timeout or retry policy for operation %s during session %s in activity %s: %v", operation.Name, session.Name, session.ActivityRef, err))
+			} else {
+				counts[utils.METRICS_OPERATION_EXECUTED]++
+			}
+		}
 		res = append(res, task)
 	}
 
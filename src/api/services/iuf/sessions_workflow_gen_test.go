@@ -25,8 +25,12 @@
 package services_iuf
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
@@ -41,6 +45,7 @@ import (
 	workflowtemplatemocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate/mocks"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -69,7 +74,7 @@ func TestWorkflowGen(t *testing.T) {
 			ActivityRef: activityName,
 		}
 
-		workflow, err, _ := iufSvc.workflowGen(&session)
+		workflow, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 		assert.Equal(t, "ncn-m002", workflow.Spec.NodeSelector["kubernetes.io/hostname"])
 	})
@@ -93,11 +98,37 @@ func TestWorkflowGen(t *testing.T) {
 			ActivityRef: activityName,
 		}
 
-		workflow, err, _ := iufSvc.workflowGen(&session)
+		workflow, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 		assert.Equal(t, "ncn-m001", workflow.Spec.NodeSelector["kubernetes.io/hostname"])
 	})
 
+	t.Run("generated workflow must only have labels of the selected products", func(t *testing.T) {
+		session := iuf.Session{
+			Products:     []iuf.Product{{Name: "product_A", Version: "1.0.0"}, {Name: "product_B", Version: "2.0.0"}},
+			CurrentStage: "deliver-product",
+			CurrentState: iuf.SessionStateInProgress,
+			InputParameters: iuf.InputParameters{
+				Stages:   []string{"deliver-product"},
+				Products: []string{"product_B"},
+			},
+			ActivityRef: activityName,
+		}
+
+		workflow, err, skipStage := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
+		assert.NoError(t, err)
+		assert.False(t, skipStage)
+		assert.Equal(t, "2.0.0", workflow.Labels[LABEL_PRODUCT_PREFIX+"product_B"])
+		_, found := workflow.Labels[LABEL_PRODUCT_PREFIX+"product_A"]
+		assert.False(t, found)
+		_, found = workflow.Labels[LABEL_PARTIAL_WORKFLOW]
+		assert.False(t, found)
+		// the products that were not selected are not remaining either, so no partial workflow is run for them
+		remainingProducts := iufSvc.getRemainingProducts(&session)
+		assert.Equal(t, 1, len(remainingProducts))
+		assert.Equal(t, "product_B", remainingProducts[0].Name)
+	})
+
 	t.Skip("TODO generated workflow must not have NodeSelector set to ncn-m001 when NoHooks=true") /*, func(t *testing.T) {
 		session := iuf.Session{
 			Products: []iuf.Product{{Name: "product_A"}, {Name: "product_B"}},
@@ -115,7 +146,7 @@ func TestWorkflowGen(t *testing.T) {
 			ActivityRef: activityName,
 		}
 
-		workflow, err := iufSvc.workflowGen(session)
+		workflow, err := iufSvc.workflowGen(context.TODO(), session, operationCounts{})
 		assert.NoError(t, err)
 		assert.Equal(t, "ncn-m002", workflow.Spec.NodeSelector["kubernetes.io/hostname"])
 	}*/
@@ -147,12 +178,12 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 4, len(dagTasks))
 		assert.Equal(t, 2, len(dagTasks[0].Arguments.Parameters))
-		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token")
+		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token_secret")
 		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Value, v1alpha1.AnyStringPtr(mockAuthToken))
 	})
 	t.Run("It should get a dag task for global stage", func(t *testing.T) {
@@ -173,7 +204,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 2, len(dagTasks))
@@ -197,11 +228,11 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 4, len(dagTasks))
-		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token")
+		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token_secret")
 		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Value, v1alpha1.AnyStringPtr(mockAuthToken))
 	})
 	t.Run("It should not get a dag task for global operations not defined in Argo", func(t *testing.T) {
@@ -224,7 +255,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 2, len(dagTasks))
@@ -235,10 +266,147 @@ func TestGetDagTasks(t *testing.T) {
 		assert.Equal(t, 0, len(dagTasks[0].Dependencies))
 		assert.Equal(t, 1, len(dagTasks[1].Dependencies))
 		assert.Equal(t, "this-is-an-operation-1", dagTasks[1].Dependencies[0])
-		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token")
+		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Name, "auth_token_secret")
 		assert.Equal(t, dagTasks[0].Arguments.Parameters[0].Value, v1alpha1.AnyStringPtr(mockAuthToken))
 	})
 
+	t.Run("It should only get dag tasks for the selected operations and products in a per-product stage", func(t *testing.T) {
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A", Version: "1.0.0"}, {Name: "product_B", Version: "2.0.0"}},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				Operations: []string{"this-is-an-operation-2"},
+				Products:   []string{"product_B"},
+			},
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "product",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1"},
+				{Name: "this-is-an-operation-2"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, products, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(dagTasks))
+		assert.True(t, strings.HasPrefix(dagTasks[0].Name, "product-B-2-0-0-this-is-an-operation-2"))
+		assert.Equal(t, "this-is-an-operation-2", dagTasks[0].TemplateRef.Name)
+		assert.Equal(t, 0, len(dagTasks[0].Dependencies))
+		// only the selected products are processed in this stage
+		assert.Equal(t, 1, len(products))
+		assert.Equal(t, "product_B", products[0].Name)
+	})
+	t.Run("It should select products by their name and version", func(t *testing.T) {
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A", Version: "1.0.0"}, {Name: "product_A", Version: "2.0.0"}},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				Products: []string{"product_A-2.0.0"},
+			},
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "product",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1"},
+				{Name: "this-is-an-operation-2"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(dagTasks))
+		for _, dagTask := range dagTasks {
+			assert.True(t, strings.HasPrefix(dagTask.Name, "product-A-2-0-0-"))
+		}
+	})
+	t.Run("It should only get dag tasks for the selected operations in a global stage", func(t *testing.T) {
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}, {Name: "product_B"}},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				Operations: []string{"this-is-an-operation-2"},
+			},
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "global",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1"},
+				{Name: "this-is-an-operation-2"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(dagTasks))
+		assert.Equal(t, "this-is-an-operation-2", dagTasks[0].Name)
+		assert.Equal(t, 0, len(dagTasks[0].Dependencies))
+	})
+	t.Run("It should only get hooks for the selected products and none when operations are selected", func(t *testing.T) {
+		session := iuf.Session{
+			Products: []iuf.Product{
+				{
+					Name:             "cos",
+					Version:          "1.2.3",
+					OriginalLocation: cosOriginalLocation,
+					Manifest:         cosManifest,
+				},
+				{
+					Name:             "sdu",
+					Version:          "3.4.5",
+					OriginalLocation: sduOriginalLocation,
+					Manifest:         sduManifest_alt,
+				},
+			},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				Products: []string{"cos"},
+			},
+		}
+		stageInfo := iuf.Stage{
+			Name: "pre-install-check",
+			Type: "global",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+			Hooks: map[string]string{
+				"master_host": "master-host-hook-script",
+				"worker_host": "worker-host-hook-script",
+			},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(dagTasks))
+		for _, dagTask := range dagTasks {
+			assert.False(t, strings.HasPrefix(dagTask.Name, "sdu"))
+		}
+
+		session.InputParameters.Operations = []string{"this-is-an-operation-1"}
+		dagTasks, _, err = iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(dagTasks))
+		assert.Equal(t, "this-is-an-operation-1", dagTasks[0].Name)
+	})
 	t.Run("It should get DAG tasks for existing hook templates defined for product operations", func(t *testing.T) {
 		session := iuf.Session{
 			Products: []iuf.Product{
@@ -275,7 +443,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 7, len(dagTasks))
@@ -293,7 +461,7 @@ func TestGetDagTasks(t *testing.T) {
 				product = session.Products[1]
 			}
 
-			assert.Equal(t, v1alpha1.AnyStringPtr(mockAuthToken), dagTask.Arguments.GetParameterByName("auth_token").Value)
+			assert.Equal(t, v1alpha1.AnyStringPtr(mockAuthToken), dagTask.Arguments.GetParameterByName("auth_token_secret").Value)
 
 			productKey := iufSvc.getProductVersionKey(product)
 			assert.Equal(t, v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.%s}}", productKey)), dagTask.Arguments.GetParameterByName("global_params").Value)
@@ -395,7 +563,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 6, len(dagTasks))
@@ -420,7 +588,7 @@ func TestGetDagTasks(t *testing.T) {
 				assert.Equal(t, v1alpha1.AnyStringPtr(fmt.Sprintf("{{workflow.parameters.global_params}}")), dagTask.Arguments.GetParameterByName("global_params").Value)
 			}
 
-			assert.Equal(t, v1alpha1.AnyStringPtr(mockAuthToken), dagTask.Arguments.GetParameterByName("auth_token").Value)
+			assert.Equal(t, v1alpha1.AnyStringPtr(mockAuthToken), dagTask.Arguments.GetParameterByName("auth_token_secret").Value)
 
 			if strings.HasPrefix(dagTask.Name, "cos-1-2-3-pre-hook-pre-install-check") {
 				t.Run("cos pre hook script operation exist and has the right dependencies", func(t *testing.T) {
@@ -475,6 +643,166 @@ func TestGetDagTasks(t *testing.T) {
 
 		assert.Equal(t, 6, found)
 	})
+	t.Run("It should retry operations with a retry policy from a template of their own", func(t *testing.T) {
+		retryLimit := 3
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}},
+			ActivityRef: activityName,
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "product",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1", OperationPolicy: iuf.OperationPolicy{RetryLimit: &retryLimit, RetryBackoff: "1m", Timeout: "30m", RetryWindow: "2h"}},
+				{Name: "this-is-an-operation-2"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(dagTasks))
+		assert.Zero(t, dagTasks[0].TemplateRef)
+		assert.Equal(t, dagTasks[0].Name, dagTasks[0].Template)
+		assert.Equal(t, "this-is-an-operation-2", dagTasks[1].TemplateRef.Name)
+
+		assert.Equal(t, 1, len(workflow.Spec.Templates))
+		template := workflow.Spec.Templates[0]
+		assert.Equal(t, dagTasks[0].Name, template.Name)
+		assert.Equal(t, "3", template.RetryStrategy.Limit.String())
+		assert.Equal(t, v1alpha1.RetryPolicyOnFailure, template.RetryStrategy.RetryPolicy)
+		assert.Equal(t, "1m", template.RetryStrategy.Backoff.Duration)
+		assert.Equal(t, "2h", template.RetryStrategy.Backoff.MaxDuration)
+		assert.Equal(t, "1800", template.ActiveDeadlineSeconds.String())
+		assert.Equal(t, 2, len(template.Inputs.Parameters))
+		step := template.Steps[0].Steps[0]
+		assert.Equal(t, "this-is-an-operation-1", step.TemplateRef.Name)
+		assert.Equal(t, "{{inputs.parameters.auth_token_secret}}", step.Arguments.Parameters[0].Value.String())
+	})
+	t.Run("It should let the session override the retry policy of an operation", func(t *testing.T) {
+		retryLimit := 3
+		noRetries := 0
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				OperationPolicies: map[string]iuf.OperationPolicy{
+					"this-is-an-operation-1": {RetryLimit: &noRetries},
+					"this-is-an-operation-2": {RetryLimit: &retryLimit, RetryPolicy: "Always"},
+				},
+			},
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "global",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1", OperationPolicy: iuf.OperationPolicy{RetryLimit: &retryLimit}},
+				{Name: "this-is-an-operation-2"},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(dagTasks))
+		assert.Equal(t, "this-is-an-operation-1", dagTasks[0].TemplateRef.Name)
+		assert.Equal(t, "this-is-an-operation-2", dagTasks[1].Template)
+		assert.Equal(t, 1, len(workflow.Spec.Templates))
+		assert.Equal(t, v1alpha1.RetryPolicyAlways, workflow.Spec.Templates[0].RetryStrategy.RetryPolicy)
+		assert.Zero(t, workflow.Spec.Templates[0].RetryStrategy.Backoff)
+	})
+	t.Run("It should time out operations without a retry policy from a template of their own", func(t *testing.T) {
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}},
+			ActivityRef: activityName,
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "global",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1", OperationPolicy: iuf.OperationPolicy{Timeout: "1h"}},
+				{Name: "this-is-an-operation-2", OperationPolicy: iuf.OperationPolicy{Timeout: "10ms"}},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(dagTasks))
+		assert.Equal(t, "this-is-an-operation-1", dagTasks[0].Template)
+		assert.Equal(t, "echo-template", dagTasks[1].TemplateRef.Name)
+		assert.Equal(t, 1, len(workflow.Spec.Templates))
+		assert.Zero(t, workflow.Spec.Templates[0].RetryStrategy)
+		assert.Equal(t, "3600", workflow.Spec.Templates[0].ActiveDeadlineSeconds.String())
+	})
+	t.Run("It should never retry management-nodes-rollout", func(t *testing.T) {
+		retryLimit := 3
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}},
+			ActivityRef: activityName,
+			InputParameters: iuf.InputParameters{
+				LimitManagementNodes:      []string{"ncn-w002"},
+				ManagementRolloutStrategy: iuf.EManagementRolloutStrategyRebuild,
+				OperationPolicies: map[string]iuf.OperationPolicy{
+					"management-nodes-rollout": {RetryLimit: &retryLimit},
+				},
+			},
+		}
+		stages, err := iufSvc.GetStages()
+		assert.NoError(t, err)
+		var stageInfo iuf.Stage
+		for _, stage := range stages.Stages {
+			if stage.Name == "management-nodes-rollout" {
+				stageInfo = stage
+			}
+		}
+		assert.True(t, stageInfo.Operations[0].NoRetry)
+		stageInfo.Operations[0].RetryLimit = &retryLimit
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(dagTasks))
+		assert.Equal(t, "management-worker-nodes-rollout", dagTasks[0].TemplateRef.Name)
+		assert.Equal(t, 0, len(workflow.Spec.Templates))
+		retryStrategy, err := iufSvc.getRetryStrategy(stageInfo.Operations[0], iuf.OperationPolicy{RetryLimit: &retryLimit})
+		assert.NoError(t, err)
+		assert.Zero(t, retryStrategy)
+	})
+	t.Run("It should report an invalid retry policy through an echo template", func(t *testing.T) {
+		retryLimit := 3
+		session := iuf.Session{
+			Products:    []iuf.Product{{Name: "product_A"}},
+			ActivityRef: activityName,
+		}
+		stageInfo := iuf.Stage{
+			Name: "this_is_a_stage_name",
+			Type: "product",
+			Operations: []iuf.Operations{
+				{Name: "this-is-an-operation-1", OperationPolicy: iuf.OperationPolicy{RetryLimit: &retryLimit, RetryPolicy: "Sometimes"}},
+			},
+		}
+		stages := iuf.Stages{
+			Stages: []iuf.Stage{stageInfo},
+		}
+		workflow := v1alpha1.Workflow{}
+
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(dagTasks))
+		assert.Equal(t, "echo-template", dagTasks[0].TemplateRef.Name)
+		assert.Equal(t, "true", dagTasks[0].Arguments.Parameters[1].Value.String())
+		assert.Equal(t, 0, len(workflow.Spec.Templates))
+	})
 	t.Run("It should get correct template for Management-nodes-rollout if worker hostname is provided", func(t *testing.T) {
 		session := iuf.Session{
 			Products:        []iuf.Product{{Name: "product_A"}, {Name: "product_B"}},
@@ -494,7 +822,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 2, len(dagTasks))
@@ -520,7 +848,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 2, len(dagTasks))
@@ -545,7 +873,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 2, len(dagTasks))
@@ -571,7 +899,7 @@ func TestGetDagTasks(t *testing.T) {
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NotEmpty(t, dagTasks)
 		assert.NoError(t, err)
 	})
@@ -638,7 +966,7 @@ content:
 		}
 		workflow := v1alpha1.Workflow{}
 
-		dagTasks, _, err := iufSvc.getDAGTasks(&session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token", &workflow)
+		dagTasks, _, err := iufSvc.getDAGTasks(context.TODO(), &session, stageInfo, stages, globalParamsPerProduct, "global_params", "auth_token_secret", &workflow, operationCounts{})
 		assert.NoError(t, err)
 		assert.NotEmpty(t, dagTasks)
 		assert.Equal(t, 6, len(dagTasks))
@@ -695,7 +1023,7 @@ content:
 		// this is a predetermined number from running this test. Remember that the workflow is split as per the size of the JSON serialized form of the workflow.
 		expectedProductsToProcessInFirstWorkflow := 15
 
-		workflowRes, err, _ := iufSvc.workflowGen(&session)
+		workflowRes, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err, "Should not have had an error when generating first workflow")
 		assert.NotEmpty(t, workflowRes.Spec.Templates[0].DAG.Tasks, "Tasks should not be empty for first workflow")
 		assert.Equal(t, workflowRes.Labels[LABEL_PARTIAL_WORKFLOW], "true", "partial workflow expected")
@@ -703,7 +1031,7 @@ content:
 		assert.Equal(t, expectedProductsToProcessInFirstWorkflow*numOperations, len(workflowRes.Spec.Templates[0].DAG.Tasks), "Unexpected number of total operations for first workflow") // (number of products to process) * (2 ops per product as per above)
 
 		// now let's try to get the next set of tasks
-		workflowRes, err, _ = iufSvc.workflowGen(&session)
+		workflowRes, err, _ = iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err, "Should not have had an error when generating second workflow")
 		assert.NotEmpty(t, workflowRes.Spec.Templates[0].DAG.Tasks, "Tasks should not be empty for second workflow")
 		assert.Equal(t, len(products), len(session.ProcessedProductsByStage["deliver-product"]), "Unexpected number of processed products for second workflow")
@@ -738,7 +1066,7 @@ content:
 		// this is a predetermined number from running this test. Remember that the workflow is split as per the size of the JSON serialized form of the workflow.
 		expectedProductsToProcessInFirstWorkflow := 15
 
-		workflowRes, err, _ := iufSvc.workflowGen(&session)
+		workflowRes, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err, "Should not have had an error when generating first workflow")
 		assert.NotEmpty(t, workflowRes.Spec.Templates[0].DAG.Tasks, "Tasks should not be empty for first workflow")
 		assert.Equal(t, workflowRes.Labels[LABEL_PARTIAL_WORKFLOW], "true", "partial workflow expected")
@@ -746,7 +1074,7 @@ content:
 		assert.Equal(t, expectedProductsToProcessInFirstWorkflow*numOperations, len(workflowRes.Spec.Templates[0].DAG.Tasks), "Unexpected number of total operations for first workflow") // (number of products to process) * (2 ops per product as per above)
 
 		// now let's try to get the next set of tasks
-		workflowRes, err, _ = iufSvc.workflowGen(&session)
+		workflowRes, err, _ = iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err, "Should not have had an error when generating second workflow")
 		assert.NotEmpty(t, workflowRes.Spec.Templates[0].DAG.Tasks, "Tasks should not be empty for second workflow")
 		assert.Equal(t, len(products), len(session.ProcessedProductsByStage["deliver-product"]), "Unexpected number of processed products for second workflow")
@@ -778,7 +1106,7 @@ content:
 			}
 		}
 
-		workflowRes, err, _ := iufSvc.workflowGen(&session)
+		workflowRes, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err, "Should not have had an error when generating first workflow")
 		assert.NotEmpty(t, workflowRes.Spec.Templates[0].DAG.Tasks, "Tasks should not be empty for first workflow")
 		assert.Empty(t, workflowRes.Labels[LABEL_PARTIAL_WORKFLOW], "partial workflow unexpected")
@@ -803,7 +1131,7 @@ content:
 			ActivityRef: activityName,
 		}
 
-		workflow, err, _ := iufSvc.workflowGen(&session)
+		workflow, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 
 		assert.Empty(t, workflow.Spec.OnExit)
@@ -827,7 +1155,7 @@ content:
 			ActivityRef: activityName,
 		}
 
-		workflow, err, _ := iufSvc.workflowGen(&session)
+		workflow, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 
 		assert.Equal(t, "onExitHandlers", workflow.Spec.OnExit)
@@ -867,13 +1195,13 @@ content:
 		// this is a predetermined number from running this test. Remember that the workflow is split as per the size of the JSON serialized form of the workflow.
 		expectedProductsToProcessInFirstWorkflow := 15
 
-		workflowRes, err, _ := iufSvc.workflowGen(&session)
+		workflowRes, err, _ := iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 		assert.Equal(t, len(session.ProcessedProductsByStage["deliver-product"]), expectedProductsToProcessInFirstWorkflow)
 		assert.Empty(t, workflowRes.Spec.OnExit)
 
 		// now let's try to get the next set of tasks
-		workflowRes, err, _ = iufSvc.workflowGen(&session)
+		workflowRes, err, _ = iufSvc.workflowGen(context.TODO(), &session, operationCounts{})
 		assert.NoError(t, err)
 		assert.Equal(t, len(session.ProcessedProductsByStage["deliver-product"]), len(products))
 
@@ -888,6 +1216,88 @@ content:
 	})
 }
 
+func TestGetStageWorkflow(t *testing.T) {
+	activityName, _, iufSvc := setup(t)
+
+	session := iuf.Session{
+		Products:     []iuf.Product{{Name: "product_A"}, {Name: "product_B"}},
+		CurrentStage: "process-media",
+		CurrentState: iuf.SessionStateInProgress,
+		InputParameters: iuf.InputParameters{
+			Stages:                    []string{"process-media", "management-nodes-rollout"},
+			LimitManagementNodes:      []string{"ncn-m001"},
+			ManagementRolloutStrategy: iuf.EManagementRolloutStrategyRebuild,
+		},
+		ActivityRef: activityName,
+	}
+
+	t.Run("It should render the workflow for the requested stage without the auth token", func(t *testing.T) {
+		workflow, err, skipStage := iufSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.NoError(t, err)
+		assert.False(t, skipStage)
+		assert.Equal(t, "management-nodes-rollout", workflow.Labels["stage"])
+
+		workflowJson, err := json.Marshal(workflow)
+		assert.NoError(t, err)
+		assert.NotContains(t, string(workflowJson), "mock_token")
+	})
+
+	t.Run("It should reference the auth token secret from a parameter and a volume", func(t *testing.T) {
+		workflow, err, _ := iufSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.NoError(t, err)
+
+		secretName := workflow.Labels[LABEL_AUTH_TOKEN_SECRET]
+		assert.True(t, strings.HasPrefix(secretName, session.Name+"-management-nodes-rollout-token-"))
+		assert.Equal(t, secretName, workflow.Spec.Arguments.GetParameterByName(WORKFLOW_PARAM_AUTH_TOKEN_SECRET).Value.String())
+		found := false
+		for _, volume := range workflow.Spec.Volumes {
+			if volume.Name == AUTH_TOKEN_VOLUME {
+				found = true
+				assert.Equal(t, secretName, volume.Secret.SecretName)
+			}
+		}
+		assert.True(t, found)
+	})
+
+	t.Run("It should set the timeout of the stage as the deadline of the workflow", func(t *testing.T) {
+		workflow, err, _ := iufSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6*60*60), *workflow.Spec.ActiveDeadlineSeconds)
+		assert.Equal(t, "main", workflow.Spec.Templates[0].Name)
+	})
+
+	t.Run("It should not change the session", func(t *testing.T) {
+		_, err, _ := iufSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.NoError(t, err)
+		assert.Equal(t, "process-media", session.CurrentStage)
+		assert.Equal(t, 0, len(session.ProcessedProductsByStage))
+	})
+
+	t.Run("It should not count the operations, since nothing is submitted", func(t *testing.T) {
+		executed := utils.IufOperationsTotal.WithLabelValues("management-nodes-rollout", utils.METRICS_OPERATION_EXECUTED)
+		before := testutil.ToFloat64(executed)
+		_, err, _ := iufSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.NoError(t, err)
+		assert.Equal(t, before, testutil.ToFloat64(executed))
+	})
+
+	t.Run("It should fail for an unknown stage", func(t *testing.T) {
+		_, err, _ := iufSvc.GetStageWorkflow(context.TODO(), session, "no-such-stage")
+		assert.Error(t, err)
+	})
+
+	t.Run("It should fail instead of using the stages of a stages.yaml that is not valid", func(t *testing.T) {
+		workflowFiles := t.TempDir()
+		stages := "version: 0.1.0\nstages:\n- name: process-media\n  type: global\n  depends-on: [management-nodes-rollout]\n- name: management-nodes-rollout\n  type: global\n"
+		assert.NoError(t, os.WriteFile(filepath.Join(workflowFiles, "stages.yaml"), []byte(stages), 0644))
+		invalidStagesSvc := iufSvc
+		invalidStagesSvc.env.IufInstallWorkflowFiles = workflowFiles
+
+		_, err, _ := invalidStagesSvc.GetStageWorkflow(context.TODO(), session, "management-nodes-rollout")
+		assert.True(t, errors.As(err, &StageValidationError{}), err)
+	})
+}
+
 func setup(t *testing.T) (string, string, iufService) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -957,9 +1367,8 @@ func setup(t *testing.T) (string, string, iufService) {
 	}
 	fakeClient := fake.NewSimpleClientset(&configmap)
 
-	mockTokenValue := "{{workflow.parameters.auth_token}}"
 	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
-	keycloakServiceMock.EXPECT().NewKeycloakAccessToken().Return(mockTokenValue, nil).AnyTimes()
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return("mock_token", nil).AnyTimes()
 
 	iufSvc := iufService{
 		logger:                 utils.GetLogger(),
@@ -969,5 +1378,5 @@ func setup(t *testing.T) (string, string, iufService) {
 		keycloakService:        keycloakServiceMock,
 		env:                    utils.Env{WorkerRebuildWorkflowFiles: "badname", IufInstallWorkflowFiles: "./_test_data_"},
 	}
-	return name, mockTokenValue, iufSvc
+	return name, "{{workflow.parameters.auth_token_secret}}", iufSvc
 }
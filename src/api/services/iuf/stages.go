@@ -27,18 +27,217 @@ package services_iuf
 
 import (
 	_ "embed"
+	"fmt"
 	"os"
+	"strings"
 
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"golang.org/x/exp/slices"
 	"sigs.k8s.io/yaml"
 )
 
+// StageValidationError is returned when the stages requested for a session are invalid
+type StageValidationError struct {
+	Message string
+}
+
+func (e StageValidationError) Error() string {
+	return e.Message
+}
+
 func (s iufService) GetStages() (iuf.Stages, error) {
-	stagesBytes, _ := os.ReadFile(s.env.IufInstallWorkflowFiles + "/stages.yaml")
+	stagesBytes, err := os.ReadFile(s.env.IufInstallWorkflowFiles + "/stages.yaml")
+	if err != nil {
+		s.logger.Error(err)
+		return iuf.Stages{}, err
+	}
 	var stages iuf.Stages
-	err := yaml.Unmarshal(stagesBytes, &stages)
+	err = yaml.Unmarshal(stagesBytes, &stages)
+	if err != nil {
+		s.logger.Error(err)
+		return stages, err
+	}
+	err = s.validateStagesGraph(stages)
 	if err != nil {
 		s.logger.Error(err)
 	}
 	return stages, err
 }
+
+// validateStagesGraph checks that every stage in stages.yaml only depends on stages defined before it, which also rules
+//  out cycles.
+func (s iufService) validateStagesGraph(stages iuf.Stages) error {
+	stageIndexByName := map[string]int{}
+	for i, stage := range stages.Stages {
+		stageIndexByName[stage.Name] = i
+	}
+
+	for i, stage := range stages.Stages {
+		for _, dependency := range stage.DependsOn {
+			if dependencyIndex, exists := stageIndexByName[dependency]; !exists || dependencyIndex >= i {
+				return StageValidationError{Message: fmt.Sprintf("The stage %s in stages.yaml depends on %s, which is not a stage defined before it.", stage.Name, dependency)}
+			}
+		}
+	}
+	return nil
+}
+
+// validateStagesToRun checks that the given stage names exist in stages.yaml, are not repeated, are given in the
+//  order defined in stages.yaml and that the activity has what the stages need to run.
+func (s iufService) validateStagesToRun(activity iuf.Activity, stageNames []string) error {
+	if len(stageNames) == 0 {
+		return StageValidationError{Message: "No stages were given to run."}
+	}
+
+	stages, err := s.GetStages()
+	if err != nil {
+		return err
+	}
+
+	stageIndexByName := map[string]int{}
+	var canonicalOrder []string
+	for i, stage := range stages.Stages {
+		stageIndexByName[stage.Name] = i
+		canonicalOrder = append(canonicalOrder, stage.Name)
+	}
+
+	var unknownStages []string
+	for _, stageName := range stageNames {
+		if _, exists := stageIndexByName[stageName]; !exists {
+			unknownStages = append(unknownStages, stageName)
+		}
+	}
+	if len(unknownStages) > 0 {
+		return StageValidationError{Message: fmt.Sprintf("Unknown stages: %s. Valid stages are: %s.", strings.Join(unknownStages, ", "), strings.Join(canonicalOrder, ", "))}
+	}
+
+	seen := map[string]bool{}
+	for i, stageName := range stageNames {
+		if seen[stageName] {
+			return StageValidationError{Message: fmt.Sprintf("The stage %s is given more than once.", stageName)}
+		}
+		seen[stageName] = true
+
+		if i > 0 && stageIndexByName[stageName] < stageIndexByName[stageNames[i-1]] {
+			return StageValidationError{Message: fmt.Sprintf("The stage %s must run before the stage %s. Stages must be given in this order: %s.", stageName, stageNames[i-1], strings.Join(canonicalOrder, ", "))}
+		}
+	}
+
+	// process-media is what populates the products of an activity, so every other stage needs it to have run before
+	if len(activity.Products) == 0 && stageNames[0] != "process-media" {
+		return StageValidationError{Message: fmt.Sprintf("The activity %s has no products, so the stage %s cannot run. Run the stage process-media first.", activity.Name, stageNames[0])}
+	}
+
+	return nil
+}
+
+// validateOperationsAndProducts checks that the operations selected to run are operations of the given stages and that
+//  the products selected to run are products of the activity. The products are only checked once the activity has
+//  products, since process-media is what finds them.
+func (s iufService) validateOperationsAndProducts(activity iuf.Activity, stageNames []string, operations []string, products []string) error {
+	stages, err := s.GetStages()
+	if err != nil {
+		return err
+	}
+
+	var validOperations []string
+	for _, stage := range stages.Stages {
+		if !slices.Contains(stageNames, stage.Name) {
+			continue
+		}
+		for _, operation := range stage.Operations {
+			if !slices.Contains(validOperations, operation.Name) {
+				validOperations = append(validOperations, operation.Name)
+			}
+		}
+	}
+
+	var unknownOperations []string
+	for _, operation := range operations {
+		if !slices.Contains(validOperations, operation) {
+			unknownOperations = append(unknownOperations, operation)
+		}
+	}
+	if len(unknownOperations) > 0 {
+		return StageValidationError{Message: fmt.Sprintf("Unknown operations: %s. Valid operations of the stages %s are: %s.", strings.Join(unknownOperations, ", "), strings.Join(stageNames, ", "), strings.Join(validOperations, ", "))}
+	}
+
+	if len(activity.Products) == 0 {
+		return nil
+	}
+
+	var unknownProducts []string
+	for _, name := range products {
+		found := false
+		for _, product := range activity.Products {
+			if s.productMatches(product, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			unknownProducts = append(unknownProducts, name)
+		}
+	}
+	if len(unknownProducts) > 0 {
+		var validProducts []string
+		for _, product := range activity.Products {
+			validProducts = append(validProducts, product.Name+"-"+product.Version)
+		}
+		return StageValidationError{Message: fmt.Sprintf("Unknown products: %s. Products of the activity %s are: %s.", strings.Join(unknownProducts, ", "), activity.Name, strings.Join(validProducts, ", "))}
+	}
+
+	return nil
+}
+
+// getStageDependencies returns the stages among requestedStages that the given stage depends on, either directly or
+//  through stages that were not requested.
+func (s iufService) getStageDependencies(stageName string, stages iuf.Stages, requestedStages []string) []string {
+	idx := slices.IndexFunc(stages.Stages, func(stage iuf.Stage) bool { return stage.Name == stageName })
+	if idx < 0 {
+		return nil
+	}
+
+	directDependencies := stages.Stages[idx].DependsOn
+	if directDependencies == nil && idx > 0 {
+		directDependencies = []string{stages.Stages[idx-1].Name}
+	}
+
+	var res []string
+	for _, dependency := range directDependencies {
+		dependencyIdx := slices.IndexFunc(stages.Stages, func(stage iuf.Stage) bool { return stage.Name == dependency })
+		if dependencyIdx < 0 || dependencyIdx >= idx {
+			// only stages defined before this one can be depended on
+			continue
+		}
+		if slices.Contains(requestedStages, dependency) {
+			res = append(res, dependency)
+		} else {
+			res = append(res, s.getStageDependencies(dependency, stages, requestedStages)...)
+		}
+	}
+	return res
+}
+
+// getStagesReadyToRun returns the stages of the session that are neither running nor completed, and whose dependencies
+//  have all completed.
+func (s iufService) getStagesReadyToRun(session *iuf.Session, stages iuf.Stages) []string {
+	var res []string
+	for _, stageName := range session.InputParameters.Stages {
+		if slices.Contains(session.CurrentStages, stageName) || slices.Contains(session.CompletedStages, stageName) {
+			continue
+		}
+
+		ready := true
+		for _, dependency := range s.getStageDependencies(stageName, stages, session.InputParameters.Stages) {
+			if !slices.Contains(session.CompletedStages, dependency) {
+				ready = false
+				break
+			}
+		}
+		if ready {
+			res = append(res, stageName)
+		}
+	}
+	return res
+}
@@ -0,0 +1,208 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	services_shared "github.com/Cray-HPE/cray-nls/src/api/services/shared"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	argo_v1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
+	"go.uber.org/fx"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// how often the auth token Secrets of running workflows are checked for a token that is about to expire
+	WORKFLOW_TOKEN_REFRESH_INTERVAL = 1 * time.Minute
+	// a Secret without a running workflow is only deleted once it got its token this long ago, so we do not race
+	//  CreateIufWorkflow, or ResumeSession retrying the workflow
+	WORKFLOW_TOKEN_SECRET_GRACE_PERIOD = 5 * time.Minute
+	// annotation of the Secrets with the time they last got a token, in RFC 3339
+	ANNOTATION_AUTH_TOKEN_UPDATED = "iuf_auth_token_updated"
+)
+
+// StartWorkflowTokenRefresher keeps the auth token Secrets of running IUF workflows current. Steps that mount the Secret
+//  see the new token, even late in a long rollout. The Secret of a workflow that has completed is deleted, and created
+//  again by ResumeSession if the workflow is retried. Secrets whose workflow was never created are deleted too.
+func StartWorkflowTokenRefresher(lifecycle fx.Lifecycle, logger utils.Logger, k8sSvc services_shared.K8sService, keycloakService services_shared.KeycloakService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	refresher := workflowTokenRefresher{
+		logger:           logger,
+		workflowClient:   k8sSvc.ArgoClient.ArgoprojV1alpha1().Workflows(DEFAULT_NAMESPACE),
+		k8sRestClientSet: k8sSvc.Client,
+		keycloakService:  keycloakService,
+		now:              time.Now,
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go refresher.run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+type workflowTokenRefresher struct {
+	logger           utils.Logger
+	workflowClient   argo_v1alpha1.WorkflowInterface
+	k8sRestClientSet kubernetes.Interface
+	keycloakService  services_shared.KeycloakService
+	now              func() time.Time
+}
+
+func (r workflowTokenRefresher) run(ctx context.Context) {
+	ticker := time.NewTicker(WORKFLOW_TOKEN_REFRESH_INTERVAL)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.refresh(ctx)
+		}
+	}
+}
+
+func (r workflowTokenRefresher) refresh(ctx context.Context) {
+	workflows, err := r.workflowClient.List(ctx, v1.ListOptions{
+		LabelSelector: "iuf=true",
+	})
+	if err != nil {
+		r.logger.Warnf("workflowTokenRefresher.1: unable to list IUF workflows: %v", err)
+		return
+	}
+	// whether the workflow of each Secret is still running
+	running := map[string]bool{}
+	for _, workflow := range workflows.Items {
+		if secretName := workflow.Labels[LABEL_AUTH_TOKEN_SECRET]; secretName != "" {
+			running[secretName] = workflow.Labels["workflows.argoproj.io/completed"] != "true"
+		}
+	}
+
+	secrets, err := r.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).List(ctx, v1.ListOptions{
+		LabelSelector: fmt.Sprintf("type=%s", LABEL_AUTH_TOKEN),
+	})
+	if err != nil {
+		r.logger.Warnf("workflowTokenRefresher.2: unable to list auth token secrets: %v", err)
+		return
+	}
+
+	var token string
+	for _, secret := range secrets.Items {
+		isRunning, hasWorkflow := running[secret.Name]
+		if !isRunning {
+			if r.now().Sub(authTokenUpdatedAt(secret)) < WORKFLOW_TOKEN_SECRET_GRACE_PERIOD {
+				continue
+			}
+			err := r.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Delete(ctx, secret.Name, v1.DeleteOptions{})
+			if err != nil && !errors.IsNotFound(err) {
+				r.logger.Warnf("workflowTokenRefresher.3: unable to delete auth token secret %s: %v", secret.Name, err)
+				continue
+			}
+			if hasWorkflow {
+				r.logger.Infof("workflowTokenRefresher: deleted auth token secret %s, its workflow has completed", secret.Name)
+			} else {
+				r.logger.Infof("workflowTokenRefresher: deleted auth token secret %s, it has no workflow", secret.Name)
+			}
+			continue
+		}
+
+		if token == "" {
+			// the token is cached, so this only changes when it is close to expiry
+			token, err = r.keycloakService.NewKeycloakAccessToken(ctx)
+			if err != nil {
+				r.logger.Warnf("workflowTokenRefresher.4: unable to get a token: %v", err)
+				return
+			}
+		}
+		if string(secret.Data[AUTH_TOKEN_SECRET_KEY]) == token {
+			continue
+		}
+		setAuthToken(&secret, token, r.now())
+		_, err = r.k8sRestClientSet.CoreV1().Secrets(DEFAULT_NAMESPACE).Update(ctx, &secret, v1.UpdateOptions{})
+		if err != nil {
+			r.logger.Warnf("workflowTokenRefresher.5: unable to refresh auth token secret %s: %v", secret.Name, err)
+			continue
+		}
+		r.logger.Infof("workflowTokenRefresher: refreshed auth token secret %s", secret.Name)
+	}
+}
+
+// newAuthTokenSecret returns the Secret holding the auth token of a workflow
+func newAuthTokenSecret(name string, token string) core_v1.Secret {
+	secret := core_v1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: DEFAULT_NAMESPACE,
+			Labels: map[string]string{
+				"type": LABEL_AUTH_TOKEN,
+				"iuf":  "true",
+			},
+		},
+		Type: core_v1.SecretTypeOpaque,
+	}
+	setAuthToken(&secret, token, time.Now())
+	return secret
+}
+
+// setAuthToken puts the token in the Secret, and records when it did
+func setAuthToken(secret *core_v1.Secret, token string, now time.Time) {
+	secret.Data = map[string][]byte{AUTH_TOKEN_SECRET_KEY: []byte(token)}
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[ANNOTATION_AUTH_TOKEN_UPDATED] = now.UTC().Format(time.RFC3339)
+}
+
+// authTokenUpdatedAt returns when the Secret last got a token. Secrets without the annotation got it when they were created.
+func authTokenUpdatedAt(secret core_v1.Secret) time.Time {
+	updated, err := time.Parse(time.RFC3339, secret.Annotations[ANNOTATION_AUTH_TOKEN_UPDATED])
+	if err != nil || updated.Before(secret.CreationTimestamp.Time) {
+		return secret.CreationTimestamp.Time
+	}
+	return updated
+}
+
+// authTokenSecretOwner makes the workflow the owner of its auth token Secret, so the Secret is deleted with the workflow
+func authTokenSecretOwner(workflow *v1alpha1.Workflow) []v1.OwnerReference {
+	return []v1.OwnerReference{{
+		APIVersion: "argoproj.io/v1alpha1",
+		Kind:       "Workflow",
+		Name:       workflow.Name,
+		UID:        workflow.UID,
+	}}
+}
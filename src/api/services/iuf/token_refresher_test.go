@@ -0,0 +1,118 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_iuf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	mocks "github.com/Cray-HPE/cray-nls/src/api/mocks/services"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	argo_fake "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/fake"
+	"github.com/golang/mock/gomock"
+	core_v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestWorkflowTokenRefresher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	newWorkflow := func(name string, secretName string, labels map[string]string) *v1alpha1.Workflow {
+		labels[LABEL_AUTH_TOKEN_SECRET] = secretName
+		return &v1alpha1.Workflow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: DEFAULT_NAMESPACE, Labels: labels},
+		}
+	}
+	newSecret := func(name string, age time.Duration, tokenAge time.Duration) *core_v1.Secret {
+		secret := newAuthTokenSecret(name, "old_token")
+		secret.CreationTimestamp = v1.NewTime(now.Add(-age))
+		setAuthToken(&secret, "old_token", now.Add(-tokenAge))
+		return &secret
+	}
+	argoClient := argo_fake.NewSimpleClientset(
+		newWorkflow("running", "running-token", map[string]string{"iuf": "true"}),
+		newWorkflow("completed", "completed-token", map[string]string{"iuf": "true", "workflows.argoproj.io/completed": "true"}),
+		newWorkflow("retried", "retried-token", map[string]string{"iuf": "true", "workflows.argoproj.io/completed": "true"}),
+	)
+	k8sClient := fake.NewSimpleClientset(
+		newSecret("running-token", time.Hour, time.Hour),
+		newSecret("completed-token", time.Hour, time.Hour),
+		newSecret("retried-token", time.Hour, time.Minute),
+		newSecret("deleted-workflow-token", time.Hour, time.Hour),
+		newSecret("new-token", time.Minute, time.Minute),
+	)
+	keycloakServiceMock := mocks.NewMockKeycloakService(ctrl)
+	keycloakServiceMock.EXPECT().NewKeycloakAccessToken(gomock.Any()).Return("new_token", nil).AnyTimes()
+
+	refresher := workflowTokenRefresher{
+		logger:           utils.GetLogger(),
+		workflowClient:   argoClient.ArgoprojV1alpha1().Workflows(DEFAULT_NAMESPACE),
+		k8sRestClientSet: k8sClient,
+		keycloakService:  keycloakServiceMock,
+		now:              func() time.Time { return now },
+	}
+	refresher.refresh(context.TODO())
+
+	getSecret := func(name string) (*core_v1.Secret, error) {
+		return k8sClient.CoreV1().Secrets(DEFAULT_NAMESPACE).Get(context.TODO(), name, v1.GetOptions{})
+	}
+	t.Run("It refreshes the token of running workflows", func(t *testing.T) {
+		secret, err := getSecret("running-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "new_token", string(secret.Data[AUTH_TOKEN_SECRET_KEY]))
+		assert.Equal(t, now, authTokenUpdatedAt(*secret))
+	})
+	t.Run("It does not change the workflows", func(t *testing.T) {
+		for _, action := range argoClient.Actions() {
+			assert.Equal(t, "list", action.GetVerb())
+		}
+	})
+	t.Run("It deletes the secrets of completed workflows", func(t *testing.T) {
+		_, err := getSecret("completed-token")
+		assert.True(t, errors.IsNotFound(err))
+	})
+	t.Run("It keeps the secrets of completed workflows that just got a token to be retried", func(t *testing.T) {
+		secret, err := getSecret("retried-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "old_token", string(secret.Data[AUTH_TOKEN_SECRET_KEY]))
+	})
+	t.Run("It deletes the secrets of workflows that do not exist", func(t *testing.T) {
+		_, err := getSecret("deleted-workflow-token")
+		assert.True(t, errors.IsNotFound(err))
+	})
+	t.Run("It keeps new secrets whose workflow may not be created yet", func(t *testing.T) {
+		secret, err := getSecret("new-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "old_token", string(secret.Data[AUTH_TOKEN_SECRET_KEY]))
+	})
+}
@@ -32,9 +32,17 @@ import (
 var Module = fx.Options(
 	fx.Provide(shared.NewK8sService),
 	fx.Provide(shared.NewKeycloakService),
+	fx.Provide(shared.NewNotifierService),
+	fx.Provide(shared.NewAuditService),
 	fx.Provide(nls.NewNcnService),
 	fx.Provide(shared.NewWorkflowService),
 	fx.Provide(shared.NewArgoService),
+	fx.Provide(shared.NewHealthService),
+	fx.Provide(shared.NewVersionService),
 	fx.Provide(iuf.NewIufService),
 	fx.Invoke(shared.NewWorkflowService),
+	fx.Invoke(shared.StartRebuildPhaseWatcher),
+	fx.Invoke(iuf.StartWorkflowTokenRefresher),
+	fx.Invoke(iuf.RegisterIufMetrics),
+	fx.Invoke(shared.RegisterRebuildMetrics),
 )
@@ -28,6 +28,7 @@ import (
 
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient"
+	"google.golang.org/grpc/metadata"
 )
 
 type ArgoService struct {
@@ -41,13 +42,18 @@ func NewArgoService(env utils.Env) ArgoService {
 			URL:                env.ArgoServerURL,
 			InsecureSkipVerify: true,
 			Secure:             false,
-			HTTP1:              true,
+			// gRPC rather than HTTP1, since the HTTP1 client of Argo does not cancel a call when its context is done
+			HTTP1: false,
 		},
 		AuthSupplier: func() string {
 			return env.ArgoToken
 		},
 	}
 	ctx, client, _ := apiclient.NewClientFromOpts(argoOps)
+	if client != nil {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		client = instrumentedArgoClient{Client: client, md: md}
+	}
 	return ArgoService{
 		Context: ctx,
 		Client:  client,
@@ -0,0 +1,284 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/info"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ARGO_CALL_TIMEOUT is how long a call to the Argo server may take. Streams are not limited.
+const ARGO_CALL_TIMEOUT = 30 * time.Second
+
+// instrumentedArgoClient hands out workflow, workflow template and info clients that trace their calls to the Argo server,
+//  and record their latency and errors. md is the gRPC metadata with the token for the Argo server, which the clients add
+//  to the context of each call, since calls are made with the context of the request rather than the one of ArgoService.
+type instrumentedArgoClient struct {
+	apiclient.Client
+	md metadata.MD
+}
+
+func (c instrumentedArgoClient) NewWorkflowServiceClient() workflow.WorkflowServiceClient {
+	return instrumentedWorkflowServiceClient{client: c.Client.NewWorkflowServiceClient(), md: c.md}
+}
+
+func (c instrumentedArgoClient) NewWorkflowTemplateServiceClient() (workflowtemplate.WorkflowTemplateServiceClient, error) {
+	client, err := c.Client.NewWorkflowTemplateServiceClient()
+	if err != nil {
+		return nil, err
+	}
+	return instrumentedWorkflowTemplateServiceClient{client: client, md: c.md}, nil
+}
+
+func (c instrumentedArgoClient) NewInfoServiceClient() (info.InfoServiceClient, error) {
+	client, err := c.Client.NewInfoServiceClient()
+	if err != nil {
+		return nil, err
+	}
+	return instrumentedInfoServiceClient{client: client, md: c.md}, nil
+}
+
+// startArgoCall starts a span for a call to the Argo server, and adds md to the metadata of ctx. The returned function
+//  ends the span, and records the latency and the error of the call.
+func startArgoCall(ctx context.Context, md metadata.MD, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	if len(md) > 0 {
+		outgoing, _ := metadata.FromOutgoingContext(ctx)
+		outgoing = outgoing.Copy()
+		for key, values := range md {
+			outgoing.Set(key, values...)
+		}
+		ctx = metadata.NewOutgoingContext(ctx, outgoing)
+	}
+	ctx, span := utils.Tracer().Start(ctx, "argo."+method, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, func(err error) {
+		utils.ArgoClientRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if err != nil {
+			utils.ArgoClientErrorsTotal.WithLabelValues(method).Inc()
+		}
+		utils.EndSpan(span, err)
+	}
+}
+
+// callArgo makes a call to the Argo server that gives up after ARGO_CALL_TIMEOUT, or as soon as ctx is done. The gRPC
+//  client cancels the call itself, so it has returned once callArgo returns. A call that creates something may still
+//  have been done by the Argo server when it times out, so callers check whether it exists before creating it again.
+func callArgo[T any](ctx context.Context, md metadata.MD, method string, call func(ctx context.Context) (T, error)) (res T, err error) {
+	ctx, end := startArgoCall(ctx, md, method)
+	defer func() { end(err) }()
+	ctx, cancel := context.WithTimeout(ctx, ARGO_CALL_TIMEOUT)
+	defer cancel()
+
+	res, err = call(ctx)
+	if err != nil && ctx.Err() != nil {
+		// as a gRPC status, so that callers can tell a timeout from an error of the Argo server
+		return res, status.FromContextError(ctx.Err()).Err()
+	}
+	return res, err
+}
+
+type instrumentedWorkflowServiceClient struct {
+	client workflow.WorkflowServiceClient
+	md     metadata.MD
+}
+
+func (c instrumentedWorkflowServiceClient) CreateWorkflow(ctx context.Context, in *workflow.WorkflowCreateRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "CreateWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.CreateWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) GetWorkflow(ctx context.Context, in *workflow.WorkflowGetRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "GetWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.GetWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) ListWorkflows(ctx context.Context, in *workflow.WorkflowListRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowList, error) {
+	return callArgo(ctx, c.md, "ListWorkflows", func(ctx context.Context) (*v1alpha1.WorkflowList, error) {
+		return c.client.ListWorkflows(ctx, in, opts...)
+	})
+}
+
+// for streams, only opening the stream is measured, and it stays open for as long as ctx
+func (c instrumentedWorkflowServiceClient) WatchWorkflows(ctx context.Context, in *workflow.WatchWorkflowsRequest, opts ...grpc.CallOption) (res workflow.WorkflowService_WatchWorkflowsClient, err error) {
+	ctx, end := startArgoCall(ctx, c.md, "WatchWorkflows")
+	defer func() { end(err) }()
+	return c.client.WatchWorkflows(ctx, in, opts...)
+}
+
+func (c instrumentedWorkflowServiceClient) WatchEvents(ctx context.Context, in *workflow.WatchEventsRequest, opts ...grpc.CallOption) (res workflow.WorkflowService_WatchEventsClient, err error) {
+	ctx, end := startArgoCall(ctx, c.md, "WatchEvents")
+	defer func() { end(err) }()
+	return c.client.WatchEvents(ctx, in, opts...)
+}
+
+func (c instrumentedWorkflowServiceClient) DeleteWorkflow(ctx context.Context, in *workflow.WorkflowDeleteRequest, opts ...grpc.CallOption) (*workflow.WorkflowDeleteResponse, error) {
+	return callArgo(ctx, c.md, "DeleteWorkflow", func(ctx context.Context) (*workflow.WorkflowDeleteResponse, error) {
+		return c.client.DeleteWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) RetryWorkflow(ctx context.Context, in *workflow.WorkflowRetryRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "RetryWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.RetryWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) ResubmitWorkflow(ctx context.Context, in *workflow.WorkflowResubmitRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "ResubmitWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.ResubmitWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) ResumeWorkflow(ctx context.Context, in *workflow.WorkflowResumeRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "ResumeWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.ResumeWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) SuspendWorkflow(ctx context.Context, in *workflow.WorkflowSuspendRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "SuspendWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.SuspendWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) TerminateWorkflow(ctx context.Context, in *workflow.WorkflowTerminateRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "TerminateWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.TerminateWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) StopWorkflow(ctx context.Context, in *workflow.WorkflowStopRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "StopWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.StopWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) SetWorkflow(ctx context.Context, in *workflow.WorkflowSetRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "SetWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.SetWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) LintWorkflow(ctx context.Context, in *workflow.WorkflowLintRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "LintWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.LintWorkflow(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowServiceClient) PodLogs(ctx context.Context, in *workflow.WorkflowLogRequest, opts ...grpc.CallOption) (res workflow.WorkflowService_PodLogsClient, err error) {
+	ctx, end := startArgoCall(ctx, c.md, "PodLogs")
+	defer func() { end(err) }()
+	return c.client.PodLogs(ctx, in, opts...)
+}
+
+func (c instrumentedWorkflowServiceClient) WorkflowLogs(ctx context.Context, in *workflow.WorkflowLogRequest, opts ...grpc.CallOption) (res workflow.WorkflowService_WorkflowLogsClient, err error) {
+	ctx, end := startArgoCall(ctx, c.md, "WorkflowLogs")
+	defer func() { end(err) }()
+	return c.client.WorkflowLogs(ctx, in, opts...)
+}
+
+func (c instrumentedWorkflowServiceClient) SubmitWorkflow(ctx context.Context, in *workflow.WorkflowSubmitRequest, opts ...grpc.CallOption) (*v1alpha1.Workflow, error) {
+	return callArgo(ctx, c.md, "SubmitWorkflow", func(ctx context.Context) (*v1alpha1.Workflow, error) {
+		return c.client.SubmitWorkflow(ctx, in, opts...)
+	})
+}
+
+type instrumentedWorkflowTemplateServiceClient struct {
+	client workflowtemplate.WorkflowTemplateServiceClient
+	md     metadata.MD
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) CreateWorkflowTemplate(ctx context.Context, in *workflowtemplate.WorkflowTemplateCreateRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowTemplate, error) {
+	return callArgo(ctx, c.md, "CreateWorkflowTemplate", func(ctx context.Context) (*v1alpha1.WorkflowTemplate, error) {
+		return c.client.CreateWorkflowTemplate(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) GetWorkflowTemplate(ctx context.Context, in *workflowtemplate.WorkflowTemplateGetRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowTemplate, error) {
+	return callArgo(ctx, c.md, "GetWorkflowTemplate", func(ctx context.Context) (*v1alpha1.WorkflowTemplate, error) {
+		return c.client.GetWorkflowTemplate(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) ListWorkflowTemplates(ctx context.Context, in *workflowtemplate.WorkflowTemplateListRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowTemplateList, error) {
+	return callArgo(ctx, c.md, "ListWorkflowTemplates", func(ctx context.Context) (*v1alpha1.WorkflowTemplateList, error) {
+		return c.client.ListWorkflowTemplates(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) UpdateWorkflowTemplate(ctx context.Context, in *workflowtemplate.WorkflowTemplateUpdateRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowTemplate, error) {
+	return callArgo(ctx, c.md, "UpdateWorkflowTemplate", func(ctx context.Context) (*v1alpha1.WorkflowTemplate, error) {
+		return c.client.UpdateWorkflowTemplate(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) DeleteWorkflowTemplate(ctx context.Context, in *workflowtemplate.WorkflowTemplateDeleteRequest, opts ...grpc.CallOption) (*workflowtemplate.WorkflowTemplateDeleteResponse, error) {
+	return callArgo(ctx, c.md, "DeleteWorkflowTemplate", func(ctx context.Context) (*workflowtemplate.WorkflowTemplateDeleteResponse, error) {
+		return c.client.DeleteWorkflowTemplate(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedWorkflowTemplateServiceClient) LintWorkflowTemplate(ctx context.Context, in *workflowtemplate.WorkflowTemplateLintRequest, opts ...grpc.CallOption) (*v1alpha1.WorkflowTemplate, error) {
+	return callArgo(ctx, c.md, "LintWorkflowTemplate", func(ctx context.Context) (*v1alpha1.WorkflowTemplate, error) {
+		return c.client.LintWorkflowTemplate(ctx, in, opts...)
+	})
+}
+
+type instrumentedInfoServiceClient struct {
+	client info.InfoServiceClient
+	md     metadata.MD
+}
+
+func (c instrumentedInfoServiceClient) GetInfo(ctx context.Context, in *info.GetInfoRequest, opts ...grpc.CallOption) (*info.InfoResponse, error) {
+	return callArgo(ctx, c.md, "GetInfo", func(ctx context.Context) (*info.InfoResponse, error) {
+		return c.client.GetInfo(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedInfoServiceClient) GetVersion(ctx context.Context, in *info.GetVersionRequest, opts ...grpc.CallOption) (*v1alpha1.Version, error) {
+	return callArgo(ctx, c.md, "GetVersion", func(ctx context.Context) (*v1alpha1.Version, error) {
+		return c.client.GetVersion(ctx, in, opts...)
+	})
+}
+
+func (c instrumentedInfoServiceClient) GetUserInfo(ctx context.Context, in *info.GetUserInfoRequest, opts ...grpc.CallOption) (*info.GetUserInfoResponse, error) {
+	return callArgo(ctx, c.md, "GetUserInfo", func(ctx context.Context) (*info.GetUserInfoResponse, error) {
+		return c.client.GetUserInfo(ctx, in, opts...)
+	})
+}
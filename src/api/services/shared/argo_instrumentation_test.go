@@ -0,0 +1,96 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	workflowmocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow/mocks"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestInstrumentedWorkflowServiceClient(t *testing.T) {
+	wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+	wfServiceClientMock.On("GetWorkflow", mock.Anything, mock.Anything).Return(&v1alpha1.Workflow{}, nil)
+	wfServiceClientMock.On("DeleteWorkflow", mock.Anything, mock.Anything).Return(nil, errors.New("argo is down"))
+	client := instrumentedWorkflowServiceClient{client: wfServiceClientMock}
+
+	t.Run("It does not count calls that succeed as errors", func(t *testing.T) {
+		before := testutil.ToFloat64(utils.ArgoClientErrorsTotal.WithLabelValues("GetWorkflow"))
+
+		_, err := client.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{})
+
+		assert.Nil(t, err)
+		assert.Equal(t, before, testutil.ToFloat64(utils.ArgoClientErrorsTotal.WithLabelValues("GetWorkflow")))
+	})
+	t.Run("It counts calls that fail and passes the error on", func(t *testing.T) {
+		before := testutil.ToFloat64(utils.ArgoClientErrorsTotal.WithLabelValues("DeleteWorkflow"))
+
+		_, err := client.DeleteWorkflow(context.TODO(), &workflow.WorkflowDeleteRequest{})
+
+		assert.EqualError(t, err, "argo is down")
+		assert.Equal(t, before+1, testutil.ToFloat64(utils.ArgoClientErrorsTotal.WithLabelValues("DeleteWorkflow")))
+	})
+	t.Run("It cancels a call when the context is done and waits for it", func(t *testing.T) {
+		returned := false
+		slowClientMock := &workflowmocks.WorkflowServiceClient{}
+		slowClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			<-args.Get(0).(context.Context).Done()
+			returned = true
+		}).Return(nil, errors.New("the call was canceled"))
+		slowClient := instrumentedWorkflowServiceClient{client: slowClientMock}
+		ctx, cancel := context.WithCancel(context.TODO())
+		cancel()
+
+		_, err := slowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{})
+
+		assert.Equal(t, codes.Canceled, status.Code(err))
+		assert.True(t, returned)
+	})
+	t.Run("It passes the token for the Argo server with the call", func(t *testing.T) {
+		var md metadata.MD
+		authClientMock := &workflowmocks.WorkflowServiceClient{}
+		authClientMock.On("GetWorkflow", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			md, _ = metadata.FromOutgoingContext(args.Get(0).(context.Context))
+		}).Return(&v1alpha1.Workflow{}, nil)
+		authClient := instrumentedWorkflowServiceClient{client: authClientMock, md: metadata.Pairs("authorization", "Bearer token")}
+
+		_, err := authClient.GetWorkflow(context.TODO(), &workflow.WorkflowGetRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+	})
+}
@@ -0,0 +1,331 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"go.uber.org/fx"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	LABEL_AUDIT_RECORD  = "audit_record"
+	LABEL_AUDIT_METHOD  = "audit_method"
+	LABEL_AUDIT_OUTCOME = "audit_outcome"
+	// records are deleted once they are older than this, or when there are more than AUDIT_MAX_RECORDS of them
+	AUDIT_MAX_AGE     = 90 * 24 * time.Hour
+	AUDIT_MAX_RECORDS = 10000
+	// how often to delete the records that are too old or too many
+	AUDIT_PRUNE_INTERVAL = time.Hour
+	AUDIT_DEFAULT_LIMIT  = 100
+	AUDIT_MAX_LIMIT      = 1000
+	// how many ConfigMaps to get at a time when reading the records
+	AUDIT_LIST_PAGE_SIZE = 500
+	// how many records may wait to be written before records are only logged
+	AUDIT_QUEUE_SIZE = 1000
+)
+
+type AuditService interface {
+	Record(record iuf.AuditRecord) error
+	ListRecords(filter iuf.AuditFilter) ([]iuf.AuditRecord, error)
+}
+
+// auditService keeps each audit record in an immutable ConfigMap of its own, so that all instances of the service
+//  share the records. Records are never changed once written, and are only removed when they are pruned. The
+//  ConfigMaps are named after the time of their record so that the API server lists the newest first.
+type auditService struct {
+	logger           utils.Logger
+	k8sRestClientSet kubernetes.Interface
+	namespace        string
+	maxAge           time.Duration
+	maxRecords       int
+	pageSize         int64
+	queue            chan iuf.AuditRecord
+}
+
+// NewAuditService creates a new AuditService keeping records in AUDIT_NAMESPACE. Records are written and pruned in
+//  the background.
+func NewAuditService(lifecycle fx.Lifecycle, logger utils.Logger, k8sSvc K8sService, env utils.Env) AuditService {
+	service := auditService{
+		logger:           logger,
+		k8sRestClientSet: k8sSvc.Client,
+		namespace:        env.AuditNamespace,
+		maxAge:           AUDIT_MAX_AGE,
+		maxRecords:       AUDIT_MAX_RECORDS,
+		pageSize:         AUDIT_LIST_PAGE_SIZE,
+		queue:            make(chan iuf.AuditRecord, AUDIT_QUEUE_SIZE),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go service.runWriter(ctx)
+			go service.runPruner(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return service
+}
+
+// Record queues the record to be written, so that the call it records does not wait for Kubernetes. A record that
+//  cannot be queued is logged instead.
+func (s auditService) Record(record iuf.AuditRecord) error {
+	select {
+	case s.queue <- record:
+		return nil
+	default:
+		s.logRecord("Record.1: too many audit records are waiting to be written", record)
+		return fmt.Errorf("too many audit records are waiting to be written")
+	}
+}
+
+// runWriter writes the queued records until the context is done, and then logs the records that are left
+func (s auditService) runWriter(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case record := <-s.queue:
+					s.logRecord("runWriter.1: the service stopped before the audit record was written", record)
+				default:
+					return
+				}
+			}
+		case record := <-s.queue:
+			writeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			_ = s.write(writeCtx, record)
+			cancel()
+		}
+	}
+}
+
+func (s auditService) write(ctx context.Context, record iuf.AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Errorf("write.1: unable to serialize audit record %#v: %v", record, err)
+		return err
+	}
+
+	immutable := true
+	configMap := core_v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: auditRecordName(record.Time),
+			Labels: map[string]string{
+				"type":              LABEL_AUDIT_RECORD,
+				LABEL_AUDIT_METHOD:  strings.ToUpper(record.Method),
+				LABEL_AUDIT_OUTCOME: record.Outcome,
+			},
+		},
+		Immutable: &immutable,
+		Data:      map[string]string{LABEL_AUDIT_RECORD: string(data)},
+	}
+	_, err = s.k8sRestClientSet.
+		CoreV1().
+		ConfigMaps(s.namespace).
+		Create(ctx, &configMap, v1.CreateOptions{})
+	if err != nil {
+		// the record still ends up in the logs of the service
+		s.logger.Errorf("write.2: unable to write audit record to namespace %s: %v. Audit record: %s", s.namespace, err, string(data))
+		return err
+	}
+	return nil
+}
+
+func (s auditService) logRecord(message string, record iuf.AuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Errorf("%s. Audit record: %#v", message, record)
+		return
+	}
+	s.logger.Errorf("%s. Audit record: %s", message, string(data))
+}
+
+// auditRecordName returns a name for the ConfigMap of a record at the given time. Names of newer records sort before
+//  the names of older ones.
+func auditRecordName(recordTime time.Time) string {
+	if recordTime.IsZero() {
+		recordTime = time.Now()
+	}
+	return fmt.Sprintf("nls-audit-%019d-%s", math.MaxInt64-recordTime.UnixNano(), utils.RandomString(5))
+}
+
+// ListRecords returns the records that match the filter, newest first. ConfigMaps are read a page at a time, only
+//  until there are enough records or the records are older than the filter wants.
+func (s auditService) ListRecords(filter iuf.AuditFilter) ([]iuf.AuditRecord, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = AUDIT_DEFAULT_LIMIT
+	} else if limit > AUDIT_MAX_LIMIT {
+		limit = AUDIT_MAX_LIMIT
+	}
+
+	res := []iuf.AuditRecord{}
+	options := v1.ListOptions{LabelSelector: auditLabelSelector(filter), Limit: s.pageSize}
+	for {
+		configMaps, err := s.k8sRestClientSet.
+			CoreV1().
+			ConfigMaps(s.namespace).
+			List(context.TODO(), options)
+		if err != nil {
+			s.logger.Errorf("ListRecords.1: unable to read audit records: %v", err)
+			return nil, err
+		}
+		for _, configMap := range configMaps.Items {
+			var record iuf.AuditRecord
+			err := json.Unmarshal([]byte(configMap.Data[LABEL_AUDIT_RECORD]), &record)
+			if err != nil {
+				s.logger.Warnf("ListRecords: skipping ConfigMap %s that is not an audit record: %v", configMap.Name, err)
+				continue
+			}
+			if !filter.Since.IsZero() && record.Time.Before(filter.Since) {
+				// the records that follow are older still
+				return res, nil
+			}
+			if matchesAuditFilter(record, filter) {
+				res = append(res, record)
+				if len(res) == limit {
+					return res, nil
+				}
+			}
+		}
+		if configMaps.Continue == "" {
+			return res, nil
+		}
+		options.Continue = configMaps.Continue
+	}
+}
+
+// auditLabelSelector selects the ConfigMaps of the records that can match the filter by their labels
+func auditLabelSelector(filter iuf.AuditFilter) string {
+	selector := "type=" + LABEL_AUDIT_RECORD
+	method := strings.ToUpper(filter.Method)
+	if method != "" && len(validation.IsValidLabelValue(method)) == 0 {
+		selector += "," + LABEL_AUDIT_METHOD + "=" + method
+	}
+	if filter.Outcome != "" && len(validation.IsValidLabelValue(filter.Outcome)) == 0 {
+		selector += "," + LABEL_AUDIT_OUTCOME + "=" + filter.Outcome
+	}
+	return selector
+}
+
+func (s auditService) listRecordConfigMaps(ctx context.Context) ([]core_v1.ConfigMap, error) {
+	var res []core_v1.ConfigMap
+	options := v1.ListOptions{LabelSelector: "type=" + LABEL_AUDIT_RECORD, Limit: s.pageSize}
+	for {
+		configMaps, err := s.k8sRestClientSet.
+			CoreV1().
+			ConfigMaps(s.namespace).
+			List(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, configMaps.Items...)
+		if configMaps.Continue == "" {
+			return res, nil
+		}
+		options.Continue = configMaps.Continue
+	}
+}
+
+func (s auditService) runPruner(ctx context.Context) {
+	ticker := time.NewTicker(AUDIT_PRUNE_INTERVAL)
+	defer ticker.Stop()
+	for {
+		s.prune(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// prune deletes the records older than the maximum age, and the oldest records beyond the maximum number. Every
+//  instance of the service prunes the same records, which is harmless.
+func (s auditService) prune(ctx context.Context) {
+	configMaps, err := s.listRecordConfigMaps(ctx)
+	if err != nil {
+		s.logger.Warnf("prune.1: unable to read audit records: %v", err)
+		return
+	}
+
+	// newest first
+	sort.Slice(configMaps, func(i, j int) bool {
+		return configMaps[j].CreationTimestamp.Before(&configMaps[i].CreationTimestamp)
+	})
+	for i, configMap := range configMaps {
+		if i < s.maxRecords && time.Since(configMap.CreationTimestamp.Time) < s.maxAge {
+			continue
+		}
+		err := s.k8sRestClientSet.
+			CoreV1().
+			ConfigMaps(s.namespace).
+			Delete(ctx, configMap.Name, v1.DeleteOptions{})
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			s.logger.Warnf("prune.2: unable to delete audit record %s: %v", configMap.Name, err)
+		}
+	}
+}
+
+func matchesAuditFilter(record iuf.AuditRecord, filter iuf.AuditFilter) bool {
+	if filter.Principal != "" && record.Principal != filter.Principal {
+		return false
+	}
+	if filter.Method != "" && !strings.EqualFold(record.Method, filter.Method) {
+		return false
+	}
+	if filter.Path != "" && !strings.HasPrefix(record.Path, filter.Path) {
+		return false
+	}
+	if filter.Outcome != "" && record.Outcome != filter.Outcome {
+		return false
+	}
+	if !filter.Since.IsZero() && record.Time.Before(filter.Since) {
+		return false
+	}
+	if !filter.Until.IsZero() && !record.Time.Before(filter.Until) {
+		return false
+	}
+	return true
+}
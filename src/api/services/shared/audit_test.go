@@ -0,0 +1,229 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	core_v1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestAuditService(maxRecords int) auditService {
+	return auditService{
+		logger:           utils.GetLogger(),
+		k8sRestClientSet: newOrderedListClientset(0, new(int)),
+		namespace:        "argo",
+		maxAge:           AUDIT_MAX_AGE,
+		maxRecords:       maxRecords,
+		pageSize:         AUDIT_LIST_PAGE_SIZE,
+		queue:            make(chan iuf.AuditRecord, AUDIT_QUEUE_SIZE),
+	}
+}
+
+// newOrderedListClientset returns a fake clientset that lists ConfigMaps like the API server does, ordered by name.
+//  The fake clientset does not pass on the limit and the continue token, so with a page size the pages are returned
+//  one after the other from the first one, as a single list would read them. It counts the lists in calls.
+func newOrderedListClientset(pageSize int, calls *int) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	next := 0
+	client.PrependReactor("list", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*calls++
+		listAction := action.(k8stesting.ListAction)
+		objects, err := client.Tracker().List(core_v1.SchemeGroupVersion.WithResource("configmaps"), core_v1.SchemeGroupVersion.WithKind("ConfigMap"), listAction.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		var items []core_v1.ConfigMap
+		for _, configMap := range objects.(*core_v1.ConfigMapList).Items {
+			if listAction.GetListRestrictions().Labels.Matches(labels.Set(configMap.Labels)) {
+				items = append(items, configMap)
+			}
+		}
+		sort.Slice(items, func(i, j int) bool {
+			return items[i].Name < items[j].Name
+		})
+
+		res := &core_v1.ConfigMapList{}
+		start, end := next, len(items)
+		if pageSize > 0 && start+pageSize < end {
+			end = start + pageSize
+			res.Continue = "next"
+			next = end
+		} else {
+			next = 0
+		}
+		res.Items = items[start:end]
+		return true, res, nil
+	})
+	return client
+}
+
+func TestAuditRecord(t *testing.T) {
+	t.Run("It can record and list audit records newest first", func(t *testing.T) {
+		service := newTestAuditService(AUDIT_MAX_RECORDS)
+		ctx, cancel := context.WithCancel(context.TODO())
+		defer cancel()
+		go service.runWriter(ctx)
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		for _, i := range []int{1, 0, 2} {
+			err := service.Record(iuf.AuditRecord{Time: start.Add(time.Duration(i) * time.Minute), Principal: "admin", Method: "POST", Status: 201 + i, Outcome: iuf.AuditOutcomeSuccess})
+			assert.NoError(t, err)
+		}
+
+		// the records are written in the background
+		var configMaps *core_v1.ConfigMapList
+		for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+			var err error
+			configMaps, err = service.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_AUDIT_RECORD})
+			assert.NoError(t, err)
+			if len(configMaps.Items) == 3 || time.Now().After(deadline) {
+				break
+			}
+		}
+		assert.Equal(t, 3, len(configMaps.Items))
+		assert.True(t, *configMaps.Items[0].Immutable)
+		assert.Equal(t, "POST", configMaps.Items[0].Labels[LABEL_AUDIT_METHOD])
+		assert.Equal(t, iuf.AuditOutcomeSuccess, configMaps.Items[0].Labels[LABEL_AUDIT_OUTCOME])
+
+		res, err := service.ListRecords(iuf.AuditFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 3, len(res))
+		assert.Equal(t, 203, res[0].Status)
+		assert.Equal(t, 202, res[1].Status)
+		assert.Equal(t, 201, res[2].Status)
+	})
+	t.Run("It logs a record instead when too many are waiting to be written", func(t *testing.T) {
+		service := newTestAuditService(AUDIT_MAX_RECORDS)
+		service.queue = make(chan iuf.AuditRecord, 1)
+		assert.NoError(t, service.Record(iuf.AuditRecord{Method: "POST"}))
+		assert.Error(t, service.Record(iuf.AuditRecord{Method: "POST"}))
+	})
+	t.Run("It only reads the pages of records it needs", func(t *testing.T) {
+		calls := 0
+		service := newTestAuditService(AUDIT_MAX_RECORDS)
+		service.k8sRestClientSet = newOrderedListClientset(2, &calls)
+		service.pageSize = 2
+		start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		for i := 0; i < 7; i++ {
+			outcome := iuf.AuditOutcomeSuccess
+			if i%2 == 0 {
+				outcome = iuf.AuditOutcomeFailure
+			}
+			assert.NoError(t, service.write(context.TODO(), iuf.AuditRecord{Time: start.Add(time.Duration(i) * time.Minute), Method: "POST", Status: i, Outcome: outcome}))
+		}
+
+		res, err := service.ListRecords(iuf.AuditFilter{Limit: 3, Outcome: iuf.AuditOutcomeFailure})
+		assert.NoError(t, err)
+		var statuses []int
+		for _, record := range res {
+			statuses = append(statuses, record.Status)
+		}
+		assert.Equal(t, []int{6, 4, 2}, statuses)
+		assert.Equal(t, 2, calls)
+	})
+	t.Run("It returns no records when nothing was recorded", func(t *testing.T) {
+		service := newTestAuditService(AUDIT_MAX_RECORDS)
+		res, err := service.ListRecords(iuf.AuditFilter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, len(res))
+	})
+	t.Run("It deletes records that are too old or too many", func(t *testing.T) {
+		service := newTestAuditService(3)
+		ages := []time.Duration{time.Minute, 2 * time.Minute, AUDIT_MAX_AGE + time.Hour, 3 * time.Minute, 4 * time.Minute, 5 * time.Minute}
+		for i, age := range ages {
+			_, err := service.k8sRestClientSet.CoreV1().ConfigMaps("argo").Create(context.TODO(), &core_v1.ConfigMap{
+				ObjectMeta: v1.ObjectMeta{
+					Name:              fmt.Sprintf("nls-audit-%d", i),
+					Labels:            map[string]string{"type": LABEL_AUDIT_RECORD},
+					CreationTimestamp: v1.NewTime(time.Now().Add(-age)),
+				},
+				Data: map[string]string{LABEL_AUDIT_RECORD: fmt.Sprintf(`{"status":%d}`, i)},
+			}, v1.CreateOptions{})
+			assert.NoError(t, err)
+		}
+
+		service.prune(context.TODO())
+		res, err := service.ListRecords(iuf.AuditFilter{})
+		assert.NoError(t, err)
+		var statuses []int
+		for _, record := range res {
+			statuses = append(statuses, record.Status)
+		}
+		assert.Equal(t, 3, len(statuses))
+		assert.Contains(t, statuses, 0)
+		assert.Contains(t, statuses, 1)
+		assert.Contains(t, statuses, 3)
+	})
+}
+
+func TestAuditListRecords(t *testing.T) {
+	service := newTestAuditService(AUDIT_MAX_RECORDS)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []iuf.AuditRecord{
+		{Time: start, Principal: "admin", Method: "POST", Path: "/apis/iuf/v1/activities", Outcome: iuf.AuditOutcomeSuccess},
+		{Time: start.Add(time.Hour), Principal: "user", Method: "PATCH", Path: "/apis/iuf/v1/activities/a", Outcome: iuf.AuditOutcomeFailure},
+		{Time: start.Add(2 * time.Hour), Principal: "admin", Method: "DELETE", Path: "/apis/nls/v1/ncns/ncn-w001", Outcome: iuf.AuditOutcomeSuccess},
+	}
+	for _, record := range records {
+		assert.NoError(t, service.write(context.TODO(), record))
+	}
+
+	tests := []struct {
+		name     string
+		filter   iuf.AuditFilter
+		expected []string
+	}{
+		{"principal", iuf.AuditFilter{Principal: "admin"}, []string{"DELETE", "POST"}},
+		{"method", iuf.AuditFilter{Method: "patch"}, []string{"PATCH"}},
+		{"path prefix", iuf.AuditFilter{Path: "/apis/iuf/"}, []string{"PATCH", "POST"}},
+		{"outcome", iuf.AuditFilter{Outcome: iuf.AuditOutcomeFailure}, []string{"PATCH"}},
+		{"time range", iuf.AuditFilter{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)}, []string{"PATCH"}},
+		{"limit", iuf.AuditFilter{Limit: 1}, []string{"DELETE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := service.ListRecords(tt.filter)
+			assert.NoError(t, err)
+			var methods []string
+			for _, record := range res {
+				methods = append(methods, record.Method)
+			}
+			assert.Equal(t, tt.expected, methods)
+		})
+	}
+}
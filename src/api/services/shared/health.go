@@ -0,0 +1,244 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+//go:generate mockgen -destination=../mocks/services/health.go -package=mocks -source=health.go
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/argoproj/pkg/json"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	argo_templates "github.com/Cray-HPE/cray-nls/src/api/argo-templates"
+	models_iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	models_nls "github.com/Cray-HPE/cray-nls/src/api/models/nls"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+)
+
+// HEALTH_CHECK_TIMEOUT is how long each check may take. The checks run at the same time.
+const HEALTH_CHECK_TIMEOUT = 5 * time.Second
+
+// HEALTH_CHECK_CACHE_PERIOD is how long Ready reuses its results. It is the default periodSeconds of a probe,
+//  so that Kubernetes, Argo and Keycloak are called at most once per probe, even when the readiness is also called by hand.
+const HEALTH_CHECK_CACHE_PERIOD = 10 * time.Second
+
+const (
+	HEALTH_CHECK_KUBERNETES        = "kubernetes"
+	HEALTH_CHECK_ARGO              = "argo"
+	HEALTH_CHECK_IUF_STAGES        = "iuf_stages"
+	HEALTH_CHECK_WORKER_TEMPLATES  = "worker_rebuild_templates"
+	HEALTH_CHECK_STORAGE_TEMPLATES = "storage_rebuild_templates"
+	HEALTH_CHECK_KEYCLOAK          = "keycloak"
+)
+
+type HealthService interface {
+	// Ready runs every check, like Check, but the results are cached for HEALTH_CHECK_CACHE_PERIOD
+	Ready(ctx context.Context) []models_nls.HealthCheck
+	// Check checks every dependency that NLS needs to serve requests, including Kubernetes, Argo and Keycloak, each time it is called
+	Check(ctx context.Context) []models_nls.HealthCheck
+}
+
+type healthCheck struct {
+	name   string
+	target string
+	check  func(ctx context.Context) error
+}
+
+type healthCache struct {
+	mu        sync.Mutex
+	results   []models_nls.HealthCheck
+	checkedAt time.Time
+}
+
+type healthService struct {
+	logger utils.Logger
+	checks []healthCheck
+	cache  *healthCache
+}
+
+// NewHealthService creates a new HealthService. Keycloak is only checked in production, where NLS gets real tokens.
+func NewHealthService(logger utils.Logger, env utils.Env, argoService ArgoService, k8sSvc K8sService, keycloakService KeycloakService) (HealthService, error) {
+	workflowTemplateClient, err := argoService.Client.NewWorkflowTemplateServiceClient()
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []healthCheck{
+		{
+			name:   HEALTH_CHECK_KUBERNETES,
+			target: "configmaps in namespace argo",
+			check:  kubernetesHealthCheck(k8sSvc.Client),
+		},
+		{
+			name:   HEALTH_CHECK_ARGO,
+			target: env.ArgoServerURL,
+			check:  argoHealthCheck(workflowTemplateClient),
+		},
+		{
+			name:   HEALTH_CHECK_IUF_STAGES,
+			target: filepath.Join(env.IufInstallWorkflowFiles, "stages.yaml"),
+			check:  iufStagesHealthCheck(filepath.Join(env.IufInstallWorkflowFiles, "stages.yaml")),
+		},
+		{
+			name:   HEALTH_CHECK_WORKER_TEMPLATES,
+			target: env.WorkerRebuildWorkflowFiles,
+			check: rebuildTemplatesHealthCheck(func() ([]byte, error) {
+				return argo_templates.GetWorkerRebuildWorkflow(os.DirFS(env.WorkerRebuildWorkflowFiles), models_nls.CreateRebuildWorkflowRequest{Hosts: []string{"ncn-w001"}, DryRun: true}, models_nls.RebuildHooks{})
+			}),
+		},
+		{
+			name:   HEALTH_CHECK_STORAGE_TEMPLATES,
+			target: env.StorageRebuildWorkflowFiles,
+			check: rebuildTemplatesHealthCheck(func() ([]byte, error) {
+				return argo_templates.GetStorageRebuildWorkflow(os.DirFS(env.StorageRebuildWorkflowFiles), models_nls.CreateRebuildWorkflowRequest{Hosts: []string{"ncn-s001"}, DryRun: true, WorkflowType: "rebuild"})
+			}),
+		},
+	}
+	if env.Environment == "production" {
+		checks = append(checks, healthCheck{
+			name:   HEALTH_CHECK_KEYCLOAK,
+			target: env.ApiGatewayURL,
+			check:  keycloakHealthCheck(keycloakService),
+		})
+	}
+
+	return healthService{logger: logger, checks: checks, cache: &healthCache{}}, nil
+}
+
+func (s healthService) Ready(ctx context.Context) []models_nls.HealthCheck {
+	// a probe that comes while the checks run waits for them, instead of running them again
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+	if s.cache.results != nil && time.Since(s.cache.checkedAt) < HEALTH_CHECK_CACHE_PERIOD {
+		return s.cache.results
+	}
+	s.cache.results = s.runAll(ctx, s.checks)
+	s.cache.checkedAt = time.Now()
+	return s.cache.results
+}
+
+func (s healthService) Check(ctx context.Context) []models_nls.HealthCheck {
+	return s.runAll(ctx, s.checks)
+}
+
+func (s healthService) runAll(ctx context.Context, checks []healthCheck) []models_nls.HealthCheck {
+	results := make([]models_nls.HealthCheck, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check healthCheck) {
+			defer wg.Done()
+			results[i] = s.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+	return results
+}
+
+func (s healthService) run(ctx context.Context, check healthCheck) models_nls.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, HEALTH_CHECK_TIMEOUT)
+	defer cancel()
+
+	start := time.Now()
+	err := check.check(ctx)
+	result := models_nls.HealthCheck{
+		Name:     check.name,
+		Healthy:  err == nil,
+		Target:   check.target,
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+		s.logger.Warnf("healthService: %s is not healthy: %v", check.name, err)
+	}
+	return result
+}
+
+func kubernetesHealthCheck(client kubernetes.Interface) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		// NLS keeps its state in ConfigMaps, so this also checks that it may read them
+		_, err := client.CoreV1().ConfigMaps("argo").List(ctx, v1.ListOptions{Limit: 1})
+		return err
+	}
+}
+
+func argoHealthCheck(client workflowtemplate.WorkflowTemplateServiceClient) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := client.ListWorkflowTemplates(ctx, &workflowtemplate.WorkflowTemplateListRequest{Namespace: "argo"})
+		return err
+	}
+}
+
+func iufStagesHealthCheck(path string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		stagesBytes, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var stages models_iuf.Stages
+		err = yaml.Unmarshal(stagesBytes, &stages)
+		if err != nil {
+			return err
+		}
+		if len(stages.Stages) == 0 {
+			return fmt.Errorf("no stages defined")
+		}
+		return nil
+	}
+}
+
+// rebuildTemplatesHealthCheck renders a dry run rebuild workflow the same way CreateRebuildWorkflow does
+func rebuildTemplatesHealthCheck(render func() ([]byte, error)) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		rebuildWorkflow, err := render()
+		if err != nil {
+			return err
+		}
+		jsonTmp, err := yaml.YAMLToJSONStrict(rebuildWorkflow)
+		if err != nil {
+			return err
+		}
+		var myWorkflow v1alpha1.Workflow
+		return json.Unmarshal(jsonTmp, &myWorkflow)
+	}
+}
+
+func keycloakHealthCheck(keycloakService KeycloakService) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := keycloakService.NewKeycloakAccessToken(ctx)
+		return err
+	}
+}
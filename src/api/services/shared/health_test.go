@@ -0,0 +1,149 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	wftemplatemocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate/mocks"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHealthChecks(t *testing.T) {
+	t.Run("Kubernetes is healthy when NLS can list ConfigMaps", func(t *testing.T) {
+		assert.NoError(t, kubernetesHealthCheck(fake.NewSimpleClientset())(context.TODO()))
+	})
+	t.Run("Argo is not healthy when it cannot list workflow templates", func(t *testing.T) {
+		wftServiceClientMock := &wftemplatemocks.WorkflowTemplateServiceClient{}
+		wftServiceClientMock.On("ListWorkflowTemplates", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused"))
+
+		assert.EqualError(t, argoHealthCheck(wftServiceClientMock)(context.TODO()), "connection refused")
+	})
+	t.Run("stages.yaml must define stages", func(t *testing.T) {
+		dir := t.TempDir()
+		stagesFile := filepath.Join(dir, "stages.yaml")
+
+		assert.Error(t, iufStagesHealthCheck(stagesFile)(context.TODO()))
+
+		assert.NoError(t, os.WriteFile(stagesFile, []byte("version: 1.0.0\nstages: []\n"), 0600))
+		assert.EqualError(t, iufStagesHealthCheck(stagesFile)(context.TODO()), "no stages defined")
+
+		assert.NoError(t, os.WriteFile(stagesFile, []byte("version: 1.0.0\nstages:\n  - name: process-media\n    type: product\n"), 0600))
+		assert.NoError(t, iufStagesHealthCheck(stagesFile)(context.TODO()))
+	})
+	t.Run("Rebuild templates must render into a workflow", func(t *testing.T) {
+		assert.NoError(t, rebuildTemplatesHealthCheck(func() ([]byte, error) {
+			return []byte("apiVersion: argoproj.io/v1alpha1\nkind: Workflow\nmetadata:\n  generateName: ncn-lifecycle-rebuild-\n"), nil
+		})(context.TODO()))
+		assert.Error(t, rebuildTemplatesHealthCheck(func() ([]byte, error) {
+			return []byte("metadata: [\n"), nil
+		})(context.TODO()))
+		assert.Error(t, rebuildTemplatesHealthCheck(func() ([]byte, error) {
+			return nil, errors.New("template: pattern matches no files")
+		})(context.TODO()))
+	})
+}
+
+func TestHealthServiceCheck(t *testing.T) {
+	wftServiceClientMock := &wftemplatemocks.WorkflowTemplateServiceClient{}
+	wftServiceClientMock.On("ListWorkflowTemplates", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowTemplateList{}, nil)
+	healthSvc := healthService{
+		logger: utils.GetLogger(),
+		checks: []healthCheck{
+			{name: HEALTH_CHECK_KUBERNETES, check: kubernetesHealthCheck(fake.NewSimpleClientset())},
+			{name: HEALTH_CHECK_ARGO, target: "localhost:2746", check: argoHealthCheck(wftServiceClientMock)},
+			{name: "hung", check: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			}},
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+
+	checks := healthSvc.Check(ctx)
+
+	assert.Equal(t, 3, len(checks))
+	assert.Equal(t, HEALTH_CHECK_KUBERNETES, checks[0].Name)
+	assert.True(t, checks[0].Healthy)
+	assert.Equal(t, "localhost:2746", checks[1].Target)
+	assert.True(t, checks[1].Healthy)
+	assert.False(t, checks[2].Healthy)
+	assert.Equal(t, context.DeadlineExceeded.Error(), checks[2].Error)
+}
+
+func TestHealthServiceReady(t *testing.T) {
+	runs := 0
+	argoRuns := 0
+	healthSvc := healthService{
+		logger: utils.GetLogger(),
+		checks: []healthCheck{
+			{name: HEALTH_CHECK_IUF_STAGES, check: func(ctx context.Context) error {
+				runs++
+				return nil
+			}},
+			{name: HEALTH_CHECK_ARGO, check: func(ctx context.Context) error {
+				argoRuns++
+				return errors.New("connection refused")
+			}},
+		},
+		cache: &healthCache{},
+	}
+
+	checks := healthSvc.Ready(context.TODO())
+
+	t.Run("It runs the checks of the dependencies too", func(t *testing.T) {
+		assert.Equal(t, 2, len(checks))
+		assert.Equal(t, HEALTH_CHECK_IUF_STAGES, checks[0].Name)
+		assert.True(t, checks[0].Healthy)
+		assert.Equal(t, HEALTH_CHECK_ARGO, checks[1].Name)
+		assert.False(t, checks[1].Healthy)
+	})
+	t.Run("It reuses its results for the probe period", func(t *testing.T) {
+		healthSvc.Ready(context.TODO())
+		assert.Equal(t, 1, runs)
+		assert.Equal(t, 1, argoRuns)
+
+		healthSvc.cache.checkedAt = time.Now().Add(-HEALTH_CHECK_CACHE_PERIOD)
+		healthSvc.Ready(context.TODO())
+		assert.Equal(t, 2, runs)
+	})
+	t.Run("The detail runs every check again", func(t *testing.T) {
+		checks := healthSvc.Check(context.TODO())
+		assert.Equal(t, 2, len(checks))
+		assert.False(t, checks[1].Healthy)
+		assert.Equal(t, 3, runs)
+	})
+}
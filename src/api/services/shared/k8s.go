@@ -23,14 +23,24 @@ package services_shared
 
 import (
 	"os"
+	"time"
 
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	argo_clientset "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// K8S_REQUEST_TIMEOUT is how long a request to the Kubernetes API server may take
+const K8S_REQUEST_TIMEOUT = 30 * time.Second
+
 type K8sService struct {
 	Client *kubernetes.Clientset
+	// for watches, which last longer than K8S_REQUEST_TIMEOUT and are bounded with TimeoutSeconds and their context instead
+	WatchClient *kubernetes.Clientset
+	// for changes to workflows that the Argo server API cannot make
+	ArgoClient argo_clientset.Interface
 }
 
 func NewK8sService() K8sService {
@@ -51,11 +61,26 @@ func NewK8sService() K8sService {
 		}
 	}
 
+	// calls to the API server show in the trace of the request that made them
+	config.Wrap(utils.NewTracingTransport)
+	watchClientSet, err := kubernetes.NewForConfig(rest.CopyConfig(config))
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// a hung API server must not hold on to the request that called it, or to the IUF sync loop
+	config.Timeout = K8S_REQUEST_TIMEOUT
 	k8sRestClientSet, err := kubernetes.NewForConfig(config)
 	if err != nil {
 		panic(err.Error())
 	}
+	argoClientSet, err := argo_clientset.NewForConfig(config)
+	if err != nil {
+		panic(err.Error())
+	}
 	return K8sService{
-		Client: k8sRestClientSet,
+		Client:      k8sRestClientSet,
+		WatchClient: watchClientSet,
+		ArgoClient:  argoClientSet,
 	}
 }
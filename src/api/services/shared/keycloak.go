@@ -23,19 +23,24 @@ package services_shared
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/Cray-HPE/cray-nls/src/utils"
 	k8sMetaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// a cached token is replaced once this much of its lifetime has passed, so it is never handed out close to expiry
+const KEYCLOAK_TOKEN_REFRESH_FRACTION = 0.75
+
 type KeycloakService interface {
-	NewKeycloakAccessToken() (string, error)
+	NewKeycloakAccessToken(ctx context.Context) (string, error)
 }
 
 type keycloakService struct {
@@ -44,10 +49,25 @@ type keycloakService struct {
 	k8sService                  K8sService
 	adminClientAuthClientId     string
 	adminClientAuthClientSecret string
+	httpClient                  *http.Client
+	cache                       *keycloakTokenCache
+}
+
+// keycloakTokenCache holds the last token, shared by every copy of the keycloakService. The mutex is only held to read
+//  and replace the token, never while a new one is requested.
+type keycloakTokenCache struct {
+	mutex     sync.Mutex
+	token     string
+	refreshAt time.Time
+	expiresAt time.Time
+	// closed once the request for a new token in flight is done, nil when no token is being requested
+	refreshing chan struct{}
+	now        func() time.Time
 }
 
 type oidcAuthToken struct {
 	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
 }
 
 type NewKeycloakAccessTokenError struct {
@@ -58,7 +78,7 @@ func (e NewKeycloakAccessTokenError) Error() string {
 	return "Could not retrieve OIDC token: " + e.body
 }
 
-func NewKeycloakService(logger utils.Logger, env utils.Env, k8sService K8sService) KeycloakService {
+func NewKeycloakService(logger utils.Logger, env utils.Env, k8sService K8sService) (KeycloakService, error) {
 	var adminClientAuthClientId string
 	var adminClientAuthClientSecret string
 
@@ -73,50 +93,122 @@ func NewKeycloakService(logger utils.Logger, env utils.Env, k8sService K8sServic
 		adminClientAuthClientSecret = string(secret.Data["client-secret"])
 	}
 
+	tlsConfig, err := utils.NewTLSConfig(env)
+	if err != nil {
+		return nil, err
+	}
+
 	return keycloakService{
 		logger:                      logger,
 		env:                         env,
 		k8sService:                  k8sService,
 		adminClientAuthClientId:     adminClientAuthClientId,
 		adminClientAuthClientSecret: adminClientAuthClientSecret,
-	}
+		httpClient: &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: utils.NewTracingTransport(&http.Transport{TLSClientConfig: tlsConfig}),
+		},
+		cache: &keycloakTokenCache{now: time.Now},
+	}, nil
 }
 
-func (ks keycloakService) NewKeycloakAccessToken() (string, error) {
+// NewKeycloakAccessToken returns a token of the admin client. The same token is returned until most of its lifetime
+//  has passed, then a new one is requested. Only one new token is requested at a time, the other calls use the
+//  current token meanwhile, or wait for the new one when the current one expired.
+func (ks keycloakService) NewKeycloakAccessToken(ctx context.Context) (string, error) {
 	if ks.env.Environment != "production" {
 		return "fake_dev_access_token", nil
 	}
 
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	for {
+		ks.cache.mutex.Lock()
+		now := ks.cache.now()
+		if ks.cache.token != "" && now.Before(ks.cache.refreshAt) {
+			token := ks.cache.token
+			ks.cache.mutex.Unlock()
+			return token, nil
+		}
+		if ks.cache.refreshing == nil {
+			refreshing := make(chan struct{})
+			ks.cache.refreshing = refreshing
+			ks.cache.mutex.Unlock()
+			return ks.refreshAccessToken(ctx, now, refreshing)
+		}
+
+		// another call is requesting a new token
+		refreshing := ks.cache.refreshing
+		if ks.cache.token != "" && now.Before(ks.cache.expiresAt) {
+			token := ks.cache.token
+			ks.cache.mutex.Unlock()
+			return token, nil
+		}
+		ks.cache.mutex.Unlock()
+		select {
+		case <-refreshing:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// refreshAccessToken requests a new token and puts it in the cache, then closes refreshing
+func (ks keycloakService) refreshAccessToken(ctx context.Context, now time.Time, refreshing chan struct{}) (string, error) {
+	token, err := ks.requestAccessToken(ctx)
+
+	ks.cache.mutex.Lock()
+	defer ks.cache.mutex.Unlock()
+	ks.cache.refreshing = nil
+	close(refreshing)
+
+	if err != nil {
+		utils.KeycloakTokenFetchFailuresTotal.Inc()
+		if ks.cache.token != "" && now.Before(ks.cache.expiresAt) {
+			// Keycloak may be back before the token we have expires
+			ks.logger.Warnf("NewKeycloakAccessToken: unable to refresh token, using the current one until %v: %v", ks.cache.expiresAt, err)
+			return ks.cache.token, nil
+		}
+		return "", err
 	}
-	client := http.Client{Transport: tr}
 
-	resp, err := client.PostForm(ks.env.ApiGatewayURL+"/keycloak/realms/shasta/protocol/openid-connect/token", url.Values{
+	lifetime := time.Duration(token.ExpiresIn) * time.Second
+	ks.cache.token = token.AccessToken
+	ks.cache.expiresAt = now.Add(lifetime)
+	ks.cache.refreshAt = now.Add(time.Duration(float64(lifetime) * KEYCLOAK_TOKEN_REFRESH_FRACTION))
+	return token.AccessToken, nil
+}
+
+func (ks keycloakService) requestAccessToken(ctx context.Context) (oidcAuthToken, error) {
+	form := url.Values{
 		"grant_type":    {"client_credentials"},
 		"client_id":     {ks.adminClientAuthClientId},
 		"client_secret": {ks.adminClientAuthClientSecret},
-	})
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ks.env.ApiGatewayURL+"/keycloak/realms/shasta/protocol/openid-connect/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return oidcAuthToken{}, NewKeycloakAccessTokenError{body: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := ks.httpClient.Do(req)
 
 	if err != nil {
-		return "", NewKeycloakAccessTokenError{body: err.Error()}
+		return oidcAuthToken{}, NewKeycloakAccessTokenError{body: err.Error()}
 	}
 
 	defer resp.Body.Close()
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", NewKeycloakAccessTokenError{body: err.Error()}
+		return oidcAuthToken{}, NewKeycloakAccessTokenError{body: err.Error()}
 	}
 
 	if resp.StatusCode != 200 {
-		return "", NewKeycloakAccessTokenError{body: fmt.Sprintf("Expected 200 response but instead got %v %v", resp.StatusCode, string(body))}
+		return oidcAuthToken{}, NewKeycloakAccessTokenError{body: fmt.Sprintf("Expected 200 response but instead got %v %v", resp.StatusCode, string(body))}
 	}
 
 	var token oidcAuthToken
 	err = json.Unmarshal(body, &token)
 	if err != nil {
-		return "", NewKeycloakAccessTokenError{body: err.Error()}
+		return oidcAuthToken{}, NewKeycloakAccessTokenError{body: err.Error()}
 	}
 
-	return token.AccessToken, nil
+	return token, nil
 }
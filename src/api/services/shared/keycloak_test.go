@@ -22,13 +22,18 @@
 package services_shared
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"github.com/Cray-HPE/cray-nls/src/utils"
-	"github.com/alecthomas/assert"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
 )
 
 func TestGetFixedValue(t *testing.T) {
@@ -68,10 +73,129 @@ func TestGetFixedValue(t *testing.T) {
 			},
 			adminClientAuthClientId:     clientId,
 			adminClientAuthClientSecret: clientSecret,
+			httpClient:                  server.Client(),
+			cache:                       &keycloakTokenCache{now: time.Now},
 		}
 
-		token, err := keycloakService.NewKeycloakAccessToken()
+		token, err := keycloakService.NewKeycloakAccessToken(context.TODO())
 		assert.NoError(t, err)
 		assert.Equal(t, fakeOidcToken, token)
 	})
 }
+
+func TestKeycloakTokenCache(t *testing.T) {
+	requests := 0
+	var failWith error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if failWith != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(failWith.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf(`{"access_token":"token-%d","expires_in":300}`, requests)))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	keycloakService := keycloakService{
+		logger:     utils.GetLogger(),
+		env:        utils.Env{Environment: "production", ApiGatewayURL: server.URL},
+		httpClient: server.Client(),
+		cache:      &keycloakTokenCache{now: func() time.Time { return now }},
+	}
+
+	t.Run("It returns the cached token until most of its lifetime has passed", func(t *testing.T) {
+		token, err := keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+
+		now = now.Add(224 * time.Second)
+		token, err = keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+		assert.Equal(t, 1, requests)
+
+		now = now.Add(1 * time.Second)
+		token, err = keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+	})
+	t.Run("It keeps using the cached token while Keycloak is down, until it expires", func(t *testing.T) {
+		failWith = errors.New("keycloak is down")
+		now = now.Add(250 * time.Second)
+		token, err := keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "token-2", token)
+
+		now = now.Add(50 * time.Second)
+		_, err = keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.Error(t, err)
+	})
+}
+
+func TestKeycloakTokenRequestInFlight(t *testing.T) {
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := atomic.AddInt32(&requests, 1)
+		<-release
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(fmt.Sprintf(`{"access_token":"token-%d","expires_in":300}`, count)))
+	}))
+	defer server.Close()
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := &keycloakTokenCache{
+		token:     "token-0",
+		refreshAt: now.Add(-time.Second),
+		expiresAt: now.Add(time.Minute),
+		now:       func() time.Time { return now },
+	}
+	keycloakService := keycloakService{
+		logger:     utils.GetLogger(),
+		env:        utils.Env{Environment: "production", ApiGatewayURL: server.URL},
+		httpClient: server.Client(),
+		cache:      cache,
+	}
+
+	t.Run("It uses the current token while a new one is requested", func(t *testing.T) {
+		refreshed := make(chan string)
+		go func() {
+			token, _ := keycloakService.NewKeycloakAccessToken(context.TODO())
+			refreshed <- token
+		}()
+		for atomic.LoadInt32(&requests) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		token, err := keycloakService.NewKeycloakAccessToken(context.TODO())
+		assert.NoError(t, err)
+		assert.Equal(t, "token-0", token)
+
+		release <- struct{}{}
+		assert.Equal(t, "token-1", <-refreshed)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+	t.Run("It waits for the new token when the current one expired", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		tokens := make(chan string, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				token, _ := keycloakService.NewKeycloakAccessToken(context.TODO())
+				tokens <- token
+			}()
+		}
+		for atomic.LoadInt32(&requests) == 1 {
+			time.Sleep(time.Millisecond)
+		}
+		// give the other calls time to find the request in flight
+		time.Sleep(50 * time.Millisecond)
+		release <- struct{}{}
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "token-2", <-tokens)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+}
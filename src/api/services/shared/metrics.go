@@ -0,0 +1,89 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	argo_v1alpha1 "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/typed/workflow/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// how long a scrape waits for the rebuild workflows
+const REBUILD_METRICS_LIST_TIMEOUT = 10 * time.Second
+
+var rebuildWorkflowsDesc = prometheus.NewDesc(prometheus.BuildFQName(utils.METRICS_NAMESPACE, "rebuild", "workflows"),
+	"Number of NCN rebuild workflows, by node type and workflow phase.", []string{"node_type", "phase"}, nil)
+
+// RegisterRebuildMetrics registers the gauge of NCN rebuild workflows. They are counted from the workflows in Argo on
+//  every scrape, so they are right whichever replica serves the scrape.
+func RegisterRebuildMetrics(logger utils.Logger, k8sSvc K8sService) {
+	err := prometheus.Register(rebuildWorkflowsCollector{
+		logger:         logger,
+		workflowClient: k8sSvc.ArgoClient.ArgoprojV1alpha1().Workflows("argo"),
+	})
+	if err != nil {
+		logger.Warnf("RegisterRebuildMetrics: unable to register rebuild metrics: %v", err)
+	}
+}
+
+type rebuildWorkflowsCollector struct {
+	logger         utils.Logger
+	workflowClient argo_v1alpha1.WorkflowInterface
+}
+
+func (c rebuildWorkflowsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- rebuildWorkflowsDesc
+}
+
+func (c rebuildWorkflowsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), REBUILD_METRICS_LIST_TIMEOUT)
+	defer cancel()
+
+	workflows, err := c.workflowClient.List(ctx, v1.ListOptions{LabelSelector: "type=rebuild"})
+	if err != nil {
+		c.logger.Warnf("rebuildWorkflowsCollector.1: unable to list rebuild workflows: %v", err)
+		return
+	}
+
+	type key struct{ nodeType, phase string }
+	counts := map[key]float64{}
+	for _, workflow := range workflows.Items {
+		phase := workflow.Status.Phase
+		if phase == v1alpha1.WorkflowUnknown {
+			// the workflow controller has not picked it up yet
+			phase = v1alpha1.WorkflowPending
+		}
+		counts[key{workflow.Labels["node-type"], string(phase)}]++
+	}
+	for k, count := range counts {
+		ch <- prometheus.MustNewConstMetric(rebuildWorkflowsDesc, prometheus.GaugeValue, count, k.nodeType, k.phase)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	argo_fake "github.com/argoproj/argo-workflows/v3/pkg/client/clientset/versioned/fake"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRebuildWorkflowsCollector(t *testing.T) {
+	newWorkflow := func(name string, labels map[string]string, phase v1alpha1.WorkflowPhase) *v1alpha1.Workflow {
+		return &v1alpha1.Workflow{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "argo", Labels: labels},
+			Status:     v1alpha1.WorkflowStatus{Phase: phase},
+		}
+	}
+	collector := rebuildWorkflowsCollector{
+		logger: utils.GetLogger(),
+		workflowClient: argo_fake.NewSimpleClientset(
+			newWorkflow("w1", map[string]string{"type": "rebuild", "node-type": "worker"}, v1alpha1.WorkflowRunning),
+			newWorkflow("w2", map[string]string{"type": "rebuild", "node-type": "worker"}, v1alpha1.WorkflowSucceeded),
+			newWorkflow("w3", map[string]string{"type": "rebuild", "node-type": "worker"}, v1alpha1.WorkflowSucceeded),
+			newWorkflow("m1", map[string]string{"type": "rebuild", "node-type": "master"}, v1alpha1.WorkflowUnknown),
+			newWorkflow("iuf", map[string]string{"iuf": "true"}, v1alpha1.WorkflowRunning),
+		).ArgoprojV1alpha1().Workflows("argo"),
+	}
+
+	expected := `
+# HELP cray_nls_rebuild_workflows Number of NCN rebuild workflows, by node type and workflow phase.
+# TYPE cray_nls_rebuild_workflows gauge
+cray_nls_rebuild_workflows{node_type="master",phase="Pending"} 1
+cray_nls_rebuild_workflows{node_type="worker",phase="Running"} 1
+cray_nls_rebuild_workflows{node_type="worker",phase="Succeeded"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,511 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/google/uuid"
+	"go.uber.org/fx"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	NOTIFICATION_VERSION             = "v1"
+	NOTIFICATION_SOURCE_IUF          = "iuf"
+	NOTIFICATION_SOURCE_NLS          = "nls"
+	NOTIFICATION_TYPE_ACTIVITY_STATE = "activity_state_changed"
+	NOTIFICATION_TYPE_REBUILD_PHASE  = "rebuild_phase_changed"
+
+	NOTIFIER_CONFIG_KEY            = "config.yaml"
+	LABEL_NOTIFICATION_DEAD_LETTER = "notification_dead_letter"
+	HEADER_NOTIFICATION_SIGNATURE  = "X-Cray-Nls-Signature"
+	HEADER_NOTIFICATION_ID         = "X-Cray-Nls-Notification-Id"
+
+	// how long dead letters are kept, and how many of them are kept for each webhook
+	NOTIFICATION_DEAD_LETTER_MAX_AGE   = 7 * 24 * time.Hour
+	NOTIFICATION_DEAD_LETTER_MAX_COUNT = 100
+
+	// how often to look for rebuild workflows that changed phase
+	REBUILD_PHASE_POLL_INTERVAL = 30 * time.Second
+	// the ConfigMap in the namespace of the notifier ConfigMap that has the phase last notified about of each rebuild workflow
+	REBUILD_PHASES_CONFIGMAP = "cray-nls-rebuild-phases"
+)
+
+// Notification is the payload of the webhooks in the json format. Fields are only ever added to it, so that receivers
+//
+//	can rely on it.
+type Notification struct {
+	Version       string    `json:"version"`                 // Version of the payload, currently v1
+	Id            string    `json:"id"`                      // Unique id of the notification, the same for all attempts to deliver it
+	Source        string    `json:"source"`                  // iuf or nls
+	Type          string    `json:"type"`                    // activity_state_changed or rebuild_phase_changed
+	Name          string    `json:"name"`                    // Name of the IUF activity or of the rebuild workflow
+	Session       string    `json:"session,omitempty"`       // Name of the IUF session, if any
+	PreviousState string    `json:"previous_state"`          // State of the activity or phase of the workflow before the change
+	State         string    `json:"state"`                   // State of the activity or phase of the workflow after the change
+	SessionState  string    `json:"session_state,omitempty"` // State of the IUF session, e.g. completed
+	Message       string    `json:"message"`                 // What happened, e.g. the comment of the history entry
+	Time          time.Time `json:"time"`                    // When it happened
+}
+
+// WebhookSecretRef is the key of a Secret in the namespace of the notifier ConfigMap
+type WebhookSecretRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// Webhook is one receiver of notifications, as configured in the notifier ConfigMap
+type Webhook struct {
+	Name        string            `json:"name"`
+	Url         string            `json:"url"`
+	Format      string            `json:"format"`       // json (the default), slack or teams
+	SecretRef   *WebhookSecretRef `json:"secret-ref"`   // Key to sign payloads with using HMAC-SHA256. Payloads are not signed without it
+	Sources     []string          `json:"sources"`      // iuf and/or nls. Empty means both
+	States      []string          `json:"states"`       // states that trigger a notification, e.g. debug or Failed. Empty means all
+	MaxAttempts int               `json:"max-attempts"` // how many times to try to deliver a notification. Defaults to 5
+	Backoff     string            `json:"backoff"`      // how long to wait after the first failed attempt, doubled after each attempt. Defaults to 10s
+	Timeout     string            `json:"timeout"`      // how long to wait for the receiver to respond. Defaults to 10s
+}
+
+// NotifierConfig is the content of the config.yaml key of the notifier ConfigMap
+type NotifierConfig struct {
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type NotifierService interface {
+	// Notify delivers the notification to all the webhooks that want it in the background
+	Notify(notification Notification)
+}
+
+type notifierService struct {
+	ctx              context.Context
+	logger           utils.Logger
+	k8sRestClientSet kubernetes.Interface
+	httpClient       *http.Client
+	configMapName    string
+	namespace        string
+}
+
+// NewNotifierService creates a new NotifierService, configured by the ConfigMap named by NOTIFIER_CONFIGMAP. Deliveries
+//  still in progress when the service stops are given up on and recorded as dead letters.
+func NewNotifierService(lifecycle fx.Lifecycle, logger utils.Logger, k8sSvc K8sService, env utils.Env) NotifierService {
+	ctx, cancel := context.WithCancel(context.Background())
+	lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+	return notifierService{
+		ctx:              ctx,
+		logger:           logger,
+		k8sRestClientSet: k8sSvc.Client,
+		httpClient:       &http.Client{},
+		configMapName:    env.NotifierConfigMap,
+		namespace:        env.NotifierNamespace,
+	}
+}
+
+func (s notifierService) Notify(notification Notification) {
+	if notification.Id == "" {
+		notification.Id = uuid.NewString()
+	}
+	notification.Version = NOTIFICATION_VERSION
+	if notification.Time.IsZero() {
+		notification.Time = time.Now()
+	}
+
+	config, err := s.getConfig(s.ctx)
+	if err != nil {
+		s.logger.Errorf("Notify.1: unable to read the notifier configuration, not sending %s notification for %s: %v", notification.Type, notification.Name, err)
+		return
+	}
+
+	for _, webhook := range config.Webhooks {
+		if !webhook.wants(notification) {
+			continue
+		}
+		go s.deliver(s.ctx, webhook, notification)
+	}
+}
+
+func (s notifierService) getConfig(ctx context.Context) (NotifierConfig, error) {
+	var config NotifierConfig
+	configMap, err := s.k8sRestClientSet.
+		CoreV1().
+		ConfigMaps(s.namespace).
+		Get(ctx, s.configMapName, v1.GetOptions{})
+	if err != nil {
+		// not having any notifications configured is fine
+		return config, nil
+	}
+
+	err = yaml.Unmarshal([]byte(configMap.Data[NOTIFIER_CONFIG_KEY]), &config)
+	return config, err
+}
+
+func (webhook Webhook) wants(notification Notification) bool {
+	if len(webhook.Sources) > 0 && !contains(webhook.Sources, notification.Source) {
+		return false
+	}
+	if len(webhook.States) > 0 && !contains(webhook.States, notification.State) && !contains(webhook.States, notification.SessionState) {
+		return false
+	}
+	return true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver sends the notification to the webhook, retrying with an exponential backoff until the context is done. When
+//
+//	all attempts fail, the notification is recorded in a dead-letter ConfigMap so that it can be inspected and resent.
+func (s notifierService) deliver(ctx context.Context, webhook Webhook, notification Notification) {
+	maxAttempts := webhook.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	backoff := parseDurationOrDefault(webhook.Backoff, 10*time.Second)
+	timeout := parseDurationOrDefault(webhook.Timeout, 10*time.Second)
+
+	body, err := formatNotification(webhook.Format, notification)
+	if err != nil {
+		s.logger.Errorf("deliver.1: unable to format %s notification %s for webhook %s: %v", notification.Type, notification.Id, webhook.Name, err)
+		s.deadLetter(webhook, notification, 0, err)
+		return
+	}
+
+	var signature string
+	if webhook.SecretRef != nil {
+		key, err := s.getSigningKey(ctx, *webhook.SecretRef)
+		if err != nil {
+			s.logger.Errorf("deliver.2: unable to get the signing key of webhook %s: %v", webhook.Name, err)
+			s.deadLetter(webhook, notification, 0, err)
+			return
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		signature = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err = s.post(ctx, webhook.Url, body, signature, notification.Id, timeout)
+		if err == nil {
+			s.logger.Infof("deliver: sent %s notification %s for %s to webhook %s", notification.Type, notification.Id, notification.Name, webhook.Name)
+			return
+		}
+		s.logger.Warnf("deliver: attempt %d of %d to send notification %s to webhook %s failed: %v", attempt, maxAttempts, notification.Id, webhook.Name, err)
+		if attempt < maxAttempts {
+			select {
+			case <-ctx.Done():
+				s.logger.Warnf("deliver: stopped sending notification %s to webhook %s: %v", notification.Id, webhook.Name, ctx.Err())
+				s.deadLetter(webhook, notification, attempt, err)
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+	}
+	s.deadLetter(webhook, notification, maxAttempts, err)
+}
+
+func (s notifierService) post(ctx context.Context, url string, body []byte, signature string, id string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HEADER_NOTIFICATION_ID, id)
+	if signature != "" {
+		req.Header.Set(HEADER_NOTIFICATION_SIGNATURE, signature)
+	}
+
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with %s", res.Status)
+	}
+	return nil
+}
+
+func (s notifierService) getSigningKey(ctx context.Context, ref WebhookSecretRef) ([]byte, error) {
+	secret, err := s.k8sRestClientSet.
+		CoreV1().
+		Secrets(s.namespace).
+		Get(ctx, ref.Name, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	key, exists := secret.Data[ref.Key]
+	if !exists || len(key) == 0 {
+		return nil, fmt.Errorf("secret %s has no key %s", ref.Name, ref.Key)
+	}
+	return key, nil
+}
+
+// deadLetter records a notification that could not be delivered. It does not use the context of the delivery, so that
+//
+//	deliveries given up on because the service stops are recorded too.
+func (s notifierService) deadLetter(webhook Webhook, notification Notification, attempts int, lastErr error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	record := map[string]interface{}{
+		"webhook":      webhook.Name,
+		"url":          webhook.Url,
+		"attempts":     attempts,
+		"last_error":   fmt.Sprint(lastErr),
+		"notification": notification,
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		s.logger.Errorf("deadLetter.1: unable to record notification %s for webhook %s: %v", notification.Id, webhook.Name, err)
+		return
+	}
+
+	configMap := core_v1.ConfigMap{
+		ObjectMeta: v1.ObjectMeta{
+			Name: utils.GenerateName("nls-notification-" + webhook.Name),
+			Labels: map[string]string{
+				"type":    LABEL_NOTIFICATION_DEAD_LETTER,
+				"webhook": webhook.Name,
+			},
+		},
+		Data: map[string]string{LABEL_NOTIFICATION_DEAD_LETTER: string(data)},
+	}
+	_, err = s.k8sRestClientSet.
+		CoreV1().
+		ConfigMaps(s.namespace).
+		Create(ctx, &configMap, v1.CreateOptions{})
+	if err != nil {
+		s.logger.Errorf("deadLetter.2: unable to record notification %s for webhook %s: %v", notification.Id, webhook.Name, err)
+		return
+	}
+	s.logger.Errorf("deadLetter: gave up sending notification %s to webhook %s, recorded it in ConfigMap %s: %v", notification.Id, webhook.Name, configMap.Name, lastErr)
+	s.pruneDeadLetters(ctx, webhook.Name, configMap.Name)
+}
+
+// pruneDeadLetters deletes the dead letters of the webhook that are older than NOTIFICATION_DEAD_LETTER_MAX_AGE, and
+//
+//	the oldest ones beyond NOTIFICATION_DEAD_LETTER_MAX_COUNT. The dead letter that was just recorded is always kept.
+func (s notifierService) pruneDeadLetters(ctx context.Context, webhookName string, recordedName string) {
+	deadLetters, err := s.k8sRestClientSet.
+		CoreV1().
+		ConfigMaps(s.namespace).
+		List(ctx, v1.ListOptions{LabelSelector: fmt.Sprintf("type=%s,webhook=%s", LABEL_NOTIFICATION_DEAD_LETTER, webhookName)})
+	if err != nil {
+		s.logger.Warnf("pruneDeadLetters.1: unable to list the dead letters of webhook %s: %v", webhookName, err)
+		return
+	}
+
+	var others []core_v1.ConfigMap
+	for _, deadLetter := range deadLetters.Items {
+		if deadLetter.Name != recordedName {
+			others = append(others, deadLetter)
+		}
+	}
+	// newest first
+	sort.Slice(others, func(i, j int) bool {
+		return others[j].CreationTimestamp.Before(&others[i].CreationTimestamp)
+	})
+	for i, deadLetter := range others {
+		if i < NOTIFICATION_DEAD_LETTER_MAX_COUNT-1 && time.Since(deadLetter.CreationTimestamp.Time) < NOTIFICATION_DEAD_LETTER_MAX_AGE {
+			continue
+		}
+		err := s.k8sRestClientSet.
+			CoreV1().
+			ConfigMaps(s.namespace).
+			Delete(ctx, deadLetter.Name, v1.DeleteOptions{})
+		if err != nil && !k8s_errors.IsNotFound(err) {
+			s.logger.Warnf("pruneDeadLetters.2: unable to delete dead letter %s of webhook %s: %v", deadLetter.Name, webhookName, err)
+		}
+	}
+}
+
+func parseDurationOrDefault(value string, defaultDuration time.Duration) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return defaultDuration
+	}
+	return duration
+}
+
+// StartRebuildPhaseWatcher notifies about rebuild workflows that change phase. NLS does not get called back when
+//
+//	a rebuild workflow makes progress, so it polls Argo for them. The phase last notified about of each workflow is
+//	kept in the REBUILD_PHASES_CONFIGMAP ConfigMap, shared by all instances of the service, so that a change is only
+//	notified about once, by the instance that records it first. Phases that were already reached when the ConfigMap
+//	was created are not notified about.
+func StartRebuildPhaseWatcher(lifecycle fx.Lifecycle, logger utils.Logger, argoService ArgoService, k8sSvc K8sService, notifier NotifierService, env utils.Env) {
+	ctx, cancel := context.WithCancel(argoService.Context)
+	watcher := rebuildPhaseWatcher{
+		logger:           logger,
+		workflowClient:   argoService.Client.NewWorkflowServiceClient(),
+		k8sRestClientSet: k8sSvc.Client,
+		namespace:        env.NotifierNamespace,
+		notifier:         notifier,
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go watcher.run(ctx)
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+}
+
+type rebuildPhaseWatcher struct {
+	logger           utils.Logger
+	workflowClient   workflow.WorkflowServiceClient
+	k8sRestClientSet kubernetes.Interface
+	namespace        string
+	notifier         NotifierService
+}
+
+func (w *rebuildPhaseWatcher) run(ctx context.Context) {
+	ticker := time.NewTicker(REBUILD_PHASE_POLL_INTERVAL)
+	defer ticker.Stop()
+	for {
+		w.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll notifies about the rebuild workflows whose phase is not the one recorded in the REBUILD_PHASES_CONFIGMAP
+//
+//	ConfigMap. A workflow that went through several phases since the last poll is notified about once, from the phase
+//	recorded to its current phase.
+func (w *rebuildPhaseWatcher) poll(ctx context.Context) {
+	workflows, err := w.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
+		Namespace: "argo",
+		ListOptions: &v1.ListOptions{
+			LabelSelector: "type=rebuild",
+		},
+	})
+	if err != nil {
+		w.logger.Warnf("rebuildPhaseWatcher: unable to list rebuild workflows: %v", err)
+		return
+	}
+
+	phases := map[string]string{}
+	for _, wf := range workflows.Items {
+		if wf.Status.Phase != v1alpha1.WorkflowUnknown {
+			phases[wf.Name] = string(wf.Status.Phase)
+		}
+	}
+
+	configMap, err := w.k8sRestClientSet.CoreV1().ConfigMaps(w.namespace).Get(ctx, REBUILD_PHASES_CONFIGMAP, v1.GetOptions{})
+	if k8s_errors.IsNotFound(err) {
+		configMap = &core_v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: REBUILD_PHASES_CONFIGMAP},
+			Data:       phases,
+		}
+		_, err = w.k8sRestClientSet.CoreV1().ConfigMaps(w.namespace).Create(ctx, configMap, v1.CreateOptions{})
+		if err != nil && !k8s_errors.IsAlreadyExists(err) {
+			w.logger.Warnf("rebuildPhaseWatcher: unable to create ConfigMap %s: %v", REBUILD_PHASES_CONFIGMAP, err)
+		}
+		return
+	}
+	if err != nil {
+		w.logger.Warnf("rebuildPhaseWatcher: unable to get ConfigMap %s: %v", REBUILD_PHASES_CONFIGMAP, err)
+		return
+	}
+
+	var changed []v1alpha1.Workflow
+	for _, wf := range workflows.Items {
+		phase, exists := phases[wf.Name]
+		if exists && configMap.Data[wf.Name] != phase {
+			changed = append(changed, wf)
+		}
+	}
+	if len(changed) == 0 && len(configMap.Data) == len(phases) {
+		return
+	}
+
+	previousPhases := configMap.Data
+	configMap.Data = phases
+	// the update fails with a conflict when another instance recorded the phases since the ConfigMap was read, in which
+	//  case that instance notifies about them.
+	_, err = w.k8sRestClientSet.CoreV1().ConfigMaps(w.namespace).Update(ctx, configMap, v1.UpdateOptions{})
+	if k8s_errors.IsConflict(err) {
+		w.logger.Infof("rebuildPhaseWatcher: another instance recorded the phases of the rebuild workflows first")
+		return
+	}
+	if err != nil {
+		w.logger.Warnf("rebuildPhaseWatcher: unable to update ConfigMap %s: %v", REBUILD_PHASES_CONFIGMAP, err)
+		return
+	}
+
+	for _, wf := range changed {
+		w.notifier.Notify(Notification{
+			Source:        NOTIFICATION_SOURCE_NLS,
+			Type:          NOTIFICATION_TYPE_REBUILD_PHASE,
+			Name:          wf.Name,
+			PreviousState: previousPhases[wf.Name],
+			State:         string(wf.Status.Phase),
+			Message:       wf.Status.Message,
+		})
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// formatNotification renders the body of the webhook request in the format the webhook asked for
+func formatNotification(format string, notification Notification) ([]byte, error) {
+	switch format {
+	case "", "json":
+		return json.Marshal(notification)
+	case "slack":
+		return json.Marshal(map[string]interface{}{
+			"text": notificationSummary(notification),
+		})
+	case "teams":
+		return json.Marshal(map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    notificationTitle(notification),
+			"themeColor": notificationColor(notification),
+			"title":      notificationTitle(notification),
+			"text":       notificationSummary(notification),
+		})
+	default:
+		return nil, fmt.Errorf("unknown webhook format %s, must be json, slack or teams", format)
+	}
+}
+
+func notificationTitle(notification Notification) string {
+	switch notification.Type {
+	case NOTIFICATION_TYPE_ACTIVITY_STATE:
+		return fmt.Sprintf("IUF activity %s is %s", notification.Name, notification.State)
+	case NOTIFICATION_TYPE_REBUILD_PHASE:
+		return fmt.Sprintf("NCN rebuild workflow %s is %s", notification.Name, notification.State)
+	default:
+		return fmt.Sprintf("%s %s is %s", notification.Source, notification.Name, notification.State)
+	}
+}
+
+func notificationSummary(notification Notification) string {
+	summary := notificationTitle(notification)
+	if notification.PreviousState != "" {
+		summary += fmt.Sprintf(" (was %s)", notification.PreviousState)
+	}
+	if notification.Session != "" {
+		summary += fmt.Sprintf(" in session %s", notification.Session)
+	}
+	if notification.Message != "" {
+		summary += ": " + notification.Message
+	}
+	return summary
+}
+
+// notificationColor is red for states that need an admin, green for success and grey for anything else
+func notificationColor(notification Notification) string {
+	switch notification.State {
+	case "debug", "blocked", "Failed", "Error":
+		return "D70000"
+	case "Succeeded":
+		return "2EB886"
+	}
+	if notification.SessionState == "completed" {
+		return "2EB886"
+	}
+	return "808080"
+}
@@ -0,0 +1,348 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	workflowmocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow/mocks"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	core_v1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestNotifier(objects ...core_v1.ConfigMap) notifierService {
+	fakeClient := fake.NewSimpleClientset()
+	for _, object := range objects {
+		object := object
+		fakeClient.CoreV1().ConfigMaps("argo").Create(context.TODO(), &object, v1.CreateOptions{})
+	}
+	return notifierService{
+		ctx:              context.Background(),
+		logger:           utils.GetLogger(),
+		k8sRestClientSet: fakeClient,
+		httpClient:       &http.Client{},
+		configMapName:    "cray-nls-notifier",
+		namespace:        "argo",
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	notification := Notification{
+		Id:            "1234",
+		Source:        NOTIFICATION_SOURCE_IUF,
+		Type:          NOTIFICATION_TYPE_ACTIVITY_STATE,
+		Name:          "activity-a",
+		Session:       "session-a",
+		PreviousState: "in_progress",
+		State:         "debug",
+		Message:       "Failed workflow session-a-deliver-product-abcde",
+	}
+
+	t.Run("It should sign the payload with the key of the webhook", func(t *testing.T) {
+		var body []byte
+		var signature string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			signature = r.Header.Get(HEADER_NOTIFICATION_SIGNATURE)
+			assert.Equal(t, "1234", r.Header.Get(HEADER_NOTIFICATION_ID))
+		}))
+		defer server.Close()
+
+		notifier := newTestNotifier()
+		notifier.k8sRestClientSet.CoreV1().Secrets("argo").Create(context.TODO(), &core_v1.Secret{
+			ObjectMeta: v1.ObjectMeta{Name: "webhooks"},
+			Data:       map[string][]byte{"pager": []byte("s3cr3t")},
+		}, v1.CreateOptions{})
+		notifier.deliver(context.Background(), Webhook{Name: "pager", Url: server.URL, SecretRef: &WebhookSecretRef{Name: "webhooks", Key: "pager"}}, notification)
+
+		var received Notification
+		assert.NoError(t, json.Unmarshal(body, &received))
+		assert.Equal(t, "debug", received.State)
+		mac := hmac.New(sha256.New, []byte("s3cr3t"))
+		mac.Write(body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+	})
+
+	t.Run("It should retry until the webhook accepts the notification", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		notifier := newTestNotifier()
+		notifier.deliver(context.Background(), Webhook{Name: "pager", Url: server.URL, Backoff: "1ms"}, notification)
+		assert.Equal(t, 3, attempts)
+
+		deadLetters, _ := notifier.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_NOTIFICATION_DEAD_LETTER})
+		assert.Equal(t, 0, len(deadLetters.Items))
+	})
+
+	t.Run("It should record a dead letter when all attempts fail", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		notifier := newTestNotifier()
+		notifier.deliver(context.Background(), Webhook{Name: "pager", Url: server.URL, Backoff: "1ms", MaxAttempts: 2}, notification)
+		assert.Equal(t, 2, attempts)
+
+		deadLetters, _ := notifier.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_NOTIFICATION_DEAD_LETTER})
+		assert.Equal(t, 1, len(deadLetters.Items))
+		data := deadLetters.Items[0].Data[LABEL_NOTIFICATION_DEAD_LETTER]
+		assert.True(t, strings.Contains(data, `"attempts":2`))
+		assert.True(t, strings.Contains(data, `"id":"1234"`))
+		assert.True(t, strings.Contains(data, "500 Internal Server Error"))
+	})
+
+	t.Run("It should stop retrying and record a dead letter when the context is done", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		notifier := newTestNotifier()
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan bool)
+		go func() {
+			notifier.deliver(ctx, Webhook{Name: "pager", Url: server.URL, Backoff: "1h"}, notification)
+			done <- true
+		}()
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the delivery did not stop")
+		}
+		assert.Equal(t, 1, attempts)
+
+		deadLetters, _ := notifier.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_NOTIFICATION_DEAD_LETTER})
+		assert.Equal(t, 1, len(deadLetters.Items))
+		assert.True(t, strings.Contains(deadLetters.Items[0].Data[LABEL_NOTIFICATION_DEAD_LETTER], `"attempts":1`))
+	})
+
+	t.Run("It should delete expired dead letters and keep only the newest ones", func(t *testing.T) {
+		var objects []core_v1.ConfigMap
+		for i := 0; i < NOTIFICATION_DEAD_LETTER_MAX_COUNT+5; i++ {
+			objects = append(objects, core_v1.ConfigMap{ObjectMeta: v1.ObjectMeta{
+				Name:              fmt.Sprintf("nls-notification-pager-%d", i),
+				Labels:            map[string]string{"type": LABEL_NOTIFICATION_DEAD_LETTER, "webhook": "pager"},
+				CreationTimestamp: v1.NewTime(time.Now().Add(-time.Duration(i) * time.Minute)),
+			}})
+		}
+		objects = append(objects, core_v1.ConfigMap{ObjectMeta: v1.ObjectMeta{
+			Name:              "nls-notification-other-expired",
+			Labels:            map[string]string{"type": LABEL_NOTIFICATION_DEAD_LETTER, "webhook": "other"},
+			CreationTimestamp: v1.NewTime(time.Now().Add(-NOTIFICATION_DEAD_LETTER_MAX_AGE - time.Hour)),
+		}})
+		notifier := newTestNotifier(objects...)
+
+		notifier.deadLetter(Webhook{Name: "pager"}, notification, 1, fmt.Errorf("refused"))
+		deadLetters, _ := notifier.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_NOTIFICATION_DEAD_LETTER + ",webhook=pager"})
+		assert.Equal(t, NOTIFICATION_DEAD_LETTER_MAX_COUNT, len(deadLetters.Items))
+		names := map[string]bool{}
+		for _, deadLetter := range deadLetters.Items {
+			names[deadLetter.Name] = true
+		}
+		assert.True(t, names["nls-notification-pager-0"])
+		assert.False(t, names[fmt.Sprintf("nls-notification-pager-%d", NOTIFICATION_DEAD_LETTER_MAX_COUNT-1)])
+
+		notifier.deadLetter(Webhook{Name: "other"}, notification, 1, fmt.Errorf("refused"))
+		deadLetters, _ = notifier.k8sRestClientSet.CoreV1().ConfigMaps("argo").List(context.TODO(), v1.ListOptions{LabelSelector: "type=" + LABEL_NOTIFICATION_DEAD_LETTER + ",webhook=other"})
+		assert.Equal(t, 1, len(deadLetters.Items))
+		assert.NotEqual(t, "nls-notification-other-expired", deadLetters.Items[0].Name)
+	})
+
+	t.Run("It should only notify the webhooks that want the notification", func(t *testing.T) {
+		received := make(chan string, 2)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received <- r.URL.Path
+		}))
+		defer server.Close()
+
+		config := `
+webhooks:
+  - name: debug-only
+    url: ` + server.URL + `/debug
+    states: [debug, blocked]
+  - name: nls-only
+    url: ` + server.URL + `/nls
+    sources: [nls]
+`
+		notifier := newTestNotifier(core_v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: "cray-nls-notifier"},
+			Data:       map[string]string{NOTIFIER_CONFIG_KEY: config},
+		})
+		notifier.Notify(notification)
+
+		select {
+		case path := <-received:
+			assert.Equal(t, "/debug", path)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the notification was not delivered")
+		}
+		select {
+		case path := <-received:
+			t.Fatalf("unexpected notification to %s", path)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("It should format notifications for slack and teams", func(t *testing.T) {
+		body, err := formatNotification("slack", notification)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"text":"IUF activity activity-a is debug (was in_progress) in session session-a: Failed workflow session-a-deliver-product-abcde"}`, string(body))
+
+		body, err = formatNotification("teams", notification)
+		assert.NoError(t, err)
+		var card map[string]string
+		assert.NoError(t, json.Unmarshal(body, &card))
+		assert.Equal(t, "MessageCard", card["@type"])
+		assert.Equal(t, "D70000", card["themeColor"])
+		assert.Equal(t, "IUF activity activity-a is debug", card["title"])
+
+		_, err = formatNotification("email", notification)
+		assert.Error(t, err)
+	})
+}
+
+type recordingNotifier struct {
+	notifications []Notification
+}
+
+func (n *recordingNotifier) Notify(notification Notification) {
+	n.notifications = append(n.notifications, notification)
+}
+
+func TestRebuildPhaseWatcher(t *testing.T) {
+	running := v1alpha1.Workflow{ObjectMeta: v1.ObjectMeta{Name: "ncn-lifecycle-rebuild-abcde"}, Status: v1alpha1.WorkflowStatus{Phase: v1alpha1.WorkflowRunning}}
+	failed := running
+	failed.Status = v1alpha1.WorkflowStatus{Phase: v1alpha1.WorkflowFailed, Message: "child 'drain' failed"}
+
+	t.Run("It should notify about rebuild workflows that changed phase since the phase recorded", func(t *testing.T) {
+		wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+		wfServiceClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{running}}, nil).Twice()
+		wfServiceClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{failed}}, nil)
+
+		notifier := &recordingNotifier{}
+		watcher := rebuildPhaseWatcher{
+			logger:           utils.GetLogger(),
+			workflowClient:   wfServiceClientMock,
+			k8sRestClientSet: fake.NewSimpleClientset(),
+			namespace:        "argo",
+			notifier:         notifier,
+		}
+		watcher.poll(context.Background())
+		watcher.poll(context.Background())
+		assert.Equal(t, 0, len(notifier.notifications))
+
+		watcher.poll(context.Background())
+		assert.Equal(t, 1, len(notifier.notifications))
+		assert.Equal(t, NOTIFICATION_TYPE_REBUILD_PHASE, notifier.notifications[0].Type)
+		assert.Equal(t, "Running", notifier.notifications[0].PreviousState)
+		assert.Equal(t, "Failed", notifier.notifications[0].State)
+	})
+
+	t.Run("It should notify about a change only once across instances", func(t *testing.T) {
+		wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+		wfServiceClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{running}}, nil).Once()
+		wfServiceClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{failed}}, nil)
+
+		client := fake.NewSimpleClientset()
+		var notifiers []*recordingNotifier
+		var watchers []rebuildPhaseWatcher
+		for i := 0; i < 2; i++ {
+			notifiers = append(notifiers, &recordingNotifier{})
+			watchers = append(watchers, rebuildPhaseWatcher{
+				logger:           utils.GetLogger(),
+				workflowClient:   wfServiceClientMock,
+				k8sRestClientSet: client,
+				namespace:        "argo",
+				notifier:         notifiers[i],
+			})
+		}
+		watchers[0].poll(context.Background())
+		watchers[0].poll(context.Background())
+		watchers[1].poll(context.Background())
+		assert.Equal(t, 1, len(notifiers[0].notifications))
+		assert.Equal(t, 0, len(notifiers[1].notifications))
+
+		configMap, err := client.CoreV1().ConfigMaps("argo").Get(context.TODO(), REBUILD_PHASES_CONFIGMAP, v1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, "Failed", configMap.Data["ncn-lifecycle-rebuild-abcde"])
+	})
+
+	t.Run("It should not notify when another instance recorded the phases first", func(t *testing.T) {
+		wfServiceClientMock := &workflowmocks.WorkflowServiceClient{}
+		wfServiceClientMock.On("ListWorkflows", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowList{Items: v1alpha1.Workflows{failed}}, nil)
+
+		client := fake.NewSimpleClientset(&core_v1.ConfigMap{
+			ObjectMeta: v1.ObjectMeta{Name: REBUILD_PHASES_CONFIGMAP, Namespace: "argo"},
+			Data:       map[string]string{"ncn-lifecycle-rebuild-abcde": "Running"},
+		})
+		client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8s_errors.NewConflict(core_v1.Resource("configmaps"), REBUILD_PHASES_CONFIGMAP, fmt.Errorf("the object has been modified"))
+		})
+		notifier := &recordingNotifier{}
+		watcher := rebuildPhaseWatcher{
+			logger:           utils.GetLogger(),
+			workflowClient:   wfServiceClientMock,
+			k8sRestClientSet: client,
+			namespace:        "argo",
+			notifier:         notifier,
+		}
+		watcher.poll(context.Background())
+		assert.Equal(t, 0, len(notifier.notifications))
+	})
+}
@@ -0,0 +1,145 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+//go:generate mockgen -destination=../mocks/services/version.go -package=mocks -source=version.go
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/info"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
+	"sigs.k8s.io/yaml"
+
+	models_iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	models_nls "github.com/Cray-HPE/cray-nls/src/api/models/nls"
+	"github.com/Cray-HPE/cray-nls/src/utils"
+)
+
+// VERSION_LOOKUP_TIMEOUT is how long the versions of the Argo server and the workflow templates may take to get
+const VERSION_LOOKUP_TIMEOUT = 5 * time.Second
+
+type VersionService interface {
+	// GetVersion returns the version of NLS and of what it depends on. A version that cannot be found is unknown.
+	GetVersion(ctx context.Context) models_nls.VersionResponse
+}
+
+type versionService struct {
+	logger                 utils.Logger
+	env                    utils.Env
+	infoClient             info.InfoServiceClient
+	workflowTemplateClient workflowtemplate.WorkflowTemplateServiceClient
+}
+
+// NewVersionService creates a new VersionService
+func NewVersionService(logger utils.Logger, env utils.Env, argoService ArgoService) (VersionService, error) {
+	infoClient, err := argoService.Client.NewInfoServiceClient()
+	if err != nil {
+		return nil, err
+	}
+	workflowTemplateClient, err := argoService.Client.NewWorkflowTemplateServiceClient()
+	if err != nil {
+		return nil, err
+	}
+	return versionService{
+		logger:                 logger,
+		env:                    env,
+		infoClient:             infoClient,
+		workflowTemplateClient: workflowTemplateClient,
+	}, nil
+}
+
+func (s versionService) GetVersion(ctx context.Context) models_nls.VersionResponse {
+	ctx, cancel := context.WithTimeout(ctx, VERSION_LOOKUP_TIMEOUT)
+	defer cancel()
+
+	return models_nls.VersionResponse{
+		Message:                utils.Version,
+		Version:                utils.Version,
+		GitCommit:              utils.GitCommit,
+		BuildDate:              utils.BuildDate,
+		GoVersion:              runtime.Version(),
+		StagesVersion:          s.getStagesVersion(),
+		ManifestSchemaVersion:  s.getManifestSchemaVersion(),
+		ManifestSchemaVersions: models_iuf.ManifestSchemaVersions(),
+		ArgoServerVersion:      s.getArgoServerVersion(ctx),
+		WorkflowTemplates:      s.getWorkflowTemplateVersions(ctx),
+	}
+}
+
+func (s versionService) getStagesVersion() string {
+	stagesBytes, err := os.ReadFile(filepath.Join(s.env.IufInstallWorkflowFiles, "stages.yaml"))
+	if err != nil {
+		s.logger.Warnf("getStagesVersion: %v", err)
+		return models_nls.VERSION_UNKNOWN
+	}
+	var stages models_iuf.Stages
+	err = yaml.Unmarshal(stagesBytes, &stages)
+	if err != nil || stages.Version == "" {
+		s.logger.Warnf("getStagesVersion: stages.yaml has no version: %v", err)
+		return models_nls.VERSION_UNKNOWN
+	}
+	return stages.Version
+}
+
+func (s versionService) getManifestSchemaVersion() string {
+	version, err := models_iuf.ManifestSchemaVersion()
+	if err != nil {
+		s.logger.Warnf("getManifestSchemaVersion: %v", err)
+		return models_nls.VERSION_UNKNOWN
+	}
+	return version
+}
+
+func (s versionService) getArgoServerVersion(ctx context.Context) string {
+	version, err := s.infoClient.GetVersion(ctx, &info.GetVersionRequest{})
+	if err != nil || version == nil {
+		s.logger.Warnf("getArgoServerVersion: %v", err)
+		return models_nls.VERSION_UNKNOWN
+	}
+	return version.Version
+}
+
+// getWorkflowTemplateVersions returns the version labels of the workflow templates, which InitializeWorkflowTemplate
+//  compares to decide whether to replace a template
+func (s versionService) getWorkflowTemplateVersions(ctx context.Context) map[string]string {
+	versions := map[string]string{}
+	workflowTemplates, err := s.workflowTemplateClient.ListWorkflowTemplates(ctx, &workflowtemplate.WorkflowTemplateListRequest{Namespace: "argo"})
+	if err != nil || workflowTemplates == nil {
+		s.logger.Warnf("getWorkflowTemplateVersions: %v", err)
+		return versions
+	}
+	for _, workflowTemplate := range workflowTemplates.Items {
+		if version := workflowTemplate.Labels["version"]; version != "" {
+			versions[workflowTemplate.Name] = version
+		}
+	}
+	return versions
+}
@@ -0,0 +1,99 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package services_shared
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/info"
+	wftemplatemocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate/mocks"
+	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeInfoServiceClient struct {
+	info.InfoServiceClient
+	version *v1alpha1.Version
+	err     error
+}
+
+func (c fakeInfoServiceClient) GetVersion(ctx context.Context, in *info.GetVersionRequest, opts ...grpc.CallOption) (*v1alpha1.Version, error) {
+	return c.version, c.err
+}
+
+func TestGetVersion(t *testing.T) {
+	t.Run("It reports the versions of what NLS depends on", func(t *testing.T) {
+		iufDir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(iufDir, "stages.yaml"), []byte("version: 2.1.0\nstages: []\n"), 0600))
+		wftServiceClientMock := &wftemplatemocks.WorkflowTemplateServiceClient{}
+		wftServiceClientMock.On("ListWorkflowTemplates", mock.Anything, mock.Anything).Return(&v1alpha1.WorkflowTemplateList{
+			Items: v1alpha1.WorkflowTemplates{
+				{ObjectMeta: v1.ObjectMeta{Name: "ssh-template", Labels: map[string]string{"version": "1.2.3"}}},
+				{ObjectMeta: v1.ObjectMeta{Name: "no-version"}},
+			},
+		}, nil)
+		versionSvc := versionService{
+			logger:                 utils.GetLogger(),
+			env:                    utils.Env{IufInstallWorkflowFiles: iufDir},
+			infoClient:             fakeInfoServiceClient{version: &v1alpha1.Version{Version: "v3.3.8"}},
+			workflowTemplateClient: wftServiceClientMock,
+		}
+
+		version := versionSvc.GetVersion(context.TODO())
+
+		assert.Equal(t, utils.Version, version.Message)
+		assert.Equal(t, utils.Version, version.Version)
+		assert.Equal(t, "2.1.0", version.StagesVersion)
+		assert.NotEqual(t, "unknown", version.ManifestSchemaVersion)
+		assert.Contains(t, version.ManifestSchemaVersions, version.ManifestSchemaVersion)
+		assert.Equal(t, "v3.3.8", version.ArgoServerVersion)
+		assert.Equal(t, map[string]string{"ssh-template": "1.2.3"}, version.WorkflowTemplates)
+	})
+	t.Run("Versions that cannot be found are unknown", func(t *testing.T) {
+		wftServiceClientMock := &wftemplatemocks.WorkflowTemplateServiceClient{}
+		wftServiceClientMock.On("ListWorkflowTemplates", mock.Anything, mock.Anything).Return(nil, errors.New("argo is down"))
+		versionSvc := versionService{
+			logger:                 utils.GetLogger(),
+			env:                    utils.Env{IufInstallWorkflowFiles: t.TempDir()},
+			infoClient:             fakeInfoServiceClient{err: errors.New("argo is down")},
+			workflowTemplateClient: wftServiceClientMock,
+		}
+
+		version := versionSvc.GetVersion(context.TODO())
+
+		assert.Equal(t, "unknown", version.StagesVersion)
+		assert.Equal(t, "unknown", version.ArgoServerVersion)
+		assert.Equal(t, map[string]string{}, version.WorkflowTemplates)
+	})
+}
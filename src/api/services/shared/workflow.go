@@ -47,25 +47,23 @@ import (
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow"
 	"github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	"github.com/gin-gonic/gin"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/client-go/kubernetes"
 )
 
 type WorkflowService interface {
-	GetWorkflows(ctx *gin.Context) (*v1alpha1.WorkflowList, error)
-	GetWorkflowByName(name string, ctx *gin.Context) (*v1alpha1.Workflow, error)
-	DeleteWorkflow(ctx *gin.Context) error
-	RerunWorkflow(ctx *gin.Context) error
-	RetryWorkflow(ctx *gin.Context) error
-	CreateRebuildWorkflow(req models_nls.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error)
-	InitializeWorkflowTemplate(template []byte) error
+	GetWorkflows(ctx context.Context, labelSelector string) (*v1alpha1.WorkflowList, error)
+	GetWorkflowByName(ctx context.Context, name string) (*v1alpha1.Workflow, error)
+	DeleteWorkflow(ctx context.Context, wfName string) error
+	RerunWorkflow(ctx context.Context, wfName string) error
+	RetryWorkflow(ctx context.Context, wfName string, requestBody models_nls.RetryWorkflowRequestBody) error
+	CreateRebuildWorkflow(ctx context.Context, req models_nls.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error)
+	InitializeWorkflowTemplate(ctx context.Context, template []byte) error
 }
 
 // WorkflowService service layer
 type workflowService struct {
 	logger                 utils.Logger
-	ctx                    context.Context
 	workflowClient         workflow.WorkflowServiceClient
 	workflowTemplateClient workflowtemplate.WorkflowTemplateServiceClient
 	k8sRestClientSet       *kubernetes.Clientset
@@ -79,7 +77,6 @@ func NewWorkflowService(logger utils.Logger, argoService ArgoService, k8sSvc K8s
 
 	workflowSvc := workflowService{
 		logger:                 logger,
-		ctx:                    argoService.Context,
 		workflowClient:         argoService.Client.NewWorkflowServiceClient(),
 		workflowTemplateClient: workflowTemplateClient,
 		k8sRestClientSet:       k8sSvc.Client,
@@ -88,10 +85,9 @@ func NewWorkflowService(logger utils.Logger, argoService ArgoService, k8sSvc K8s
 	return workflowSvc
 }
 
-func (s workflowService) DeleteWorkflow(ctx *gin.Context) error {
-	wfName := ctx.Param("name")
+func (s workflowService) DeleteWorkflow(ctx context.Context, wfName string) error {
 	workflowToDelete, err := s.workflowClient.GetWorkflow(
-		s.ctx,
+		ctx,
 		&workflow.WorkflowGetRequest{
 			Namespace: "argo",
 			Name:      wfName,
@@ -109,7 +105,7 @@ func (s workflowService) DeleteWorkflow(ctx *gin.Context) error {
 	}
 
 	_, err = s.workflowClient.DeleteWorkflow(
-		s.ctx,
+		ctx,
 		&workflow.WorkflowDeleteRequest{
 			Namespace: "argo",
 			Name:      wfName,
@@ -123,8 +119,7 @@ func (s workflowService) DeleteWorkflow(ctx *gin.Context) error {
 	return nil
 }
 
-func (s workflowService) RerunWorkflow(ctx *gin.Context) error {
-	wfName := ctx.Param("name")
+func (s workflowService) RerunWorkflow(ctx context.Context, wfName string) error {
 	wf, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 		Namespace: "argo",
 		Name:      wfName,
@@ -136,7 +131,7 @@ func (s workflowService) RerunWorkflow(ctx *gin.Context) error {
 		s.logger.Error(err)
 		return err
 	}
-	workflows, err := s.checkRunningOrFailedWorkflows(models_nls.RebuildWorkflowType(wf.Labels["node-type"]))
+	workflows, err := s.checkRunningOrFailedWorkflows(ctx, models_nls.RebuildWorkflowType(wf.Labels["node-type"]))
 	if err != nil {
 		s.logger.Error(err)
 		return err
@@ -149,7 +144,7 @@ func (s workflowService) RerunWorkflow(ctx *gin.Context) error {
 	}
 
 	_, err = s.workflowClient.ResubmitWorkflow(
-		s.ctx,
+		ctx,
 		&workflow.WorkflowResubmitRequest{
 			Namespace: "argo",
 			Name:      wfName,
@@ -162,8 +157,7 @@ func (s workflowService) RerunWorkflow(ctx *gin.Context) error {
 	return nil
 }
 
-func (s workflowService) RetryWorkflow(ctx *gin.Context) error {
-	wfName := ctx.Param("name")
+func (s workflowService) RetryWorkflow(ctx context.Context, wfName string, requestBody models_nls.RetryWorkflowRequestBody) error {
 	wf, err := s.workflowClient.GetWorkflow(ctx, &workflow.WorkflowGetRequest{
 		Namespace: "argo",
 		Name:      wfName,
@@ -175,7 +169,7 @@ func (s workflowService) RetryWorkflow(ctx *gin.Context) error {
 		s.logger.Error(err)
 		return err
 	}
-	workflows, err := s.checkRunningOrFailedWorkflows(models_nls.RebuildWorkflowType(wf.Labels["node-type"]))
+	workflows, err := s.checkRunningOrFailedWorkflows(ctx, models_nls.RebuildWorkflowType(wf.Labels["node-type"]))
 	if err != nil {
 		s.logger.Error(err)
 		return err
@@ -187,16 +181,8 @@ func (s workflowService) RetryWorkflow(ctx *gin.Context) error {
 		return err
 	}
 
-	var requestBody models_nls.RetryWorkflowRequestBody
-	if err := ctx.BindJSON(&requestBody); err != nil {
-		s.logger.Error(err)
-		errResponse := utils.ResponseError{Message: err.Error()}
-		ctx.JSON(400, errResponse)
-		return err
-	}
-
 	_, err = s.workflowClient.RetryWorkflow(
-		s.ctx,
+		ctx,
 		&workflow.WorkflowRetryRequest{
 			Namespace:         "argo",
 			Name:              wfName,
@@ -212,10 +198,9 @@ func (s workflowService) RetryWorkflow(ctx *gin.Context) error {
 	return nil
 }
 
-func (s workflowService) GetWorkflows(ctx *gin.Context) (*v1alpha1.WorkflowList, error) {
-	labelSelector := ctx.Query("labelSelector")
+func (s workflowService) GetWorkflows(ctx context.Context, labelSelector string) (*v1alpha1.WorkflowList, error) {
 	return s.workflowClient.ListWorkflows(
-		s.ctx,
+		ctx,
 		&workflow.WorkflowListRequest{
 			Namespace: "argo",
 			ListOptions: &v1.ListOptions{
@@ -225,7 +210,7 @@ func (s workflowService) GetWorkflows(ctx *gin.Context) (*v1alpha1.WorkflowList,
 	)
 }
 
-func (s workflowService) GetWorkflowByName(name string, ctx *gin.Context) (*v1alpha1.Workflow, error) {
+func (s workflowService) GetWorkflowByName(ctx context.Context, name string) (*v1alpha1.Workflow, error) {
 	return s.workflowClient.GetWorkflow(
 		ctx,
 		&workflow.WorkflowGetRequest{
@@ -235,7 +220,7 @@ func (s workflowService) GetWorkflowByName(name string, ctx *gin.Context) (*v1al
 	)
 }
 
-func (s workflowService) CreateRebuildWorkflow(req models_nls.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error) {
+func (s workflowService) CreateRebuildWorkflow(ctx context.Context, req models_nls.CreateRebuildWorkflowRequest) (*v1alpha1.Workflow, error) {
 	// support worker rebuild and storage rebuild for now
 	workerNodeSet, storageNodeSet := false, false
 	var rebuildType models_nls.RebuildWorkflowType
@@ -273,7 +258,7 @@ func (s workflowService) CreateRebuildWorkflow(req models_nls.CreateRebuildWorkf
 		}
 	}
 
-	_, err = s.checkRunningOrFailedWorkflows(rebuildType)
+	_, err = s.checkRunningOrFailedWorkflows(ctx, rebuildType)
 	if err != nil {
 		s.logger.Error(err)
 		return nil, err
@@ -283,7 +268,7 @@ func (s workflowService) CreateRebuildWorkflow(req models_nls.CreateRebuildWorkf
 	var rebuildWorkflow []byte
 	var getWorkflowErr error
 	if workerNodeSet {
-		rebuildHooks, err := s.getRebuildHooks()
+		rebuildHooks, err := s.getRebuildHooks(ctx)
 		if err != nil {
 			s.logger.Error(err)
 			return nil, err
@@ -329,7 +314,7 @@ func (s workflowService) CreateRebuildWorkflow(req models_nls.CreateRebuildWorkf
 		}
 	}
 
-	res, err := s.workflowClient.CreateWorkflow(s.ctx, &workflow.WorkflowCreateRequest{
+	res, err := s.workflowClient.CreateWorkflow(ctx, &workflow.WorkflowCreateRequest{
 		Namespace: "argo",
 		Workflow:  &myWorkflow,
 	})
@@ -341,7 +326,7 @@ func (s workflowService) CreateRebuildWorkflow(req models_nls.CreateRebuildWorkf
 	return res, nil
 }
 
-func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
+func (s workflowService) InitializeWorkflowTemplate(ctx context.Context, template []byte) error {
 	var myWorkflowTemplate v1alpha1.WorkflowTemplate
 	tmpBytes, _ := yaml.YAMLToJSON(template)
 	err := json.Unmarshal(tmpBytes, &myWorkflowTemplate)
@@ -351,10 +336,14 @@ func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
 	}
 	s.logger.Infof("Initializing workflow template: %s", myWorkflowTemplate.Name)
 	for {
-		workflowTemplateList, err := s.workflowTemplateClient.ListWorkflowTemplates(s.ctx, &workflowtemplate.WorkflowTemplateListRequest{Namespace: "argo"})
+		workflowTemplateList, err := s.workflowTemplateClient.ListWorkflowTemplates(ctx, &workflowtemplate.WorkflowTemplateListRequest{Namespace: "argo"})
 		if err != nil {
 			s.logger.Errorf("Failded to get a list of workflow templates: %v", err)
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 
@@ -362,7 +351,7 @@ func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
 			if workflowTemplate.Name == myWorkflowTemplate.Name && (myWorkflowTemplate.ObjectMeta.Labels == nil || workflowTemplate.ObjectMeta.Labels == nil ||
 				myWorkflowTemplate.ObjectMeta.Labels["version"] != workflowTemplate.ObjectMeta.Labels["version"]) {
 				s.logger.Info("workflow template has already been initialized")
-				s.workflowTemplateClient.DeleteWorkflowTemplate(s.ctx, &workflowtemplate.WorkflowTemplateDeleteRequest{
+				s.workflowTemplateClient.DeleteWorkflowTemplate(ctx, &workflowtemplate.WorkflowTemplateDeleteRequest{
 					Namespace: "argo",
 					Name:      workflowTemplate.Name,
 				})
@@ -371,7 +360,7 @@ func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
 		}
 
 		_, err = s.workflowTemplateClient.CreateWorkflowTemplate(
-			s.ctx,
+			ctx,
 			&workflowtemplate.WorkflowTemplateCreateRequest{
 				Namespace: "argo",
 				Template:  &myWorkflowTemplate,
@@ -384,7 +373,11 @@ func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
 			}
 			// retry
 			s.logger.Warnf("Failded to initialize workflow templates: %v", err)
-			time.Sleep(5 * time.Second)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+			}
 			continue
 		}
 		break
@@ -394,8 +387,8 @@ func (s workflowService) InitializeWorkflowTemplate(template []byte) error {
 	return nil
 }
 
-func (s workflowService) checkRunningOrFailedWorkflows(rebuildType models_nls.RebuildWorkflowType) (v1alpha1.Workflows, error) {
-	workflows, err := s.workflowClient.ListWorkflows(s.ctx, &workflow.WorkflowListRequest{
+func (s workflowService) checkRunningOrFailedWorkflows(ctx context.Context, rebuildType models_nls.RebuildWorkflowType) (v1alpha1.Workflows, error) {
+	workflows, err := s.workflowClient.ListWorkflows(ctx, &workflow.WorkflowListRequest{
 		Namespace: "argo",
 		ListOptions: &v1.ListOptions{
 			LabelSelector: fmt.Sprintf("workflows.argoproj.io/phase!=Succeeded,workflows.argoproj.io/complated!=true,type=rebuild,node-type=%s", rebuildType),
@@ -415,11 +408,11 @@ func (s workflowService) checkRunningOrFailedWorkflows(rebuildType models_nls.Re
 	return workflows.Items, nil
 }
 
-func (s workflowService) getRebuildHooks() (models_nls.RebuildHooks, error) {
+func (s workflowService) getRebuildHooks(ctx context.Context) (models_nls.RebuildHooks, error) {
 	var result models_nls.RebuildHooks
 	// get all hooks
 	var beforeAllHooks unstructured.UnstructuredList
-	beforeAllHooks, err := s.getHooksByLabel("before-all=true")
+	beforeAllHooks, err := s.getHooksByLabel(ctx, "before-all=true")
 	if err != nil {
 		s.logger.Error(err)
 		return result, err
@@ -428,7 +421,7 @@ func (s workflowService) getRebuildHooks() (models_nls.RebuildHooks, error) {
 	result.BeforeAll = beforeAllHooks.Items
 
 	var beforeEachHooks unstructured.UnstructuredList
-	beforeEachHooks, err = s.getHooksByLabel("before-each=true")
+	beforeEachHooks, err = s.getHooksByLabel(ctx, "before-each=true")
 	if err != nil {
 		s.logger.Error(err)
 		return result, err
@@ -437,7 +430,7 @@ func (s workflowService) getRebuildHooks() (models_nls.RebuildHooks, error) {
 	result.BeforeEach = beforeEachHooks.Items
 
 	var afterEachHooks unstructured.UnstructuredList
-	afterEachHooks, err = s.getHooksByLabel("after-each=true")
+	afterEachHooks, err = s.getHooksByLabel(ctx, "after-each=true")
 	if err != nil {
 		s.logger.Error(err)
 		return result, err
@@ -446,7 +439,7 @@ func (s workflowService) getRebuildHooks() (models_nls.RebuildHooks, error) {
 	result.AfterEach = afterEachHooks.Items
 
 	var afterAllHooks unstructured.UnstructuredList
-	afterAllHooks, err = s.getHooksByLabel("after-all=true")
+	afterAllHooks, err = s.getHooksByLabel(ctx, "after-all=true")
 	if err != nil {
 		s.logger.Error(err)
 		return result, err
@@ -457,7 +450,7 @@ func (s workflowService) getRebuildHooks() (models_nls.RebuildHooks, error) {
 	return result, nil
 }
 
-func (s workflowService) getHooksByLabel(label string) (unstructured.UnstructuredList, error) {
+func (s workflowService) getHooksByLabel(ctx context.Context, label string) (unstructured.UnstructuredList, error) {
 	var myHooks unstructured.UnstructuredList
 	if s.k8sRestClientSet == nil {
 		return myHooks, nil
@@ -468,7 +461,7 @@ func (s workflowService) getHooksByLabel(label string) (unstructured.Unstructure
 		AbsPath("/apis/cray-nls.hpe.com/v1").
 		Resource("hooks").
 		Param("labelSelector", label).
-		DoRaw(context.TODO())
+		DoRaw(ctx)
 	if err != nil {
 		s.logger.Error(err)
 		return myHooks, err
@@ -25,8 +25,6 @@ package services_shared
 
 import (
 	"context"
-	"net/http"
-	"net/http/httptest"
 	"testing"
 
 	models_nls "github.com/Cray-HPE/cray-nls/src/api/models/nls"
@@ -35,7 +33,6 @@ import (
 	workflowmocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflow/mocks"
 	wftemplatemocks "github.com/argoproj/argo-workflows/v3/pkg/apiclient/workflowtemplate/mocks"
 	"github.com/argoproj/argo-workflows/v3/pkg/apis/workflow/v1alpha1"
-	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -58,7 +55,6 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 
 		workflowSvc := workflowService{
 			logger:                 utils.GetLogger(),
-			ctx:                    context.Background(),
 			workflowClient:         wfServiceClientMock,
 			workflowTemplateClient: wftServiceSclientMock,
 			env:                    utils.Env{WorkerRebuildWorkflowFiles: "../argo-templates/_test_data_"},
@@ -66,7 +62,7 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 		req := models_nls.CreateRebuildWorkflowRequest{
 			Hosts: []string{"ncn-w001"},
 		}
-		_, err := workflowSvc.CreateRebuildWorkflow(req)
+		_, err := workflowSvc.CreateRebuildWorkflow(context.TODO(), req)
 
 		// we don't actually test the template render/upload
 		// this is tested in the render package
@@ -84,7 +80,6 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 
 		workflowSvc := workflowService{
 			logger:                 utils.GetLogger(),
-			ctx:                    context.Background(),
 			workflowClient:         wfServiceClientMock,
 			workflowTemplateClient: wftServiceSclientMock,
 			env:                    utils.Env{},
@@ -92,7 +87,7 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 		req := models_nls.CreateRebuildWorkflowRequest{
 			Hosts: []string{"ncn-w001"},
 		}
-		_, err := workflowSvc.CreateRebuildWorkflow(req)
+		_, err := workflowSvc.CreateRebuildWorkflow(context.TODO(), req)
 
 		// we don't actually test the template render/upload
 		// this is tested in the render package
@@ -106,7 +101,6 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 	t.Run("It should NOT create a new workflow when request has mixed type", func(t *testing.T) {
 		workflowSvc := workflowService{
 			logger:                 utils.GetLogger(),
-			ctx:                    context.Background(),
 			workflowClient:         nil,
 			workflowTemplateClient: nil,
 			env:                    utils.Env{},
@@ -114,13 +108,12 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 		req := models_nls.CreateRebuildWorkflowRequest{
 			Hosts: []string{"ncn-w001", "ncn-s001"},
 		}
-		_, err := workflowSvc.CreateRebuildWorkflow(req)
+		_, err := workflowSvc.CreateRebuildWorkflow(context.TODO(), req)
 		assert.Contains(t, err.Error(), "hostnames cannot contain both worker and storage nodes. Only one node type is supported at a time")
 	})
 	t.Run("It should NOT create a new workflow when request has wrong hostname", func(t *testing.T) {
 		workflowSvc := workflowService{
 			logger:                 utils.GetLogger(),
-			ctx:                    context.Background(),
 			workflowClient:         nil,
 			workflowTemplateClient: nil,
 			env:                    utils.Env{},
@@ -128,7 +121,7 @@ func TestCreateRebuildWorkflow(t *testing.T) {
 		req := models_nls.CreateRebuildWorkflowRequest{
 			Hosts: []string{"ncn-ws1", "ncn-s001"},
 		}
-		_, err := workflowSvc.CreateRebuildWorkflow(req)
+		_, err := workflowSvc.CreateRebuildWorkflow(context.TODO(), req)
 		assert.Contains(t, err.Error(), "invalid worker or storage node hostname")
 	})
 }
@@ -145,17 +138,12 @@ func TestGetWorkflows(t *testing.T) {
 
 	workflowSvc := workflowService{
 		logger:                 utils.GetLogger(),
-		ctx:                    context.Background(),
 		workflowClient:         wfServiceClientMock,
 		workflowTemplateClient: wftServiceSclientMock,
 		env:                    utils.Env{},
 	}
 	t.Run("It should get workflows", func(t *testing.T) {
-		response := httptest.NewRecorder()
-		context, _ := gin.CreateTestContext(response)
-
-		context.Request, _ = http.NewRequest("GET", "/", nil)
-		_, err := workflowSvc.GetWorkflows(context)
+		_, err := workflowSvc.GetWorkflows(context.TODO(), "")
 		assert.Nil(t, err)
 		wfServiceClientMock.AssertExpectations(t)
 	})
@@ -174,17 +162,12 @@ func TestGetWorkflowByName(t *testing.T) {
 
 	workflowSvc := workflowService{
 		logger:                 utils.GetLogger(),
-		ctx:                    context.Background(),
 		workflowClient:         wfServiceClientMock,
 		workflowTemplateClient: wftServiceSclientMock,
 		env:                    utils.Env{},
 	}
 	t.Run("It should get workflow", func(t *testing.T) {
-		response := httptest.NewRecorder()
-		context, _ := gin.CreateTestContext(response)
-
-		context.Request, _ = http.NewRequest("GET", "/", nil)
-		_, err := workflowSvc.GetWorkflowByName("test", context)
+		_, err := workflowSvc.GetWorkflowByName(context.TODO(), "test")
 		assert.Nil(t, err)
 		wfServiceClientMock.AssertExpectations(t)
 	})
@@ -42,34 +42,40 @@ var Module = fx.Options(
 	services.Module,
 	middlewares.Module,
 	fx.Invoke(bootstrap),
+	fx.StopTimeout(APP_STOP_TIMEOUT),
 )
 
 func bootstrap(
 	lifecycle fx.Lifecycle,
+	shutdowner fx.Shutdowner,
 	handler utils.RequestHandler,
 	routes routes.Routes,
 	env utils.Env,
 	logger utils.Logger,
 	middlewares middlewares.Middlewares,
-) {
+) error {
+	server, err := newHttpServer(env, handler.Gin)
+	if err != nil {
+		return err
+	}
 
 	lifecycle.Append(fx.Hook{
 		OnStart: func(context.Context) error {
-
-			go func() {
-				middlewares.Setup()
-				routes.Setup()
-				host := "0.0.0.0"
-				if env.Environment == "development" {
-					host = "127.0.0.1"
-				}
-				handler.Gin.Run(host + ":" + env.ServerPort)
-			}()
-			return nil
+			middlewares.Setup()
+			routes.Setup()
+			return server.start(logger, func(err error) {
+				logger.Errorf("bootstrap: server stopped: %v", err)
+				shutdowner.Shutdown()
+			})
 		},
-		OnStop: func(context.Context) error {
+		OnStop: func(ctx context.Context) error {
 			logger.Info("Stopping Application")
-			return nil
+			err := server.stop(ctx)
+			if err != nil {
+				logger.Errorf("bootstrap: requests still in flight were cut off: %v", err)
+			}
+			return err
 		},
 	})
+	return nil
 }
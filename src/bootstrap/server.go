@@ -0,0 +1,142 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package bootstrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+)
+
+// APP_STOP_TIMEOUT is how long fx waits for the application to stop, so SERVER_SHUTDOWN_TIMEOUT has to be shorter
+const APP_STOP_TIMEOUT = time.Minute
+
+// httpServer serves the API, over TLS when SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE are set
+type httpServer struct {
+	server          *http.Server
+	shutdownTimeout time.Duration
+}
+
+func newHttpServer(env utils.Env, handler http.Handler) (httpServer, error) {
+	host := "0.0.0.0"
+	if env.Environment == "development" {
+		host = "127.0.0.1"
+	}
+
+	readTimeout, err := parseServerTimeout("SERVER_READ_TIMEOUT", env.ServerReadTimeout)
+	if err != nil {
+		return httpServer{}, err
+	}
+	writeTimeout, err := parseServerTimeout("SERVER_WRITE_TIMEOUT", env.ServerWriteTimeout)
+	if err != nil {
+		return httpServer{}, err
+	}
+	idleTimeout, err := parseServerTimeout("SERVER_IDLE_TIMEOUT", env.ServerIdleTimeout)
+	if err != nil {
+		return httpServer{}, err
+	}
+	shutdownTimeout, err := parseServerTimeout("SERVER_SHUTDOWN_TIMEOUT", env.ServerShutdownTimeout)
+	if err != nil {
+		return httpServer{}, err
+	}
+	if shutdownTimeout >= APP_STOP_TIMEOUT {
+		return httpServer{}, fmt.Errorf("SERVER_SHUTDOWN_TIMEOUT must be shorter than %v, not %s", APP_STOP_TIMEOUT, env.ServerShutdownTimeout)
+	}
+
+	// streams are exempt from the write timeout, and end when the server shuts down, see utils.StreamContext
+	shutdown, shutdownStarted := context.WithCancel(context.Background())
+	server := &http.Server{
+		Addr:              host + ":" + env.ServerPort,
+		Handler:           handler,
+		ReadHeaderTimeout: readTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return utils.WithShutdown(context.Background(), shutdown)
+		},
+		ConnContext: utils.WithConn,
+	}
+	server.RegisterOnShutdown(shutdownStarted)
+
+	if (env.ServerTLSCertFile == "") != (env.ServerTLSKeyFile == "") {
+		return httpServer{}, fmt.Errorf("SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	}
+	if env.ServerTLSCertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(env.ServerTLSCertFile, env.ServerTLSKeyFile)
+		if err != nil {
+			return httpServer{}, fmt.Errorf("unable to load the server certificate %s: %v", env.ServerTLSCertFile, err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12}
+	}
+
+	return httpServer{server: server, shutdownTimeout: shutdownTimeout}, nil
+}
+
+func parseServerTimeout(name string, value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration such as 30s, not %q", name, value)
+	}
+	return timeout, nil
+}
+
+// start binds the address before it returns, so that a port that is taken fails the startup. Serving goes on in the
+//  background, and stopped is called if it ends for any other reason than stop.
+func (s httpServer) start(logger utils.Logger, stopped func(err error)) error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("unable to listen on %s: %v", s.server.Addr, err)
+	}
+
+	go func() {
+		var err error
+		if s.server.TLSConfig != nil {
+			err = s.server.ServeTLS(listener, "", "")
+		} else {
+			err = s.server.Serve(listener)
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			stopped(err)
+		}
+	}()
+	logger.Infof("Serving on %s, TLS: %v", listener.Addr(), s.server.TLSConfig != nil)
+	return nil
+}
+
+// stop stops accepting connections, and waits up to SERVER_SHUTDOWN_TIMEOUT for the requests in flight to finish,
+//  so that a rolling deploy does not cut off a Sync call in the middle of updating its session
+func (s httpServer) stop(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
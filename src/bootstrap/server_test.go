@@ -0,0 +1,237 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package bootstrap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Cray-HPE/cray-nls/src/utils"
+	"github.com/alecthomas/assert"
+)
+
+func testServerEnv() utils.Env {
+	return utils.Env{
+		Environment:           "development",
+		ServerPort:            "0",
+		ServerReadTimeout:     "1m",
+		ServerWriteTimeout:    "5m",
+		ServerIdleTimeout:     "2m",
+		ServerShutdownTimeout: "5s",
+	}
+}
+
+func TestNewHttpServer(t *testing.T) {
+	t.Run("It applies the timeouts", func(t *testing.T) {
+		server, err := newHttpServer(testServerEnv(), http.NotFoundHandler())
+		assert.NoError(t, err)
+		assert.Equal(t, "127.0.0.1:0", server.server.Addr)
+		assert.Equal(t, time.Minute, server.server.ReadTimeout)
+		assert.Equal(t, 5*time.Minute, server.server.WriteTimeout)
+		assert.Equal(t, 2*time.Minute, server.server.IdleTimeout)
+		assert.Equal(t, 5*time.Second, server.shutdownTimeout)
+		assert.Nil(t, server.server.TLSConfig)
+	})
+	t.Run("It fails on an invalid timeout", func(t *testing.T) {
+		env := testServerEnv()
+		env.ServerWriteTimeout = "forever"
+		_, err := newHttpServer(env, http.NotFoundHandler())
+		assert.Error(t, err)
+
+		env = testServerEnv()
+		env.ServerShutdownTimeout = "10m"
+		_, err = newHttpServer(env, http.NotFoundHandler())
+		assert.Error(t, err)
+	})
+	t.Run("It fails when only the certificate or only the key is set", func(t *testing.T) {
+		env := testServerEnv()
+		env.ServerTLSCertFile = "/tmp/tls.crt"
+		_, err := newHttpServer(env, http.NotFoundHandler())
+		assert.EqualError(t, err, "SERVER_TLS_CERT_FILE and SERVER_TLS_KEY_FILE must be set together")
+	})
+	t.Run("It serves over TLS", func(t *testing.T) {
+		env := testServerEnv()
+		env.ServerTLSCertFile, env.ServerTLSKeyFile = writeTestCertificate(t)
+		server, err := newHttpServer(env, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		assert.NoError(t, err)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		server.server.Addr = listener.Addr().String()
+		listener.Close()
+		assert.NoError(t, server.start(utils.GetLogger(), func(err error) { t.Error(err) }))
+		defer server.stop(context.TODO())
+
+		client := http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+		res, err := client.Get("https://" + server.server.Addr)
+		assert.NoError(t, err)
+		res.Body.Close()
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestHttpServerLifecycle(t *testing.T) {
+	t.Run("It fails to start when the port is taken", func(t *testing.T) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		defer listener.Close()
+
+		server, err := newHttpServer(testServerEnv(), http.NotFoundHandler())
+		assert.NoError(t, err)
+		server.server.Addr = listener.Addr().String()
+
+		assert.Error(t, server.start(utils.GetLogger(), func(err error) {}))
+	})
+	t.Run("It waits for requests in flight when it stops", func(t *testing.T) {
+		started := make(chan struct{})
+		server, err := newHttpServer(testServerEnv(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			// only streams are canceled when the server stops
+			if r.Context().Err() != nil {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		assert.NoError(t, err)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		server.server.Addr = listener.Addr().String()
+		listener.Close()
+		assert.NoError(t, server.start(utils.GetLogger(), func(err error) { t.Error(err) }))
+
+		status := make(chan int, 1)
+		go func() {
+			res, err := http.Get("http://" + server.server.Addr)
+			if err != nil {
+				status <- 0
+				return
+			}
+			res.Body.Close()
+			status <- res.StatusCode
+		}()
+		<-started
+
+		assert.NoError(t, server.stop(context.TODO()))
+		assert.Equal(t, http.StatusOK, <-status)
+	})
+	t.Run("It does not apply the write timeout to streams", func(t *testing.T) {
+		env := testServerEnv()
+		env.ServerWriteTimeout = "100ms"
+		server, err := newHttpServer(env, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, cancel := utils.StreamContext(r)
+			defer cancel()
+			w.Write([]byte("started "))
+			w.(http.Flusher).Flush()
+			time.Sleep(300 * time.Millisecond)
+			w.Write([]byte("done"))
+		}))
+		assert.NoError(t, err)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		server.server.Addr = listener.Addr().String()
+		listener.Close()
+		assert.NoError(t, server.start(utils.GetLogger(), func(err error) { t.Error(err) }))
+		defer server.stop(context.TODO())
+
+		res, err := http.Get("http://" + server.server.Addr)
+		assert.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+		assert.Equal(t, "started done", string(body))
+	})
+	t.Run("It ends streams when it stops", func(t *testing.T) {
+		started := make(chan struct{})
+		server, err := newHttpServer(testServerEnv(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := utils.StreamContext(r)
+			defer cancel()
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			close(started)
+			<-ctx.Done()
+		}))
+		assert.NoError(t, err)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		server.server.Addr = listener.Addr().String()
+		listener.Close()
+		assert.NoError(t, server.start(utils.GetLogger(), func(err error) { t.Error(err) }))
+
+		go func() {
+			res, err := http.Get("http://" + server.server.Addr)
+			if err == nil {
+				io.Copy(io.Discard, res.Body)
+				res.Body.Close()
+			}
+		}()
+		<-started
+
+		start := time.Now()
+		assert.NoError(t, server.stop(context.TODO()))
+		// well within the shutdown timeout of 5s
+		assert.True(t, time.Since(start) < time.Second)
+	})
+}
+
+// writeTestCertificate writes a self-signed certificate and its key, and returns their paths
+func writeTestCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600))
+	return certFile, keyFile
+}
@@ -27,6 +27,7 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/Cray-HPE/cray-nls/src/bootstrap"
@@ -49,7 +50,8 @@ var rootCmd = &cobra.Command{
 		implements the NLS and IUF APIs.
 
 		This entry point can also be used to validate IUF Product Manifest files
-		using the "validate" subcommand.
+		using the "validate" subcommand, and all the products of a media
+		directory using the "validate-media" subcommand.
 		`),
 	Run: func(cmd *cobra.Command, args []string) {
 		godotenv.Load()
@@ -58,6 +60,11 @@ var rootCmd = &cobra.Command{
 	},
 }
 
+func init() {
+	rootCmd.Version = utils.Version
+	rootCmd.SetVersionTemplate(fmt.Sprintf("craynls {{.Version}} (commit %s, built %s)\n", utils.GitCommit, utils.BuildDate))
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -27,12 +27,16 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/joho/godotenv"
 	"github.com/lithammer/dedent"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
 var validateCmd = &cobra.Command{
@@ -42,21 +46,122 @@ var validateCmd = &cobra.Command{
 		Validate an IUF Product Manifest file against the IUF Product Manifest
 		schema understood by this version of the IUF.
 
-		Exits with zero exit code on successful validation, non-zero exit code
-		on validation failure. Validation errors are printed to stderr.
+		The iuf_version of the manifest is checked against --iuf-version, or
+		else against the version of stages.yaml in IUF_INSTALL_WORKFLOW_FILES.
+		When neither is set, the iuf_version is not checked.
+
+		The manifest is validated against the schema version of its
+		schema_version, or else against the schema of the IUF version named by
+		its iuf_version.
+
+		When there is a SHA256SUMS or else an MD5SUMS file next to the manifest,
+		in the format of the sha256sum and md5sum commands, every file it lists
+		must match its checksum.
+
+		Every issue found is reported with the JSON pointer of what it is about
+		and a severity. With --output text, errors and warnings are printed to
+		stderr. With --output json, the report is printed to stdout as JSON:
+
+		  {"file": "...", "valid": false, "issues": [
+		    {"pointer": "/content/s3/0/path", "severity": "error", "message": "..."}]}
+
+		Exits with zero exit code when there are no errors, even if there are
+		warnings, and non-zero exit code otherwise.
 	`),
 	Run: func(cmd *cobra.Command, args []string) {
-		if err := iuf.ValidateFile(args[0]); err != nil {
+		output, err := validateOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		iufVersion, err := validateIufVersion(cmd)
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "%v\n", err)
 			os.Exit(1)
 		}
+
+		if output == "text" {
+			fmt.Printf("Validating file %v against IUF Product Manifest Schema.\n", args[0])
+		}
+		report := iuf.ValidateFileReport(args[0], iufVersion)
+		printValidationReport(report, output)
+		if !report.Valid {
+			os.Exit(1)
+		}
 	},
 	Args: cobra.ExactArgs(1),
 }
 
+// validateOutput returns the --output flag, which is either text or json
+func validateOutput(cmd *cobra.Command) (string, error) {
+	output, _ := cmd.Flags().GetString("output")
+	if output != "text" && output != "json" {
+		return "", fmt.Errorf("invalid output %q, must be text or json", output)
+	}
+	return output, nil
+}
+
+// printValidationReport prints the issues of a report, as JSON on stdout or as text on stderr followed by a summary
+func printValidationReport(report iuf.ValidationReport, output string) {
+	if output == "json" {
+		if report.Issues == nil {
+			report.Issues = []iuf.ValidationIssue{}
+		}
+		reportJson, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportJson))
+		return
+	}
+
+	errorCount := 0
+	for _, issue := range report.Issues {
+		if issue.Severity == iuf.SeverityError {
+			errorCount++
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", issue.Severity, issue)
+	}
+	if report.Checksums != nil && report.Checksums.Verified {
+		fmt.Printf("%d file(s) match their %s checksum in %s.\n", report.Checksums.Files, report.Checksums.Algorithm, report.Checksums.File)
+	}
+	if report.Valid && len(report.Issues) == 0 {
+		fmt.Printf("File %v is valid against IUF Product Manifest schema %s and data.\n", report.File, report.SchemaVersion)
+	} else if report.Valid {
+		fmt.Printf("File %v is valid against IUF Product Manifest schema %s and data, with %d warning(s).\n", report.File, report.SchemaVersion, len(report.Issues))
+	} else {
+		fmt.Fprintf(os.Stderr, "File %v is not valid: %d error(s) and %d warning(s).\n", report.File, errorCount, len(report.Issues)-errorCount)
+	}
+}
+
+// validateIufVersion returns the IUF version to check manifests against: the --iuf-version flag, or else the version
+//  of stages.yaml in IUF_INSTALL_WORKFLOW_FILES, like the running IUF does. Empty when neither is set.
+func validateIufVersion(cmd *cobra.Command) (string, error) {
+	iufVersion, _ := cmd.Flags().GetString("iuf-version")
+	if iufVersion != "" {
+		return iufVersion, nil
+	}
+
+	godotenv.Load()
+	workflowFiles := os.Getenv("IUF_INSTALL_WORKFLOW_FILES")
+	if workflowFiles == "" {
+		return "", nil
+	}
+	stagesBytes, err := os.ReadFile(filepath.Join(workflowFiles, "stages.yaml"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the IUF version: %v", err)
+	}
+	var stages iuf.Stages
+	err = yaml.Unmarshal(stagesBytes, &stages)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the IUF version from %v: %v", filepath.Join(workflowFiles, "stages.yaml"), err)
+	}
+	return stages.Version, nil
+}
+
 func init() {
 	rootCmd.AddCommand(validateCmd)
 
+	validateCmd.Flags().String("iuf-version", "", "IUF version that the iuf_version of the manifest must be satisfied by")
+	validateCmd.Flags().StringP("output", "o", "text", "Output format of the issues found, text or json")
+
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,123 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	"github.com/lithammer/dedent"
+	"github.com/spf13/cobra"
+)
+
+var validateMediaCmd = &cobra.Command{
+	Use:   "validate-media dir",
+	Short: "Validate every IUF Product Manifest of an extracted media directory.",
+	Long: dedent.Dedent(`
+		Validate the products of an extracted media directory before handing it
+		to admins. Every iuf-product-manifest.yaml under the directory is found,
+		like the process-media stage does, and validated like "validate" does,
+		against the content of its own product directory and the checksums file
+		of the product directory, if there is one.
+
+		Then the products are checked against each other. No two products may
+		have the same name and version, define the same hosted Nexus repository,
+		or provide Loftsman manifests with the same name.
+
+		The iuf_version of the manifests is checked against --iuf-version, or
+		else against the version of stages.yaml in IUF_INSTALL_WORKFLOW_FILES.
+
+		With --output text, the issues are printed to stderr. With --output json,
+		the report of every manifest and the issues between products are printed
+		to stdout as JSON.
+
+		Exits with zero exit code when there are no errors, even if there are
+		warnings, and non-zero exit code otherwise.
+	`),
+	Run: func(cmd *cobra.Command, args []string) {
+		output, err := validateOutput(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		iufVersion, err := validateIufVersion(cmd)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+
+		if output == "text" {
+			fmt.Printf("Validating media directory %v.\n", args[0])
+		}
+		report := iuf.ValidateMedia(args[0], iufVersion)
+		printMediaValidationReport(report, output)
+		if !report.Valid {
+			os.Exit(1)
+		}
+	},
+	Args: cobra.ExactArgs(1),
+}
+
+// printMediaValidationReport prints the reports of the manifests followed by the issues between products, as JSON on
+//  stdout or as text on stderr followed by a summary
+func printMediaValidationReport(report iuf.MediaValidationReport, output string) {
+	if output == "json" {
+		if report.Issues == nil {
+			report.Issues = []iuf.CrossProductIssue{}
+		}
+		for i := range report.Manifests {
+			if report.Manifests[i].Issues == nil {
+				report.Manifests[i].Issues = []iuf.ValidationIssue{}
+			}
+		}
+		reportJson, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportJson))
+		return
+	}
+
+	for _, manifestReport := range report.Manifests {
+		printValidationReport(manifestReport, output)
+	}
+	for _, issue := range report.Issues {
+		fmt.Fprintf(os.Stderr, "%s: %v (%s)\n", issue.Severity, issue.ValidationIssue, strings.Join(issue.Files, ", "))
+	}
+	if report.Valid {
+		fmt.Printf("Media directory %v is valid, with %d product(s).\n", report.MediaDir, len(report.Manifests))
+	} else {
+		fmt.Fprintf(os.Stderr, "Media directory %v is not valid.\n", report.MediaDir)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(validateMediaCmd)
+
+	validateMediaCmd.Flags().String("iuf-version", "", "IUF version that the iuf_version of the manifests must be satisfied by")
+	validateMediaCmd.Flags().StringP("output", "o", "text", "Output format of the issues found, text or json")
+}
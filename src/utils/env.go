@@ -28,6 +28,12 @@ import (
 // Env has environment stored
 type Env struct {
 	ServerPort                  string `mapstructure:"SERVER_PORT"`
+	ServerReadTimeout           string `mapstructure:"SERVER_READ_TIMEOUT"`
+	ServerWriteTimeout          string `mapstructure:"SERVER_WRITE_TIMEOUT"`
+	ServerIdleTimeout           string `mapstructure:"SERVER_IDLE_TIMEOUT"`
+	ServerShutdownTimeout       string `mapstructure:"SERVER_SHUTDOWN_TIMEOUT"`
+	ServerTLSCertFile           string `mapstructure:"SERVER_TLS_CERT_FILE"`
+	ServerTLSKeyFile            string `mapstructure:"SERVER_TLS_KEY_FILE"`
 	Environment                 string `mapstructure:"ENV"`
 	ArgoToken                   string `mapstructure:"ARGO_TOKEN"`
 	ArgoServerURL               string `mapstructure:"ARGO_SERVER_URL"`
@@ -36,6 +42,24 @@ type Env struct {
 	StorageRebuildWorkflowFiles string `mapstructure:"STORAGE_REBUILD_WORKFLOW_FILES"`
 	IufInstallWorkflowFiles     string `mapstructure:"IUF_INSTALL_WORKFLOW_FILES"`
 	MediaDirBase                string `mapstructure:"MEDIA_DIR_BASE"`
+	NotifierConfigMap           string `mapstructure:"NOTIFIER_CONFIGMAP"`
+	NotifierNamespace           string `mapstructure:"NOTIFIER_NAMESPACE"`
+	AuditNamespace              string `mapstructure:"AUDIT_NAMESPACE"`
+	AuthDisabled                bool   `mapstructure:"AUTH_DISABLED"`
+	AuthJwksURL                 string `mapstructure:"AUTH_JWKS_URL"`
+	AuthJwksFile                string `mapstructure:"AUTH_JWKS_FILE"`
+	AuthJwksCacheTTL            string `mapstructure:"AUTH_JWKS_CACHE_TTL"`
+	AuthIssuer                  string `mapstructure:"AUTH_ISSUER"`
+	AuthAdminRoles              string `mapstructure:"AUTH_ADMIN_ROLES"`
+	AuthOperatorRoles           string `mapstructure:"AUTH_OPERATOR_ROLES"`
+	AuthReadOnlyRoles           string `mapstructure:"AUTH_READ_ONLY_ROLES"`
+	SyncWebhookSecret           string `mapstructure:"SYNC_WEBHOOK_SECRET"`
+	CaBundle                    string `mapstructure:"CA_BUNDLE"`
+	TLSInsecureSkipVerify       bool   `mapstructure:"TLS_INSECURE_SKIP_VERIFY"`
+	TracingExporter             string `mapstructure:"TRACING_EXPORTER"`
+	TracingOtlpEndpoint         string `mapstructure:"TRACING_OTLP_ENDPOINT"`
+	TracingOtlpInsecure         bool   `mapstructure:"TRACING_OTLP_INSECURE"`
+	TracingSampleRatio          string `mapstructure:"TRACING_SAMPLE_RATIO"`
 }
 
 // NewEnv creates a new environment
@@ -56,11 +80,67 @@ func NewEnv(log Logger) Env {
 		log.Fatal("☠️ environment can't be loaded: ", err)
 	}
 
+	if len(env.ServerReadTimeout) == 0 {
+		env.ServerReadTimeout = "1m"
+	}
+	// long enough for a Sync call to finish updating its session
+	if len(env.ServerWriteTimeout) == 0 {
+		env.ServerWriteTimeout = "5m"
+	}
+	if len(env.ServerIdleTimeout) == 0 {
+		env.ServerIdleTimeout = "2m"
+	}
+	if len(env.ServerShutdownTimeout) == 0 {
+		env.ServerShutdownTimeout = "25s"
+	}
 	// for the intiial realse, this is hard coded
 	if len(env.MediaDirBase) == 0 {
 		env.MediaDirBase = "/etc/cray/upgrade/csm"
 	}
+	if len(env.NotifierConfigMap) == 0 {
+		env.NotifierConfigMap = "cray-nls-notifier"
+	}
+	if len(env.NotifierNamespace) == 0 {
+		env.NotifierNamespace = "argo"
+	}
+	if len(env.AuditNamespace) == 0 {
+		env.AuditNamespace = "argo"
+	}
+	if len(env.AuthJwksURL) == 0 {
+		env.AuthJwksURL = env.ApiGatewayURL + "/keycloak/realms/shasta/protocol/openid-connect/certs"
+	}
+	// tokens of another realm, or of another Keycloak, must not be accepted even if they are signed by a key of the JWKS
+	if len(env.AuthIssuer) == 0 {
+		env.AuthIssuer = env.ApiGatewayURL + "/keycloak/realms/shasta"
+	}
+	if len(env.AuthJwksCacheTTL) == 0 {
+		env.AuthJwksCacheTTL = "10m"
+	}
+	if len(env.AuthAdminRoles) == 0 {
+		env.AuthAdminRoles = "admin"
+	}
+	if len(env.AuthOperatorRoles) == 0 {
+		env.AuthOperatorRoles = "operator"
+	}
+	if len(env.AuthReadOnlyRoles) == 0 {
+		env.AuthReadOnlyRoles = "user,monitor-ro"
+	}
+	if len(env.TracingExporter) == 0 {
+		env.TracingExporter = TRACING_EXPORTER_NONE
+	}
+	if len(env.TracingSampleRatio) == 0 {
+		env.TracingSampleRatio = "1"
+	}
+
+	if env.TLSInsecureSkipVerify {
+		log.Warn("TLS_INSECURE_SKIP_VERIFY is set, certificates of other services are not verified")
+	}
 
-	log.Infof("%+v \n", env)
+	// secrets are not logged
+	logged := env
+	if len(logged.SyncWebhookSecret) > 0 {
+		logged.SyncWebhookSecret = "<redacted>"
+	}
+	log.Infof("%+v \n", logged)
 	return env
 }
@@ -0,0 +1,86 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package utils
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const METRICS_NAMESPACE = "cray_nls"
+
+var (
+	HttpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests, by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HttpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests, by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	ArgoClientRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "argo_client_request_duration_seconds",
+		Help:      "Latency of calls to the Argo server, by client method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	ArgoClientErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "argo_client_errors_total",
+		Help:      "Number of calls to the Argo server that returned an error, by client method.",
+	}, []string{"method"})
+
+	KeycloakTokenFetchFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "keycloak_token_fetch_failures_total",
+		Help:      "Number of failed requests for a Keycloak access token.",
+	})
+
+	IufStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "iuf_stage_duration_seconds",
+		Help:      "Duration of IUF stage workflows, by stage and final workflow phase.",
+		// stages take from a few seconds to several hours
+		Buckets: []float64{10, 30, 60, 300, 600, 1800, 3600, 7200, 14400, 28800},
+	}, []string{"stage", "phase"})
+
+	IufOperationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Name:      "iuf_operations_total",
+		Help:      "Number of IUF operations in submitted stage workflows, by stage and whether they are executed or skipped.",
+	}, []string{"stage", "result"})
+)
+
+const (
+	METRICS_OPERATION_EXECUTED = "executed"
+	METRICS_OPERATION_SKIPPED  = "skipped"
+)
@@ -0,0 +1,73 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+type serverContextKey int
+
+const (
+	connContextKey serverContextKey = iota
+	shutdownContextKey
+)
+
+// WithConn keeps the connection of a request in the context of the request, see http.Server.ConnContext
+func WithConn(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey, conn)
+}
+
+// WithShutdown keeps a context that is done when the server shuts down in the context of each request, see
+//  http.Server.BaseContext. It is not the parent of the request context, so that shutting down does not cancel the
+//  requests that the server waits for.
+func WithShutdown(ctx context.Context, shutdown context.Context) context.Context {
+	return context.WithValue(ctx, shutdownContextKey, shutdown)
+}
+
+// StreamContext prepares a request for a response that is streamed for as long as the client keeps it open. The write
+//  timeout of the server does not apply to it, and the returned context is also done when the server shuts down, so
+//  that a stream does not hold up the shutdown.
+func StreamContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(r.Context())
+	if conn, ok := r.Context().Value(connContextKey).(net.Conn); ok {
+		// http.ResponseController does this from Go 1.20 on
+		conn.SetWriteDeadline(time.Time{})
+	}
+	if shutdown, ok := r.Context().Value(shutdownContextKey).(context.Context); ok {
+		go func() {
+			select {
+			case <-shutdown.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	return ctx, cancel
+}
@@ -0,0 +1,65 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// where the chart mounts the certificate_authority.crt key of the cray-configmap-ca-public-key ConfigMap, the platform CA
+const DEFAULT_CA_BUNDLE = "/etc/cray/ca/certificate_authority.crt"
+
+// NewTLSConfig returns the TLS config for calls to other services, like Keycloak through the API gateway. Their
+//  certificates are verified against the system roots and the PEM bundle in CA_BUNDLE, usually the platform CA. The
+//  bundle must be readable when CA_BUNDLE is set, and in production, where it defaults to DEFAULT_CA_BUNDLE.
+func NewTLSConfig(env Env) (*tls.Config, error) {
+	if env.TLSInsecureSkipVerify {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	caBundle := env.CaBundle
+	if caBundle == "" && env.Environment == "production" {
+		// without the platform CA, the certificate of the API gateway cannot be verified
+		caBundle = DEFAULT_CA_BUNDLE
+	}
+	if caBundle != "" {
+		pem, err := os.ReadFile(caBundle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA_BUNDLE %s: %v", caBundle, err)
+		}
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA_BUNDLE %s", caBundle)
+		}
+	}
+	return &tls.Config{RootCAs: rootCAs, MinVersion: tls.VersionTLS12}, nil
+}
@@ -0,0 +1,88 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package utils
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alecthomas/assert"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	get := func(env Env) error {
+		tlsConfig, err := NewTLSConfig(env)
+		if err != nil {
+			return err
+		}
+		client := http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	t.Run("It does not trust unknown certificates", func(t *testing.T) {
+		assert.Error(t, get(Env{}))
+	})
+	t.Run("It trusts certificates of the CA bundle", func(t *testing.T) {
+		caBundle := filepath.Join(t.TempDir(), "ca.crt")
+		certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+		assert.NoError(t, os.WriteFile(caBundle, certificate, 0600))
+		assert.NoError(t, get(Env{CaBundle: caBundle}))
+	})
+	t.Run("It fails when the CA bundle cannot be used", func(t *testing.T) {
+		_, err := NewTLSConfig(Env{CaBundle: filepath.Join(t.TempDir(), "missing.crt")})
+		assert.Error(t, err)
+
+		empty := filepath.Join(t.TempDir(), "empty.crt")
+		assert.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0600))
+		_, err = NewTLSConfig(Env{CaBundle: empty})
+		assert.Error(t, err)
+	})
+	t.Run("It only needs the default CA bundle in production", func(t *testing.T) {
+		if _, err := os.Stat(DEFAULT_CA_BUNDLE); err == nil {
+			t.Skipf("%s exists", DEFAULT_CA_BUNDLE)
+		}
+		_, err := NewTLSConfig(Env{Environment: "development"})
+		assert.NoError(t, err)
+		_, err = NewTLSConfig(Env{Environment: "production"})
+		assert.Error(t, err)
+	})
+	t.Run("It can skip verification", func(t *testing.T) {
+		assert.NoError(t, get(Env{TLSInsecureSkipVerify: true}))
+	})
+}
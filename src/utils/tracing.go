@@ -0,0 +1,168 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+)
+
+const (
+	TRACER_NAME  = "github.com/Cray-HPE/cray-nls"
+	SERVICE_NAME = "cray-nls"
+
+	TRACING_EXPORTER_NONE   = "none"
+	TRACING_EXPORTER_STDOUT = "stdout"
+	TRACING_EXPORTER_OTLP   = "otlp"
+)
+
+// Tracer returns the tracer of the service. Its spans go nowhere until StartTracing has set up an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TRACER_NAME)
+}
+
+// EndSpan records err on the span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// DetachedContext returns a context that carries the span of ctx, but is not cancelled with it. It is for work that
+//  must finish even when the caller goes away.
+func DetachedContext(ctx context.Context) context.Context {
+	return trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx))
+}
+
+// StartTracing exports spans to stdout or to an OTLP collector over HTTP, as set by TRACING_EXPORTER. Spans that are
+//  not exported yet are flushed when the application stops.
+func StartTracing(lifecycle fx.Lifecycle, env Env, logger Logger) error {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch env.TracingExporter {
+	case TRACING_EXPORTER_NONE:
+		return nil
+	case TRACING_EXPORTER_STDOUT:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case TRACING_EXPORTER_OTLP:
+		var options []otlptracehttp.Option
+		if env.TracingOtlpEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(env.TracingOtlpEndpoint))
+		}
+		if env.TracingOtlpInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		} else {
+			tlsConfig, err := NewTLSConfig(env)
+			if err != nil {
+				return err
+			}
+			options = append(options, otlptracehttp.WithTLSClientConfig(tlsConfig))
+		}
+		// this does not connect yet, so it does not block the start on a collector that is down
+		exporter, err = otlptracehttp.New(context.Background(), options...)
+	default:
+		return fmt.Errorf("unknown TRACING_EXPORTER %s, must be one of %s, %s or %s", env.TracingExporter, TRACING_EXPORTER_NONE, TRACING_EXPORTER_STDOUT, TRACING_EXPORTER_OTLP)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create %s trace exporter: %v", env.TracingExporter, err)
+	}
+
+	ratio, err := strconv.ParseFloat(env.TracingSampleRatio, 64)
+	if err != nil || ratio < 0 || ratio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be a number between 0 and 1, not %s", env.TracingSampleRatio)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(SERVICE_NAME))),
+		// a trace that was started by the caller is kept whenever the caller keeps it
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	logger.Infof("Exporting traces to %s", env.TracingExporter)
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return provider.Shutdown(ctx)
+		},
+	})
+	return nil
+}
+
+// NewTracingTransport wraps an HTTP transport so that each request made with it is a span, and carries the trace to
+//  the service that is called
+func NewTracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return tracingTransport{base: base}
+}
+
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+func (t tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := Tracer().Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethod(req.Method),
+			semconv.NetPeerName(req.URL.Hostname()),
+			// the query may have secrets, so only the path is kept
+			attribute.String("http.path", req.URL.Path),
+		))
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	res, err := t.base.RoundTrip(req)
+	if err != nil {
+		EndSpan(span, err)
+		return res, err
+	}
+	span.SetAttributes(semconv.HTTPStatusCode(res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, res.Status)
+	}
+	span.End()
+	return res, nil
+}
@@ -36,6 +36,7 @@ var Module = fx.Options(
 	fx.Provide(NewEnv),
 	fx.Provide(GetLogger),
 	fx.Provide(NewValidator),
+	fx.Invoke(StartTracing),
 )
 
 type GenericError struct {
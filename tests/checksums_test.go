@@ -0,0 +1,135 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+)
+
+func sha256Hex(contents string) string {
+	sum := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+func md5Hex(contents string) string {
+	sum := md5.Sum([]byte(contents))
+	return hex.EncodeToString(sum[:])
+}
+
+// Unit testcase for verifying the checksums file of a product distribution
+func TestVerifyChecksums(t *testing.T) {
+	t.Run("no checksums file", func(t *testing.T) {
+		result, err := iuf.VerifyChecksums(t.TempDir())
+		if err != nil || result != nil {
+			t.Errorf("expected no result and no error, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("sha256 is preferred over md5", func(t *testing.T) {
+		file := writeContentFiles(t, map[string]string{
+			"docker/image.tar": "image",
+			"helm/chart.tgz":   "chart",
+			"SHA256SUMS":       "# comment\n" + sha256Hex("image") + "  docker/image.tar\n" + sha256Hex("chart") + " *helm/chart.tgz\n",
+			"MD5SUMS":          md5Hex("not the image") + "  docker/image.tar\n",
+		})
+		result, err := iuf.VerifyChecksums(filepath.Dir(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.File != "SHA256SUMS" || result.Algorithm != "sha256" || result.Files != 2 || !result.Verified {
+			t.Errorf("expected 2 verified sha256 files, got %+v", result)
+		}
+	})
+
+	t.Run("md5 mismatches", func(t *testing.T) {
+		file := writeContentFiles(t, map[string]string{
+			"docker/image.tar": "image",
+			"helm/chart.tgz":   "modified chart",
+			"MD5SUMS": md5Hex("image") + "  docker/image.tar\n" + md5Hex("chart") + "  helm/chart.tgz\n" +
+				md5Hex("rpm") + "  rpms/missing.rpm\n" + md5Hex("passwd") + "  ../../etc/passwd\n",
+		})
+		result, err := iuf.VerifyChecksums(filepath.Dir(file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Algorithm != "md5" || result.Files != 4 || result.Verified {
+			t.Errorf("expected 4 unverified md5 files, got %+v", result)
+		}
+		expected := []iuf.ChecksumMismatch{
+			{File: "helm/chart.tgz", Expected: md5Hex("chart"), Actual: md5Hex("modified chart")},
+			{File: "rpms/missing.rpm", Expected: md5Hex("rpm")},
+			{File: "../../etc/passwd", Expected: md5Hex("passwd"), Error: "the file is not inside the distribution"},
+		}
+		if len(result.Mismatches) != len(expected) {
+			t.Fatalf("expected %d mismatches, got %+v", len(expected), result.Mismatches)
+		}
+		for i, mismatch := range result.Mismatches {
+			if mismatch.File != expected[i].File || mismatch.Expected != expected[i].Expected || mismatch.Actual != expected[i].Actual {
+				t.Errorf("expected %+v, got %+v", expected[i], mismatch)
+			}
+		}
+		if result.Mismatches[1].Error == "" || result.Mismatches[2].Error != expected[2].Error {
+			t.Errorf("expected errors for missing and outside files, got %+v", result.Mismatches)
+		}
+	})
+
+	t.Run("invalid checksums file", func(t *testing.T) {
+		file := writeContentFiles(t, map[string]string{
+			"SHA256SUMS": md5Hex("image") + "  docker/image.tar\n",
+		})
+		_, err := iuf.VerifyChecksums(filepath.Dir(file))
+		if err == nil || err.Error() != "line 1 of checksums file SHA256SUMS is not a sha256 checksum followed by a file" {
+			t.Errorf("expected invalid line error, got %v", err)
+		}
+	})
+}
+
+// Unit testcase for reporting checksum mismatches when validating a manifest file
+func TestValidateFileReportChecksums(t *testing.T) {
+	file := writeContentFiles(t, map[string]string{
+		"iuf-product-manifest.yaml": "iuf_version: ^0.5.0\nname: dummy\nversion: 1.0.0\ncontent: {}\n",
+		"s3/artifact.txt":           "modified",
+		"SHA256SUMS":                sha256Hex("original") + "  s3/artifact.txt\n",
+	})
+	report := iuf.ValidateFileReport(file, "0.5.1")
+	if report.Valid {
+		t.Error("expected manifest with checksum mismatch to be invalid")
+	}
+	if report.Checksums == nil || report.Checksums.Verified {
+		t.Errorf("expected unverified checksums, got %+v", report.Checksums)
+	}
+	expected := "checksum of file s3/artifact.txt does not match: expected " + sha256Hex("original") + ", got " + sha256Hex("modified") + " (SHA256SUMS)"
+	if errors := report.Errors(); len(errors) != 1 || errors[0].String() != expected {
+		t.Errorf("expected %q, got %v", expected, errors)
+	}
+}
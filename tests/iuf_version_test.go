@@ -0,0 +1,90 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+)
+
+// Unit testcase for the iuf_version constraints documented in the manifest schema
+func TestCheckIufVersion(t *testing.T) {
+	tests := []struct {
+		constraint string
+		iufVersion string
+		compatible bool
+	}{
+		{"1.0.0", "1.0.0", true},
+		{"1.0.0", "1.0.1", false},
+		{"== 1.0.0", "1.0.0", true},
+		{"== 1.0.0", "1.1.0", false},
+		{">= 1.0.0 < 2.0", "1.5.0", true},
+		{">= 1.0.0 < 2.0", "2.0.0", false},
+		{">= 1.0.0 < 2.0", "0.9.0", false},
+		{"~1.2.0", "1.2.9", true},
+		{"~1.2.0", "1.3.0", false},
+		{"^1.2.0", "1.9.0", true},
+		{"^1.2.0", "2.0.0", false},
+		{"^0.5.0", "0.5.3", true},
+		{"^0.5.0", "0.6.0", false},
+		{"not a version", "1.0.0", false},
+	}
+	for _, tt := range tests {
+		err := iuf.CheckIufVersion(tt.constraint, tt.iufVersion)
+		if tt.compatible && err != nil {
+			t.Errorf("%q should be satisfied by %s, error: %v", tt.constraint, tt.iufVersion, err)
+		}
+		var versionErr iuf.IufVersionError
+		if !tt.compatible && !errors.As(err, &versionErr) {
+			t.Errorf("%q should not be satisfied by %s, error: %v", tt.constraint, tt.iufVersion, err)
+		}
+	}
+}
+
+// Unit testcase for an IUF version that is not a semantic version
+func TestCheckIufVersionInvalidIufVersion(t *testing.T) {
+	err := iuf.CheckIufVersion("^1.0.0", "latest")
+	var versionErr iuf.IufVersionError
+	if err == nil || errors.As(err, &versionErr) {
+		t.Fatal("an invalid IUF version must not be blamed on the product, error:", err)
+	}
+}
+
+// Unit testcase for validating a manifest file against the IUF version
+func TestValidateManifestIufVersion(t *testing.T) {
+	file := "data/iuf-product-manifest.yaml"
+	err := iuf.ValidateFile(file, "0.5.3")
+	if err != nil {
+		t.Fatal("Manifest file:", file, "should be compatible with IUF 0.5.3, error:", err)
+	}
+	err = iuf.ValidateFile(file, "1.0.0")
+	if err == nil {
+		t.Fatal("Manifest file:", file, "should not be compatible with IUF 1.0.0")
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+)
+
+// writeContentFiles writes the files of a product distribution into a temporary directory and returns its manifest
+func writeContentFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, iuf.IUF_PRODUCT_MANIFEST_FILE)
+}
+
+// tarball returns the contents of a gzipped tar file with the given files
+func tarball(t *testing.T, files map[string]string) string {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, contents := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(contents))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// Unit testcase for the docker, helm, loftsman and ims content of the manifest
+func TestValidateManifestContent(t *testing.T) {
+	const header = "iuf_version: ^0.5.0\nname: dummy\nversion: 1.0.0\ncontent:\n"
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name: "docker directory without images",
+			files: map[string]string{
+				"iuf-product-manifest.yaml":  header + "  docker:\n  - path: docker\n  - path: missing\n",
+				"docker/registry/README.txt": "not an image",
+			},
+			expected: []string{
+				"/content/docker/0/path: docker directory DIR/docker does not contain any container image with a manifest.json file",
+				"/content/docker/1/path: docker directory DIR/missing does not exist or is empty",
+			},
+		},
+		{
+			name: "helm charts",
+			files: map[string]string{
+				"iuf-product-manifest.yaml": header + "  helm:\n  - path: helm\n  - path: empty\n",
+				"helm/broken-1.0.0.tgz":     "not a tarball",
+				"helm/chart-1.0.0.tgz":      tarball(t, map[string]string{"chart/Chart.yaml": "name: chart"}),
+				"helm/nochart-1.0.0.tgz":    tarball(t, map[string]string{"nochart/values.yaml": "{}"}),
+				"empty/README.txt":          "no charts",
+			},
+			expected: []string{
+				"/content/helm/0/path: helm chart DIR/helm/broken-1.0.0.tgz is not a gzipped tar file: gzip: invalid header",
+				"/content/helm/0/path: helm chart DIR/helm/nochart-1.0.0.tgz does not contain a Chart.yaml file",
+				"/content/helm/1/path: helm directory DIR/empty does not contain any helm chart as a gzipped tar file",
+			},
+		},
+		{
+			name: "loftsman manifests",
+			files: map[string]string{
+				"iuf-product-manifest.yaml": header + "  loftsman:\n  - path: manifests\n  - path: missing.yaml\n",
+				"manifests/services.yaml":   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: services\n",
+				"manifests/platform.yml":    "apiVersion: manifests/v1beta1\nmetadata: {}\nspec:\n  charts:\n  - name: platform\n",
+			},
+			expected: []string{
+				"/content/loftsman/0/path: loftsman manifest DIR/manifests/platform.yml does not have a metadata.name",
+				"/content/loftsman/0/path: chart 0 of loftsman manifest DIR/manifests/platform.yml does not have a name and a version",
+				"/content/loftsman/0/path: loftsman manifest DIR/manifests/services.yaml has apiVersion \"v1\" instead of manifests/<version>",
+				"/content/loftsman/1/path: loftsman manifest DIR/missing.yaml does not exist",
+			},
+		},
+		{
+			name: "ims images and recipes",
+			files: map[string]string{
+				"iuf-product-manifest.yaml": header + "  ims:\n" +
+					"    recipes:\n    - path: recipes/missing.tar.gz\n      recipe_type: kiwi-ng\n      linux_distribution: sles15\n" +
+					"    images:\n    - path: images/compute\n      rootfs:\n        path: rootfs.squashfs\n      kernel:\n        path: kernel\n" +
+					"    - path: images/missing\n" +
+					"    content_dirs:\n    - ims/x86_64\n",
+				"images/compute/rootfs.squashfs": "rootfs",
+			},
+			expected: []string{
+				"/content/ims/recipes/0/path: IMS recipe DIR/recipes/missing.tar.gz does not exist",
+				"/content/ims/images/0/kernel/path: IMS image kernel artifact DIR/images/compute/kernel does not exist",
+				"/content/ims/images/1/path: IMS image directory DIR/images/missing does not exist",
+				"/content/ims/content_dirs/0: IMS content directory manifest DIR/ims/x86_64/manifest.yaml does not exist",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := writeContentFiles(t, tt.files)
+			dir := filepath.Dir(file)
+
+			report := iuf.ValidateFileReport(file, "0.5.1")
+			if report.Valid {
+				t.Fatal("expected manifest to be invalid")
+			}
+			errors := report.Errors()
+			if len(errors) != len(tt.expected) {
+				t.Fatalf("expected %d errors, got %v", len(tt.expected), errors)
+			}
+			for i, issue := range errors {
+				if expected := strings.ReplaceAll(tt.expected[i], "DIR", dir); issue.String() != expected {
+					t.Errorf("expected %q, got %q", expected, issue.String())
+				}
+			}
+		})
+	}
+}
+
+// Unit testcase for the docker, helm, loftsman and ims content of the sample manifest
+func TestValidateManifestContentSample(t *testing.T) {
+	report := iuf.ValidateFileReport("data/iuf-product-manifest.yaml", "0.5.1")
+	if !report.Valid {
+		t.Errorf("expected sample manifest to be valid, got %v", report.Issues)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+)
+
+// Unit testcase for choosing the schema that a manifest is validated against
+func TestManifestSchemaSelection(t *testing.T) {
+	newest, err := iuf.ManifestSchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := iuf.ManifestSchemaVersions()
+	if versions[len(versions)-1] != newest {
+		t.Errorf("expected the newest schema %s to be the last of %v", newest, versions)
+	}
+
+	tests := []struct {
+		name     string
+		manifest string
+		expected string
+		errors   []string
+	}{
+		{
+			name:     "iuf_version of an older IUF",
+			manifest: "iuf_version: ^0.5.0\nname: old\nversion: 1.0.0\n",
+			expected: "0.7.2",
+		},
+		{
+			name:     "iuf_version without lower bound",
+			manifest: "iuf_version: < 2.0\nname: new\nversion: 1.0.0\n",
+			expected: "0.7.2",
+		},
+		{
+			// 0.7.2 does not define schema_version, so it is only used to choose the schema
+			name:     "schema_version of a schema that does not define it",
+			manifest: "iuf_version: ^0.6.0\nschema_version: 0.7.2\nname: old\nversion: 1.0.0\n",
+			expected: "0.7.2",
+		},
+		{
+			name:     "unknown schema_version",
+			manifest: "iuf_version: ^0.5.0\nschema_version: 9.9.9\nname: new\nversion: 1.0.0\n",
+			expected: "0.7.2",
+			errors:   []string{"/schema_version: schema version 9.9.9 is not one of the supported versions 0.7.2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := iuf.ValidateSchemaReport([]byte(tt.manifest))
+			if report.SchemaVersion != tt.expected {
+				t.Errorf("expected schema %s, got %s", tt.expected, report.SchemaVersion)
+			}
+			var errors []string
+			for _, issue := range report.Errors() {
+				errors = append(errors, issue.String())
+			}
+			if strings.Join(errors, "\n") != strings.Join(tt.errors, "\n") {
+				t.Errorf("expected errors %v, got %v", tt.errors, errors)
+			}
+		})
+	}
+}
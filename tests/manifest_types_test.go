@@ -0,0 +1,166 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	mdv "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestDataValidation"
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestTypes"
+	"github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
+
+	"sigs.k8s.io/yaml"
+)
+
+const newest_manifest_schema_file = "../src/api/models/iuf/schemaValidator/schemas/iuf-manifest-schema.yaml"
+
+// Unit testcase for reading the sample manifest into the manifest types and writing it back unchanged
+func TestManifestTypesRoundTrip(t *testing.T) {
+	data, err := os.ReadFile("data/iuf-product-manifest.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := manifestTypes.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest.Name == "" || manifest.Content == nil {
+		t.Fatalf("expected the name and content of the sample manifest, got %+v", manifest)
+	}
+
+	written, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var original, roundTripped interface{}
+	if err := yaml.Unmarshal(data, &original); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(written, &roundTripped); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(original, roundTripped) {
+		t.Errorf("expected the manifest to round-trip\noriginal: %v\nwritten:  %v", original, roundTripped)
+	}
+}
+
+// checkSchemaProperties checks that every property of an object in the schema has a field of the given type
+func checkSchemaProperties(t *testing.T, defs map[string]interface{}, schema map[string]interface{}, typ reflect.Type, pointer string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		schema, _ = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if schema == nil {
+			t.Errorf("%s: $ref %s is not in the $defs of the schema", pointer, ref)
+			return
+		}
+	}
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		if typ.Kind() != reflect.Slice {
+			t.Errorf("%s: the schema has an array, the type is %v", pointer, typ)
+			return
+		}
+		checkSchemaProperties(t, defs, items, typ.Elem(), pointer+"/items")
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for name, property := range properties {
+		propertySchema, _ := property.(map[string]interface{})
+		switch typ.Kind() {
+		case reflect.Map:
+			// stages of hooks and exit handlers
+			checkSchemaProperties(t, defs, propertySchema, typ.Elem(), pointer+"/"+name)
+		case reflect.Struct:
+			field, found := fieldByJsonName(typ, name)
+			if !found {
+				t.Errorf("%s: property %s of the schema is not a field of %v", pointer, name, typ)
+				continue
+			}
+			checkSchemaProperties(t, defs, propertySchema, field.Type, pointer+"/"+name)
+		default:
+			t.Errorf("%s: the schema has an object, the type is %v", pointer, typ)
+			return
+		}
+	}
+}
+
+// fieldByJsonName returns the field of a struct with the given name in its json tag
+func fieldByJsonName(typ reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if strings.Split(field.Tag.Get("json"), ",")[0] == name {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Unit testcase for the manifest types having every property of the newest schema
+func TestManifestTypesCoverSchema(t *testing.T) {
+	data, err := os.ReadFile(newest_manifest_schema_file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	if err := yaml.Unmarshal(data, &schema); err != nil {
+		t.Fatal(err)
+	}
+	defs, _ := schema["$defs"].(map[string]interface{})
+	checkSchemaProperties(t, defs, schema, reflect.TypeOf(manifestTypes.Manifest{}), "")
+}
+
+// Unit testcase for a content section that does not have the type of the schema
+func TestValidateManifestWrongContentType(t *testing.T) {
+	mdv.FileReader = mutils.ReadYamFile
+	data := []byte(`
+content:
+  s3: oops
+  nexus_blob_stores:
+    yaml_path: 'do/not/exist/nexus-blobstores.yaml'
+`)
+	var dataObject map[string]interface{}
+	if err := yaml.Unmarshal(data, &dataObject); err != nil {
+		t.Fatal("Test setup has issues, error details:", err)
+	}
+
+	issues := mdv.ValidateIssues(dataObject)
+	if len(issues) != 2 {
+		t.Fatalf("expected an issue for s3 and one for nexus_blob_stores, got %v", issues)
+	}
+	if !strings.HasPrefix(issues[0].String(), "/content/s3: content s3 cannot be read, so it is not checked: ") {
+		t.Errorf("expected s3 to be reported as not read, got %q", issues[0].String())
+	}
+	if issues[1].Pointer != "/content/nexus_blob_stores/yaml_path" {
+		t.Errorf("expected nexus_blob_stores to still be checked, got %q", issues[1].String())
+	}
+}
@@ -38,7 +38,7 @@ import (
 // Unit testcase for Basic sanity of manifest validate
 func TestValidateManifestSaninty(t *testing.T) {
 	file := "data/iuf-product-manifest.yaml"
-	err := iuf.ValidateFile(file)
+	err := iuf.ValidateFile(file, "")
 	if err != nil {
 		t.Fatal("Issue seen in manifest file:", file, "error:", err)
 	}
@@ -523,6 +523,8 @@ type: group
 	mdv.FileReader = func(filePath string) ([]byte, error) {
 		return nexusCotent, nil
 	}
+	// np/nexus-repositories-dummy.yaml must exist in the directory of the manifest
+	mdv.SetManifestRootDir("data")
 	err := mdv.Validate(dataObject)
 
 	if err != nil {
@@ -582,6 +584,8 @@ type: group
 	mdv.FileReader = func(filePath string) ([]byte, error) {
 		return nexusCotent, nil
 	}
+	// np/nexus-repositories-dummy.yaml must exist in the directory of the manifest
+	mdv.SetManifestRootDir("data")
 	err := mdv.Validate(dataObject)
 
 	if err != nil {
@@ -0,0 +1,111 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	mdv "github.com/Cray-HPE/cray-nls/src/api/models/iuf/manifestDataValidation"
+	mutils "github.com/Cray-HPE/cray-nls/src/api/models/iuf/mutils"
+)
+
+// Unit testcase for discovering the product manifests of a media directory
+func TestFindManifestFiles(t *testing.T) {
+	files, err := iuf.FindManifestFiles("data_media/conflicting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"data_media/conflicting/product-a/iuf-product-manifest.yaml",
+		"data_media/conflicting/product-b/iuf-product-manifest.yaml",
+		"data_media/conflicting/product-c/iuf-product-manifest.yaml",
+	}
+	if strings.Join(files, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected %v, got %v", expected, files)
+	}
+}
+
+// Unit testcase for validating a media directory without conflicts
+func TestValidateMediaValid(t *testing.T) {
+	mdv.FileReader = mutils.ReadYamFile
+
+	report := iuf.ValidateMedia("data_media/valid", "0.5.1")
+	if !report.Valid {
+		t.Errorf("expected media to be valid, got %+v", report)
+	}
+	if len(report.Manifests) != 2 {
+		t.Errorf("expected 2 manifests, got %d", len(report.Manifests))
+	}
+	if len(report.Issues) != 0 {
+		t.Errorf("expected no cross-product issues, got %v", report.Issues)
+	}
+}
+
+// Unit testcase for the cross-product checks of a media directory
+func TestValidateMediaCrossProductIssues(t *testing.T) {
+	mdv.FileReader = mutils.ReadYamFile
+
+	report := iuf.ValidateMedia("data_media/conflicting", "0.5.1")
+	if report.Valid {
+		t.Error("expected media to be invalid")
+	}
+	for _, manifest := range report.Manifests {
+		if !manifest.Valid {
+			t.Errorf("expected %s to be valid on its own, got %v", manifest.File, manifest.Issues)
+		}
+	}
+
+	expected := []string{
+		"product product-a 1.0.0 is in the media more than once",
+		"/content/nexus_repositories/yaml_path: hosted Nexus repository shared is defined by more than one product",
+		"/content/loftsman: Loftsman manifest shared-services is provided by more than one product",
+	}
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %v", len(expected), report.Issues)
+	}
+	for i, issue := range report.Issues {
+		if issue.String() != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], issue.String())
+		}
+		if issue.Severity != iuf.SeverityError {
+			t.Errorf("expected %q to be an error", issue.String())
+		}
+		if len(issue.Files) != 2 {
+			t.Errorf("expected %q to name 2 files, got %v", issue.String(), issue.Files)
+		}
+	}
+}
+
+// Unit testcase for a media directory without any product manifest
+func TestValidateMediaEmpty(t *testing.T) {
+	report := iuf.ValidateMedia(t.TempDir(), "0.5.1")
+	if report.Valid {
+		t.Error("expected media without manifests to be invalid")
+	}
+}
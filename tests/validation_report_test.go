@@ -0,0 +1,124 @@
+/*
+ *
+ *  MIT License
+ *
+ *  (C) Copyright 2025 Hewlett Packard Enterprise Development LP
+ *
+ *  Permission is hereby granted, free of charge, to any person obtaining a
+ *  copy of this software and associated documentation files (the "Software"),
+ *  to deal in the Software without restriction, including without limitation
+ *  the rights to use, copy, modify, merge, publish, distribute, sublicense,
+ *  and/or sell copies of the Software, and to permit persons to whom the
+ *  Software is furnished to do so, subject to the following conditions:
+ *
+ *  The above copyright notice and this permission notice shall be included
+ *  in all copies or substantial portions of the Software.
+ *
+ *  THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+ *  IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+ *  FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL
+ *  THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR
+ *  OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE,
+ *  ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR
+ *  OTHER DEALINGS IN THE SOFTWARE.
+ *
+ */
+
+package tests
+
+import (
+	"testing"
+
+	iuf "github.com/Cray-HPE/cray-nls/src/api/models/iuf"
+	sv "github.com/Cray-HPE/cray-nls/src/api/models/iuf/schemaValidator"
+	"sigs.k8s.io/yaml"
+)
+
+// Unit testcase for reporting every schema error, not just the first one
+func TestSchemaValidatorAllIssues(t *testing.T) {
+	data := []byte(`
+iuf_version: 1
+name: product
+unknown: true
+content:
+  s3:
+  - path: 3
+`)
+	var dataObject map[string]interface{}
+	inputErr := yaml.Unmarshal(data, &dataObject)
+	if inputErr != nil {
+		t.Fatal("Test setup has issues, error details:", inputErr)
+	}
+
+	issues, err := sv.ValidateIssues(dataObject, "schemas/iuf-manifest-schema.yaml")
+	if err != nil {
+		t.Fatal("Schema could not be used, error:", err)
+	}
+	pointers := map[string]bool{}
+	for _, issue := range issues {
+		if issue.Severity != iuf.SeverityError {
+			t.Error("Schema issue should be an error:", issue)
+		}
+		pointers[issue.Pointer] = true
+	}
+	for _, pointer := range []string{"", "/iuf_version", "/content/s3/0", "/content/s3/0/path"} {
+		if !pointers[pointer] {
+			t.Error("Missing schema issue at", pointer, "in", issues)
+		}
+	}
+}
+
+// Unit testcase for reporting every schema and data error of a manifest with its JSON pointer
+func TestValidateReportAggregatesIssues(t *testing.T) {
+	data := []byte(`
+iuf_version: ^0.5.0
+name: product
+version: 1.0.0
+content:
+  s3:
+  - path: not/exists/path/dummy_upload_1.txt
+    bucket: dummy-bucket
+    key: dummy-key
+  - path: not/exists/path/dummy_upload_2.txt
+    bucket: dummy-bucket
+  vcs:
+    path: vcs_empty
+`)
+	report := iuf.ValidateReport(data, "", "0.6.0")
+	if report.Valid {
+		t.Fatal("Manifest should not be valid, issues:", report.Issues)
+	}
+
+	want := []struct {
+		pointer  string
+		severity iuf.Severity
+	}{
+		{"/content/s3/1", iuf.SeverityError},
+		{"/content/s3/0/path", iuf.SeverityError},
+		{"/content/s3/1/path", iuf.SeverityError},
+		{"/content/vcs/path", iuf.SeverityError},
+		{"/iuf_version", iuf.SeverityError},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatal("Wrong number of issues, got:", report.Issues)
+	}
+	for i, issue := range report.Issues {
+		if issue.Pointer != want[i].pointer || issue.Severity != want[i].severity || issue.Message == "" {
+			t.Error("Wrong issue", i, "got:", issue, "want:", want[i])
+		}
+	}
+	if report.Err() == nil || len(report.Errors()) != len(want) {
+		t.Error("Report should have", len(want), "errors, got:", report.Err())
+	}
+}
+
+// Unit testcase for a valid manifest whose iuf_version cannot be checked
+func TestValidateReportWarning(t *testing.T) {
+	report := iuf.ValidateReport([]byte("iuf_version: ^0.5.0\nname: product\n"), "", "")
+	if !report.Valid || report.Err() != nil {
+		t.Fatal("Manifest should be valid, issues:", report.Issues)
+	}
+	if len(report.Issues) != 1 || report.Issues[0].Severity != iuf.SeverityWarning || report.Issues[0].Pointer != "/iuf_version" {
+		t.Fatal("Manifest should have a warning about iuf_version, issues:", report.Issues)
+	}
+}